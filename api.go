@@ -4,6 +4,7 @@ package webrtc
 
 import (
 	"github.com/pion/logging"
+	"github.com/pion/webrtc/v2/pkg/interceptor"
 )
 
 // API bundles the global funcions of the WebRTC and ORTC API.
@@ -11,8 +12,9 @@ import (
 // defaultAPI object. Note that the global version of the API
 // may be phased out in the future.
 type API struct {
-	settingEngine *SettingEngine
-	mediaEngine   *MediaEngine
+	settingEngine       *SettingEngine
+	mediaEngine         *MediaEngine
+	interceptorRegistry interceptor.Registry
 }
 
 // NewAPI Creates a new API object for keeping semi-global settings to WebRTC objects
@@ -53,3 +55,13 @@ func WithSettingEngine(s SettingEngine) func(a *API) {
 		a.settingEngine = &s
 	}
 }
+
+// WithInterceptorRegistry allows providing the interceptors that see the RTP
+// and RTCP of every PeerConnection created by the API. They are created
+// for each PeerConnection and come after the built-in ones, which send
+// Receiver Reports, and send and answer NACKs.
+func WithInterceptorRegistry(r interceptor.Registry) func(a *API) {
+	return func(a *API) {
+		a.interceptorRegistry = r
+	}
+}
@@ -0,0 +1,118 @@
+// +build !js
+
+package webrtc
+
+import (
+	"encoding/binary"
+	"net"
+	"sync"
+	"time"
+)
+
+// Number of RTP packets per SSRC whose arrival time is remembered
+const arrivalHistorySize = 1024
+
+// arrivalTimes remembers when the recent RTP packets of the received SSRCs were
+// read from the network. Packets then wait in the SRTP session until they are
+// read from their Track, the reception statistics and the jitter buffer use the
+// time of arrival so that a slow reader doesn't skew them. The packets and bytes
+// that arrived are counted for the stats of the RTPReceivers.
+// The zero value records nothing until an SSRC is watched
+type arrivalTimes struct {
+	mu      sync.Mutex
+	streams map[uint32]*arrivalStream
+}
+
+type arrivalStream struct {
+	history [arrivalHistorySize]arrival
+
+	packets uint64
+	bytes   uint64
+	last    time.Time
+}
+
+type arrival struct {
+	sequenceNumber uint16
+	time           time.Time
+}
+
+// watch starts recording the arrivals of ssrc
+func (a *arrivalTimes) watch(ssrc uint32) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.streams == nil {
+		a.streams = map[uint32]*arrivalStream{}
+	}
+	if _, ok := a.streams[ssrc]; !ok {
+		a.streams[ssrc] = &arrivalStream{}
+	}
+}
+
+// forget stops recording the arrivals of ssrc
+func (a *arrivalTimes) forget(ssrc uint32) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	delete(a.streams, ssrc)
+}
+
+// record notes that the RTP packet in b arrived at now. The RTP header of SRTP
+// packets isn't encrypted, packets of SSRCs that aren't watched are ignored
+func (a *arrivalTimes) record(b []byte, now time.Time) {
+	if len(b) < 12 {
+		return
+	}
+	ssrc := binary.BigEndian.Uint32(b[8:12])
+	sequenceNumber := binary.BigEndian.Uint16(b[2:4])
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if stream, ok := a.streams[ssrc]; ok {
+		stream.history[sequenceNumber%arrivalHistorySize] = arrival{sequenceNumber: sequenceNumber, time: now}
+		stream.packets++
+		stream.bytes += uint64(len(b))
+		stream.last = now
+	}
+}
+
+// get returns when a packet arrived, or the current time if it wasn't recorded
+// or has been forgotten, like the packets recovered with FEC
+func (a *arrivalTimes) get(ssrc uint32, sequenceNumber uint16) time.Time {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if stream, ok := a.streams[ssrc]; ok {
+		if entry := stream.history[sequenceNumber%arrivalHistorySize]; entry.sequenceNumber == sequenceNumber && !entry.time.IsZero() {
+			return entry.time
+		}
+	}
+	return time.Now()
+}
+
+// received returns the number of packets and bytes of ssrc that arrived since
+// it is watched, and when the last one did
+func (a *arrivalTimes) received(ssrc uint32) (packets, bytes uint64, last time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if stream, ok := a.streams[ssrc]; ok {
+		return stream.packets, stream.bytes, stream.last
+	}
+	return 0, 0, time.Time{}
+}
+
+// arrivalConn records the arrival of the packets read from a net.Conn
+type arrivalConn struct {
+	net.Conn
+	arrivals *arrivalTimes
+}
+
+func (c *arrivalConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if err == nil {
+		c.arrivals.record(b[:n], time.Now())
+	}
+	return n, err
+}
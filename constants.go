@@ -1,5 +1,7 @@
 package webrtc
 
+import "time"
+
 const (
 	// Unknown defines default public constant to use for "enum" like struct
 	// comparisons when no value was defined.
@@ -10,5 +12,29 @@ const (
 	// Equal to UDP MTU
 	receiveMTU = 1460
 
+	// Default size of outbound datagrams, this fits into the path MTU of most networks
+	defaultMTU = 1200
+
 	mediaSectionApplication = "application"
+
+	sdpAttributeRid       = "rid"
+	sdpAttributeSimulcast = "simulcast"
+
+	// Semantics of the ssrc-group of a FlexFEC stream and the stream it protects, RFC 5956
+	sdpSemanticTokenFECFramework = "FEC-FR"
+
+	// RTP header extension IDs must fit the one-byte header of RFC 8285
+	maxHeaderExtensionID = 14
+
+	// Number of sent RTP packets kept per RTPSender to answer NACKs
+	defaultNACKHistorySize = 512
+
+	// How long ICETransport.Stop waits for the ICE agent to report that it is closed
+	iceAgentCloseTimeout = 5 * time.Second
+
+	// How long the jitter buffer holds packets while waiting for a missing one
+	defaultJitterBufferMaxDelay = 100 * time.Millisecond
+
+	// Number of packets read from an undeclared SSRC while looking for its MID and RID
+	simulcastProbeCount = 10
 )
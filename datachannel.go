@@ -18,9 +18,16 @@ import (
 const dataChannelBufferSize = math.MaxUint16 //message size limit for Chromium
 var errSCTPNotEstablished = errors.New("SCTP not established")
 
+type dataChannelQueuedMessage struct {
+	data     []byte
+	isString bool
+}
+
 // DataChannel represents a WebRTC DataChannel
 // The DataChannel interface represents a network channel
-// which can be used for bidirectional peer-to-peer transfers of arbitrary data
+// which can be used for bidirectional peer-to-peer transfers of arbitrary data.
+// The handlers of each kind of event are run one at a time in the order of the
+// events, a handler waiting for a later event of its own kind never returns.
 type DataChannel struct {
 	mu sync.RWMutex
 
@@ -51,9 +58,22 @@ type DataChannel struct {
 	onBufferedAmountLow func()
 	onErrorHandler      func(error)
 
+	// Deliver the events to the handlers above in order, a queue per kind.
+	// The handler of OnOpen isn't ordered, it may keep running to send.
+	messageEvents           eventDispatcher
+	stateEvents             eventDispatcher
+	bufferedAmountLowEvents eventDispatcher
+
 	sctpTransport *SCTPTransport
 	dataChannel   *datachannel.DataChannel
 
+	// Messages sent before the DataChannel opened
+	sendQueue      []dataChannelQueuedMessage
+	sendQueueBytes uint64
+
+	// Closed to wake up the sends waiting for the buffered amount to get low
+	bufferedAmountLow chan struct{}
+
 	// A reference to the associated api object used by this datachannel
 	api *API
 	log logging.LeveledLogger
@@ -96,6 +116,10 @@ func (api *API) newDataChannel(params *DataChannelParameters, log logging.Levele
 		readyState:        DataChannelStateConnecting,
 		api:               api,
 		log:               log,
+
+		messageEvents:           eventDispatcher{executor: api.settingEngine.events.Executor},
+		stateEvents:             eventDispatcher{executor: api.settingEngine.events.Executor},
+		bufferedAmountLowEvents: eventDispatcher{executor: api.settingEngine.events.Executor},
 	}, nil
 }
 
@@ -164,9 +188,6 @@ func (d *DataChannel) open(sctpTransport *SCTPTransport) error {
 		return err
 	}
 
-	// bufferedAmountLowThreshold and onBufferedAmountLow might be set earlier
-	dc.SetBufferedAmountLowThreshold(d.bufferedAmountLowThreshold)
-	dc.OnBufferedAmountLow(d.onBufferedAmountLow)
 	d.mu.Unlock()
 
 	d.handleOpen(dc)
@@ -216,9 +237,11 @@ func (d *DataChannel) OnOpen(f func()) {
 
 	if readyState == DataChannelStateOpen {
 		// If the data channel is already open, call the handler immediately.
-		go d.openHandlerOnce.Do(func() {
-			f()
-			d.checkDetachAfterOpen()
+		runEvent(d.api.settingEngine.events.Executor, func() {
+			d.openHandlerOnce.Do(func() {
+				f()
+				d.checkDetachAfterOpen()
+			})
 		})
 	}
 }
@@ -229,9 +252,11 @@ func (d *DataChannel) onOpen() {
 	d.mu.RUnlock()
 
 	if hdlr != nil {
-		go d.openHandlerOnce.Do(func() {
-			hdlr()
-			d.checkDetachAfterOpen()
+		runEvent(d.api.settingEngine.events.Executor, func() {
+			d.openHandlerOnce.Do(func() {
+				hdlr()
+				d.checkDetachAfterOpen()
+			})
 		})
 	}
 }
@@ -250,7 +275,7 @@ func (d *DataChannel) onClose() {
 	d.mu.RUnlock()
 
 	if hdlr != nil {
-		go hdlr()
+		d.stateEvents.dispatch(hdlr)
 	}
 }
 
@@ -274,15 +299,55 @@ func (d *DataChannel) onMessage(msg DataChannelMessage) {
 	if hdlr == nil {
 		return
 	}
-	hdlr(msg)
+	// Waiting for the handler stops reading until it is done, the
+	// messages not read yet are held back by SCTP flow control
+	d.messageEvents.dispatchAndWait(func() { hdlr(msg) })
 }
 
 func (d *DataChannel) handleOpen(dc *datachannel.DataChannel) {
-	d.setReadyState(DataChannelStateOpen)
 	d.mu.Lock()
 	d.dataChannel = dc
+
+	// bufferedAmountLowThreshold and onBufferedAmountLow might be set earlier
+	dc.SetBufferedAmountLowThreshold(d.bufferedAmountLowThreshold)
+	dc.OnBufferedAmountLow(d.handleBufferedAmountLow)
 	d.mu.Unlock()
 
+	// Messages queued before opening are written before the state changes so
+	// they can't be overtaken by new calls to Send, which keep being queued
+	// meanwhile. The lock isn't held while writing
+	failed := false
+	for {
+		d.mu.Lock()
+		if d.readyState != DataChannelStateConnecting {
+			// Closed before it opened, Close may not have seen dc
+			d.mu.Unlock()
+			if err := dc.Close(); err != nil {
+				d.log.Warnf("Failed to close DataChannel %s: %v", d.label, err)
+			}
+			return
+		}
+		sendQueue := d.sendQueue
+		d.sendQueue = nil
+		d.sendQueueBytes = 0
+		if len(sendQueue) == 0 {
+			d.readyState = DataChannelStateOpen
+			d.mu.Unlock()
+			break
+		}
+		d.mu.Unlock()
+
+		for _, msg := range sendQueue {
+			if failed {
+				break
+			}
+			if _, err := dc.WriteDataChannel(msg.data, msg.isString); err != nil {
+				d.log.Warnf("Failed to send queued message on DataChannel %s: %v", d.label, err)
+				failed = true
+			}
+		}
+	}
+
 	d.onOpen()
 
 	d.mu.Lock()
@@ -307,7 +372,7 @@ func (d *DataChannel) onError(err error) {
 	d.mu.RUnlock()
 
 	if hdlr != nil {
-		go hdlr(err)
+		d.stateEvents.dispatch(func() { hdlr(err) })
 	}
 }
 
@@ -330,26 +395,108 @@ func (d *DataChannel) readLoop() {
 
 // Send sends the binary message to the DataChannel peer
 func (d *DataChannel) Send(data []byte) error {
-	err := d.ensureOpen()
-	if err != nil {
-		return err
-	}
-
-	_, err = d.dataChannel.WriteDataChannel(data, false)
-	return err
+	return d.send(data, false)
 }
 
 // SendText sends the text message to the DataChannel peer
 func (d *DataChannel) SendText(s string) error {
+	return d.send([]byte(s), true)
+}
+
+func (d *DataChannel) send(data []byte, isString bool) error {
+	if d.queueMessage(data, isString) {
+		return nil
+	}
+
 	err := d.ensureOpen()
 	if err != nil {
 		return err
 	}
 
-	_, err = d.dataChannel.WriteDataChannel([]byte(s), true)
+	if err = d.waitBufferedAmount(uint64(len(data))); err != nil {
+		return err
+	}
+
+	_, err = d.dataChannel.WriteDataChannel(data, isString)
 	return err
 }
 
+// waitBufferedAmount checks that n more bytes fit into the buffered amount
+// configured with SetDataChannelMaxBufferedAmount. A message that doesn't fit
+// is still sent once the buffered amount is at or below the threshold, so
+// messages larger than the maximum can't block forever
+func (d *DataChannel) waitBufferedAmount(n uint64) error {
+	maxAmount := d.api.settingEngine.sendBuffer.DataChannelMaxBytes
+	if maxAmount == 0 {
+		return nil
+	}
+
+	for {
+		d.mu.Lock()
+		if d.readyState != DataChannelStateOpen {
+			d.mu.Unlock()
+			return &rtcerr.InvalidStateError{Err: ErrDataChannelNotOpen}
+		}
+
+		amount := d.dataChannel.BufferedAmount()
+		if amount+n <= maxAmount || amount <= d.dataChannel.BufferedAmountLowThreshold() {
+			d.mu.Unlock()
+			return nil
+		}
+
+		if !d.api.settingEngine.sendBuffer.DataChannelBlock {
+			d.mu.Unlock()
+			return ErrDataChannelBufferFull
+		}
+
+		if d.bufferedAmountLow == nil {
+			d.bufferedAmountLow = make(chan struct{})
+		}
+		bufferedAmountLow := d.bufferedAmountLow
+		d.mu.Unlock()
+
+		<-bufferedAmountLow
+	}
+}
+
+// notifyBufferedAmountLow wakes up the sends waiting in waitBufferedAmount.
+// Caller must hold d.mu
+func (d *DataChannel) notifyBufferedAmountLow() {
+	if d.bufferedAmountLow != nil {
+		close(d.bufferedAmountLow)
+		d.bufferedAmountLow = nil
+	}
+}
+
+func (d *DataChannel) handleBufferedAmountLow() {
+	d.mu.Lock()
+	d.notifyBufferedAmountLow()
+	hdlr := d.onBufferedAmountLow
+	d.mu.Unlock()
+
+	if hdlr != nil {
+		d.bufferedAmountLowEvents.dispatch(hdlr)
+	}
+}
+
+// queueMessage stores a message sent before the DataChannel is open, if
+// it fits into the queue configured with SetDataChannelSendQueueSize
+func (d *DataChannel) queueMessage(data []byte, isString bool) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// Without a queue, empty messages aren't queued either
+	limit := d.api.settingEngine.sendQueue.DataChannelBytes
+	if limit == 0 || d.readyState != DataChannelStateConnecting ||
+		d.sendQueueBytes+uint64(len(data)) > limit {
+		return false
+	}
+
+	d.sendQueue = append(d.sendQueue, dataChannelQueuedMessage{data: append([]byte{}, data...), isString: isString})
+	d.sendQueueBytes += uint64(len(data))
+	return true
+}
+
 func (d *DataChannel) ensureOpen() error {
 	d.mu.RLock()
 	defer d.mu.RUnlock()
@@ -396,7 +543,12 @@ func (d *DataChannel) Close() error {
 		return nil
 	}
 
-	d.setReadyState(DataChannelStateClosing)
+	d.mu.Lock()
+	d.readyState = DataChannelStateClosing
+	d.sendQueue = nil
+	d.notifyBufferedAmountLow()
+	d.mu.Unlock()
+
 	if !haveSctpTransport {
 		return nil
 	}
@@ -495,7 +647,7 @@ func (d *DataChannel) BufferedAmount() uint64 {
 	defer d.mu.RUnlock()
 
 	if d.dataChannel == nil {
-		return 0
+		return d.sendQueueBytes
 	}
 	return d.dataChannel.BufferedAmount()
 }
@@ -537,9 +689,6 @@ func (d *DataChannel) OnBufferedAmountLow(f func()) {
 	defer d.mu.Unlock()
 
 	d.onBufferedAmountLow = f
-	if d.dataChannel != nil {
-		d.dataChannel.OnBufferedAmountLow(f)
-	}
 }
 
 func (d *DataChannel) getStatsID() string {
@@ -583,4 +732,7 @@ func (d *DataChannel) setReadyState(r DataChannelState) {
 	defer d.mu.Unlock()
 
 	d.readyState = r
+	if r != DataChannelStateOpen {
+		d.notifyBufferedAmountLow()
+	}
 }
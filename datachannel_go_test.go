@@ -6,11 +6,13 @@ import (
 	"bytes"
 	"crypto/rand"
 	"encoding/binary"
+	"errors"
 	"io"
 	"io/ioutil"
 	"math/big"
 	"reflect"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -174,7 +176,7 @@ func TestDataChannelBufferedAmount(t *testing.T) {
 		report := test.CheckRoutines(t)
 		defer report()
 
-		var nCbs int
+		var nCbs int32
 		buf := make([]byte, 1000)
 
 		offerPC, answerPC, err := newPair()
@@ -232,7 +234,7 @@ func TestDataChannelBufferedAmount(t *testing.T) {
 		// The callback function is temporarily stored in the dc object
 		// until the dc gets opened
 		dc.OnBufferedAmountLow(func() {
-			nCbs++
+			atomic.AddInt32(&nCbs, 1)
 		})
 
 		err = signalPair(offerPC, answerPC)
@@ -242,14 +244,14 @@ func TestDataChannelBufferedAmount(t *testing.T) {
 
 		closePair(t, offerPC, answerPC, done)
 
-		assert.True(t, nCbs > 0, "callback should be made at least once")
+		assert.True(t, atomic.LoadInt32(&nCbs) > 0, "callback should be made at least once")
 	})
 
 	t.Run("set after datachannel becomes open", func(t *testing.T) {
 		report := test.CheckRoutines(t)
 		defer report()
 
-		var nCbs int
+		var nCbs int32
 		buf := make([]byte, 1000)
 
 		offerPC, answerPC, err := newPair()
@@ -291,7 +293,7 @@ func TestDataChannelBufferedAmount(t *testing.T) {
 			dc.SetBufferedAmountLowThreshold(1500)
 			// The callback function should directly be passed to sctp
 			dc.OnBufferedAmountLow(func() {
-				nCbs++
+				atomic.AddInt32(&nCbs, 1)
 			})
 
 			for i := 0; i < 10; i++ {
@@ -315,7 +317,7 @@ func TestDataChannelBufferedAmount(t *testing.T) {
 
 		closePair(t, offerPC, answerPC, done)
 
-		assert.True(t, nCbs > 0, "callback should be made at least once")
+		assert.True(t, atomic.LoadInt32(&nCbs) > 0, "callback should be made at least once")
 	})
 }
 
@@ -528,3 +530,204 @@ func TestEOF(t *testing.T) {
 		<-dcbClosedCh // (2)
 	})
 }
+
+func TestDataChannel_SendQueue(t *testing.T) {
+	lim := test.TimeOut(time.Second * 10)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	s := SettingEngine{}
+	s.SetDataChannelSendQueueSize(10)
+
+	offerPC, answerPC, err := NewAPI(WithSettingEngine(s)).newPair(Configuration{})
+	assert.NoError(t, err)
+
+	received := make(chan string, 3)
+	answerPC.OnDataChannel(func(d *DataChannel) {
+		if d.Label() != expectedLabel {
+			return
+		}
+		d.OnMessage(func(msg DataChannelMessage) {
+			received <- string(msg.Data)
+		})
+	})
+
+	dc, err := offerPC.CreateDataChannel(expectedLabel, nil)
+	assert.NoError(t, err)
+
+	// Sent before signaling, the third message doesn't fit into the queue
+	assert.NoError(t, dc.SendText("first"))
+	assert.NoError(t, dc.Send([]byte("two")))
+	assert.Error(t, dc.SendText("third"))
+	assert.Equal(t, uint64(8), dc.BufferedAmount())
+
+	dc.OnOpen(func() {
+		assert.NoError(t, dc.SendText("after open"))
+	})
+
+	assert.NoError(t, signalPair(offerPC, answerPC))
+
+	assert.Equal(t, "first", <-received)
+	assert.Equal(t, "two", <-received)
+	assert.Equal(t, "after open", <-received)
+
+	assert.NoError(t, offerPC.Close())
+	assert.NoError(t, answerPC.Close())
+}
+
+// Assert that without a queue, even empty messages sent before the
+// DataChannel is open fail
+func TestDataChannel_SendQueueDisabled(t *testing.T) {
+	offerPC, answerPC, err := newPair()
+	assert.NoError(t, err)
+
+	dc, err := offerPC.CreateDataChannel(expectedLabel, nil)
+	assert.NoError(t, err)
+
+	assert.True(t, errors.Is(dc.SendText(""), ErrDataChannelNotOpen))
+	assert.True(t, errors.Is(dc.Send([]byte{}), ErrDataChannelNotOpen))
+	assert.Equal(t, uint64(0), dc.BufferedAmount())
+
+	assert.NoError(t, offerPC.Close())
+	assert.NoError(t, answerPC.Close())
+}
+
+// Assert that messages sent while the queue is written on open are not
+// reordered with the queued ones
+func TestDataChannel_SendQueueConcurrentSend(t *testing.T) {
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	const messages = 1000
+
+	s := SettingEngine{}
+	s.SetDataChannelSendQueueSize(messages * 4)
+
+	offerPC, answerPC, err := NewAPI(WithSettingEngine(s)).newPair(Configuration{})
+	assert.NoError(t, err)
+
+	received := make(chan uint32, messages)
+	answerPC.OnDataChannel(func(d *DataChannel) {
+		if d.Label() != expectedLabel {
+			return
+		}
+		d.OnMessage(func(msg DataChannelMessage) {
+			received <- binary.BigEndian.Uint32(msg.Data)
+		})
+	})
+
+	dc, err := offerPC.CreateDataChannel(expectedLabel, nil)
+	assert.NoError(t, err)
+
+	sent := make(chan struct{})
+	go func() {
+		defer close(sent)
+		for i := uint32(0); i < messages; i++ {
+			msg := make([]byte, 4)
+			binary.BigEndian.PutUint32(msg, i)
+			assert.NoError(t, dc.Send(msg))
+			time.Sleep(time.Millisecond)
+		}
+	}()
+
+	assert.NoError(t, signalPair(offerPC, answerPC))
+	for i := uint32(0); i < messages; i++ {
+		assert.Equal(t, i, <-received)
+	}
+	<-sent
+
+	assert.NoError(t, offerPC.Close())
+	assert.NoError(t, answerPC.Close())
+}
+
+func TestDataChannel_MaxBufferedAmount(t *testing.T) {
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	const (
+		maxAmount   = 4096
+		messageSize = 1024
+		messages    = 64
+	)
+
+	t.Run("Error", func(t *testing.T) {
+		s := SettingEngine{}
+		s.SetDataChannelMaxBufferedAmount(maxAmount, false)
+
+		offerPC, answerPC, err := NewAPI(WithSettingEngine(s)).newPair(Configuration{})
+		assert.NoError(t, err)
+
+		dc, err := offerPC.CreateDataChannel(expectedLabel, nil)
+		assert.NoError(t, err)
+
+		done := make(chan struct{})
+		dc.OnOpen(func() {
+			defer close(done)
+
+			// Sending faster than the messages are acknowledged fills the buffer
+			for i := 0; i < messages; i++ {
+				if err := dc.Send(make([]byte, messageSize)); err != nil {
+					assert.Equal(t, ErrDataChannelBufferFull, err)
+					return
+				}
+				assert.True(t, dc.BufferedAmount() <= maxAmount)
+			}
+			assert.Fail(t, "buffered amount never exceeded the maximum")
+		})
+
+		assert.NoError(t, signalPair(offerPC, answerPC))
+		<-done
+
+		assert.NoError(t, offerPC.Close())
+		assert.NoError(t, answerPC.Close())
+	})
+
+	t.Run("Block", func(t *testing.T) {
+		s := SettingEngine{}
+		s.SetDataChannelMaxBufferedAmount(maxAmount, true)
+
+		offerPC, answerPC, err := NewAPI(WithSettingEngine(s)).newPair(Configuration{})
+		assert.NoError(t, err)
+
+		received := make(chan int, messages)
+		answerPC.OnDataChannel(func(d *DataChannel) {
+			if d.Label() != expectedLabel {
+				return
+			}
+			d.OnMessage(func(msg DataChannelMessage) {
+				received <- len(msg.Data)
+			})
+		})
+
+		dc, err := offerPC.CreateDataChannel(expectedLabel, nil)
+		assert.NoError(t, err)
+
+		done := make(chan struct{})
+		dc.OnOpen(func() {
+			defer close(done)
+
+			for i := 0; i < messages; i++ {
+				assert.NoError(t, dc.Send(make([]byte, messageSize)))
+				assert.True(t, dc.BufferedAmount() <= maxAmount)
+			}
+		})
+
+		assert.NoError(t, signalPair(offerPC, answerPC))
+		<-done
+
+		for i := 0; i < messages; i++ {
+			assert.Equal(t, messageSize, <-received)
+		}
+
+		assert.NoError(t, offerPC.Close())
+		assert.NoError(t, answerPC.Close())
+	})
+}
@@ -3,6 +3,8 @@
 package webrtc
 
 import (
+	"io"
+	"net"
 	"testing"
 	"time"
 
@@ -77,6 +79,134 @@ func TestDataChannel_ORTCE2E(t *testing.T) {
 	}
 }
 
+func TestDataChannel_ORTCExternalConn(t *testing.T) {
+	runTest := func(t *testing.T, connA, connB func(api *API, role ICERole) *ICETransport) {
+		lim := test.TimeOut(time.Second * 20)
+		defer lim.Stop()
+
+		report := test.CheckRoutines(t)
+		defer report()
+
+		newStack := func(newICE func(api *API, role ICERole) *ICETransport, role ICERole) *testORTCStack {
+			api := NewAPI()
+			ice := newICE(api, role)
+			dtls, err := api.NewDTLSTransport(ice, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return &testORTCStack{api: api, ice: ice, dtls: dtls, sctp: api.NewSCTPTransport(dtls)}
+		}
+		stackA := newStack(connA, ICERoleControlling)
+		stackB := newStack(connB, ICERoleControlled)
+
+		awaitSetup := make(chan struct{})
+		awaitString := make(chan struct{})
+		stackB.sctp.OnDataChannel(func(d *DataChannel) {
+			close(awaitSetup)
+
+			d.OnMessage(func(msg DataChannelMessage) {
+				if msg.IsString && string(msg.Data) == "ABC" {
+					close(awaitString)
+				}
+			})
+		})
+
+		start := func(local, remote *testORTCStack) error {
+			dtlsParams, err := remote.dtls.GetLocalParameters()
+			if err != nil {
+				return err
+			}
+			if err = local.dtls.Start(dtlsParams); err != nil {
+				return err
+			}
+			return local.sctp.Start(remote.sctp.GetCapabilities())
+		}
+
+		a := make(chan error)
+		go func() {
+			a <- start(stackB, stackA)
+		}()
+		if err := start(stackA, stackB); err != nil {
+			t.Fatal(err)
+		}
+		if err := <-a; err != nil {
+			t.Fatal(err)
+		}
+
+		var id uint16 = 1
+		channelA, err := stackA.api.NewDataChannel(stackA.sctp, &DataChannelParameters{Label: "Foo", ID: &id})
+		if err != nil {
+			t.Fatal(err)
+		}
+		<-awaitSetup
+
+		if err = channelA.SendText("ABC"); err != nil {
+			t.Fatal(err)
+		}
+		<-awaitString
+
+		if err = stackA.close(); err != nil {
+			t.Fatal(err)
+		}
+		if err = stackB.close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("Conn", func(t *testing.T) {
+		ca, cb := net.Pipe()
+		runTest(t,
+			func(api *API, role ICERole) *ICETransport { return api.NewICETransportFromConn(ca, role) },
+			func(api *API, role ICERole) *ICETransport { return api.NewICETransportFromConn(cb, role) },
+		)
+	})
+
+	t.Run("PacketConn", func(t *testing.T) {
+		ca, err := net.ListenPacket("udp4", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		cb, err := net.ListenPacket("udp4", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		runTest(t,
+			func(api *API, role ICERole) *ICETransport {
+				return api.NewICETransportFromPacketConn(ca, cb.LocalAddr(), role)
+			},
+			func(api *API, role ICERole) *ICETransport {
+				return api.NewICETransportFromPacketConn(cb, ca.LocalAddr(), role)
+			},
+		)
+	})
+
+	t.Run("ReadWriteCloser", func(t *testing.T) {
+		ra, wb := io.Pipe()
+		rb, wa := io.Pipe()
+		runTest(t,
+			func(api *API, role ICERole) *ICETransport {
+				return api.NewICETransportFromReadWriteCloser(&testPipeEnd{ra, wa}, role)
+			},
+			func(api *API, role ICERole) *ICETransport {
+				return api.NewICETransportFromReadWriteCloser(&testPipeEnd{rb, wb}, role)
+			},
+		)
+	})
+}
+
+// testPipeEnd is one end of a bidirectional transport built from two io.Pipes
+type testPipeEnd struct {
+	*io.PipeReader
+	*io.PipeWriter
+}
+
+func (p *testPipeEnd) Close() error {
+	if err := p.PipeWriter.Close(); err != nil {
+		return err
+	}
+	return p.PipeReader.Close()
+}
+
 type testORTCStack struct {
 	api      *API
 	gatherer *ICEGatherer
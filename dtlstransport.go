@@ -8,7 +8,6 @@ import (
 	"crypto/rand"
 	"crypto/tls"
 	"crypto/x509"
-	"errors"
 	"fmt"
 	"strings"
 	"sync"
@@ -16,9 +15,12 @@ import (
 
 	"github.com/pion/dtls/v2"
 	"github.com/pion/dtls/v2/pkg/crypto/fingerprint"
+	"github.com/pion/logging"
+	"github.com/pion/rtcp"
 	"github.com/pion/srtp"
 	"github.com/pion/webrtc/v2/internal/mux"
 	"github.com/pion/webrtc/v2/internal/util"
+	"github.com/pion/webrtc/v2/pkg/interceptor"
 	"github.com/pion/webrtc/v2/pkg/rtcerr"
 )
 
@@ -46,7 +48,16 @@ type DTLSTransport struct {
 
 	dtlsMatcher mux.MatchFunc
 
+	// RTP and RTCP of the RTPSenders and RTPReceivers go through interceptor,
+	// rtcpWriter is the RTCP writer bound to it
+	interceptor *interceptor.Chain
+	rtcpWriter  interceptor.RTCPWriter
+
+	// When the RTP packets of the RTPReceivers were read from srtpEndpoint
+	arrivals arrivalTimes
+
 	api *API
+	log logging.LeveledLogger
 }
 
 // NewDTLSTransport creates a new DTLSTransport.
@@ -58,6 +69,7 @@ func (api *API) NewDTLSTransport(transport *ICETransport, certificates []Certifi
 		api:          api,
 		state:        DTLSTransportStateNew,
 		dtlsMatcher:  mux.MatchDTLS,
+		log:          api.settingEngine.LoggerFactory.NewLogger("dtls"),
 	}
 
 	if len(certificates) > 0 {
@@ -80,6 +92,12 @@ func (api *API) NewDTLSTransport(transport *ICETransport, certificates []Certifi
 		t.certificates = []Certificate{*certificate}
 	}
 
+	var err error
+	if t.interceptor, err = api.newInterceptorChain(&t.arrivals); err != nil {
+		return nil, err
+	}
+	t.rtcpWriter = t.interceptor.BindRTCPWriter(interceptor.RTCPWriterFunc(t.writeRTCP))
+
 	return t, nil
 }
 
@@ -134,6 +152,13 @@ func (t *DTLSTransport) GetLocalParameters() (DTLSParameters, error) {
 	}, nil
 }
 
+// GetRemoteParameters returns the DTLS parameters the DTLSTransport was started with
+func (t *DTLSTransport) GetRemoteParameters() DTLSParameters {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return t.remoteParameters
+}
+
 // GetRemoteCertificate returns the certificate chain in use by the remote side
 // returns an empty list prior to selection of the remote certificate
 func (t *DTLSTransport) GetRemoteCertificate() []byte {
@@ -149,7 +174,7 @@ func (t *DTLSTransport) startSRTP() error {
 	if t.srtpSession != nil && t.srtcpSession != nil {
 		return nil
 	} else if t.conn == nil {
-		return fmt.Errorf("the DTLS transport has not started yet")
+		return ErrDTLSTransportNotStarted
 	}
 
 	srtpConfig := &srtp.Config{
@@ -187,17 +212,22 @@ func (t *DTLSTransport) startSRTP() error {
 	connState := t.conn.ConnectionState()
 	err := srtpConfig.ExtractSessionKeysFromDTLS(&connState, t.role() == DTLSRoleClient)
 	if err != nil {
-		return fmt.Errorf("failed to extract sctp session keys: %v", err)
+		return fmt.Errorf("%w: %v", ErrSRTPSessionKeys, err)
 	}
 
-	srtpSession, err := srtp.NewSessionSRTP(t.srtpEndpoint, srtpConfig)
+	timings := t.api.settingEngine.instrumentation.ReceiveTimings
+	srtpSession, err := srtp.NewSessionSRTP(timeReceive(timings, &arrivalConn{Conn: t.srtpEndpoint, arrivals: &t.arrivals}, func(r *ReceiveTimings) *TimingHistogram {
+		return &r.SRTP
+	}), srtpConfig)
 	if err != nil {
-		return fmt.Errorf("failed to start srtp: %v", err)
+		return fmt.Errorf("%w: %v", ErrSRTPStart, err)
 	}
 
-	srtcpSession, err := srtp.NewSessionSRTCP(t.srtcpEndpoint, srtpConfig)
+	srtcpSession, err := srtp.NewSessionSRTCP(timeReceive(timings, t.srtcpEndpoint, func(r *ReceiveTimings) *TimingHistogram {
+		return &r.SRTCP
+	}), srtpConfig)
 	if err != nil {
-		return fmt.Errorf("failed to start srtp: %v", err)
+		return fmt.Errorf("%w: %v", ErrSRTPStart, err)
 	}
 
 	t.srtpSession = srtpSession
@@ -235,6 +265,26 @@ func (t *DTLSTransport) getSRTCPSession() (*srtp.SessionSRTCP, error) {
 	return t.srtcpSession, nil
 }
 
+// writeRTCP sends RTCP packets as a compound packet, it is the end of the interceptor chain
+func (t *DTLSTransport) writeRTCP(pkts []rtcp.Packet) (int, error) {
+	raw, err := rtcp.Marshal(pkts)
+	if err != nil {
+		return 0, err
+	}
+
+	srtcpSession, err := t.getSRTCPSession()
+	if err != nil {
+		return 0, err
+	}
+
+	writeStream, err := srtcpSession.OpenWriteStream()
+	if err != nil {
+		return 0, err
+	}
+
+	return writeStream.Write(raw)
+}
+
 func (t *DTLSTransport) role() DTLSRole {
 	// If remote has an explicit role use the inverse
 	switch t.remoteParameters.Role {
@@ -272,7 +322,7 @@ func (t *DTLSTransport) Start(remoteParameters DTLSParameters) error {
 		}
 
 		if t.state != DTLSTransportStateNew {
-			return DTLSRole(0), nil, &rtcerr.InvalidStateError{Err: fmt.Errorf("attempted to start DTLSTransport that is not in new state: %s", t.state)}
+			return DTLSRole(0), nil, &rtcerr.InvalidStateError{Err: fmt.Errorf("%w: %s", ErrDTLSTransportNotNew, t.state)}
 		}
 
 		t.srtpEndpoint = t.iceTransport.NewEndpoint(mux.MatchSRTP)
@@ -292,11 +342,14 @@ func (t *DTLSTransport) Start(remoteParameters DTLSParameters) error {
 			ClientAuth:             dtls.RequireAnyClientCert,
 			LoggerFactory:          t.api.settingEngine.LoggerFactory,
 			InsecureSkipVerify:     true,
+			MTU:                    t.api.settingEngine.getMTU(),
 		}, nil
 	}
 
 	var dtlsConn *dtls.Conn
-	dtlsEndpoint := t.iceTransport.NewEndpoint(mux.MatchDTLS)
+	dtlsEndpoint := timeReceive(t.api.settingEngine.instrumentation.ReceiveTimings, tapPackets(t.api.settingEngine.instrumentation.PacketTap, t.iceTransport.NewEndpoint(mux.MatchDTLS), packetTapDTLS), func(r *ReceiveTimings) *TimingHistogram {
+		return &r.DTLS
+	})
 	role, dtlsConfig, err := prepareTransport()
 	if err != nil {
 		return err
@@ -319,6 +372,7 @@ func (t *DTLSTransport) Start(remoteParameters DTLSParameters) error {
 	defer t.lock.Unlock()
 
 	if err != nil {
+		t.log.Warnf("Failed to complete DTLS handshake as %s: %v", role, err)
 		t.onStateChange(DTLSTransportStateFailed)
 		return err
 	}
@@ -333,19 +387,22 @@ func (t *DTLSTransport) Start(remoteParameters DTLSParameters) error {
 	// Check the fingerprint if a certificate was exchanged
 	remoteCerts := t.conn.ConnectionState().PeerCertificates
 	if len(remoteCerts) == 0 {
+		t.log.Warn("Peer didn't provide certificate via DTLS")
 		t.onStateChange(DTLSTransportStateFailed)
-		return fmt.Errorf("peer didn't provide certificate via DTLS")
+		return ErrNoRemoteCertificate
 	}
 	t.remoteCertificate = remoteCerts[0]
 
 	parsedRemoteCert, err := x509.ParseCertificate(t.remoteCertificate)
 	if err != nil {
+		t.log.Warnf("Failed to parse remote certificate: %v", err)
 		t.onStateChange(DTLSTransportStateFailed)
 		return err
 	}
 
 	err = t.validateFingerPrint(parsedRemoteCert)
 	if err != nil {
+		t.log.Warnf("Failed to validate remote certificate fingerprint: %v", err)
 		t.onStateChange(DTLSTransportStateFailed)
 	}
 	return err
@@ -359,6 +416,10 @@ func (t *DTLSTransport) Stop() error {
 	// Try closing everything and collect the errors
 	var closeErrs []error
 
+	if err := t.interceptor.Close(); err != nil {
+		closeErrs = append(closeErrs, err)
+	}
+
 	if t.srtpSession != nil {
 		if err := t.srtpSession.Close(); err != nil {
 			closeErrs = append(closeErrs, err)
@@ -398,12 +459,12 @@ func (t *DTLSTransport) validateFingerPrint(remoteCert *x509.Certificate) error
 		}
 	}
 
-	return errors.New("no matching fingerprint")
+	return ErrNoMatchingFingerprint
 }
 
 func (t *DTLSTransport) ensureICEConn() error {
 	if t.iceTransport == nil || t.iceTransport.State() == ICETransportStateNew {
-		return errors.New("ICE connection not started")
+		return ErrICENotStarted
 	}
 
 	return nil
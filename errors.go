@@ -16,6 +16,14 @@ var (
 	// channel is not (yet) open.
 	ErrDataChannelNotOpen = errors.New("data channel not open")
 
+	// ErrDataChannelBufferFull indicates a message was sent while the buffered
+	// amount of the data channel is at the maximum set in the SettingEngine.
+	ErrDataChannelBufferFull = errors.New("data channel buffered amount exceeds the maximum")
+
+	// ErrPayloadProtocolIdentifierNotMessage indicates a PPID that doesn't
+	// identify a DataChannel message, like the one of DCEP.
+	ErrPayloadProtocolIdentifierNotMessage = errors.New("payload protocol identifier doesn't identify a data channel message")
+
 	// ErrCertificateExpired indicates that an x509 certificate has expired.
 	ErrCertificateExpired = errors.New("x509Cert expired")
 
@@ -54,6 +62,10 @@ var (
 	// ICECandidatePoolSize was made after PeerConnection has been initialized.
 	ErrModifyingICECandidatePoolSize = errors.New("ice candidate pool size cannot be modified")
 
+	// ErrHeaderExtensionLimit indicates that no more RTP header extensions
+	// can be registered as all one-byte header IDs are taken.
+	ErrHeaderExtensionLimit = errors.New("no free RTP header extension IDs")
+
 	// ErrStringSizeLimit indicates that the character size limit of string is
 	// exceeded. The limit is hardcoded to 65535 according to specifications.
 	ErrStringSizeLimit = errors.New("data channel label exceeds size limit")
@@ -80,6 +92,24 @@ var (
 	// the remote description is not set
 	ErrNoRemoteDescription = errors.New("remote description is not set")
 
+	// ErrSignalingStateCannotRollback indicates a rollback was requested
+	// while there is no pending description of that side to roll back.
+	ErrSignalingStateCannotRollback = errors.New("can't rollback from this signaling state")
+
+	// ErrSignalingStateRollbackIrreversible indicates a rollback of a remote
+	// offer was requested after the offer started the transports or stopped
+	// transceivers, which can't be undone.
+	ErrSignalingStateRollbackIrreversible = errors.New("can't rollback a remote offer that started the transports or stopped transceivers")
+
+	// ErrSignalingStateProposedTransitionInvalid indicates a description was
+	// set that isn't valid in the current signaling state, e.g. an answer
+	// while there is no offer to answer.
+	ErrSignalingStateProposedTransitionInvalid = errors.New("invalid proposed signaling state transition")
+
+	// ErrIncorrectSignalingState indicates an operation was called in a
+	// signaling state it isn't valid in, e.g. CreateAnswer without a remote offer.
+	ErrIncorrectSignalingState = errors.New("operation can not be run in current signaling state")
+
 	// ErrIncorrectSDPSemantics indicates that the PeerConnection was configured to
 	// generate SDP Answers with different SDP Semantics than the received Offer
 	ErrIncorrectSDPSemantics = errors.New("offer SDP semantics does not match configuration")
@@ -111,4 +141,143 @@ var (
 	// ErrSessionDescriptionMissingIcePwd indicates SetRemoteDescription was called with a SessionDescription that
 	// is missing an ice-pwd value
 	ErrSessionDescriptionMissingIcePwd = errors.New("SetRemoteDescription called with no ice-pwd")
+
+	// ErrDTMFNotNegotiated indicates InsertDTMF was called on a RTPSender for
+	// which the telephone-event codec has not been negotiated
+	ErrDTMFNotNegotiated = errors.New("telephone-event has not been negotiated")
+
+	// ErrDTMFInvalidTone indicates InsertDTMF was called with a character that
+	// is not a DTMF tone
+	ErrDTMFInvalidTone = errors.New("invalid DTMF tone")
+
+	// ErrPlayoutDelayInvalid indicates a playout delay whose minimum is greater
+	// than its maximum, or that is longer than the extension can carry
+	ErrPlayoutDelayInvalid = errors.New("invalid playout delay")
+
+	// ErrHeaderExtensionTooSmall indicates a RTP header extension payload is
+	// too short to be parsed
+	ErrHeaderExtensionTooSmall = errors.New("RTP header extension payload is too small")
+
+	// ErrFECProtectionRateInvalid indicates a FEC protection rate that is not
+	// greater than 0 and at most 1
+	ErrFECProtectionRateInvalid = errors.New("invalid FEC protection rate")
+
+	// ErrOptionsNotSupported indicates CreateOffer or CreateAnswer was called
+	// with options, which are not supported yet
+	ErrOptionsNotSupported = errors.New("offer and answer options are not supported")
+
+	// ErrIdentityProviderNotSupported indicates an identity provider was
+	// configured, which is not supported yet
+	ErrIdentityProviderNotSupported = errors.New("identity providers are not supported")
+
+	// ErrSDPDoesNotMatchOffer indicates SetLocalDescription was called with an
+	// offer that is not the last one created by CreateOffer
+	ErrSDPDoesNotMatchOffer = errors.New("new sdp does not match previous offer")
+
+	// ErrSDPDoesNotMatchAnswer indicates SetLocalDescription was called with
+	// an answer that is not the last one created by CreateAnswer
+	ErrSDPDoesNotMatchAnswer = errors.New("new sdp does not match previous answer")
+
+	// ErrSignalingStateChangeOpInvalid indicates a description of a type that
+	// can't be set by the operation
+	ErrSignalingStateChangeOpInvalid = errors.New("invalid state change op")
+
+	// ErrSDPTypeInvalid indicates a SessionDescription of a type that can't be
+	// used, e.g. rollback without a SDP to SetLocalDescription
+	ErrSDPTypeInvalid = errors.New("invalid SDP type")
+
+	// ErrMediaSectionWithoutMid indicates the remote description has a media
+	// section without a mid
+	ErrMediaSectionWithoutMid = errors.New("RemoteDescription contained media section without mid value")
+
+	// ErrNoSimulcastMediaSection indicates an unknown SSRC was received while
+	// the remote description doesn't announce Simulcast
+	ErrNoSimulcastMediaSection = errors.New("RemoteDescription has no Simulcast media sections")
+
+	// ErrSimulcastProbeFailed indicates the first packets of an unknown SSRC
+	// didn't carry the MID and RID header extensions
+	ErrSimulcastProbeFailed = errors.New("no MID and RID header extensions")
+
+	// ErrTransceiverNotFound indicates a media section or a Simulcast stream
+	// refers to a mid no transceiver has
+	ErrTransceiverNotFound = errors.New("no transceiver with mid")
+
+	// ErrTransceiverInitTooMany indicates AddTransceiverFromKind or
+	// AddTransceiverFromTrack was called with more than one RtpTransceiverInit
+	ErrTransceiverInitTooMany = errors.New("only one RtpTransceiverInit is accepted")
+
+	// ErrTransceiverDirectionNotSupported indicates a transceiver was added
+	// with a direction that is not supported for it
+	ErrTransceiverDirectionNotSupported = errors.New("transceiver direction is not supported")
+
+	// ErrCodecPayloaderNotSet indicates a Track was created for a codec that
+	// has no Payloader
+	ErrCodecPayloaderNotSet = errors.New("codec payloader not set")
+
+	// ErrNilTrack indicates a RTPSender was constructed without a Track
+	ErrNilTrack = errors.New("Track must not be nil")
+
+	// ErrNilDTLSTransport indicates a RTPSender or RTPReceiver was constructed
+	// without a DTLSTransport
+	ErrNilDTLSTransport = errors.New("DTLSTransport must not be nil")
+
+	// ErrRTPSenderWithRemoteTrack indicates a RTPSender was constructed with a
+	// Track that is received
+	ErrRTPSenderWithRemoteTrack = errors.New("RTPSender can not be constructed with remote track")
+
+	// ErrRTPSenderSendAlreadyCalled indicates Send was called twice on a RTPSender
+	ErrRTPSenderSendAlreadyCalled = errors.New("Send has already been called")
+
+	// ErrRTPSenderStopped indicates an operation on a RTPSender that has been stopped
+	ErrRTPSenderStopped = errors.New("RTPSender has been stopped")
+
+	// ErrRTPSenderNotSending indicates an operation on a RTPSender that has
+	// not started sending yet
+	ErrRTPSenderNotSending = errors.New("RTPSender has not started sending")
+
+	// ErrRTPReceiverReceiveAlreadyCalled indicates Receive was called twice on
+	// a RTPReceiver
+	ErrRTPReceiverReceiveAlreadyCalled = errors.New("Receive has already been called")
+
+	// ErrRTPReceiverStopped indicates an operation on a RTPReceiver that has
+	// been stopped
+	ErrRTPReceiverStopped = errors.New("RTPReceiver has been stopped")
+
+	// ErrRTPReceiverRIDExists indicates a Simulcast layer was received twice
+	ErrRTPReceiverRIDExists = errors.New("RTPReceiver is already receiving rid")
+
+	// ErrRTPReceiverNoStreams indicates RTCP was read from a RTPReceiver that
+	// receives nothing
+	ErrRTPReceiverNoStreams = errors.New("RTPReceiver has no streams")
+
+	// ErrRTPReceiverStreamNotFound indicates RTP was read for a Track the
+	// RTPReceiver doesn't receive
+	ErrRTPReceiverStreamNotFound = errors.New("unable to find stream for Track")
+
+	// ErrDTLSTransportNotStarted indicates an operation that requires the DTLS
+	// handshake to be done
+	ErrDTLSTransportNotStarted = errors.New("the DTLS transport has not started yet")
+
+	// ErrDTLSTransportNotNew indicates Start was called on a DTLSTransport
+	// that is not in the new state
+	ErrDTLSTransportNotNew = errors.New("attempted to start DTLSTransport that is not in new state")
+
+	// ErrSRTPSessionKeys indicates the SRTP keys could not be extracted from
+	// the DTLS connection
+	ErrSRTPSessionKeys = errors.New("failed to extract srtp session keys")
+
+	// ErrSRTPStart indicates the SRTP or SRTCP session could not be started
+	ErrSRTPStart = errors.New("failed to start srtp")
+
+	// ErrNoRemoteCertificate indicates the remote didn't provide a certificate
+	// in the DTLS handshake
+	ErrNoRemoteCertificate = errors.New("peer didn't provide certificate via DTLS")
+
+	// ErrNoMatchingFingerprint indicates the certificate of the remote doesn't
+	// match the fingerprint of its description
+	ErrNoMatchingFingerprint = errors.New("no matching fingerprint")
+
+	// ErrICENotStarted indicates the DTLSTransport was started before its
+	// ICETransport
+	ErrICENotStarted = errors.New("ICE connection not started")
 )
@@ -0,0 +1,76 @@
+// +build !js
+
+package webrtc
+
+import (
+	"sync"
+)
+
+// eventDispatcher delivers the events of one kind to their handler one at a
+// time, in the order they were fired. Events are queued and the handler is
+// run on the executor, never on the goroutine firing them: that one usually
+// holds the state of a transport or reads the network, and a handler calling
+// back into the object would deadlock. A handler that doesn't return only
+// holds back the events of its own kind, objects have a dispatcher per kind.
+// The zero value runs every batch of events on a goroutine of its own.
+type eventDispatcher struct {
+	executor func(func())
+
+	mu      sync.Mutex
+	events  []func()
+	running bool
+}
+
+// dispatch queues an event, if no handler is running the queue is handed to
+// the executor to be drained
+func (d *eventDispatcher) dispatch(event func()) {
+	d.mu.Lock()
+	d.events = append(d.events, event)
+	if d.running {
+		d.mu.Unlock()
+		return
+	}
+	d.running = true
+	d.mu.Unlock()
+
+	runEvent(d.executor, d.run)
+}
+
+// dispatchAndWait queues an event and waits for its handler to return, so the
+// caller is paced by the handlers. It must not be called by a handler.
+func (d *eventDispatcher) dispatchAndWait(event func()) {
+	done := make(chan struct{})
+	d.dispatch(func() {
+		defer close(done)
+		event()
+	})
+	<-done
+}
+
+func (d *eventDispatcher) run() {
+	for {
+		d.mu.Lock()
+		if len(d.events) == 0 {
+			d.running = false
+			d.mu.Unlock()
+			return
+		}
+		event := d.events[0]
+		d.events = d.events[1:]
+		d.mu.Unlock()
+
+		event()
+	}
+}
+
+// runEvent runs the handler of an event that isn't ordered with the others of
+// its kind on executor, or on a goroutine of its own if it is nil. It is used
+// for the handlers expected to run as long as what they were given, like the
+// ones reading a Track.
+func runEvent(executor func(func()), event func()) {
+	if executor != nil {
+		executor(event)
+	} else {
+		go event()
+	}
+}
@@ -0,0 +1,218 @@
+// +build !js
+
+package webrtc
+
+import (
+	"math/rand"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pion/transport/test"
+	"github.com/pion/webrtc/v2/pkg/media"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventDispatcher(t *testing.T) {
+	lim := test.TimeOut(time.Second * 10)
+	defer lim.Stop()
+
+	t.Run("Ordered", func(t *testing.T) {
+		d := eventDispatcher{}
+
+		const events = 100
+		delivered := make(chan int, events+1)
+		for i := 0; i < events; i++ {
+			i := i
+			d.dispatch(func() {
+				if i == 0 {
+					// Events fired by a handler are delivered after it returns
+					d.dispatch(func() { delivered <- events })
+					time.Sleep(10 * time.Millisecond)
+				}
+				delivered <- i
+			})
+		}
+
+		for i := 0; i < events; i++ {
+			assert.Equal(t, i, <-delivered)
+		}
+		assert.Equal(t, events, <-delivered)
+	})
+
+	t.Run("Executor", func(t *testing.T) {
+		var executed int32
+		d := eventDispatcher{executor: func(run func()) {
+			atomic.AddInt32(&executed, 1)
+			go run()
+		}}
+
+		handled := false
+		d.dispatchAndWait(func() { handled = true })
+		assert.True(t, handled)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&executed))
+	})
+}
+
+// Assert that the handlers run on the executor in the order of the events, and
+// that a handler can close its PeerConnection
+func TestPeerConnection_EventExecutor(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	handlers := make(chan func(), 64)
+	stop := make(chan struct{})
+	loopDone := make(chan struct{})
+	go func() {
+		defer close(loopDone)
+		for {
+			select {
+			case handler := <-handlers:
+				handler()
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	s := SettingEngine{}
+	s.SetEventExecutor(func(handler func()) {
+		select {
+		case handlers <- handler:
+		case <-stop:
+		}
+	})
+
+	pcOffer, pcAnswer, err := NewAPI(WithSettingEngine(s)).newPair(Configuration{})
+	assert.NoError(t, err)
+
+	var states []PeerConnectionState
+	statesDone := make(chan []PeerConnectionState)
+	pcOffer.OnConnectionStateChange(func(state PeerConnectionState) {
+		// Connecting is skipped when ICE connects right away
+		if state == PeerConnectionStateConnecting {
+			return
+		}
+		states = append(states, state)
+		switch state {
+		case PeerConnectionStateConnected:
+			assert.NoError(t, pcOffer.Close())
+		case PeerConnectionStateClosed:
+			statesDone <- states
+		}
+	})
+
+	assert.NoError(t, signalPair(pcOffer, pcAnswer))
+
+	assert.Equal(t, []PeerConnectionState{
+		PeerConnectionStateConnected,
+		PeerConnectionStateClosed,
+	}, <-statesDone)
+
+	assert.NoError(t, pcAnswer.Close())
+	close(stop)
+	<-loopDone
+}
+
+// Assert that OnTrack handlers reading their Track until it ends don't hold
+// back the other OnTrack events nor the state changes
+func TestPeerConnection_BlockingOnTrack(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pcOffer, pcAnswer, err := newPair()
+	assert.NoError(t, err)
+
+	audioTrack, err := pcOffer.NewTrack(DefaultPayloadTypeOpus, rand.Uint32(), "audio", "pion")
+	assert.NoError(t, err)
+	_, err = pcOffer.AddTrack(audioTrack)
+	assert.NoError(t, err)
+
+	videoTrack, err := pcOffer.NewTrack(DefaultPayloadTypeVP8, rand.Uint32(), "video", "pion")
+	assert.NoError(t, err)
+	_, err = pcOffer.AddTrack(videoTrack)
+	assert.NoError(t, err)
+
+	onTrack := make(chan RTPCodecType, 2)
+	pcAnswer.OnTrack(func(track *Track, receiver *RTPReceiver) {
+		onTrack <- track.Kind()
+		for {
+			if _, readErr := track.ReadRTP(); readErr != nil {
+				return
+			}
+		}
+	})
+
+	connected := make(chan struct{})
+	pcAnswer.OnConnectionStateChange(func(state PeerConnectionState) {
+		if state == PeerConnectionStateConnected {
+			close(connected)
+		}
+	})
+
+	assert.NoError(t, signalPair(pcOffer, pcAnswer))
+
+	kinds := map[RTPCodecType]bool{}
+	for len(kinds) != 2 {
+		select {
+		case kind := <-onTrack:
+			kinds[kind] = true
+		case <-time.After(20 * time.Millisecond):
+			for _, track := range []*Track{audioTrack, videoTrack} {
+				assert.NoError(t, track.WriteSample(media.Sample{Data: []byte{0x00}, Samples: 1}))
+			}
+		}
+	}
+	<-connected
+
+	assert.NoError(t, pcOffer.Close())
+	assert.NoError(t, pcAnswer.Close())
+}
+
+// Assert that an OnOpen handler sending until it is told to stop doesn't hold
+// back OnMessage
+func TestDataChannel_BlockingOnOpen(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pcOffer, pcAnswer, err := newPair()
+	assert.NoError(t, err)
+
+	received := make(chan struct{})
+	dc, err := pcOffer.CreateDataChannel("data", nil)
+	assert.NoError(t, err)
+	dc.OnOpen(func() {
+		for {
+			select {
+			case <-received:
+				return
+			case <-time.After(20 * time.Millisecond):
+				assert.NoError(t, dc.SendText("ping"))
+			}
+		}
+	})
+
+	pcAnswer.OnDataChannel(func(d *DataChannel) {
+		var once int32
+		d.OnMessage(func(DataChannelMessage) {
+			if atomic.CompareAndSwapInt32(&once, 0, 1) {
+				close(received)
+			}
+		})
+	})
+
+	assert.NoError(t, signalPair(pcOffer, pcAnswer))
+	<-received
+
+	assert.NoError(t, pcOffer.Close())
+	assert.NoError(t, pcAnswer.Close())
+}
@@ -80,7 +80,8 @@ func main() {
 
 		// Send our video file frame at a time. Pace our sending so we send it at the same speed it should be played back as.
 		// This isn't required since the video is timestamped, but we will such much higher loss if we send all at once.
-		sleepTime := time.Millisecond * time.Duration((float32(header.TimebaseNumerator)/float32(header.TimebaseDenominator))*1000)
+		frameDuration := time.Duration(float64(header.TimebaseNumerator) / float64(header.TimebaseDenominator) * float64(time.Second))
+		writer := media.NewRealtimeWriter(videoTrack)
 		for {
 			frame, _, ivfErr := ivf.ParseNextFrame()
 			if ivfErr == io.EOF {
@@ -92,8 +93,7 @@ func main() {
 				panic(ivfErr)
 			}
 
-			time.Sleep(sleepTime)
-			if ivfErr = videoTrack.WriteSample(media.Sample{Data: frame, Samples: 90000}); ivfErr != nil {
+			if ivfErr = writer.WriteSample(media.Sample{Data: frame, Duration: frameDuration}); ivfErr != nil {
 				panic(ivfErr)
 			}
 		}
@@ -0,0 +1,61 @@
+// +build !js
+
+package webrtc
+
+import (
+	mathRand "math/rand"
+	"sync"
+
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v2/pkg/fec"
+	"github.com/pion/webrtc/v2/pkg/interceptor"
+)
+
+// fecGenerator is the built-in interceptor that sends FlexFEC packets
+// protecting the streams FlexFEC has been negotiated for. It is closer to the
+// network than the nackResponder, so FEC packets are not kept to be
+// retransmitted, and the encoder ignores the retransmissions.
+type fecGenerator struct {
+	interceptor.NoOp
+	protectionRate float64
+}
+
+func newFECGenerator(protectionRate float64) *fecGenerator {
+	return &fecGenerator{protectionRate: protectionRate}
+}
+
+// BindLocalStream sends a FEC packet every time a group of packets written to
+// the stream is complete
+func (f *fecGenerator) BindLocalStream(info *interceptor.StreamInfo, writer interceptor.RTPWriter) interceptor.RTPWriter {
+	if info.FECPayloadType == 0 {
+		return writer
+	}
+
+	var mu sync.Mutex
+	encoder := fec.NewEncoder(info.SSRC, fec.WithProtectionRate(f.protectionRate))
+	sequenceNumber := uint16(mathRand.Uint32())
+
+	return interceptor.RTPWriterFunc(func(header *rtp.Header, payload []byte) (int, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		i, err := writer.Write(header, payload)
+		if err != nil {
+			return i, err
+		}
+
+		// The media packet has been sent, failing to protect it isn't reported
+		if fecPayload, err := encoder.Push(header, payload); err == nil && fecPayload != nil {
+			fecHeader := &rtp.Header{
+				Version:        2,
+				PayloadType:    info.FECPayloadType,
+				SequenceNumber: sequenceNumber,
+				Timestamp:      header.Timestamp,
+				SSRC:           info.FECSSRC,
+			}
+			sequenceNumber++
+			_, _ = writer.Write(fecHeader, fecPayload)
+		}
+		return i, nil
+	})
+}
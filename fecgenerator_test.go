@@ -0,0 +1,52 @@
+// +build !js
+
+package webrtc
+
+import (
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v2/pkg/interceptor"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFECGenerator(t *testing.T) {
+	var written []rtp.Header
+	writer := interceptor.RTPWriterFunc(func(header *rtp.Header, payload []byte) (int, error) {
+		written = append(written, *header)
+		return len(payload), nil
+	})
+	write := func(w interceptor.RTPWriter, sequenceNumber uint16) {
+		_, err := w.Write(&rtp.Header{Version: 2, SSRC: 1000, SequenceNumber: sequenceNumber, Timestamp: uint32(sequenceNumber) * 10}, []byte{0x01})
+		assert.NoError(t, err)
+	}
+
+	generator := newFECGenerator(0.5)
+
+	t.Run("NotNegotiated", func(t *testing.T) {
+		written = nil
+		w := generator.BindLocalStream(&interceptor.StreamInfo{SSRC: 1000}, writer)
+		for i := uint16(0); i < 4; i++ {
+			write(w, i)
+		}
+		assert.Equal(t, 4, len(written))
+	})
+
+	t.Run("Negotiated", func(t *testing.T) {
+		written = nil
+		w := generator.BindLocalStream(&interceptor.StreamInfo{SSRC: 1000, FECSSRC: 2000, FECPayloadType: 118}, writer)
+		for i := uint16(0); i < 4; i++ {
+			write(w, i)
+		}
+		// Retransmissions are not protected again
+		write(w, 3)
+
+		assert.Equal(t, 7, len(written))
+		for _, i := range []int{2, 5} {
+			assert.Equal(t, uint32(2000), written[i].SSRC)
+			assert.Equal(t, uint8(118), written[i].PayloadType)
+			assert.Equal(t, written[i-1].Timestamp, written[i].Timestamp)
+		}
+		assert.Equal(t, written[2].SequenceNumber+1, written[5].SequenceNumber)
+	})
+}
@@ -0,0 +1,82 @@
+// +build !js
+
+package webrtc
+
+import (
+	"io"
+	"sync"
+
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v2/pkg/fec"
+	"github.com/pion/webrtc/v2/pkg/interceptor"
+)
+
+// fecRecoverer reads the packets of a stream protected by FlexFEC, and inserts
+// the packets recovered from the FEC stream among them. It is read before the
+// interceptors, so they see the recovered packets as received. Those are out
+// of order, a jitter buffer puts them back in place.
+type fecRecoverer struct {
+	reader interceptor.RTPReader
+
+	mu        sync.Mutex
+	decoder   *fec.Decoder
+	recovered [][]byte
+}
+
+func newFECRecoverer(ssrc uint32, reader interceptor.RTPReader) *fecRecoverer {
+	return &fecRecoverer{
+		reader:  reader,
+		decoder: fec.NewDecoder(ssrc),
+	}
+}
+
+// Read returns the next recovered packet if there is one, or else reads the
+// stream. If b is too small for the recovered packet io.ErrShortBuffer is
+// returned and the packet is kept
+func (f *fecRecoverer) Read(b []byte) (int, error) {
+	f.mu.Lock()
+	if len(f.recovered) != 0 {
+		defer f.mu.Unlock()
+
+		if len(f.recovered[0]) > len(b) {
+			return 0, io.ErrShortBuffer
+		}
+		n := copy(b, f.recovered[0])
+		f.recovered = f.recovered[1:]
+		return n, nil
+	}
+	f.mu.Unlock()
+
+	n, err := f.reader.Read(b)
+	if err != nil {
+		return n, err
+	}
+
+	f.mu.Lock()
+	f.recovered = append(f.recovered, f.decoder.Push(b[:n])...)
+	f.mu.Unlock()
+	return n, nil
+}
+
+// readFEC reads the FEC packets of stream until it is closed. Packets recovered
+// once a FEC packet arrives are returned by the next Read.
+func (f *fecRecoverer) readFEC(stream interceptor.RTPReader, mtu int) {
+	b := make([]byte, mtu)
+	for {
+		n, err := stream.Read(b)
+		if err != nil {
+			return
+		}
+
+		packet := &rtp.Packet{}
+		if err := packet.Unmarshal(b[:n]); err != nil {
+			continue
+		}
+
+		f.mu.Lock()
+		if recovered, err := f.decoder.PushFEC(packet.Payload); err == nil {
+			f.recovered = append(f.recovered, recovered...)
+		}
+		f.mu.Unlock()
+	}
+}
@@ -0,0 +1,124 @@
+// +build !js
+
+package webrtc
+
+import (
+	"encoding/binary"
+	"io"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/pion/logging"
+	"github.com/pion/rtp"
+	"github.com/pion/transport/test"
+	"github.com/pion/transport/vnet"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFECRecoverer(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	const lostSequenceNumber = 5
+	trackSSRC := rand.Uint32()
+
+	// Every packet of the track with the lost sequence number is dropped,
+	// retransmissions included, so it can only be recovered from FEC
+	wan, err := vnet.NewRouter(&vnet.RouterConfig{
+		CIDR:          "1.2.3.0/24",
+		LoggerFactory: logging.NewDefaultLoggerFactory(),
+	})
+	assert.NoError(t, err)
+	wan.AddChunkFilter(func(c vnet.Chunk) bool {
+		b := c.UserData()
+		return len(b) < 12 || binary.BigEndian.Uint32(b[8:]) != trackSSRC || binary.BigEndian.Uint16(b[2:]) != lostSequenceNumber
+	})
+
+	m := MediaEngine{}
+	m.RegisterDefaultCodecs()
+	m.RegisterCodec(NewRTPFlexFECCodec(118, 90000))
+
+	newPeerConnection := func(ip string) *PeerConnection {
+		n := vnet.NewNet(&vnet.NetConfig{StaticIPs: []string{ip}})
+		assert.NoError(t, wan.AddNet(n))
+
+		s := SettingEngine{}
+		s.SetVNet(n)
+		assert.NoError(t, s.SetFECProtectionRate(0.25))
+
+		pc, newErr := NewAPI(WithMediaEngine(m), WithSettingEngine(s)).NewPeerConnection(Configuration{})
+		assert.NoError(t, newErr)
+		return pc
+	}
+	pcOffer, pcAnswer := newPeerConnection("1.2.3.4"), newPeerConnection("1.2.3.5")
+	assert.NoError(t, wan.Start())
+
+	track, err := pcOffer.NewTrack(DefaultPayloadTypeVP8, trackSSRC, "video", "pion")
+	assert.NoError(t, err)
+
+	sender, err := pcOffer.AddTrack(track)
+	assert.NoError(t, err)
+
+	recovered := make(chan *rtp.Packet)
+	pcAnswer.OnTrack(func(remoteTrack *Track, receiver *RTPReceiver) {
+		for {
+			p, readErr := remoteTrack.ReadRTP()
+			if readErr != nil {
+				return
+			}
+			if p.SequenceNumber == lostSequenceNumber {
+				recovered <- p
+				return
+			}
+		}
+	})
+
+	assert.NoError(t, signalPair(pcOffer, pcAnswer))
+
+	// Packets written before the RTPSender is sending would be dropped
+	for !sender.GetEncodings()[0].Active {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.NotZero(t, sender.GetEncodings()[0].FEC.SSRC)
+
+	func() {
+		for sequenceNumber := uint16(0); ; sequenceNumber++ {
+			select {
+			case <-time.After(20 * time.Millisecond):
+				assert.NoError(t, track.WriteRTP(&rtp.Packet{
+					Header: rtp.Header{
+						Version:        2,
+						SSRC:           trackSSRC,
+						SequenceNumber: sequenceNumber,
+						Timestamp:      uint32(sequenceNumber) * 3000,
+					},
+					Payload: []byte{0x10, byte(sequenceNumber), 0x20},
+				}))
+			case p := <-recovered:
+				assert.Equal(t, uint32(lostSequenceNumber*3000), p.Timestamp)
+				assert.Equal(t, []byte{0x10, lostSequenceNumber, 0x20}, p.Payload)
+				return
+			}
+		}
+	}()
+
+	assert.NoError(t, pcOffer.Close())
+	assert.NoError(t, pcAnswer.Close())
+	assert.NoError(t, wan.Stop())
+}
+
+func TestFECRecoverer_ShortBuffer(t *testing.T) {
+	f := newFECRecoverer(1, nil)
+	f.recovered = [][]byte{{0x01, 0x02}}
+
+	// The recovered packet is kept until it is read with a buffer large enough
+	n, err := f.Read(make([]byte, 1))
+	assert.Equal(t, io.ErrShortBuffer, err)
+	assert.Equal(t, 0, n)
+
+	b := make([]byte, 2)
+	n, err = f.Read(b)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x01, 0x02}, b[:n])
+}
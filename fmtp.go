@@ -0,0 +1,114 @@
+// +build !js
+
+package webrtc
+
+import (
+	"strconv"
+	"strings"
+)
+
+const (
+	h264PacketizationModeDefault = "0"
+	h264ProfileLevelIDDefault    = "420010"
+	vp9ProfileIDDefault          = "0"
+)
+
+// H264 profiles as identified by the profile_idc and profile-iop bytes of the
+// profile-level-id, RFC 6184 Section 8.1. Levels don't affect compatibility
+const (
+	h264ProfileUnknown = iota
+	h264ProfileConstrainedBaseline
+	h264ProfileBaseline
+	h264ProfileMain
+	h264ProfileExtended
+	h264ProfileHigh
+)
+
+// parseFmtp splits the parameters of a fmtp line, names are case insensitive
+func parseFmtp(line string) map[string]string {
+	parameters := map[string]string{}
+	for _, parameter := range strings.Split(line, ";") {
+		split := strings.SplitN(strings.TrimSpace(parameter), "=", 2)
+		if split[0] == "" {
+			continue
+		}
+
+		value := ""
+		if len(split) == 2 {
+			value = split[1]
+		}
+		parameters[strings.ToLower(split[0])] = value
+	}
+	return parameters
+}
+
+func fmtpParameter(parameters map[string]string, name, defaultValue string) string {
+	if value, ok := parameters[name]; ok {
+		return value
+	}
+	return defaultValue
+}
+
+// h264Profile returns the profile of a profile-level-id, which is h264ProfileUnknown if it is invalid
+func h264Profile(profileLevelID string) int {
+	if len(profileLevelID) != 6 {
+		return h264ProfileUnknown
+	}
+
+	profileIDC, err := strconv.ParseUint(profileLevelID[0:2], 16, 8)
+	if err != nil {
+		return h264ProfileUnknown
+	}
+	profileIOP, err := strconv.ParseUint(profileLevelID[2:4], 16, 8)
+	if err != nil {
+		return h264ProfileUnknown
+	}
+
+	// The constraint_set flags of profile-iop make some profiles decodable as others
+	switch profileIDC {
+	case 0x42:
+		if profileIOP&0x40 != 0 {
+			return h264ProfileConstrainedBaseline
+		}
+		return h264ProfileBaseline
+	case 0x4d:
+		if profileIOP&0x80 != 0 {
+			return h264ProfileConstrainedBaseline
+		}
+		return h264ProfileMain
+	case 0x58:
+		switch {
+		case profileIOP&0xc0 == 0xc0:
+			return h264ProfileConstrainedBaseline
+		case profileIOP&0x80 != 0:
+			return h264ProfileBaseline
+		}
+		return h264ProfileExtended
+	case 0x64:
+		return h264ProfileHigh
+	}
+	return h264ProfileUnknown
+}
+
+// fmtpConsistent reports if two fmtp lines of the codec name describe streams that
+// can be exchanged. Only the parameters that change the format of the stream are
+// compared, the others like the Opus options are negotiated by each side independently
+func fmtpConsistent(name, a, b string) bool {
+	parametersA, parametersB := parseFmtp(a), parseFmtp(b)
+
+	switch {
+	case strings.EqualFold(name, H264):
+		if fmtpParameter(parametersA, "packetization-mode", h264PacketizationModeDefault) !=
+			fmtpParameter(parametersB, "packetization-mode", h264PacketizationModeDefault) {
+			return false
+		}
+
+		profileA := h264Profile(fmtpParameter(parametersA, "profile-level-id", h264ProfileLevelIDDefault))
+		profileB := h264Profile(fmtpParameter(parametersB, "profile-level-id", h264ProfileLevelIDDefault))
+		return profileA != h264ProfileUnknown && profileA == profileB
+	case strings.EqualFold(name, VP9):
+		return fmtpParameter(parametersA, "profile-id", vp9ProfileIDDefault) ==
+			fmtpParameter(parametersB, "profile-id", vp9ProfileIDDefault)
+	}
+	return true
+}
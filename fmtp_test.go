@@ -0,0 +1,58 @@
+// +build !js
+
+package webrtc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFmtp(t *testing.T) {
+	assert.Equal(t, map[string]string{
+		"minptime":     "10",
+		"useinbandfec": "1",
+		"flag":         "",
+	}, parseFmtp("minptime=10; useInbandFEC=1;flag"))
+	assert.Equal(t, map[string]string{}, parseFmtp(""))
+}
+
+func TestH264Profile(t *testing.T) {
+	for profileLevelID, profile := range map[string]int{
+		"42e01f": h264ProfileConstrainedBaseline,
+		"42c01f": h264ProfileConstrainedBaseline,
+		"4d801f": h264ProfileConstrainedBaseline,
+		"58c01f": h264ProfileConstrainedBaseline,
+		"42001f": h264ProfileBaseline,
+		"42a01f": h264ProfileBaseline,
+		"58801f": h264ProfileBaseline,
+		"4d001f": h264ProfileMain,
+		"58001f": h264ProfileExtended,
+		"640032": h264ProfileHigh,
+		"f4001f": h264ProfileUnknown,
+		"42e0":   h264ProfileUnknown,
+		"zze01f": h264ProfileUnknown,
+	} {
+		assert.Equal(t, profile, h264Profile(profileLevelID), profileLevelID)
+	}
+}
+
+func TestFmtpConsistent(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		a, b       string
+		consistent bool
+	}{
+		{H264, "packetization-mode=1;profile-level-id=42e01f", "profile-level-id=42E034;packetization-mode=1", true},
+		{H264, "packetization-mode=1;profile-level-id=42e01f", "packetization-mode=0;profile-level-id=42e01f", false},
+		{H264, "packetization-mode=1;profile-level-id=42001f", "packetization-mode=1;profile-level-id=42e01f", false},
+		{H264, "packetization-mode=1;profile-level-id=640032", "packetization-mode=1;profile-level-id=42e01f", false},
+		{H264, "", "profile-level-id=42000a", true},
+		{VP9, "", "profile-id=0", true},
+		{VP9, "profile-id=2", "profile-id=0", false},
+		{Opus, "minptime=10;useinbandfec=1", "stereo=1", true},
+		{VP8, "", "max-fr=30", true},
+	} {
+		assert.Equal(t, test.consistent, fmtpConsistent(test.name, test.a, test.b), "%s %s %s", test.name, test.a, test.b)
+	}
+}
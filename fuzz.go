@@ -0,0 +1,100 @@
+// +build gofuzz
+
+package webrtc
+
+import (
+	"github.com/pion/logging"
+	"github.com/pion/sdp/v2"
+)
+
+// Fuzz implements a randomized fuzz test of the RTP header extension parsers
+// of this package using go-fuzz.
+//
+// To run the fuzzer, first download go-fuzz:
+// `go get github.com/dvyukov/go-fuzz/...`
+//
+// Then build the testing package:
+// `go-fuzz-build github.com/pion/webrtc/v2`
+//
+// And run the fuzzer on the corpus:
+// ```
+// mkdir workdir
+//
+// # start from the seeds of header extension payloads
+// cp -r testdata/fuzz/headerextensions workdir/corpus
+//
+// go-fuzz -bin=webrtc-fuzz.zip -workdir=workdir
+// ```
+func Fuzz(data []byte) int {
+	var playoutDelay PlayoutDelayExtension
+	playoutDelayErr := playoutDelay.Unmarshal(data)
+	if playoutDelayErr == nil {
+		if _, err := playoutDelay.Marshal(); err != nil {
+			// Unmarshal can yield a Min above Max that Marshal rejects
+			playoutDelayErr = err
+		}
+	}
+
+	var videoTiming VideoTimingExtension
+	videoTimingErr := videoTiming.Unmarshal(data)
+	if videoTimingErr == nil {
+		if _, err := videoTiming.Marshal(); err != nil {
+			panic(err)
+		}
+	}
+
+	if playoutDelayErr != nil && videoTimingErr != nil {
+		return 0
+	}
+	return 1
+}
+
+// FuzzSessionDescription implements a randomized fuzz test of the SDP
+// attributes this package parses itself once a remote description is
+// unmarshaled, like rtpmap, fmtp, extmap, rid and ssrc-group. It is built
+// with `go-fuzz-build -func FuzzSessionDescription github.com/pion/webrtc/v2`
+// and the seeds in testdata/fuzz/sessiondescription are descriptions of
+// browsers and of this package.
+func FuzzSessionDescription(data []byte) int {
+	desc := SessionDescription{Type: SDPTypeOffer, SDP: string(data), parsed: &sdp.SessionDescription{}}
+	if err := desc.parsed.Unmarshal(data); err != nil {
+		return 0
+	}
+
+	m := MediaEngine{}
+	m.RegisterDefaultCodecs()
+	if err := m.PopulateFromSDP(desc); err != nil {
+		return 0
+	}
+
+	trackDetailsFromSDP(logging.NewDefaultLoggerFactory().NewLogger("fuzz"), desc.parsed)
+	descriptionIsPlanB(&desc)
+	haveApplicationMediaSection(desc.parsed)
+	_, _, _ = extractFingerprint(desc.parsed)
+	_, _, _, _ = extractICEDetails(desc.parsed)
+
+	for _, media := range desc.parsed.MediaDescriptions {
+		findMediaByMid(desc.parsed, getMidValue(media))
+		isMediaSectionRejected(media)
+		getPeerDirection(media)
+		getRids(media)
+		getExtMaps(media)
+		negotiatedHeaderExtensions(media, media)
+		negotiatedRTXPayloadTypes(media, media)
+		negotiatedFlexFECPayloadType(media, media)
+		for clockRate := range getTelephoneEventPayloadTypes(media) {
+			negotiatedTelephoneEvent(media, media, clockRate)
+		}
+
+		for _, codec := range m.matchRemoteCodecs(media) {
+			parameters := parseFmtp(codec.SDPFmtpLine)
+			h264Profile(fmtpParameter(parameters, "profile-level-id", ""))
+			fmtpConsistent(codec.Name, codec.SDPFmtpLine, codec.SDPFmtpLine)
+		}
+	}
+
+	if len(desc.parsed.MediaDescriptions) == 0 {
+		return 0
+	}
+	return 1
+}
@@ -2,9 +2,51 @@
 
 package webrtc
 
+import (
+	"io"
+	"net"
+)
+
 // NewICETransport creates a new NewICETransport.
 // This constructor is part of the ORTC API. It is not
 // meant to be used together with the basic WebRTC API.
 func (api *API) NewICETransport(gatherer *ICEGatherer) *ICETransport {
 	return NewICETransport(gatherer, api.settingEngine.LoggerFactory)
 }
+
+// NewICETransportFromConn creates an ICETransport that sends and receives on
+// conn instead of establishing a connection with ICE, the DTLSTransport and
+// SCTPTransport are then started on top of it as usual. This is meant for
+// tests and for deployments that bring their own transport, role decides the
+// DTLS role when the remote parameters don't. The ICETransport is already
+// connected and stopping it closes conn.
+// conn must preserve packet boundaries: every Write must deliver a single
+// packet and every Read must return one, as with UDP. DTLS records and SRTP
+// packets are not framed, so a stream conn like TCP or a Unix stream socket
+// breaks them silently. Such a conn needs framing of its own before use.
+// This constructor is part of the ORTC API. It is not
+// meant to be used together with the basic WebRTC API.
+func (api *API) NewICETransportFromConn(conn net.Conn, role ICERole) *ICETransport {
+	conn = timeReceive(api.settingEngine.instrumentation.ReceiveTimings, conn, func(r *ReceiveTimings) *TimingHistogram {
+		return &r.Demux
+	})
+	return newICETransportFromConn(conn, role, api.settingEngine.getReceiveMTU(), api.settingEngine.LoggerFactory)
+}
+
+// NewICETransportFromPacketConn is NewICETransportFromConn for a connectionless
+// conn, packets are sent to raddr and only the ones received from it are read.
+// This constructor is part of the ORTC API. It is not
+// meant to be used together with the basic WebRTC API.
+func (api *API) NewICETransportFromPacketConn(conn net.PacketConn, raddr net.Addr, role ICERole) *ICETransport {
+	return api.NewICETransportFromConn(&packetConnAdapter{PacketConn: conn, raddr: raddr}, role)
+}
+
+// NewICETransportFromReadWriteCloser is NewICETransportFromConn for any packet
+// oriented transport like a WebSocket relay or a custom overlay. Every Write of
+// rwc must deliver a single packet and every Read must return one, the addresses
+// of the transport are unknown and setting deadlines has no effect.
+// This constructor is part of the ORTC API. It is not
+// meant to be used together with the basic WebRTC API.
+func (api *API) NewICETransportFromReadWriteCloser(rwc io.ReadWriteCloser, role ICERole) *ICETransport {
+	return api.NewICETransportFromConn(&readWriteCloserAdapter{ReadWriteCloser: rwc}, role)
+}
@@ -116,6 +116,14 @@ func (c ICECandidate) toICE() (ice.Candidate, error) {
 	}
 }
 
+// priorityForComponent returns the priority of the same candidate for another component
+func priorityForComponent(priority uint32, from, to uint16) uint32 {
+	if from == 0 {
+		return priority
+	}
+	return priority + uint32(from) - uint32(to)
+}
+
 func convertTypeFromICE(t ice.CandidateType) (ICECandidateType, error) {
 	switch t {
 	case ice.CandidateTypeHost:
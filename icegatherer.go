@@ -3,11 +3,13 @@
 package webrtc
 
 import (
+	"net"
 	"sync"
 	"sync/atomic"
 
 	"github.com/pion/ice"
 	"github.com/pion/logging"
+	"github.com/pion/transport/vnet"
 )
 
 // ICEGatherer gathers local host, server reflexive and relay
@@ -27,6 +29,10 @@ type ICEGatherer struct {
 	onLocalCandidateHdlr atomic.Value // func(candidate *ICECandidate)
 	onStateChangeHdlr    atomic.Value // func(state ICEGathererState)
 
+	// Deliver the events to the handlers above in order, a queue per kind
+	candidateEvents eventDispatcher
+	stateEvents     eventDispatcher
+
 	api *API
 }
 
@@ -49,6 +55,8 @@ func (api *API) NewICEGatherer(opts ICEGatherOptions) (*ICEGatherer, error) {
 		state:            ICEGathererStateNew,
 		gatherPolicy:     opts.ICEGatherPolicy,
 		validatedServers: validatedServers,
+		candidateEvents:  eventDispatcher{executor: api.settingEngine.events.Executor},
+		stateEvents:      eventDispatcher{executor: api.settingEngine.events.Executor},
 		api:              api,
 		log:              api.settingEngine.LoggerFactory.NewLogger("ice"),
 	}, nil
@@ -79,11 +87,22 @@ func (g *ICEGatherer) createAgent() error {
 		nat1To1CandiTyp = ice.CandidateTypeUnspecified
 	}
 
+	nat1To1IPs, err := g.nat1To1IPs()
+	if err != nil {
+		return err
+	}
+
 	var multicastDNSMode ice.MulticastDNSMode
-	if g.api.settingEngine.candidates.GenerateMulticastDNSCandidates {
+	if g.api.settingEngine.candidates.MulticastDNSMode != 0 {
+		multicastDNSMode = g.api.settingEngine.candidates.MulticastDNSMode.toICE()
+	} else if g.api.settingEngine.candidates.GenerateMulticastDNSCandidates {
 		multicastDNSMode = ice.MulticastDNSModeQueryAndGather
 	}
 
+	// TODO: bind every agent to a single shared UDP port through a UDP mux, as
+	// large SFUs need. The agent of pion/ice v0.7 opens a socket per candidate
+	// and can't be given one, the mux is only in pion/ice/v2. Follow-up of
+	// StudioSol/webrtc#synth-305
 	config := &ice.AgentConfig{
 		Trickle:                   g.api.settingEngine.candidates.ICETrickle,
 		Lite:                      g.api.settingEngine.candidates.ICELite,
@@ -100,13 +119,14 @@ func (g *ICEGatherer) createAgent() error {
 		PrflxAcceptanceMinWait:    g.api.settingEngine.timeout.ICEPrflxAcceptanceMinWait,
 		RelayAcceptanceMinWait:    g.api.settingEngine.timeout.ICERelayAcceptanceMinWait,
 		InterfaceFilter:           g.api.settingEngine.candidates.InterfaceFilter,
-		NAT1To1IPs:                g.api.settingEngine.candidates.NAT1To1IPs,
+		NAT1To1IPs:                nat1To1IPs,
 		NAT1To1IPCandidateType:    nat1To1CandiTyp,
 		Net:                       g.api.settingEngine.vnet,
 		MulticastDNSMode:          multicastDNSMode,
 		MulticastDNSHostName:      g.api.settingEngine.candidates.MulticastDNSHostName,
 		LocalUfrag:                g.api.settingEngine.candidates.UsernameFragment,
 		LocalPwd:                  g.api.settingEngine.candidates.Password,
+		InsecureSkipVerify:        g.api.settingEngine.candidates.InsecureSkipVerify,
 	}
 
 	requestedNetworkTypes := g.api.settingEngine.candidates.ICENetworkTypes
@@ -124,6 +144,7 @@ func (g *ICEGatherer) createAgent() error {
 	}
 
 	g.agent = agent
+	atomic.AddInt64(&resourceCounters.iceAgents, 1)
 	if !g.api.settingEngine.candidates.ICETrickle {
 		atomicStoreICEGathererState(&g.state, ICEGathererStateComplete)
 	}
@@ -131,6 +152,64 @@ func (g *ICEGatherer) createAgent() error {
 	return nil
 }
 
+// nat1To1IPs returns the external IP addresses of the 1:1 NAT set in the
+// SettingEngine. The mapper is called for every local IP address that could
+// be gathered, they are paired as external/local for the ICE agent.
+func (g *ICEGatherer) nat1To1IPs() ([]string, error) {
+	mapper := g.api.settingEngine.candidates.NAT1To1IPMapper
+	if mapper == nil {
+		return g.api.settingEngine.candidates.NAT1To1IPs, nil
+	}
+
+	n := g.api.settingEngine.vnet
+	if n == nil {
+		n = vnet.NewNet(nil)
+	}
+	ifaces, err := n.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+
+	ips := []string{}
+	mapped := map[string]bool{}
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		if filter := g.api.settingEngine.candidates.InterfaceFilter; filter != nil && !filter(iface.Name) {
+			continue
+		}
+
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			var ip net.IP
+			switch addr := addr.(type) {
+			case *net.IPNet:
+				ip = addr.IP
+			case *net.IPAddr:
+				ip = addr.IP
+			}
+			if ip == nil || ip.IsLoopback() || mapped[ip.String()] {
+				continue
+			}
+
+			externalIP := mapper(ip)
+			if externalIP == nil {
+				continue
+			} else if (externalIP.To4() == nil) != (ip.To4() == nil) {
+				g.log.Warnf("Ignoring NAT 1:1 mapping of %s to %s of another IP family", ip, externalIP)
+				continue
+			}
+			mapped[ip.String()] = true
+			ips = append(ips, externalIP.String()+"/"+ip.String())
+		}
+	}
+	return ips, nil
+}
+
 // Gather ICE candidates.
 func (g *ICEGatherer) Gather() error {
 	if err := g.createAgent(); err != nil {
@@ -159,11 +238,11 @@ func (g *ICEGatherer) Gather() error {
 				g.log.Warnf("Failed to convert ice.Candidate: %s", err)
 				return
 			}
-			onLocalCandidateHdlr(&c)
+			g.candidateEvents.dispatch(func() { onLocalCandidateHdlr(&c) })
 		} else {
 			g.setState(ICEGathererStateComplete)
 
-			onLocalCandidateHdlr(nil)
+			g.candidateEvents.dispatch(func() { onLocalCandidateHdlr(nil) })
 		}
 	}); err != nil {
 		return err
@@ -176,13 +255,15 @@ func (g *ICEGatherer) Close() error {
 	g.lock.Lock()
 	defer g.lock.Unlock()
 
+	// The agent is already closed if the ICETransport was started with it
 	if g.agent == nil {
 		return nil
-	} else if err := g.agent.Close(); err != nil {
+	} else if err := g.agent.Close(); err != nil && err != ice.ErrClosed {
 		return err
 	}
 
 	g.agent = nil
+	atomic.AddInt64(&resourceCounters.iceAgents, -1)
 	g.setState(ICEGathererStateClosed)
 
 	return nil
@@ -235,7 +316,7 @@ func (g *ICEGatherer) setState(s ICEGathererState) {
 	atomicStoreICEGathererState(&g.state, s)
 
 	if hdlr, ok := g.onStateChangeHdlr.Load().(func(state ICEGathererState)); ok && hdlr != nil {
-		hdlr(s)
+		g.stateEvents.dispatch(func() { hdlr(s) })
 	}
 }
 
@@ -259,14 +340,14 @@ func (g *ICEGatherer) SignalCandidates() error {
 	}
 
 	if onLocalCandidateHdlr != nil {
-		go func() {
+		g.candidateEvents.dispatch(func() {
 			for i := range candidates {
 				onLocalCandidateHdlr(&candidates[i])
 			}
 			// Call the handler one last time with nil. This is a signal that candidate
 			// gathering is complete.
 			onLocalCandidateHdlr(nil)
-		}()
+		})
 	}
 	return nil
 }
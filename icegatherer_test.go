@@ -4,11 +4,14 @@ package webrtc
 
 import (
 	"context"
+	"net"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/pion/logging"
 	"github.com/pion/transport/test"
+	"github.com/pion/transport/vnet"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -144,3 +147,137 @@ func TestICEGather_mDNSCandidateGathering(t *testing.T) {
 	<-gotMulticastDNSCandidate.Done()
 	assert.NoError(t, gatherer.Close())
 }
+
+func TestICEGather_mDNSMode(t *testing.T) {
+	// Limit runtime in case of deadlocks
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	s := SettingEngine{}
+	s.SetICEMulticastDNSMode(ICEMulticastDNSModeQueryAndGather)
+
+	gatherer, err := NewAPI(WithSettingEngine(s)).NewICEGatherer(ICEGatherOptions{})
+	if err != nil {
+		t.Error(err)
+	}
+
+	gotMulticastDNSCandidate, resolveFunc := context.WithCancel(context.Background())
+	gatherer.OnLocalCandidate(func(c *ICECandidate) {
+		if c != nil && strings.HasSuffix(c.Address, ".local") {
+			resolveFunc()
+		}
+	})
+
+	if err := gatherer.SignalCandidates(); err != nil {
+		t.Error(err)
+	}
+
+	<-gotMulticastDNSCandidate.Done()
+	assert.NoError(t, gatherer.Close())
+}
+
+func TestICEGather_NetworkTypes(t *testing.T) {
+	// Limit runtime in case of deadlocks
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	for _, networkType := range []NetworkType{NetworkTypeUDP4, NetworkTypeUDP6} {
+		s := SettingEngine{}
+		s.SetNetworkTypes([]NetworkType{networkType})
+
+		gatherer, err := NewAPI(WithSettingEngine(s)).NewICEGatherer(ICEGatherOptions{})
+		assert.NoError(t, err)
+		assert.NoError(t, gatherer.Gather())
+
+		candidates, err := gatherer.GetLocalCandidates()
+		assert.NoError(t, err)
+
+		for _, c := range candidates {
+			ip := net.ParseIP(c.Address)
+			if !assert.NotNil(t, ip, "candidate address %s is not an IP", c.Address) {
+				continue
+			}
+			assert.Equal(t, networkType == NetworkTypeUDP4, ip.To4() != nil, "unexpected IP family for %s", c.Address)
+			assert.False(t, ip.IsLinkLocalUnicast(), "link-local address %s gathered", c.Address)
+		}
+
+		assert.NoError(t, gatherer.Close())
+	}
+}
+
+func TestICEGather_NAT1To1IPMapper(t *testing.T) {
+	// Limit runtime in case of deadlocks
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	wan, err := vnet.NewRouter(&vnet.RouterConfig{
+		CIDR:          "10.0.0.0/24",
+		LoggerFactory: logging.NewDefaultLoggerFactory(),
+	})
+	assert.NoError(t, err)
+
+	n := vnet.NewNet(&vnet.NetConfig{StaticIPs: []string{"10.0.0.2", "10.0.0.3"}})
+	assert.NoError(t, wan.AddNet(n))
+	assert.NoError(t, wan.Start())
+
+	s := SettingEngine{}
+	s.SetVNet(n)
+	s.SetNetworkTypes([]NetworkType{NetworkTypeUDP4})
+	s.SetNAT1To1IPMapper(func(localIP net.IP) net.IP {
+		if localIP.Equal(net.ParseIP("10.0.0.2")) {
+			return net.ParseIP("1.2.3.4")
+		}
+		return nil
+	}, ICECandidateTypeHost)
+
+	gatherer, err := NewAPI(WithSettingEngine(s)).NewICEGatherer(ICEGatherOptions{})
+	assert.NoError(t, err)
+	assert.NoError(t, gatherer.Gather())
+
+	candidates, err := gatherer.GetLocalCandidates()
+	assert.NoError(t, err)
+
+	addresses := []string{}
+	for _, c := range candidates {
+		addresses = append(addresses, c.Address)
+	}
+	assert.ElementsMatch(t, []string{"1.2.3.4", "10.0.0.3"}, addresses)
+
+	assert.NoError(t, gatherer.Close())
+	assert.NoError(t, wan.Stop())
+}
+
+// Assert that the candidates are advertised with the priority the agent uses to
+// order the candidate pairs and in its connectivity checks
+func TestICEGatherer_CandidatePriority(t *testing.T) {
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	gatherer, err := NewAPI().NewICEGatherer(ICEGatherOptions{})
+	assert.NoError(t, err)
+	assert.NoError(t, gatherer.Gather())
+
+	candidates, err := gatherer.GetLocalCandidates()
+	assert.NoError(t, err)
+	iceCandidates, err := gatherer.getAgent().GetLocalCandidates()
+	assert.NoError(t, err)
+	assert.NotEmpty(t, candidates)
+	assert.Equal(t, len(iceCandidates), len(candidates))
+	for i := range candidates {
+		assert.Equal(t, iceCandidates[i].Priority(), candidates[i].Priority)
+	}
+
+	assert.NoError(t, gatherer.Close())
+}
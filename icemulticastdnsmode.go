@@ -0,0 +1,59 @@
+// +build !js
+
+package webrtc
+
+import (
+	"github.com/pion/ice"
+)
+
+// ICEMulticastDNSMode controls if the ICE agent publishes its host candidates
+// as mDNS (.local) hostnames, and if it resolves mDNS candidates received
+// from the remote peer.
+type ICEMulticastDNSMode int
+
+const (
+	// ICEMulticastDNSModeDisabled means remote mDNS candidates are discarded,
+	// and local host candidates use IP addresses.
+	ICEMulticastDNSModeDisabled ICEMulticastDNSMode = iota + 1
+
+	// ICEMulticastDNSModeQueryOnly means remote mDNS candidates are resolved,
+	// and local host candidates use IP addresses.
+	ICEMulticastDNSModeQueryOnly
+
+	// ICEMulticastDNSModeQueryAndGather means remote mDNS candidates are
+	// resolved, and local host candidates are published with mDNS hostnames.
+	ICEMulticastDNSModeQueryAndGather
+)
+
+// This is done this way because of a linter.
+const (
+	iceMulticastDNSModeDisabledStr       = "disabled"
+	iceMulticastDNSModeQueryOnlyStr      = "query-only"
+	iceMulticastDNSModeQueryAndGatherStr = "query-and-gather"
+)
+
+func (t ICEMulticastDNSMode) String() string {
+	switch t {
+	case ICEMulticastDNSModeDisabled:
+		return iceMulticastDNSModeDisabledStr
+	case ICEMulticastDNSModeQueryOnly:
+		return iceMulticastDNSModeQueryOnlyStr
+	case ICEMulticastDNSModeQueryAndGather:
+		return iceMulticastDNSModeQueryAndGatherStr
+	default:
+		return ErrUnknownType.Error()
+	}
+}
+
+func (t ICEMulticastDNSMode) toICE() ice.MulticastDNSMode {
+	switch t {
+	case ICEMulticastDNSModeDisabled:
+		return ice.MulticastDNSModeDisabled
+	case ICEMulticastDNSModeQueryOnly:
+		return ice.MulticastDNSModeQueryOnly
+	case ICEMulticastDNSModeQueryAndGather:
+		return ice.MulticastDNSModeQueryAndGather
+	default:
+		return ice.MulticastDNSMode(Unknown)
+	}
+}
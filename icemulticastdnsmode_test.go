@@ -0,0 +1,50 @@
+// +build !js
+
+package webrtc
+
+import (
+	"testing"
+
+	"github.com/pion/ice"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestICEMulticastDNSMode_String(t *testing.T) {
+	testCases := []struct {
+		mode           ICEMulticastDNSMode
+		expectedString string
+	}{
+		{ICEMulticastDNSMode(Unknown), unknownStr},
+		{ICEMulticastDNSModeDisabled, "disabled"},
+		{ICEMulticastDNSModeQueryOnly, "query-only"},
+		{ICEMulticastDNSModeQueryAndGather, "query-and-gather"},
+	}
+
+	for i, testCase := range testCases {
+		assert.Equal(t,
+			testCase.expectedString,
+			testCase.mode.String(),
+			"testCase: %d %v", i, testCase,
+		)
+	}
+}
+
+func TestICEMulticastDNSMode_toICE(t *testing.T) {
+	testCases := []struct {
+		mode     ICEMulticastDNSMode
+		expected ice.MulticastDNSMode
+	}{
+		{ICEMulticastDNSMode(Unknown), ice.MulticastDNSMode(Unknown)},
+		{ICEMulticastDNSModeDisabled, ice.MulticastDNSModeDisabled},
+		{ICEMulticastDNSModeQueryOnly, ice.MulticastDNSModeQueryOnly},
+		{ICEMulticastDNSModeQueryAndGather, ice.MulticastDNSModeQueryAndGather},
+	}
+
+	for i, testCase := range testCases {
+		assert.Equal(t,
+			testCase.expected,
+			testCase.mode.toICE(),
+			"testCase: %d %v", i, testCase,
+		)
+	}
+}
@@ -9,6 +9,10 @@ import (
 
 // ICEServer describes a single STUN and TURN server that can be used by
 // the ICEAgent to establish a connection with a peer.
+//
+// TURN allocations can be made over UDP, TCP (turn:host?transport=tcp),
+// TLS (turns:host?transport=tcp) and DTLS (turns:host?transport=udp), so a
+// relayed connection can be established when only outbound TCP is allowed.
 type ICEServer struct {
 	URLs           []string
 	Username       string
@@ -31,6 +31,18 @@ func TestICEServer_validate(t *testing.T) {
 				},
 				CredentialType: ICECredentialTypeOauth,
 			}, true},
+			{ICEServer{
+				URLs:           []string{"turn:192.158.29.39?transport=tcp"},
+				Username:       "unittest",
+				Credential:     "placeholder",
+				CredentialType: ICECredentialTypePassword,
+			}, true},
+			{ICEServer{
+				URLs:           []string{"turns:192.158.29.39:443?transport=tcp"},
+				Username:       "unittest",
+				Credential:     "placeholder",
+				CredentialType: ICECredentialTypePassword,
+			}, true},
 		}
 
 		for i, testCase := range testCases {
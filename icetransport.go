@@ -5,6 +5,8 @@ package webrtc
 import (
 	"context"
 	"errors"
+	"io"
+	"net"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -12,6 +14,7 @@ import (
 	"github.com/pion/ice"
 	"github.com/pion/logging"
 	"github.com/pion/webrtc/v2/internal/mux"
+	"github.com/pion/webrtc/v2/internal/util"
 )
 
 // ICETransport allows an application access to information about the ICE
@@ -33,31 +36,19 @@ type ICETransport struct {
 	conn     *ice.Conn
 	mux      *mux.Mux
 
+	remoteParameters      ICEParameters
+	remoteCandidates      []ICECandidate
+	selectedCandidatePair *ICECandidatePair
+
+	// agentClosed is closed once the agent has reported that it is closed,
+	// it is nil until Start has registered for the report
+	agentClosed chan struct{}
+
 	loggerFactory logging.LoggerFactory
 
 	log logging.LeveledLogger
 }
 
-// func (t *ICETransport) GetLocalCandidates() []ICECandidate {
-//
-// }
-//
-// func (t *ICETransport) GetRemoteCandidates() []ICECandidate {
-//
-// }
-//
-// func (t *ICETransport) GetSelectedCandidatePair() ICECandidatePair {
-//
-// }
-//
-// func (t *ICETransport) GetLocalParameters() ICEParameters {
-//
-// }
-//
-// func (t *ICETransport) GetRemoteParameters() ICEParameters {
-//
-// }
-
 // NewICETransport creates a new NewICETransport.
 func NewICETransport(gatherer *ICEGatherer, loggerFactory logging.LoggerFactory) *ICETransport {
 	return &ICETransport{
@@ -68,6 +59,65 @@ func NewICETransport(gatherer *ICEGatherer, loggerFactory logging.LoggerFactory)
 	}
 }
 
+func newICETransportFromConn(conn net.Conn, role ICERole, receiveMTU int, loggerFactory logging.LoggerFactory) *ICETransport {
+	return &ICETransport{
+		role:          role,
+		state:         ICETransportStateConnected,
+		mux:           mux.NewMux(mux.Config{Conn: conn, BufferSize: receiveMTU, LoggerFactory: loggerFactory}),
+		loggerFactory: loggerFactory,
+		log:           loggerFactory.NewLogger("ortc"),
+	}
+}
+
+// GetLocalParameters returns the ICE parameters of the ICEGatherer the
+// ICETransport was constructed with
+func (t *ICETransport) GetLocalParameters() (ICEParameters, error) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	if t.gatherer == nil {
+		return ICEParameters{}, errors.New("gatherer not started")
+	}
+	return t.gatherer.GetLocalParameters()
+}
+
+// GetRemoteParameters returns the ICE parameters the ICETransport was started with
+func (t *ICETransport) GetRemoteParameters() ICEParameters {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	return t.remoteParameters
+}
+
+// GetLocalCandidates returns the local candidates of the ICEGatherer the
+// ICETransport was constructed with
+func (t *ICETransport) GetLocalCandidates() ([]ICECandidate, error) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	if t.gatherer == nil {
+		return nil, errors.New("gatherer not started")
+	}
+	return t.gatherer.GetLocalCandidates()
+}
+
+// GetRemoteCandidates returns the candidates that were added for the remote ICETransport
+func (t *ICETransport) GetRemoteCandidates() []ICECandidate {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	return append([]ICECandidate{}, t.remoteCandidates...)
+}
+
+// GetSelectedCandidatePair returns the candidate pair packets are sent on, or
+// nil if no pair has been selected yet
+func (t *ICETransport) GetSelectedCandidatePair() *ICECandidatePair {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	return t.selectedCandidatePair
+}
+
 // Start incoming connectivity checks based on its configured role.
 func (t *ICETransport) Start(gatherer *ICEGatherer, params ICEParameters, role *ICERole) error {
 	t.lock.Lock()
@@ -86,6 +136,11 @@ func (t *ICETransport) Start(gatherer *ICEGatherer, params ICEParameters, role *
 		return errors.New("ICEAgent does not exist, unable to start ICETransport")
 	}
 
+	agentClosed := make(chan struct{})
+	var agentClosedOnce sync.Once
+	onAgentClosed := func() {
+		agentClosedOnce.Do(func() { close(agentClosed) })
+	}
 	if err := agent.OnConnectionStateChange(func(iceState ice.ConnectionState) {
 		state := newICETransportStateFromICE(iceState)
 		t.lock.Lock()
@@ -93,16 +148,32 @@ func (t *ICETransport) Start(gatherer *ICEGatherer, params ICEParameters, role *
 		t.lock.Unlock()
 
 		t.onConnectionStateChange(state)
+
+		// The agent reports no state after it is closed
+		if iceState == ice.ConnectionStateClosed {
+			onAgentClosed()
+		}
 	}); err != nil {
 		return err
 	}
+	// An agent closed before the handler was set won't report it again
+	if _, err := agent.GetLocalCandidates(); err == ice.ErrClosed {
+		onAgentClosed()
+	}
+	t.agentClosed = agentClosed
+
 	if err := agent.OnSelectedCandidatePairChange(func(local, remote ice.Candidate) {
 		candidates, err := newICECandidatesFromICE([]ice.Candidate{local, remote})
 		if err != nil {
 			t.log.Warnf("Unable to convert ICE candidates to ICECandidates: %s", err)
 			return
 		}
-		t.onSelectedCandidatePairChange(NewICECandidatePair(&candidates[0], &candidates[1]))
+		pair := NewICECandidatePair(&candidates[0], &candidates[1])
+		t.lock.Lock()
+		t.selectedCandidatePair = pair
+		t.lock.Unlock()
+
+		t.onSelectedCandidatePairChange(pair)
 	}); err != nil {
 		return err
 	}
@@ -112,6 +183,7 @@ func (t *ICETransport) Start(gatherer *ICEGatherer, params ICEParameters, role *
 		role = &controlled
 	}
 	t.role = *role
+	t.remoteParameters = params
 
 	// Drop the lock here to allow trickle-ICE candidates to be
 	// added so that the agent can complete a connection
@@ -137,14 +209,17 @@ func (t *ICETransport) Start(gatherer *ICEGatherer, params ICEParameters, role *
 	// Reacquire the lock to set the connection/mux
 	t.lock.Lock()
 	if err != nil {
+		t.log.Warnf("Failed to establish ICE connection as %s: %v", *role, err)
 		return err
 	}
 
 	t.conn = iceConn
 
 	config := mux.Config{
-		Conn:          t.conn,
-		BufferSize:    receiveMTU,
+		Conn: timeReceive(t.gatherer.api.settingEngine.instrumentation.ReceiveTimings, t.conn, func(r *ReceiveTimings) *TimingHistogram {
+			return &r.Demux
+		}),
+		BufferSize:    t.gatherer.api.settingEngine.getReceiveMTU(),
 		LoggerFactory: t.loggerFactory,
 	}
 	t.mux = mux.NewMux(config)
@@ -154,15 +229,31 @@ func (t *ICETransport) Start(gatherer *ICEGatherer, params ICEParameters, role *
 
 // Stop irreversibly stops the ICETransport.
 func (t *ICETransport) Stop() error {
-	t.lock.Lock()
-	defer t.lock.Unlock()
+	// The lock is not held while closing, the agent reporting its closed
+	// state needs it
+	t.lock.RLock()
+	m, gatherer, agentClosed := t.mux, t.gatherer, t.agentClosed
+	t.lock.RUnlock()
+
+	// Closing the mux closes the agent through its conn, the gatherer still
+	// has to release it
+	var closeErrs []error
+	if m != nil {
+		closeErrs = append(closeErrs, m.Close())
+	}
+	if gatherer != nil {
+		closeErrs = append(closeErrs, gatherer.Close())
+	}
 
-	if t.mux != nil {
-		return t.mux.Close()
-	} else if t.gatherer != nil {
-		return t.gatherer.Close()
+	// The report is bounded in case the agent failed to close
+	if agentClosed != nil {
+		select {
+		case <-agentClosed:
+		case <-time.After(iceAgentCloseTimeout):
+			t.log.Warnf("ICE agent didn't report that it is closed after %s", iceAgentCloseTimeout)
+		}
 	}
-	return nil
+	return util.FlattenErrs(closeErrs)
 }
 
 // OnSelectedCandidatePairChange sets a handler that is invoked when a new
@@ -201,8 +292,8 @@ func (t *ICETransport) Role() ICERole {
 
 // SetRemoteCandidates sets the sequence of candidates associated with the remote ICETransport.
 func (t *ICETransport) SetRemoteCandidates(remoteCandidates []ICECandidate) error {
-	t.lock.RLock()
-	defer t.lock.RUnlock()
+	t.lock.Lock()
+	defer t.lock.Unlock()
 
 	if err := t.ensureGatherer(); err != nil {
 		return err
@@ -222,6 +313,7 @@ func (t *ICETransport) SetRemoteCandidates(remoteCandidates []ICECandidate) erro
 		if err != nil {
 			return err
 		}
+		t.remoteCandidates = append(t.remoteCandidates, c)
 	}
 
 	return nil
@@ -229,8 +321,8 @@ func (t *ICETransport) SetRemoteCandidates(remoteCandidates []ICECandidate) erro
 
 // AddRemoteCandidate adds a candidate associated with the remote ICETransport.
 func (t *ICETransport) AddRemoteCandidate(remoteCandidate ICECandidate) error {
-	t.lock.RLock()
-	defer t.lock.RUnlock()
+	t.lock.Lock()
+	defer t.lock.Unlock()
 
 	if err := t.ensureGatherer(); err != nil {
 		return err
@@ -250,6 +342,7 @@ func (t *ICETransport) AddRemoteCandidate(remoteCandidate ICECandidate) error {
 	if err != nil {
 		return err
 	}
+	t.remoteCandidates = append(t.remoteCandidates, remoteCandidate)
 
 	return nil
 }
@@ -301,3 +394,47 @@ func (t *ICETransport) collectStats(collector *statsReportCollector) {
 
 	collector.Collect(stats.ID, stats)
 }
+
+// packetConnAdapter turns a net.PacketConn into the net.Conn of a single remote
+type packetConnAdapter struct {
+	net.PacketConn
+	raddr net.Addr
+}
+
+func (c *packetConnAdapter) Read(b []byte) (int, error) {
+	for {
+		n, addr, err := c.ReadFrom(b)
+		if err != nil {
+			return n, err
+		}
+		if addr.String() == c.raddr.String() {
+			return n, nil
+		}
+	}
+}
+
+func (c *packetConnAdapter) Write(b []byte) (int, error) {
+	return c.WriteTo(b, c.raddr)
+}
+
+func (c *packetConnAdapter) RemoteAddr() net.Addr {
+	return c.raddr
+}
+
+// readWriteCloserAdapter turns an io.ReadWriteCloser into a net.Conn without
+// addresses, setting deadlines is a stub
+type readWriteCloserAdapter struct {
+	io.ReadWriteCloser
+}
+
+// readWriteCloserAddr is the address of both ends of a readWriteCloserAdapter
+type readWriteCloserAddr struct{}
+
+func (readWriteCloserAddr) Network() string { return "readwritecloser" }
+func (readWriteCloserAddr) String() string  { return "readwritecloser" }
+
+func (c *readWriteCloserAdapter) LocalAddr() net.Addr                { return readWriteCloserAddr{} }
+func (c *readWriteCloserAdapter) RemoteAddr() net.Addr               { return readWriteCloserAddr{} }
+func (c *readWriteCloserAdapter) SetDeadline(t time.Time) error      { return nil }
+func (c *readWriteCloserAdapter) SetReadDeadline(t time.Time) error  { return nil }
+func (c *readWriteCloserAdapter) SetWriteDeadline(t time.Time) error { return nil }
@@ -64,3 +64,84 @@ func TestICETransport_OnSelectedCandidatePairChange(t *testing.T) {
 
 	closePairNow(t, pcOffer, pcAnswer)
 }
+
+func TestICETransport_GetParameters(t *testing.T) {
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	stackA, stackB, err := newORTCPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sigA, err := stackA.getSignal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sigB, err := stackB.getSignal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Nil(t, stackA.ice.GetSelectedCandidatePair())
+
+	localParams, err := stackA.ice.GetLocalParameters()
+	assert.NoError(t, err)
+	assert.Equal(t, sigA.ICEParameters, localParams)
+
+	localCandidates, err := stackA.ice.GetLocalCandidates()
+	assert.NoError(t, err)
+	assert.Equal(t, len(sigA.ICECandidates), len(localCandidates))
+
+	errB := make(chan error)
+	go func() {
+		errB <- stackB.setSignal(sigA, false)
+	}()
+	assert.NoError(t, stackA.setSignal(sigB, true))
+	assert.NoError(t, <-errB)
+
+	assert.Equal(t, sigB.ICEParameters, stackA.ice.GetRemoteParameters())
+	assert.Equal(t, sigB.ICECandidates, stackA.ice.GetRemoteCandidates())
+	assert.NotNil(t, stackA.ice.GetSelectedCandidatePair())
+	assert.Equal(t, sigB.DTLSParameters, stackA.dtls.GetRemoteParameters())
+
+	assert.NoError(t, stackA.close())
+	assert.NoError(t, stackB.close())
+}
+
+// Assert that Stop doesn't wait for the closed state of an agent that was
+// closed before the transport was started
+func TestICETransport_StopAfterFailedStart(t *testing.T) {
+	lim := test.TimeOut(time.Second * 10)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	api := NewAPI()
+	gatherer, err := api.NewICEGatherer(ICEGatherOptions{})
+	assert.NoError(t, err)
+	assert.NoError(t, gatherer.createAgent())
+	assert.NoError(t, gatherer.getAgent().Close())
+
+	// Let the agent report its closed state before there is a handler for it
+	time.Sleep(100 * time.Millisecond)
+
+	transport := api.NewICETransport(gatherer)
+	assert.Error(t, transport.Start(nil, ICEParameters{}, nil))
+
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		assert.NoError(t, transport.Stop())
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(iceAgentCloseTimeout / 2):
+		t.Fatal("Stop waited for an agent that was closed before Start")
+	}
+}
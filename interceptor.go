@@ -0,0 +1,85 @@
+// +build !js
+
+package webrtc
+
+import (
+	"github.com/pion/webrtc/v2/pkg/interceptor"
+)
+
+// newInterceptorChain builds the interceptors of a DTLSTransport. The built-in
+// ones are the closest to the network, followed by the ones of the Registry.
+// arrivals are the arrival times of the RTP packets received by the transport
+func (api *API) newInterceptorChain(arrivals *arrivalTimes) (*interceptor.Chain, error) {
+	registered, err := api.interceptorRegistry.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	var interceptors []interceptor.Interceptor
+	if tap := api.settingEngine.instrumentation.PacketTap; tap != nil {
+		interceptors = append(interceptors, &packetTapInterceptor{tap: tap})
+	}
+	interceptors = append(interceptors, newFECGenerator(api.settingEngine.video.FECProtectionRate))
+	interceptors = append(interceptors, newNACKResponder(api.settingEngine.getNACKHistorySize()))
+	interceptors = append(interceptors, newNACKGenerator())
+	if interval := api.settingEngine.rtcp.ReceiverReportInterval; interval != 0 {
+		interceptors = append(interceptors, newReceiverReporter(interval, arrivals))
+	}
+	if playoutDelay := api.settingEngine.video.PlayoutDelay; playoutDelay != nil {
+		setter, err := newPlayoutDelaySetter(*playoutDelay)
+		if err != nil {
+			return nil, err
+		}
+		interceptors = append(interceptors, setter)
+	}
+	return interceptor.NewChain(append(interceptors, registered)), nil
+}
+
+// localStreamInfo describes a stream sent with codec
+func localStreamInfo(ssrc uint32, payloadType uint8, codec *RTPCodec, headerExtensions []RTPHeaderExtensionParameter) *interceptor.StreamInfo {
+	return newStreamInfo(ssrc, payloadType, codec.RTPCodecCapability, headerExtensions)
+}
+
+// remoteStreamInfo describes a received stream with the first of the codecs
+// negotiated for it, the one the remote prefers
+func remoteStreamInfo(ssrc uint32, codecs []RTPCodecParameters, headerExtensions []RTPHeaderExtensionParameter) *interceptor.StreamInfo {
+	if len(codecs) == 0 {
+		return newStreamInfo(ssrc, 0, RTPCodecCapability{}, headerExtensions)
+	}
+	return newStreamInfo(ssrc, codecs[0].PayloadType, codecs[0].RTPCodecCapability, headerExtensions)
+}
+
+func newStreamInfo(ssrc uint32, payloadType uint8, codec RTPCodecCapability, headerExtensions []RTPHeaderExtensionParameter) *interceptor.StreamInfo {
+	info := &interceptor.StreamInfo{
+		SSRC:        ssrc,
+		PayloadType: payloadType,
+		MimeType:    codec.MimeType,
+		ClockRate:   codec.ClockRate,
+		Channels:    codec.Channels,
+		SDPFmtpLine: codec.SDPFmtpLine,
+	}
+	for _, feedback := range codec.RTCPFeedback {
+		info.RTCPFeedback = append(info.RTCPFeedback, interceptor.RTCPFeedback{
+			Type:      feedback.Type,
+			Parameter: feedback.Parameter,
+		})
+	}
+	for _, headerExtension := range headerExtensions {
+		info.RTPHeaderExtensions = append(info.RTPHeaderExtensions, interceptor.RTPHeaderExtension{
+			URI: headerExtension.URI,
+			ID:  headerExtension.ID,
+		})
+	}
+	return info
+}
+
+// streamHasGenericNACK reports if the stream announces Generic NACK feedback, as
+// opposed to "nack pli" which requests a keyframe
+func streamHasGenericNACK(info *interceptor.StreamInfo) bool {
+	for _, feedback := range info.RTCPFeedback {
+		if feedback.Type == TypeRTCPFBNACK && feedback.Parameter == "" {
+			return true
+		}
+	}
+	return false
+}
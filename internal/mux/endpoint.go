@@ -6,13 +6,16 @@ import (
 	"time"
 
 	"github.com/pion/ice"
-	"github.com/pion/transport/packetio"
 )
 
 // Endpoint implements net.Conn. It is used to read muxed packets.
+// Read must not be called concurrently, packets are handed over from
+// the read loop of the Mux through a single-consumer queue. Once the
+// queue is full the read loop waits for it, so an Endpoint must be
+// read until it is closed
 type Endpoint struct {
-	mux    *Mux
-	buffer *packetio.Buffer
+	mux   *Mux
+	queue *packetQueue
 }
 
 // Close unregisters the endpoint from the Mux
@@ -27,13 +30,20 @@ func (e *Endpoint) Close() (err error) {
 }
 
 func (e *Endpoint) close() error {
-	return e.buffer.Close()
+	e.queue.close()
+	return nil
 }
 
 // Read reads a packet of len(p) bytes from the underlying conn
 // that are matched by the associated MuxFunc
 func (e *Endpoint) Read(p []byte) (int, error) {
-	return e.buffer.Read(p)
+	return e.queue.pop(p)
+}
+
+// DroppedPackets returns the number of packets matched by the associated
+// MuxFunc that were dropped because they didn't fit the buffer passed to Read
+func (e *Endpoint) DroppedPackets() uint64 {
+	return e.queue.droppedPackets()
 }
 
 // Write writes len(p) bytes to the underlying conn
@@ -2,15 +2,15 @@
 package mux
 
 import (
+	"io"
 	"net"
 	"sync"
 
 	"github.com/pion/logging"
-	"github.com/pion/transport/packetio"
 )
 
-// The maximum amount of data that can be buffered before returning errors.
-const maxBufferSize = 1000 * 1000 // 1MB
+// The number of packets queued per Endpoint before the read loop waits for it to catch up.
+const endpointQueueSize = 1024
 
 // Config collects the arguments to mux.Mux construction into
 // a single structure
@@ -49,15 +49,10 @@ func NewMux(config Config) *Mux {
 // NewEndpoint creates a new Endpoint
 func (m *Mux) NewEndpoint(f MatchFunc) *Endpoint {
 	e := &Endpoint{
-		mux:    m,
-		buffer: packetio.NewBuffer(),
+		mux:   m,
+		queue: newPacketQueue(endpointQueueSize),
 	}
 
-	// Set a maximum size of the buffer in bytes.
-	// NOTE: We actually won't get anywhere close to this limit.
-	// SRTP will constantly read from the endpoint and drop packets if it's full.
-	e.buffer.SetLimitSize(maxBufferSize)
-
 	m.lock.Lock()
 	m.endpoints[e] = f
 	m.lock.Unlock()
@@ -118,14 +113,14 @@ func (m *Mux) readLoop() {
 func (m *Mux) dispatch(buf []byte) error {
 	var endpoint *Endpoint
 
-	m.lock.Lock()
+	m.lock.RLock()
 	for e, f := range m.endpoints {
 		if f(buf) {
 			endpoint = e
 			break
 		}
 	}
-	m.lock.Unlock()
+	m.lock.RUnlock()
 
 	if endpoint == nil {
 		if len(buf) > 0 {
@@ -136,8 +131,8 @@ func (m *Mux) dispatch(buf []byte) error {
 		return nil
 	}
 
-	_, err := endpoint.buffer.Write(buf)
-	if err != nil {
+	// Packets for an Endpoint that has been closed in the meantime are dropped
+	if err := endpoint.queue.push(buf); err != nil && err != io.ErrClosedPipe {
 		return err
 	}
 
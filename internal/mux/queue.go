@@ -0,0 +1,134 @@
+package mux
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// packetQueue is a bounded single-producer single-consumer queue of packets.
+// The read loop of the Mux is the only producer and the reader of an Endpoint
+// the only consumer, so packets are handed over by publishing the head and
+// tail indexes atomically instead of taking a lock for every packet.
+//
+// A full queue blocks the producer until the consumer catches up, which
+// pushes back on the underlying conn instead of growing without bound. The
+// other Endpoints of the Mux wait too, every Endpoint must be read until it
+// is closed.
+type packetQueue struct {
+	// Only written by the consumer
+	head uint32
+	// Only written by the producer
+	tail uint32
+
+	// The capacity is a power of two so indexes wrap with a mask.
+	// Slots keep their backing array to avoid an allocation per packet
+	slots [][]byte
+	mask  uint32
+
+	// The number of packets dropped because they didn't fit the buffer they
+	// were read into
+	dropped uint64
+
+	// Wakeups for a consumer waiting on an empty queue, and a producer
+	// waiting on a full one. Both are buffered so a signal is never lost
+	readable chan struct{}
+	writable chan struct{}
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+// newPacketQueue creates a packetQueue that holds at least size packets
+func newPacketQueue(size int) *packetQueue {
+	capacity := 1
+	for capacity < size {
+		capacity <<= 1
+	}
+
+	return &packetQueue{
+		slots:    make([][]byte, capacity),
+		mask:     uint32(capacity - 1),
+		readable: make(chan struct{}, 1),
+		writable: make(chan struct{}, 1),
+		closed:   make(chan struct{}),
+	}
+}
+
+func signal(c chan struct{}) {
+	select {
+	case c <- struct{}{}:
+	default:
+	}
+}
+
+// push copies packet to the end of the queue, waiting for space while it is full.
+// It must only be called from a single goroutine
+func (q *packetQueue) push(packet []byte) error {
+	for {
+		select {
+		case <-q.closed:
+			return io.ErrClosedPipe
+		default:
+		}
+
+		tail := atomic.LoadUint32(&q.tail)
+		if tail-atomic.LoadUint32(&q.head) <= q.mask {
+			i := tail & q.mask
+			q.slots[i] = append(q.slots[i][:0], packet...)
+			atomic.StoreUint32(&q.tail, tail+1)
+			signal(q.readable)
+			return nil
+		}
+
+		select {
+		case <-q.writable:
+		case <-q.closed:
+			return io.ErrClosedPipe
+		}
+	}
+}
+
+// pop copies the first packet into buf, waiting for one while the queue is empty.
+// A packet that doesn't fit buf is dropped and io.ErrShortBuffer returned. Once
+// the queue is closed the remaining packets can still be read, followed by
+// io.EOF. It must only be called from a single goroutine
+func (q *packetQueue) pop(buf []byte) (int, error) {
+	for {
+		head := atomic.LoadUint32(&q.head)
+		if head != atomic.LoadUint32(&q.tail) {
+			packet := q.slots[head&q.mask]
+			if len(packet) > len(buf) {
+				atomic.StoreUint32(&q.head, head+1)
+				atomic.AddUint64(&q.dropped, 1)
+				signal(q.writable)
+				return 0, io.ErrShortBuffer
+			}
+
+			n := copy(buf, packet)
+			atomic.StoreUint32(&q.head, head+1)
+			signal(q.writable)
+			return n, nil
+		}
+
+		select {
+		case <-q.readable:
+		case <-q.closed:
+			if head == atomic.LoadUint32(&q.tail) {
+				return 0, io.EOF
+			}
+		}
+	}
+}
+
+// droppedPackets returns the number of packets dropped so far
+func (q *packetQueue) droppedPackets() uint64 {
+	return atomic.LoadUint64(&q.dropped)
+}
+
+// close wakes up both sides, further pushes fail
+func (q *packetQueue) close() {
+	q.closeOnce.Do(func() {
+		close(q.closed)
+	})
+}
@@ -0,0 +1,146 @@
+package mux
+
+import (
+	"encoding/binary"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPacketQueue(t *testing.T) {
+	t.Run("Order", func(t *testing.T) {
+		q := newPacketQueue(3)
+		assert.Equal(t, 4, len(q.slots))
+
+		for i := byte(0); i < 4; i++ {
+			assert.NoError(t, q.push([]byte{i, i}))
+		}
+
+		buf := make([]byte, 2)
+		for i := byte(0); i < 4; i++ {
+			n, err := q.pop(buf)
+			assert.NoError(t, err)
+			assert.Equal(t, []byte{i, i}, buf[:n])
+		}
+	})
+
+	t.Run("ShortBuffer", func(t *testing.T) {
+		q := newPacketQueue(2)
+		assert.NoError(t, q.push([]byte{1, 2, 3}))
+		assert.NoError(t, q.push([]byte{4}))
+
+		// The packet that doesn't fit is dropped, the next one is read
+		_, err := q.pop(make([]byte, 2))
+		assert.Equal(t, io.ErrShortBuffer, err)
+		assert.Equal(t, uint64(1), q.droppedPackets())
+
+		buf := make([]byte, 2)
+		n, err := q.pop(buf)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte{4}, buf[:n])
+	})
+
+	t.Run("Backpressure", func(t *testing.T) {
+		q := newPacketQueue(1)
+		assert.NoError(t, q.push([]byte{1}))
+
+		pushed := make(chan error)
+		go func() {
+			pushed <- q.push([]byte{2})
+		}()
+
+		select {
+		case <-pushed:
+			assert.Fail(t, "push to a full queue returned")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		buf := make([]byte, 1)
+		_, err := q.pop(buf)
+		assert.NoError(t, err)
+		assert.Equal(t, byte(1), buf[0])
+		assert.NoError(t, <-pushed)
+
+		_, err = q.pop(buf)
+		assert.NoError(t, err)
+		assert.Equal(t, byte(2), buf[0])
+		assert.Equal(t, uint64(0), q.droppedPackets())
+	})
+
+	t.Run("Close while full", func(t *testing.T) {
+		q := newPacketQueue(1)
+		assert.NoError(t, q.push([]byte{1}))
+
+		pushed := make(chan error)
+		go func() {
+			pushed <- q.push([]byte{2})
+		}()
+
+		time.Sleep(10 * time.Millisecond)
+		q.close()
+		assert.Equal(t, io.ErrClosedPipe, <-pushed)
+	})
+
+	t.Run("Close", func(t *testing.T) {
+		q := newPacketQueue(1)
+		assert.NoError(t, q.push([]byte{1}))
+
+		popped := make(chan error)
+		go func() {
+			buf := make([]byte, 1)
+			if _, err := q.pop(buf); err != nil {
+				popped <- err
+				return
+			}
+			_, err := q.pop(buf)
+			popped <- err
+		}()
+
+		// A consumer waiting for a packet is woken up, packets queued before
+		// closing are still read
+		time.Sleep(10 * time.Millisecond)
+		q.close()
+		assert.Equal(t, io.EOF, <-popped)
+		assert.Equal(t, io.ErrClosedPipe, q.push([]byte{2}))
+	})
+
+	t.Run("Concurrent", func(t *testing.T) {
+		const count = 100000
+		q := newPacketQueue(16)
+
+		go func() {
+			packet := make([]byte, 4)
+			for i := uint32(0); i < count; i++ {
+				binary.BigEndian.PutUint32(packet, i)
+				if err := q.push(packet); err != nil {
+					return
+				}
+			}
+			q.close()
+		}()
+
+		// Every packet is read in order
+		received := uint64(0)
+		next := uint32(0)
+		buf := make([]byte, 4)
+		for {
+			n, err := q.pop(buf)
+			if err == io.EOF {
+				break
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, 4, n)
+			i := binary.BigEndian.Uint32(buf)
+			if i != next {
+				assert.Fail(t, "packets out of order", "%d after %d", i, next)
+				return
+			}
+			next = i + 1
+			received++
+		}
+		assert.Equal(t, uint64(count), received)
+		assert.Equal(t, uint64(0), q.droppedPackets())
+	})
+}
@@ -18,6 +18,16 @@ func RandSeq(n int) string {
 	return string(b)
 }
 
+// ntpEpochOffset is the number of seconds between the NTP epoch (1900) and the Unix epoch (1970)
+const ntpEpochOffset = 2208988800
+
+// NTPTime converts a time.Time to a 64bit NTP timestamp, as used by RTCP Sender Reports
+func NTPTime(t time.Time) uint64 {
+	seconds := uint64(t.Unix()) + ntpEpochOffset
+	fraction := (uint64(t.Nanosecond()) << 32) / uint64(time.Second)
+	return seconds<<32 | fraction
+}
+
 // FlattenErrs flattens multiple errors into one
 func FlattenErrs(errs []error) error {
 	errs2 := []error{}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"regexp"
 	"testing"
+	"time"
 )
 
 func TestRandSeq(t *testing.T) {
@@ -17,6 +18,16 @@ func TestRandSeq(t *testing.T) {
 	}
 }
 
+func TestNTPTime(t *testing.T) {
+	if NTPTime(time.Unix(0, 0)) != uint64(2208988800)<<32 {
+		t.Errorf("NTPTime of the Unix epoch is invalid")
+	}
+
+	if NTPTime(time.Unix(1, int64(time.Second/2))) != (uint64(2208988801)<<32)|(1<<31) {
+		t.Errorf("NTPTime fraction is invalid")
+	}
+}
+
 func TestMultiError(t *testing.T) {
 	rawErrs := []error{
 		errors.New("err1"),
@@ -0,0 +1,201 @@
+// +build !js
+
+package webrtc
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v2/pkg/interceptor"
+)
+
+// Packets that are further behind the next expected one when the jitter buffer
+// is empty are taken as a restart of the sequence numbers, RFC 3550 Appendix A.1
+const jitterBufferMaxMisorder = 100
+
+// jitterBuffer reorders the RTP packets of a single SSRC by sequence number.
+// Packets are held until the next expected one arrives, until more than depth
+// packets are waiting or until one of them has waited maxDelay, in the last two
+// cases the missing ones are given up on
+type jitterBuffer struct {
+	mu sync.Mutex
+
+	depth    uint16
+	maxDelay time.Duration
+	started  bool
+	next     uint16
+	packets  map[uint16]jitterBufferPacket
+
+	// Signaled when a packet is pushed or popped, or when reading failed
+	pushed, popped chan struct{}
+	readErr        error
+}
+
+type jitterBufferPacket struct {
+	raw     []byte
+	arrival time.Time
+}
+
+func newJitterBuffer(depth uint16, maxDelay time.Duration) *jitterBuffer {
+	return &jitterBuffer{
+		depth:    depth,
+		maxDelay: maxDelay,
+		packets:  map[uint16]jitterBufferPacket{},
+		pushed:   make(chan struct{}, 1),
+		popped:   make(chan struct{}, 1),
+	}
+}
+
+// push adds a copy of a packet that arrived at arrival. Packets that arrive
+// after their turn has been skipped, or that are duplicates, are dropped
+func (j *jitterBuffer) push(sequenceNumber uint16, raw []byte, arrival time.Time) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if !j.started {
+		j.started = true
+		j.next = sequenceNumber
+	} else if behind := j.next - sequenceNumber; behind != 0 && behind <= 1<<15 {
+		if len(j.packets) != 0 || behind <= jitterBufferMaxMisorder {
+			return
+		}
+		j.next = sequenceNumber
+	}
+
+	if _, ok := j.packets[sequenceNumber]; ok {
+		return
+	}
+	j.packets[sequenceNumber] = jitterBufferPacket{raw: append([]byte{}, raw...), arrival: arrival}
+	signal(j.pushed)
+}
+
+// pop copies the next packet in order into b, it returns 0 if it hasn't arrived
+// yet and the packets waiting for it can still wait at now. If b is too small
+// io.ErrShortBuffer is returned and the packet is kept
+func (j *jitterBuffer) pop(b []byte, now time.Time) (int, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if len(j.packets) == 0 {
+		return 0, nil
+	}
+
+	packet, ok := j.packets[j.next]
+	if !ok {
+		// Skip to the oldest packet we have if too many are waiting, or if
+		// one has waited too long
+		oldest := j.next
+		expired := false
+		for sequenceNumber, waiting := range j.packets {
+			if oldest == j.next || sequenceNumber-j.next < oldest-j.next {
+				oldest = sequenceNumber
+			}
+			if now.Sub(waiting.arrival) >= j.maxDelay {
+				expired = true
+			}
+		}
+		if len(j.packets) <= int(j.depth) && !expired {
+			return 0, nil
+		}
+
+		j.next = oldest
+		packet = j.packets[oldest]
+	}
+
+	if len(packet.raw) > len(b) {
+		return 0, io.ErrShortBuffer
+	}
+
+	delete(j.packets, j.next)
+	j.next++
+	signal(j.popped)
+	return copy(b, packet.raw), nil
+}
+
+// read copies the next packet in order into b, waiting for it to be pushed by
+// readFrom or for the packets waiting for it to expire. It returns the error
+// reading failed with once no packet can be popped anymore
+func (j *jitterBuffer) read(b []byte) (int, error) {
+	for {
+		if n, err := j.pop(b, time.Now()); n != 0 || err != nil {
+			return n, err
+		}
+
+		j.mu.Lock()
+		readErr := j.readErr
+		var expiry time.Time
+		for _, waiting := range j.packets {
+			if expiry.IsZero() || waiting.arrival.Before(expiry) {
+				expiry = waiting.arrival
+			}
+		}
+		j.mu.Unlock()
+
+		if readErr != nil {
+			return 0, readErr
+		}
+
+		if expiry.IsZero() {
+			<-j.pushed
+			continue
+		}
+
+		timer := time.NewTimer(time.Until(expiry.Add(j.maxDelay)))
+		select {
+		case <-j.pushed:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
+
+// readFrom pushes the packets of reader until reading fails or done is closed.
+// Reading waits while more than depth packets are held, the packets are then
+// left to the buffers of the stream. arrival returns when a packet arrived
+func (j *jitterBuffer) readFrom(reader interceptor.RTPReader, mtu int, arrival func(*rtp.Header) time.Time, done <-chan interface{}) {
+	b := make([]byte, mtu)
+	for {
+		j.mu.Lock()
+		full := len(j.packets) > int(j.depth)
+		j.mu.Unlock()
+
+		if full {
+			select {
+			case <-j.popped:
+				continue
+			case <-done:
+				j.fail(io.EOF)
+				return
+			}
+		}
+
+		n, err := reader.Read(b)
+		if err != nil {
+			j.fail(err)
+			return
+		}
+
+		header := &rtp.Header{}
+		if err := header.Unmarshal(b[:n]); err == nil {
+			j.push(header.SequenceNumber, b[:n], arrival(header))
+		}
+	}
+}
+
+// fail makes read return err once no packet can be popped anymore
+func (j *jitterBuffer) fail(err error) {
+	j.mu.Lock()
+	j.readErr = err
+	j.mu.Unlock()
+	signal(j.pushed)
+}
+
+// signal wakes up the goroutine waiting on c, if it isn't already woken up
+func signal(c chan struct{}) {
+	select {
+	case c <- struct{}{}:
+	default:
+	}
+}
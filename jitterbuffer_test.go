@@ -0,0 +1,185 @@
+// +build !js
+
+package webrtc
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v2/pkg/interceptor"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJitterBuffer(t *testing.T) {
+	now := time.Now()
+	pop := func(j *jitterBuffer) []byte {
+		b := make([]byte, 8)
+		n, err := j.pop(b, now)
+		assert.NoError(t, err)
+		if n == 0 {
+			return nil
+		}
+		return b[:n]
+	}
+
+	t.Run("Reorder", func(t *testing.T) {
+		j := newJitterBuffer(4, time.Hour)
+		j.push(65534, []byte{0}, now)
+		assert.Equal(t, []byte{0}, pop(j))
+
+		j.push(0, []byte{2}, now)
+		assert.Nil(t, pop(j))
+
+		j.push(65535, []byte{1}, now)
+		assert.Equal(t, []byte{1}, pop(j))
+		assert.Equal(t, []byte{2}, pop(j))
+		assert.Nil(t, pop(j))
+	})
+
+	t.Run("Late and duplicate", func(t *testing.T) {
+		j := newJitterBuffer(4, time.Hour)
+		j.push(10, []byte{10}, now)
+		assert.Equal(t, []byte{10}, pop(j))
+
+		j.push(10, []byte{10}, now)
+		j.push(9, []byte{9}, now)
+		assert.Nil(t, pop(j))
+	})
+
+	t.Run("Skip lost", func(t *testing.T) {
+		j := newJitterBuffer(2, time.Hour)
+		j.push(10, []byte{10}, now)
+		assert.Equal(t, []byte{10}, pop(j))
+
+		// 11 is lost, it is waited for while no more than depth packets are held
+		j.push(13, []byte{13}, now)
+		j.push(12, []byte{12}, now)
+		assert.Nil(t, pop(j))
+
+		j.push(14, []byte{14}, now)
+		assert.Equal(t, []byte{12}, pop(j))
+		assert.Equal(t, []byte{13}, pop(j))
+		assert.Equal(t, []byte{14}, pop(j))
+
+		// Once skipped it is dropped
+		j.push(11, []byte{11}, now)
+		assert.Nil(t, pop(j))
+	})
+
+	t.Run("Skip lost after max delay", func(t *testing.T) {
+		j := newJitterBuffer(16, 100*time.Millisecond)
+		j.push(10, []byte{10}, now.Add(-time.Second))
+		assert.Equal(t, []byte{10}, pop(j))
+
+		// 11 is lost, it is waited for until 12 has waited the max delay
+		j.push(12, []byte{12}, now.Add(-50*time.Millisecond))
+		assert.Nil(t, pop(j))
+
+		j.push(13, []byte{13}, now)
+		assert.Nil(t, pop(j))
+
+		b := make([]byte, 8)
+		n, err := j.pop(b, now.Add(50*time.Millisecond))
+		assert.NoError(t, err)
+		assert.Equal(t, []byte{12}, b[:n])
+		assert.Equal(t, []byte{13}, pop(j))
+	})
+
+	t.Run("Restarted sequence numbers", func(t *testing.T) {
+		j := newJitterBuffer(4, time.Hour)
+		j.push(10, []byte{10}, now)
+		assert.Equal(t, []byte{10}, pop(j))
+
+		// Too far behind to be late, it is taken as a restart once nothing is waiting
+		j.push(10+40000, []byte{1}, now)
+		assert.Equal(t, []byte{1}, pop(j))
+		j.push(10+40001, []byte{2}, now)
+		assert.Equal(t, []byte{2}, pop(j))
+	})
+
+	t.Run("Read after max delay without more arrivals", func(t *testing.T) {
+		j := newJitterBuffer(16, 50*time.Millisecond)
+
+		packets := make(chan []byte, 2)
+		packets <- []byte{0x80, 0x60, 0x00, 0x01, 0, 0, 0, 0, 0, 0, 0, 0}
+		packets <- []byte{0x80, 0x60, 0x00, 0x03, 0, 0, 0, 0, 0, 0, 0, 0}
+		reader := interceptor.RTPReaderFunc(func(b []byte) (int, error) {
+			packet, ok := <-packets
+			if !ok {
+				return 0, io.EOF
+			}
+			return copy(b, packet), nil
+		})
+
+		done := make(chan interface{})
+		readDone := make(chan struct{})
+		go func() {
+			j.readFrom(reader, 1500, func(*rtp.Header) time.Time { return time.Now() }, done)
+			close(readDone)
+		}()
+
+		b := make([]byte, 1500)
+		start := time.Now()
+		for _, expected := range []byte{1, 3} {
+			n, err := j.read(b)
+			assert.NoError(t, err)
+			assert.Equal(t, 12, n)
+			assert.Equal(t, expected, b[3])
+		}
+		assert.True(t, time.Since(start) >= 50*time.Millisecond)
+
+		close(packets)
+		_, err := j.read(b)
+		assert.Equal(t, io.EOF, err)
+		close(done)
+		<-readDone
+	})
+
+	t.Run("Short buffer", func(t *testing.T) {
+		j := newJitterBuffer(4, time.Hour)
+		j.push(10, []byte{10, 10}, now)
+
+		// The packet is kept until it is read with a buffer large enough
+		n, err := j.pop(make([]byte, 1), now)
+		assert.Equal(t, io.ErrShortBuffer, err)
+		assert.Equal(t, 0, n)
+		assert.Equal(t, []byte{10, 10}, pop(j))
+	})
+}
+
+func TestArrivalTimes(t *testing.T) {
+	packet := func(ssrc uint32, sequenceNumber uint16) []byte {
+		return []byte{
+			0x80, 0x60, byte(sequenceNumber >> 8), byte(sequenceNumber),
+			0x00, 0x00, 0x00, 0x00,
+			byte(ssrc >> 24), byte(ssrc >> 16), byte(ssrc >> 8), byte(ssrc),
+		}
+	}
+	arrived := time.Now().Add(-time.Second)
+
+	a := arrivalTimes{}
+	a.record(packet(1, 10), arrived)
+	assert.NotEqual(t, arrived, a.get(1, 10), "SSRCs that aren't watched aren't recorded")
+
+	a.watch(1)
+	a.record(packet(1, 10), arrived)
+	a.record(packet(2, 10), arrived)
+	assert.Equal(t, arrived, a.get(1, 10))
+	assert.NotEqual(t, arrived, a.get(1, 11))
+	assert.NotEqual(t, arrived, a.get(2, 10))
+
+	packets, bytes, last := a.received(1)
+	assert.Equal(t, uint64(1), packets)
+	assert.Equal(t, uint64(12), bytes)
+	assert.Equal(t, arrived, last)
+
+	// Overwritten by a packet arriving arrivalHistorySize packets later
+	a.record(packet(1, 10+arrivalHistorySize), time.Now())
+	assert.NotEqual(t, arrived, a.get(1, 10))
+
+	a.record(packet(1, 12), arrived)
+	a.forget(1)
+	assert.NotEqual(t, arrived, a.get(1, 12))
+}
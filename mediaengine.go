@@ -26,18 +26,101 @@ const (
 	mediaNameVideo = "video"
 )
 
+// URIs of RTP header extensions that can be registered with RegisterHeaderExtension
+const (
+	SDESMidURI         = "urn:ietf:params:rtp-hdrext:sdes:mid"
+	SDESRTPStreamIDURI = "urn:ietf:params:rtp-hdrext:sdes:rtp-stream-id"
+	TransportCCURI     = "http://www.ietf.org/id/draft-holmer-rmcat-transport-wide-cc-extensions-01"
+	AudioLevelURI      = "urn:ietf:params:rtp-hdrext:ssrc-audio-level"
+	AbsSendTimeURI     = "http://www.webrtc.org/experiments/rtp-hdrext/abs-send-time"
+	PlayoutDelayURI    = "http://www.webrtc.org/experiments/rtp-hdrext/playout-delay"
+	VideoTimingURI     = "http://www.webrtc.org/experiments/rtp-hdrext/video-timing"
+)
+
 // MediaEngine defines the codecs supported by a PeerConnection
 type MediaEngine struct {
-	codecs []*RTPCodec
+	codecs           []*RTPCodec
+	headerExtensions []mediaEngineHeaderExtension
 }
 
-// RegisterCodec registers a codec to a media engine
+type mediaEngineHeaderExtension struct {
+	uri   string
+	id    int
+	kinds []RTPCodecType
+}
+
+// RegisterCodec registers a codec to a media engine. The payload type of the codec
+// is the one used in offers. Answers only contain the registered codecs the remote
+// offered, matched by name, clock rate, channels and the fmtp parameters that affect
+// the stream such as the H264 profile, and use the payload types of the offer
 func (m *MediaEngine) RegisterCodec(codec *RTPCodec) uint8 {
 	// pion/webrtc#43
 	m.codecs = append(m.codecs, codec)
 	return codec.PayloadType
 }
 
+// RegisterHeaderExtension adds a RTP header extension that is offered for, and
+// accepted in, media sections of the given kind. The extension is assigned the
+// lowest free ID, when answering the ID chosen by the remote is used instead.
+// The negotiated IDs can be found with RTPSender.GetParameters and
+// RTPReceiver.GetParameters
+func (m *MediaEngine) RegisterHeaderExtension(extension RTPHeaderExtensionCapability, kind RTPCodecType) error {
+	for i := range m.headerExtensions {
+		if m.headerExtensions[i].uri != extension.URI {
+			continue
+		}
+
+		for _, k := range m.headerExtensions[i].kinds {
+			if k == kind {
+				return nil
+			}
+		}
+		m.headerExtensions[i].kinds = append(m.headerExtensions[i].kinds, kind)
+		return nil
+	}
+
+	for id := 1; id <= maxHeaderExtensionID; id++ {
+		// Codecs with transport-cc feedback announce the extension with this ID
+		if id == sdp.ExtMapValueTransportCC && extension.URI != TransportCCURI {
+			continue
+		}
+
+		inUse := false
+		for i := range m.headerExtensions {
+			if m.headerExtensions[i].id == id {
+				inUse = true
+				break
+			}
+		}
+		if inUse {
+			continue
+		}
+
+		m.headerExtensions = append(m.headerExtensions, mediaEngineHeaderExtension{
+			uri:   extension.URI,
+			id:    id,
+			kinds: []RTPCodecType{kind},
+		})
+		return nil
+	}
+
+	return ErrHeaderExtensionLimit
+}
+
+// getHeaderExtensions returns the extensions registered for a kind
+func (m *MediaEngine) getHeaderExtensions(kind RTPCodecType) []RTPHeaderExtensionParameter {
+	var headerExtensions []RTPHeaderExtensionParameter
+	for _, e := range m.headerExtensions {
+		for _, k := range e.kinds {
+			if k == kind {
+				headerExtensions = append(headerExtensions, RTPHeaderExtensionParameter{URI: e.uri, ID: e.id})
+				break
+			}
+		}
+	}
+	return headerExtensions
+}
+
 // RegisterDefaultCodecs is a helper that registers the default codecs supported by Pion WebRTC
 func (m *MediaEngine) RegisterDefaultCodecs() {
 	// Audio Codecs in order of preference
@@ -93,6 +176,10 @@ func (m *MediaEngine) PopulateFromSDP(sd SessionDescription) error {
 				codec = NewRTPVP9Codec(payloadType, payloadCodec.ClockRate)
 			case strings.EqualFold(payloadCodec.Name, H264):
 				codec = NewRTPH264Codec(payloadType, payloadCodec.ClockRate)
+			case strings.EqualFold(payloadCodec.Name, TelephoneEvent):
+				codec = NewRTPTelephoneEventCodec(payloadType, payloadCodec.ClockRate)
+			case strings.EqualFold(payloadCodec.Name, FlexFEC):
+				codec = NewRTPFlexFECCodec(payloadType, payloadCodec.ClockRate)
 			default:
 				// ignoring other codecs
 				continue
@@ -120,13 +207,103 @@ func (m *MediaEngine) getCodecSDP(sdpCodec sdp.Codec) (*RTPCodec, error) {
 			codec.ClockRate == sdpCodec.ClockRate &&
 			(sdpCodec.EncodingParameters == "" ||
 				strconv.Itoa(int(codec.Channels)) == sdpCodec.EncodingParameters) &&
-			codec.SDPFmtpLine == sdpCodec.Fmtp { // pion/webrtc#43
+			fmtpConsistent(codec.Name, codec.SDPFmtpLine, sdpCodec.Fmtp) { // pion/webrtc#43
 			return codec, nil
 		}
 	}
 	return nil, ErrCodecNotFound
 }
 
+// staticPayloadTypes are the codecs that may be used without a rtpmap, RFC 3551 Section 6
+var staticPayloadTypes = map[uint8]sdp.Codec{
+	DefaultPayloadTypePCMU: {PayloadType: DefaultPayloadTypePCMU, Name: PCMU, ClockRate: 8000},
+	DefaultPayloadTypePCMA: {PayloadType: DefaultPayloadTypePCMA, Name: PCMA, ClockRate: 8000},
+	DefaultPayloadTypeG722: {PayloadType: DefaultPayloadTypeG722, Name: G722, ClockRate: 8000},
+}
+
+// codecMatches reports if a codec of the MediaEngine can be used for a codec of a remote description
+func codecMatches(codec *RTPCodec, remote sdp.Codec) bool {
+	if !strings.EqualFold(codec.Name, remote.Name) || codec.ClockRate != remote.ClockRate {
+		return false
+	}
+	if codec.Channels != 0 && remote.EncodingParameters != "" && strconv.Itoa(int(codec.Channels)) != remote.EncodingParameters {
+		return false
+	}
+	return fmtpConsistent(codec.Name, codec.SDPFmtpLine, remote.Fmtp)
+}
+
+// matchRemoteCodecs returns the codecs of the MediaEngine that the remote offers in a media
+// section, in the order the remote prefers them and with the payload types of the remote.
+// RTX is only kept for the matched codecs that it is registered for. The result is empty,
+// not nil, if no codec is shared
+func (m *MediaEngine) matchRemoteCodecs(media *sdp.MediaDescription) []*RTPCodec {
+	kind := NewRTPCodecType(media.MediaName.Media)
+	description := &sdp.SessionDescription{MediaDescriptions: []*sdp.MediaDescription{media}}
+
+	// Local RTX codecs by their associated payload type
+	localRTX := map[uint8]*RTPCodec{}
+	for _, codec := range m.codecs {
+		if codec.Type != kind || codec.Name != RTX {
+			continue
+		}
+		if apt, err := strconv.ParseUint(fmtpParameter(parseFmtp(codec.SDPFmtpLine), "apt", ""), 10, 8); err == nil {
+			localRTX[uint8(apt)] = codec
+		}
+	}
+
+	matched := []*RTPCodec{}
+	localPayloadTypes := map[uint8]uint8{}
+	var remoteRTX []sdp.Codec
+	for _, format := range media.MediaName.Formats {
+		payloadType, err := strconv.ParseUint(format, 10, 8)
+		if err != nil {
+			continue
+		}
+
+		remote, err := description.GetCodecForPayloadType(uint8(payloadType))
+		if err != nil {
+			var ok bool
+			if remote, ok = staticPayloadTypes[uint8(payloadType)]; !ok {
+				continue
+			}
+		}
+
+		if strings.EqualFold(remote.Name, RTX) {
+			remoteRTX = append(remoteRTX, remote)
+			continue
+		}
+
+		for _, codec := range m.codecs {
+			if codec.Type == kind && codec.Name != RTX && codecMatches(codec, remote) {
+				negotiated := *codec
+				negotiated.PayloadType = remote.PayloadType
+				matched = append(matched, &negotiated)
+				localPayloadTypes[remote.PayloadType] = codec.PayloadType
+				break
+			}
+		}
+	}
+
+	for _, remote := range remoteRTX {
+		apt, err := strconv.ParseUint(fmtpParameter(parseFmtp(remote.Fmtp), "apt", ""), 10, 8)
+		if err != nil {
+			continue
+		}
+
+		localPayloadType, ok := localPayloadTypes[uint8(apt)]
+		if !ok {
+			continue
+		}
+		if codec, ok := localRTX[localPayloadType]; ok {
+			rtx := *codec
+			rtx.PayloadType = remote.PayloadType
+			rtx.SDPFmtpLine = fmt.Sprintf("apt=%d", apt)
+			matched = append(matched, &rtx)
+		}
+	}
+	return matched
+}
+
 // GetCodecsByKind returns all codecs of a chosen kind in the codecs list
 func (m *MediaEngine) GetCodecsByKind(kind RTPCodecType) []*RTPCodec {
 	var codecs []*RTPCodec
@@ -158,6 +335,18 @@ const (
 	VP8  = "VP8"
 	VP9  = "VP9"
 	H264 = "H264"
+
+	// RTX is not a media codec, it carries retransmissions for the codec
+	// referenced by its apt (associated payload type) parameter
+	RTX = "rtx"
+
+	// TelephoneEvent is not a media codec, it carries DTMF tones (RFC 4733)
+	// alongside the audio codec that has the same clock rate
+	TelephoneEvent = "telephone-event"
+
+	// FlexFEC is not a media codec, it carries the forward error correction
+	// packets protecting the video codecs of the media section
+	FlexFEC = "flexfec-03"
 )
 
 // NewRTPPCMUCodec is a helper to create a PCMU codec
@@ -208,6 +397,20 @@ func NewRTPOpusCodec(payloadType uint8, clockrate uint32) *RTPCodec {
 	return c
 }
 
+// NewRTPOpusCodecExt is a helper to create an Opus codec with fmtp options, such as
+// "minptime=10;useinbandfec=1;stereo=1"
+func NewRTPOpusCodecExt(payloadType uint8, clockrate uint32, rtcpfb []RTCPFeedback, fmtp string) *RTPCodec {
+	c := NewRTPCodecExt(RTPCodecTypeAudio,
+		Opus,
+		clockrate,
+		2, //According to RFC7587, Opus RTP streams must have exactly 2 channels.
+		fmtp,
+		payloadType,
+		rtcpfb,
+		&codecs.OpusPayloader{})
+	return c
+}
+
 // NewRTPVP8Codec is a helper to create an VP8 codec
 func NewRTPVP8Codec(payloadType uint8, clockrate uint32) *RTPCodec {
 	c := NewRTPCodec(RTPCodecTypeVideo,
@@ -233,6 +436,49 @@ func NewRTPVP8CodecExt(payloadType uint8, clockrate uint32, rtcpfb []RTCPFeedbac
 	return c
 }
 
+// NewRTPRTXCodec is a helper to create a RTX codec (RFC 4588) carrying retransmissions of
+// the codec with payload type apt. When it is registered and both sides negotiated it,
+// packets requested by Generic NACK are resent on a separate SSRC using this codec
+func NewRTPRTXCodec(payloadType uint8, clockrate uint32, apt uint8) *RTPCodec {
+	c := NewRTPCodec(RTPCodecTypeVideo,
+		RTX,
+		clockrate,
+		0,
+		fmt.Sprintf("apt=%d", apt),
+		payloadType,
+		nil)
+	return c
+}
+
+// NewRTPFlexFECCodec is a helper to create a FlexFEC codec (draft-ietf-payload-flexible-fec-scheme-03).
+// When it is registered and both sides negotiated it, FEC packets protecting the video Tracks are
+// sent on a separate SSRC using this codec, see SettingEngine.SetFECProtectionRate, and the lost
+// packets of received video Tracks are recovered from the FEC packets of the remote
+func NewRTPFlexFECCodec(payloadType uint8, clockrate uint32) *RTPCodec {
+	c := NewRTPCodec(RTPCodecTypeVideo,
+		FlexFEC,
+		clockrate,
+		0,
+		"repair-window=10000000",
+		payloadType,
+		nil)
+	return c
+}
+
+// NewRTPTelephoneEventCodec is a helper to create a telephone-event codec (RFC 4733) for the
+// DTMF events 0-15. When it is registered and both sides negotiated it, RTPSender.InsertDTMF
+// can send DTMF tones on audio Tracks. clockrate should match the one of the audio codec
+func NewRTPTelephoneEventCodec(payloadType uint8, clockrate uint32) *RTPCodec {
+	c := NewRTPCodec(RTPCodecTypeAudio,
+		TelephoneEvent,
+		clockrate,
+		0,
+		"0-15",
+		payloadType,
+		nil)
+	return c
+}
+
 // NewRTPVP9Codec is a helper to create an VP9 codec
 func NewRTPVP9Codec(payloadType uint8, clockrate uint32) *RTPCodec {
 	c := NewRTPCodec(RTPCodecTypeVideo,
@@ -245,6 +491,20 @@ func NewRTPVP9Codec(payloadType uint8, clockrate uint32) *RTPCodec {
 	return c
 }
 
+// NewRTPVP9CodecExt is a helper to create an VP9 codec, fmtp selects the profile
+// with profile-id, which has to match the one of the remote
+func NewRTPVP9CodecExt(payloadType uint8, clockrate uint32, rtcpfb []RTCPFeedback, fmtp string) *RTPCodec {
+	c := NewRTPCodecExt(RTPCodecTypeVideo,
+		VP9,
+		clockrate,
+		0,
+		fmtp,
+		payloadType,
+		rtcpfb,
+		&codecs.VP9Payloader{})
+	return c
+}
+
 // NewRTPH264Codec is a helper to create an H264 codec
 func NewRTPH264Codec(payloadType uint8, clockrate uint32) *RTPCodec {
 	c := NewRTPCodec(RTPCodecTypeVideo,
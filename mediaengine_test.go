@@ -3,6 +3,7 @@
 package webrtc
 
 import (
+	"fmt"
 	"regexp"
 	"testing"
 
@@ -108,3 +109,89 @@ func TestOpusCase(t *testing.T) {
 	assert.True(t, regexp.MustCompile(`(?m)^a=rtpmap:\d+ opus/48000/2`).MatchString(offer.SDP))
 	assert.NoError(t, pc.Close())
 }
+
+func TestRegisterHeaderExtension(t *testing.T) {
+	m := MediaEngine{}
+
+	assert.NoError(t, m.RegisterHeaderExtension(RTPHeaderExtensionCapability{URI: SDESMidURI}, RTPCodecTypeAudio))
+	assert.NoError(t, m.RegisterHeaderExtension(RTPHeaderExtensionCapability{URI: SDESMidURI}, RTPCodecTypeVideo))
+	assert.NoError(t, m.RegisterHeaderExtension(RTPHeaderExtensionCapability{URI: AudioLevelURI}, RTPCodecTypeAudio))
+	assert.NoError(t, m.RegisterHeaderExtension(RTPHeaderExtensionCapability{URI: AbsSendTimeURI}, RTPCodecTypeVideo))
+	assert.NoError(t, m.RegisterHeaderExtension(RTPHeaderExtensionCapability{URI: TransportCCURI}, RTPCodecTypeVideo))
+
+	// The ID used by codecs with transport-cc feedback is skipped for other extensions
+	assert.Equal(t, []RTPHeaderExtensionParameter{
+		{URI: SDESMidURI, ID: 1},
+		{URI: AudioLevelURI, ID: 2},
+	}, m.getHeaderExtensions(RTPCodecTypeAudio))
+	assert.Equal(t, []RTPHeaderExtensionParameter{
+		{URI: SDESMidURI, ID: 1},
+		{URI: AbsSendTimeURI, ID: 4},
+		{URI: TransportCCURI, ID: 3},
+	}, m.getHeaderExtensions(RTPCodecTypeVideo))
+
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, m.RegisterHeaderExtension(RTPHeaderExtensionCapability{URI: fmt.Sprintf("urn:test:%d", i)}, RTPCodecTypeVideo))
+	}
+	assert.Equal(t, ErrHeaderExtensionLimit, m.RegisterHeaderExtension(RTPHeaderExtensionCapability{URI: "urn:test:full"}, RTPCodecTypeVideo))
+}
+
+func TestMatchRemoteCodecs(t *testing.T) {
+	m := MediaEngine{}
+	m.RegisterDefaultCodecs()
+	m.RegisterCodec(NewRTPRTXCodec(97, 90000, DefaultPayloadTypeVP8))
+
+	offer := &sdp.SessionDescription{}
+	assert.NoError(t, offer.Unmarshal([]byte(`v=0
+o=- 0 2 IN IP4 127.0.0.1
+s=-
+t=0 0
+m=video 9 UDP/TLS/RTP/SAVPF 125 100 101 107 108 109 98
+a=rtpmap:125 H264/90000
+a=fmtp:125 level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=640032
+a=rtpmap:100 VP8/90000
+a=rtpmap:101 rtx/90000
+a=fmtp:101 apt=100
+a=rtpmap:107 H264/90000
+a=fmtp:107 level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=42001f
+a=rtpmap:108 rtx/90000
+a=fmtp:108 apt=107
+a=rtpmap:109 VP9/90000
+a=fmtp:109 profile-id=2
+a=rtpmap:98 VP9/90000
+a=fmtp:98 profile-id=0
+m=audio 9 UDP/TLS/RTP/SAVPF 111 0 126
+a=rtpmap:111 opus/48000/2
+a=fmtp:111 minptime=10;useinbandfec=1;stereo=1
+a=rtpmap:126 telephone-event/8000
+m=audio 9 UDP/TLS/RTP/SAVPF 13
+a=rtpmap:13 CN/8000
+`)))
+
+	type match struct {
+		name        string
+		payloadType uint8
+		fmtp        string
+	}
+	matches := func(media *sdp.MediaDescription) []match {
+		matched := []match{}
+		for _, codec := range m.matchRemoteCodecs(media) {
+			matched = append(matched, match{codec.Name, codec.PayloadType, codec.SDPFmtpLine})
+		}
+		return matched
+	}
+
+	assert.Equal(t, []match{
+		{VP8, 100, ""},
+		{H264, 107, "level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=42001f"},
+		{VP9, 98, ""},
+		{RTX, 101, "apt=100"},
+	}, matches(offer.MediaDescriptions[0]))
+
+	assert.Equal(t, []match{
+		{Opus, 111, "minptime=10;useinbandfec=1"},
+		{PCMU, 0, ""},
+	}, matches(offer.MediaDescriptions[1]))
+
+	assert.Equal(t, []match{}, matches(offer.MediaDescriptions[2]))
+}
@@ -0,0 +1,144 @@
+// +build !js
+
+package webrtc
+
+import (
+	mathRand "math/rand"
+	"sync"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v2/pkg/interceptor"
+	"github.com/pion/webrtc/v2/pkg/nack"
+)
+
+// How often the missing packets of the remote streams are looked for, each
+// stream sends a Generic NACK at most once per interval of its nack.Generator
+const nackGeneratorInterval = 20 * time.Millisecond
+
+// nackGenerator is the built-in interceptor that requests the retransmission of
+// the packets lost on the remote streams announcing Generic NACK. The packets
+// are accounted for when they are read, like the reception statistics
+type nackGenerator struct {
+	interceptor.NoOp
+
+	// SSRC that Generic NACKs are sent from
+	ssrc uint32
+
+	mu      sync.Mutex
+	streams map[*interceptor.StreamInfo]*nack.Generator
+	writer  interceptor.RTCPWriter
+	started bool
+
+	closeOnce sync.Once
+	closed    chan struct{}
+	routines  routineGroup
+}
+
+func newNACKGenerator() *nackGenerator {
+	return &nackGenerator{
+		ssrc:    mathRand.Uint32(),
+		streams: map[*interceptor.StreamInfo]*nack.Generator{},
+		closed:  make(chan struct{}),
+	}
+}
+
+// BindRTCPWriter remembers the writer Generic NACKs are sent to, they are sent
+// once a stream announcing Generic NACK is bound
+func (n *nackGenerator) BindRTCPWriter(writer interceptor.RTCPWriter) interceptor.RTCPWriter {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.writer = writer
+	n.start()
+	return writer
+}
+
+// BindRemoteStream looks for the packets missing from the streams that announce Generic NACK
+func (n *nackGenerator) BindRemoteStream(info *interceptor.StreamInfo, reader interceptor.RTPReader) interceptor.RTPReader {
+	if !streamHasGenericNACK(info) {
+		return reader
+	}
+
+	generator := nack.NewGenerator(n.ssrc, info.SSRC)
+	n.mu.Lock()
+	n.streams[info] = generator
+	n.start()
+	n.mu.Unlock()
+
+	return interceptor.RTPReaderFunc(func(b []byte) (int, error) {
+		i, err := reader.Read(b)
+		if err != nil {
+			return i, err
+		}
+
+		header := &rtp.Header{}
+		if err := header.Unmarshal(b[:i]); err == nil {
+			n.mu.Lock()
+			generator.Push(header.SequenceNumber)
+			n.mu.Unlock()
+		}
+		return i, nil
+	})
+}
+
+// UnbindRemoteStream stops requesting the packets missing from the stream
+func (n *nackGenerator) UnbindRemoteStream(info *interceptor.StreamInfo) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	delete(n.streams, info)
+}
+
+// Close stops sending Generic NACKs
+func (n *nackGenerator) Close() error {
+	n.closeOnce.Do(func() {
+		close(n.closed)
+	})
+	n.routines.Close()
+	return nil
+}
+
+// start sends Generic NACKs once there are a writer and a stream to send them
+// about. Caller must hold n.mu
+func (n *nackGenerator) start() {
+	if n.started || n.writer == nil || len(n.streams) == 0 {
+		return
+	}
+	n.started = true
+
+	writer := n.writer
+	n.routines.Go(func() { n.sendNACKs(writer) })
+}
+
+// nacks returns the Generic NACKs that are due at now
+func (n *nackGenerator) nacks(now time.Time) []rtcp.Packet {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	var pkts []rtcp.Packet
+	for _, generator := range n.streams {
+		if pkt := generator.Generate(now); pkt != nil {
+			pkts = append(pkts, pkt)
+		}
+	}
+	return pkts
+}
+
+// sendNACKs runs until Close. NACKs that can't be written are dropped, the
+// packets are requested again until they have been requested enough times
+func (n *nackGenerator) sendNACKs(writer interceptor.RTCPWriter) {
+	ticker := time.NewTicker(nackGeneratorInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-n.closed:
+			return
+		case now := <-ticker.C:
+			if pkts := n.nacks(now); len(pkts) != 0 {
+				_, _ = writer.Write(pkts)
+			}
+		}
+	}
+}
@@ -0,0 +1,133 @@
+// +build !js
+
+package webrtc
+
+import (
+	"encoding/binary"
+	mathRand "math/rand"
+	"sync"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v2/pkg/interceptor"
+)
+
+// nackResponder is the built-in interceptor that keeps the packets sent on
+// the streams announcing Generic NACK, and retransmits the ones the remote
+// reports lost. They are sent as RTX packets if RTX has been negotiated
+type nackResponder struct {
+	interceptor.NoOp
+
+	historySize uint16
+
+	mu      sync.Mutex
+	streams map[uint32]*nackResponderStream
+}
+
+type nackResponderStream struct {
+	info    *interceptor.StreamInfo
+	writer  interceptor.RTPWriter
+	history *rtpSendHistory
+
+	rtxSequenceNumber uint16
+}
+
+func newNACKResponder(historySize uint16) *nackResponder {
+	return &nackResponder{
+		historySize: historySize,
+		streams:     map[uint32]*nackResponderStream{},
+	}
+}
+
+// BindLocalStream keeps the packets written to streams that announce Generic NACK
+func (n *nackResponder) BindLocalStream(info *interceptor.StreamInfo, writer interceptor.RTPWriter) interceptor.RTPWriter {
+	if !streamHasGenericNACK(info) {
+		return writer
+	}
+
+	stream := &nackResponderStream{
+		info:              info,
+		writer:            writer,
+		history:           newRTPSendHistory(n.historySize),
+		rtxSequenceNumber: uint16(mathRand.Uint32()),
+	}
+
+	n.mu.Lock()
+	n.streams[info.SSRC] = stream
+	n.mu.Unlock()
+
+	return interceptor.RTPWriterFunc(func(header *rtp.Header, payload []byte) (int, error) {
+		i, err := writer.Write(header, payload)
+		if err == nil {
+			stream.history.add(header, payload)
+		}
+		return i, err
+	})
+}
+
+// UnbindLocalStream forgets the packets of the stream
+func (n *nackResponder) UnbindLocalStream(info *interceptor.StreamInfo) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	delete(n.streams, info.SSRC)
+}
+
+// BindRTCPReader answers the Generic NACKs that are read
+func (n *nackResponder) BindRTCPReader(reader interceptor.RTCPReader) interceptor.RTCPReader {
+	return interceptor.RTCPReaderFunc(func(b []byte) (int, error) {
+		i, err := reader.Read(b)
+		if err != nil {
+			return i, err
+		}
+
+		pkts, err := rtcp.Unmarshal(b[:i])
+		if err != nil {
+			return i, nil
+		}
+
+		for _, pkt := range pkts {
+			if nack, ok := pkt.(*rtcp.TransportLayerNack); ok {
+				n.handleNACK(nack)
+			}
+		}
+		return i, nil
+	})
+}
+
+// handleNACK retransmits the requested packets that are still in the history
+func (n *nackResponder) handleNACK(nack *rtcp.TransportLayerNack) {
+	n.mu.Lock()
+	stream, ok := n.streams[nack.MediaSSRC]
+	n.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	for _, pair := range nack.Nacks {
+		for _, sequenceNumber := range pair.PacketList() {
+			p := stream.history.get(sequenceNumber)
+			if p == nil {
+				continue
+			}
+
+			n.mu.Lock()
+			if stream.info.RTXPayloadType != 0 && p.PayloadType == stream.info.PayloadType {
+				// RFC 4588 Section 4, the payload starts with the original sequence number
+				payload := make([]byte, 2+len(p.Payload))
+				binary.BigEndian.PutUint16(payload, p.SequenceNumber)
+				copy(payload[2:], p.Payload)
+
+				p.Payload = payload
+				p.PayloadType = stream.info.RTXPayloadType
+				p.SSRC = stream.info.RTXSSRC
+				p.SequenceNumber = stream.rtxSequenceNumber
+				stream.rtxSequenceNumber++
+			}
+			n.mu.Unlock()
+
+			if _, err := stream.writer.Write(&p.Header, p.Payload); err != nil {
+				return
+			}
+		}
+	}
+}
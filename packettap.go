@@ -0,0 +1,226 @@
+// +build !js
+
+package webrtc
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v2/pkg/interceptor"
+)
+
+// pcapng blocks and options, see
+// https://tools.ietf.org/html/draft-tuexen-opsawg-pcapng
+const (
+	pcapngSectionHeaderBlock        = 0x0A0D0D0A
+	pcapngInterfaceDescriptionBlock = 0x00000001
+	pcapngEnhancedPacketBlock       = 0x00000006
+	pcapngByteOrderMagic            = 0x1A2B3C4D
+
+	pcapngOptionEndOfOpt = 0
+	pcapngOptionEPBFlags = 2
+
+	pcapngFlagsInbound  = 1
+	pcapngFlagsOutbound = 2
+
+	// The packets are captured as exported PDUs, which carry the name of the
+	// Wireshark dissector of the packet in front of it
+	pcapngLinkTypeWiresharkUpperPDU = 252
+	exportedPDUTagEndOfOpt          = 0
+	exportedPDUTagProtoName         = 12
+)
+
+// Names of the Wireshark dissectors of the captured packets
+const (
+	packetTapDTLS = "dtls"
+	packetTapSCTP = "sctp"
+	packetTapRTP  = "rtp"
+	packetTapRTCP = "rtcp"
+)
+
+// PacketTap writes the packets of the transports of a PeerConnection to a
+// pcapng capture, which can be opened in Wireshark. It captures the DTLS
+// records, and the SCTP, RTP and RTCP packets after they are decrypted, in
+// both directions. The STUN packets of ICE are handled by the ICE agent and
+// are not captured.
+//
+// Every packet is written as it is sent or received, so a PacketTap should
+// only be used for debugging. Set it with SettingEngine.SetPacketTap, it can
+// be shared by many PeerConnections.
+type PacketTap struct {
+	mu     sync.Mutex
+	writer io.Writer
+	err    error
+}
+
+// NewPacketTap creates a PacketTap writing the pcapng capture to w
+func NewPacketTap(w io.Writer) *PacketTap {
+	t := &PacketTap{writer: w}
+
+	// Section Header Block, the length of the section is not specified
+	shb := make([]byte, 28)
+	binary.LittleEndian.PutUint32(shb[0:], pcapngSectionHeaderBlock)
+	binary.LittleEndian.PutUint32(shb[4:], uint32(len(shb)))
+	binary.LittleEndian.PutUint32(shb[8:], pcapngByteOrderMagic)
+	binary.LittleEndian.PutUint16(shb[12:], 1)
+	binary.LittleEndian.PutUint16(shb[14:], 0)
+	binary.LittleEndian.PutUint64(shb[16:], ^uint64(0))
+	binary.LittleEndian.PutUint32(shb[24:], uint32(len(shb)))
+
+	// Interface Description Block without a snapshot length
+	idb := make([]byte, 20)
+	binary.LittleEndian.PutUint32(idb[0:], pcapngInterfaceDescriptionBlock)
+	binary.LittleEndian.PutUint32(idb[4:], uint32(len(idb)))
+	binary.LittleEndian.PutUint16(idb[8:], pcapngLinkTypeWiresharkUpperPDU)
+	binary.LittleEndian.PutUint32(idb[16:], uint32(len(idb)))
+
+	t.write(append(shb, idb...))
+	return t
+}
+
+// Err returns the first error returned by the writer, nothing is written
+// after it
+func (t *PacketTap) Err() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.err
+}
+
+func (t *PacketTap) write(b []byte) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.err == nil {
+		_, t.err = t.writer.Write(b)
+	}
+}
+
+// capture writes packet as an Enhanced Packet Block
+func (t *PacketTap) capture(protocol string, flags uint32, packet []byte) {
+	// Exported PDU tags: the dissector name padded to 32 bits, then the end
+	tagsLen := 4 + pad32(len(protocol)) + 4
+	dataLen := tagsLen + len(packet)
+	blockLen := 28 + pad32(dataLen) + 12 + 4
+
+	timestamp := uint64(time.Now().UnixNano() / int64(time.Microsecond))
+
+	b := make([]byte, blockLen)
+	binary.LittleEndian.PutUint32(b[0:], pcapngEnhancedPacketBlock)
+	binary.LittleEndian.PutUint32(b[4:], uint32(blockLen))
+	binary.LittleEndian.PutUint32(b[12:], uint32(timestamp>>32))
+	binary.LittleEndian.PutUint32(b[16:], uint32(timestamp))
+	binary.LittleEndian.PutUint32(b[20:], uint32(dataLen))
+	binary.LittleEndian.PutUint32(b[24:], uint32(dataLen))
+
+	// The exported PDU tags are in network byte order
+	data := b[28:]
+	binary.BigEndian.PutUint16(data[0:], exportedPDUTagProtoName)
+	binary.BigEndian.PutUint16(data[2:], uint16(pad32(len(protocol))))
+	copy(data[4:], protocol)
+	binary.BigEndian.PutUint16(data[tagsLen-4:], exportedPDUTagEndOfOpt)
+	copy(data[tagsLen:], packet)
+
+	options := b[28+pad32(dataLen):]
+	binary.LittleEndian.PutUint16(options[0:], pcapngOptionEPBFlags)
+	binary.LittleEndian.PutUint16(options[2:], 4)
+	binary.LittleEndian.PutUint32(options[4:], flags)
+	binary.LittleEndian.PutUint16(options[8:], pcapngOptionEndOfOpt)
+
+	binary.LittleEndian.PutUint32(b[blockLen-4:], uint32(blockLen))
+	t.write(b)
+}
+
+func pad32(n int) int {
+	return (n + 3) &^ 3
+}
+
+// tapConn captures the packets read from and written to a net.Conn
+type tapConn struct {
+	net.Conn
+	tap      *PacketTap
+	protocol string
+}
+
+// tapPackets captures the packets of conn as protocol, conn is returned as
+// is when tap is nil
+func tapPackets(tap *PacketTap, conn net.Conn, protocol string) net.Conn {
+	if tap == nil {
+		return conn
+	}
+	return &tapConn{Conn: conn, tap: tap, protocol: protocol}
+}
+
+func (c *tapConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if n > 0 {
+		c.tap.capture(c.protocol, pcapngFlagsInbound, b[:n])
+	}
+	return n, err
+}
+
+func (c *tapConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	if err == nil {
+		c.tap.capture(c.protocol, pcapngFlagsOutbound, b)
+	}
+	return n, err
+}
+
+// packetTapInterceptor captures the RTP and RTCP packets once they are
+// decrypted. It is the closest interceptor to the network, so it captures
+// the packets as they are sent and received. A RTCP compound packet is
+// captured once for every RTCP reader it is delivered to.
+type packetTapInterceptor struct {
+	interceptor.NoOp
+	tap *PacketTap
+}
+
+func (p *packetTapInterceptor) BindRTCPReader(reader interceptor.RTCPReader) interceptor.RTCPReader {
+	return interceptor.RTCPReaderFunc(func(b []byte) (int, error) {
+		n, err := reader.Read(b)
+		if err == nil {
+			p.tap.capture(packetTapRTCP, pcapngFlagsInbound, b[:n])
+		}
+		return n, err
+	})
+}
+
+func (p *packetTapInterceptor) BindRTCPWriter(writer interceptor.RTCPWriter) interceptor.RTCPWriter {
+	return interceptor.RTCPWriterFunc(func(pkts []rtcp.Packet) (int, error) {
+		n, err := writer.Write(pkts)
+		if err == nil {
+			if raw, marshalErr := rtcp.Marshal(pkts); marshalErr == nil {
+				p.tap.capture(packetTapRTCP, pcapngFlagsOutbound, raw)
+			}
+		}
+		return n, err
+	})
+}
+
+func (p *packetTapInterceptor) BindLocalStream(info *interceptor.StreamInfo, writer interceptor.RTPWriter) interceptor.RTPWriter {
+	return interceptor.RTPWriterFunc(func(header *rtp.Header, payload []byte) (int, error) {
+		n, err := writer.Write(header, payload)
+		if err == nil {
+			packet := rtp.Packet{Header: *header, Payload: payload}
+			if raw, marshalErr := packet.Marshal(); marshalErr == nil {
+				p.tap.capture(packetTapRTP, pcapngFlagsOutbound, raw)
+			}
+		}
+		return n, err
+	})
+}
+
+func (p *packetTapInterceptor) BindRemoteStream(info *interceptor.StreamInfo, reader interceptor.RTPReader) interceptor.RTPReader {
+	return interceptor.RTPReaderFunc(func(b []byte) (int, error) {
+		n, err := reader.Read(b)
+		if err == nil {
+			p.tap.capture(packetTapRTP, pcapngFlagsInbound, b[:n])
+		}
+		return n, err
+	})
+}
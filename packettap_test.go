@@ -0,0 +1,146 @@
+// +build !js
+
+package webrtc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/pion/transport/test"
+	"github.com/stretchr/testify/assert"
+)
+
+// syncBuffer is a bytes.Buffer that can be written while it is read
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) Bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]byte{}, b.buf.Bytes()...)
+}
+
+type capturedPacket struct {
+	protocol string
+	flags    uint32
+}
+
+// parseCapture returns the protocol and direction of every packet of a
+// pcapng capture written by a PacketTap
+func parseCapture(t *testing.T, b []byte) []capturedPacket {
+	assert.Equal(t, uint32(pcapngSectionHeaderBlock), binary.LittleEndian.Uint32(b))
+	b = b[binary.LittleEndian.Uint32(b[4:]):]
+	assert.Equal(t, uint32(pcapngInterfaceDescriptionBlock), binary.LittleEndian.Uint32(b))
+	assert.Equal(t, uint16(pcapngLinkTypeWiresharkUpperPDU), binary.LittleEndian.Uint16(b[8:]))
+	b = b[binary.LittleEndian.Uint32(b[4:]):]
+
+	var packets []capturedPacket
+	for len(b) != 0 {
+		assert.Equal(t, uint32(pcapngEnhancedPacketBlock), binary.LittleEndian.Uint32(b))
+		blockLen := binary.LittleEndian.Uint32(b[4:])
+		assert.Equal(t, blockLen, binary.LittleEndian.Uint32(b[blockLen-4:]))
+
+		data := b[28:]
+		assert.Equal(t, uint16(exportedPDUTagProtoName), binary.BigEndian.Uint16(data))
+		protocol := bytes.TrimRight(data[4:4+binary.BigEndian.Uint16(data[2:])], "\x00")
+
+		options := b[28+pad32(int(binary.LittleEndian.Uint32(b[20:]))):]
+		assert.Equal(t, uint16(pcapngOptionEPBFlags), binary.LittleEndian.Uint16(options))
+
+		packets = append(packets, capturedPacket{string(protocol), binary.LittleEndian.Uint32(options[4:])})
+		b = b[blockLen:]
+	}
+	return packets
+}
+
+func TestPacketTap(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	m := MediaEngine{}
+	m.RegisterDefaultCodecs()
+
+	capture := &syncBuffer{}
+	tap := NewPacketTap(capture)
+	s := SettingEngine{}
+	s.SetPacketTap(tap)
+
+	pcOffer, err := NewAPI(WithMediaEngine(m), WithSettingEngine(s)).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	pcAnswer, err := NewAPI(WithMediaEngine(m)).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	track, err := pcOffer.NewTrack(DefaultPayloadTypeVP8, rand.Uint32(), "video", "pion")
+	assert.NoError(t, err)
+
+	_, err = pcOffer.AddTrack(track)
+	assert.NoError(t, err)
+
+	dc, err := pcOffer.CreateDataChannel(expectedLabel, nil)
+	assert.NoError(t, err)
+
+	messageReceived := make(chan struct{})
+	dc.OnMessage(func(DataChannelMessage) {
+		close(messageReceived)
+	})
+
+	pcAnswer.OnDataChannel(func(d *DataChannel) {
+		if d.Label() != expectedLabel {
+			return
+		}
+		d.OnOpen(func() {
+			assert.NoError(t, d.SendText("tapped"))
+		})
+	})
+
+	trackReceived := make(chan struct{})
+	pcAnswer.OnTrack(func(*Track, *RTPReceiver) {
+		close(trackReceived)
+	})
+
+	assert.NoError(t, signalPair(pcOffer, pcAnswer))
+
+	func() {
+		for sequenceNumber := uint16(0); ; sequenceNumber++ {
+			select {
+			case <-time.After(20 * time.Millisecond):
+				assert.NoError(t, track.WriteRTP(&rtp.Packet{
+					Header:  rtp.Header{Version: 2, SSRC: track.SSRC(), SequenceNumber: sequenceNumber},
+					Payload: []byte{0x00},
+				}))
+			case <-trackReceived:
+				return
+			}
+		}
+	}()
+	<-messageReceived
+
+	assert.NoError(t, pcOffer.Close())
+	assert.NoError(t, pcAnswer.Close())
+	assert.NoError(t, tap.Err())
+
+	packets := parseCapture(t, capture.Bytes())
+	for _, expected := range []capturedPacket{
+		{packetTapDTLS, pcapngFlagsInbound},
+		{packetTapDTLS, pcapngFlagsOutbound},
+		{packetTapSCTP, pcapngFlagsInbound},
+		{packetTapSCTP, pcapngFlagsOutbound},
+		{packetTapRTP, pcapngFlagsOutbound},
+	} {
+		assert.Contains(t, packets, expected)
+	}
+}
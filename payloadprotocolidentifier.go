@@ -0,0 +1,63 @@
+package webrtc
+
+import (
+	"github.com/pion/sctp"
+)
+
+// PayloadProtocolIdentifier is the SCTP Payload Protocol Identifier (PPID) a
+// DataChannel message is sent with, it tells the type of the message. The values
+// are registered by IANA, see RFC 8831 Section 8 and RFC 8832 Section 8.1.
+// It is the type of pion/sctp, so it can be used with the SCTP streams directly
+type PayloadProtocolIdentifier = sctp.PayloadProtocolIdentifier
+
+const (
+	// PayloadProtocolIdentifierDCEP identifies a message of the Data Channel
+	// Establishment Protocol, RFC 8832
+	PayloadProtocolIdentifierDCEP = sctp.PayloadTypeWebRTCDCEP
+
+	// PayloadProtocolIdentifierString identifies a UTF-8 string message
+	PayloadProtocolIdentifierString = sctp.PayloadTypeWebRTCString
+
+	// PayloadProtocolIdentifierBinary identifies a binary message
+	PayloadProtocolIdentifierBinary = sctp.PayloadTypeWebRTCBinary
+
+	// PayloadProtocolIdentifierStringEmpty identifies an empty string message,
+	// it carries a single byte because SCTP messages can't be empty
+	PayloadProtocolIdentifierStringEmpty = sctp.PayloadTypeWebRTCStringEmpty
+
+	// PayloadProtocolIdentifierBinaryEmpty identifies an empty binary message,
+	// it carries a single byte because SCTP messages can't be empty
+	PayloadProtocolIdentifierBinaryEmpty = sctp.PayloadTypeWebRTCBinaryEmpty
+)
+
+// NewDataChannelMessage returns the DataChannelMessage carried by an SCTP
+// payload sent with ppid, the byte of empty messages is dropped.
+// ErrPayloadProtocolIdentifierNotMessage is returned for DCEP and unknown PPIDs
+func NewDataChannelMessage(ppid PayloadProtocolIdentifier, payload []byte) (DataChannelMessage, error) {
+	switch ppid {
+	case PayloadProtocolIdentifierString:
+		return DataChannelMessage{IsString: true, Data: payload}, nil
+	case PayloadProtocolIdentifierBinary:
+		return DataChannelMessage{Data: payload}, nil
+	case PayloadProtocolIdentifierStringEmpty:
+		return DataChannelMessage{IsString: true, Data: []byte{}}, nil
+	case PayloadProtocolIdentifierBinaryEmpty:
+		return DataChannelMessage{Data: []byte{}}, nil
+	default:
+		return DataChannelMessage{}, ErrPayloadProtocolIdentifierNotMessage
+	}
+}
+
+// PayloadProtocolIdentifier returns the PPID the message is sent with
+func (m DataChannelMessage) PayloadProtocolIdentifier() PayloadProtocolIdentifier {
+	switch {
+	case m.IsString && len(m.Data) == 0:
+		return PayloadProtocolIdentifierStringEmpty
+	case m.IsString:
+		return PayloadProtocolIdentifierString
+	case len(m.Data) == 0:
+		return PayloadProtocolIdentifierBinaryEmpty
+	default:
+		return PayloadProtocolIdentifierBinary
+	}
+}
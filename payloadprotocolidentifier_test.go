@@ -0,0 +1,48 @@
+package webrtc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewDataChannelMessage(t *testing.T) {
+	testCases := []struct {
+		ppid            PayloadProtocolIdentifier
+		payload         []byte
+		expectedMessage DataChannelMessage
+		expectedErr     error
+	}{
+		{PayloadProtocolIdentifierString, []byte("pion"), DataChannelMessage{IsString: true, Data: []byte("pion")}, nil},
+		{PayloadProtocolIdentifierBinary, []byte{0x01}, DataChannelMessage{Data: []byte{0x01}}, nil},
+		{PayloadProtocolIdentifierStringEmpty, []byte{0x00}, DataChannelMessage{IsString: true, Data: []byte{}}, nil},
+		{PayloadProtocolIdentifierBinaryEmpty, []byte{0x00}, DataChannelMessage{Data: []byte{}}, nil},
+		{PayloadProtocolIdentifierDCEP, []byte{0x02}, DataChannelMessage{}, ErrPayloadProtocolIdentifierNotMessage},
+		{PayloadProtocolIdentifier(Unknown), []byte{0x00}, DataChannelMessage{}, ErrPayloadProtocolIdentifierNotMessage},
+	}
+
+	for i, testCase := range testCases {
+		message, err := NewDataChannelMessage(testCase.ppid, testCase.payload)
+		assert.Equal(t, testCase.expectedErr, err, "testCase: %d %v", i, testCase)
+		assert.Equal(t, testCase.expectedMessage, message, "testCase: %d %v", i, testCase)
+		if err == nil {
+			assert.Equal(t, testCase.ppid, message.PayloadProtocolIdentifier(), "testCase: %d %v", i, testCase)
+		}
+	}
+}
+
+func TestDataChannelMessage_PayloadProtocolIdentifier(t *testing.T) {
+	testCases := []struct {
+		message      DataChannelMessage
+		expectedPPID PayloadProtocolIdentifier
+	}{
+		{DataChannelMessage{IsString: true, Data: []byte("pion")}, PayloadProtocolIdentifierString},
+		{DataChannelMessage{IsString: true}, PayloadProtocolIdentifierStringEmpty},
+		{DataChannelMessage{Data: []byte{0x00}}, PayloadProtocolIdentifierBinary},
+		{DataChannelMessage{}, PayloadProtocolIdentifierBinaryEmpty},
+	}
+
+	for i, testCase := range testCases {
+		assert.Equal(t, testCase.expectedPPID, testCase.message.PayloadProtocolIdentifier(), "testCase: %d %v", i, testCase)
+	}
+}
@@ -16,7 +16,9 @@ import (
 
 	"github.com/pion/logging"
 	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
 	"github.com/pion/sdp/v2"
+	"github.com/pion/srtp"
 
 	"github.com/pion/webrtc/v2/internal/util"
 	"github.com/pion/webrtc/v2/pkg/rtcerr"
@@ -25,6 +27,8 @@ import (
 // PeerConnection represents a WebRTC connection that establishes a
 // peer-to-peer communications with another PeerConnection instance in a
 // browser, or to another endpoint implementing the required protocols.
+// The handlers of its state changes are run one at a time in the order of the
+// events, see SettingEngine.SetEventExecutor.
 type PeerConnection struct {
 	statsID string
 	mu      sync.RWMutex
@@ -34,6 +38,9 @@ type PeerConnection struct {
 	// remote and local descriptions
 	ops *operations
 
+	// routines runs the goroutines reading the transports, Close waits for them
+	routines routineGroup
+
 	configuration Configuration
 
 	currentLocalDescription  *SessionDescription
@@ -61,11 +68,23 @@ type PeerConnection struct {
 
 	rtpTransceivers []*RTPTransceiver
 
+	// What the pending remote offer changed, a rollback reverts it
+	remoteOfferChanges remoteOfferChanges
+
 	onSignalingStateChangeHandler     func(SignalingState)
 	onICEConnectionStateChangeHandler func(ICEConnectionState)
 	onConnectionStateChangeHandler    func(PeerConnectionState)
 	onTrackHandler                    func(*Track, *RTPReceiver)
 	onDataChannelHandler              func(*DataChannel)
+	onNegotiationNeededHandler        func()
+
+	// Deliver the state changes to the handlers above in order, a queue per
+	// kind. The handlers of OnTrack and OnDataChannel aren't ordered, they
+	// are expected to keep running to read what they are given.
+	signalingStateEvents     eventDispatcher
+	iceConnectionStateEvents eventDispatcher
+	connectionStateEvents    eventDispatcher
+	negotiationNeededEvents  eventDispatcher
 
 	iceGatherer   *ICEGatherer
 	iceTransport  *ICETransport
@@ -112,6 +131,11 @@ func (api *API) NewPeerConnection(configuration Configuration) (*PeerConnection,
 		iceConnectionState:           ICEConnectionStateNew,
 		connectionState:              PeerConnectionStateNew,
 
+		signalingStateEvents:     eventDispatcher{executor: api.settingEngine.events.Executor},
+		iceConnectionStateEvents: eventDispatcher{executor: api.settingEngine.events.Executor},
+		connectionStateEvents:    eventDispatcher{executor: api.settingEngine.events.Executor},
+		negotiationNeededEvents:  eventDispatcher{executor: api.settingEngine.events.Executor},
+
 		api: api,
 		log: api.settingEngine.LoggerFactory.NewLogger("pc"),
 	}
@@ -152,7 +176,14 @@ func (api *API) NewPeerConnection(configuration Configuration) (*PeerConnection,
 		hdlr := pc.onDataChannelHandler
 		pc.mu.RUnlock()
 		if hdlr != nil {
-			hdlr(d)
+			// Wait for the handler, it must be able to set up the
+			// DataChannel before its own events are fired
+			done := make(chan struct{})
+			runEvent(pc.api.settingEngine.events.Executor, func() {
+				defer close(done)
+				hdlr(d)
+			})
+			<-done
 		}
 	})
 
@@ -238,8 +269,110 @@ func (pc *PeerConnection) onSignalingStateChange(newState SignalingState) {
 
 	pc.log.Infof("signaling state changed to %s", newState)
 	if hdlr != nil {
-		go hdlr(newState)
+		pc.signalingStateEvents.dispatch(func() { hdlr(newState) })
+	}
+}
+
+// OnNegotiationNeeded sets an event handler which is invoked when a change
+// has occurred which requires session negotiation, e.g. a Track or the first
+// DataChannel was added after the initial offer/answer. The handler is
+// expected to create a new offer and signal it to the remote peer.
+func (pc *PeerConnection) OnNegotiationNeeded(f func()) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	pc.onNegotiationNeededHandler = f
+}
+
+// updateNegotiationNeeded schedules a check if negotiation is needed, and
+// fires OnNegotiationNeeded if it is. The check runs on the operations queue
+// so it is done after any pending description has been applied.
+// https://www.w3.org/TR/webrtc/#updating-the-negotiation-needed-flag
+func (pc *PeerConnection) updateNegotiationNeeded() {
+	pc.ops.Enqueue(func() {
+		if pc.isClosed.get() {
+			return
+		}
+
+		pc.mu.Lock()
+		hdlr := pc.onNegotiationNeededHandler
+		if hdlr == nil || pc.signalingState != SignalingStateStable {
+			pc.mu.Unlock()
+			return
+		}
+
+		if !pc.checkNegotiationNeeded() {
+			pc.negotiationNeeded = false
+			pc.mu.Unlock()
+			return
+		}
+
+		// Only fire once until the next negotiation completes
+		if pc.negotiationNeeded {
+			pc.mu.Unlock()
+			return
+		}
+		pc.negotiationNeeded = true
+		pc.mu.Unlock()
+
+		// Not run on the operations queue, the operations enqueued by the
+		// negotiation the handler starts would wait for it to return
+		pc.negotiationNeededEvents.dispatch(hdlr)
+	})
+}
+
+// checkNegotiationNeeded compares the transceivers and DataChannels with the
+// current local description. Caller must hold pc.mu
+// https://www.w3.org/TR/webrtc/#dfn-check-if-negotiation-is-needed
+func (pc *PeerConnection) checkNegotiationNeeded() bool {
+	localDesc := pc.currentLocalDescription
+
+	haveDataChannels := false
+	if pc.sctpTransport != nil {
+		pc.sctpTransport.lock.RLock()
+		haveDataChannels = len(pc.sctpTransport.dataChannels) != 0
+		pc.sctpTransport.lock.RUnlock()
+	}
+
+	if haveDataChannels && (localDesc == nil || localDesc.parsed == nil || !haveApplicationMediaSection(localDesc.parsed)) {
+		return true
+	}
+
+	for _, t := range pc.rtpTransceivers {
+		mid := t.Mid()
+		if t.Stopped() {
+			// A stopped transceiver only needs its m= section to be rejected
+			if mid != "" && localDesc != nil && localDesc.parsed != nil {
+				if media := findMediaByMid(localDesc.parsed, mid); media != nil && !isMediaSectionRejected(media) {
+					return true
+				}
+			}
+			continue
+		}
+		if mid == "" || localDesc == nil || localDesc.parsed == nil {
+			return true
+		}
+
+		media := findMediaByMid(localDesc.parsed, mid)
+		if media == nil {
+			return true
+		}
+
+		localDirection := getPeerDirection(media)
+		if localDesc.Type == SDPTypeOffer {
+			if localDirection != t.Direction() {
+				return true
+			}
+			continue
+		}
+
+		// In an answer the receive direction is limited by what the remote offered,
+		// only a change of what we want to send requires a new offer
+		if hasSendDirection(localDirection) != hasSendDirection(t.Direction()) {
+			return true
+		}
 	}
+
+	return false
 }
 
 // OnDataChannel sets an event handler which is invoked when a data
@@ -279,7 +412,7 @@ func (pc *PeerConnection) onTrack(t *Track, r *RTPReceiver) {
 
 	pc.log.Debugf("got new track: %+v", t)
 	if hdlr != nil && t != nil {
-		go hdlr(t, r)
+		runEvent(pc.api.settingEngine.events.Executor, func() { hdlr(t, r) })
 	}
 }
 
@@ -299,7 +432,7 @@ func (pc *PeerConnection) onICEConnectionStateChange(cs ICEConnectionState) {
 
 	pc.log.Infof("ICE connection state changed: %s", cs)
 	if hdlr != nil {
-		go hdlr(cs)
+		pc.iceConnectionStateEvents.dispatch(func() { hdlr(cs) })
 	}
 }
 
@@ -403,9 +536,9 @@ func (pc *PeerConnection) CreateOffer(options *OfferOptions) (SessionDescription
 	useIdentity := pc.idpLoginURL != nil
 	switch {
 	case options != nil:
-		return SessionDescription{}, fmt.Errorf("TODO handle options")
+		return SessionDescription{}, ErrOptionsNotSupported
 	case useIdentity:
-		return SessionDescription{}, fmt.Errorf("TODO handle identity provider")
+		return SessionDescription{}, ErrIdentityProviderNotSupported
 	case pc.isClosed.get():
 		return SessionDescription{}, &rtcerr.InvalidStateError{Err: ErrConnectionClosed}
 	}
@@ -417,6 +550,9 @@ func (pc *PeerConnection) CreateOffer(options *OfferOptions) (SessionDescription
 
 	// include unmatched local transceivers
 	if !isPlanB {
+		// rejected m= sections no transceiver uses anymore are recycled before adding new ones
+		recyclableMids := []string{}
+
 		// update the greater mid if the remote description provides a greater one
 		if pc.currentRemoteDescription != nil {
 			for _, media := range pc.currentRemoteDescription.parsed.MediaDescriptions {
@@ -424,6 +560,9 @@ func (pc *PeerConnection) CreateOffer(options *OfferOptions) (SessionDescription
 				if mid == "" {
 					continue
 				}
+				if media.MediaName.Media != mediaSectionApplication && pc.isRemoteMediaSectionRejected(media, true) && !pc.haveTransceiverWithMid(mid) {
+					recyclableMids = append(recyclableMids, mid)
+				}
 				numericMid, err := strconv.Atoi(mid)
 				if err != nil {
 					continue
@@ -437,9 +576,15 @@ func (pc *PeerConnection) CreateOffer(options *OfferOptions) (SessionDescription
 			if t.Mid() != "" {
 				continue
 			}
-			pc.greaterMid++
-			err := t.setMid(strconv.Itoa(pc.greaterMid))
-			if err != nil {
+
+			var mid string
+			if len(recyclableMids) != 0 {
+				mid, recyclableMids = recyclableMids[0], recyclableMids[1:]
+			} else {
+				pc.greaterMid++
+				mid = strconv.Itoa(pc.greaterMid)
+			}
+			if err := t.setMid(mid); err != nil {
 				return SessionDescription{}, err
 			}
 		}
@@ -525,7 +670,7 @@ func (pc *PeerConnection) updateConnectionState(iceConnectionState ICEConnection
 	pc.connectionState = connectionState
 	hdlr := pc.onConnectionStateChangeHandler
 	if hdlr != nil {
-		go hdlr(connectionState)
+		pc.connectionStateEvents.dispatch(func() { hdlr(connectionState) })
 	}
 }
 
@@ -564,11 +709,13 @@ func (pc *PeerConnection) CreateAnswer(options *AnswerOptions) (SessionDescripti
 	useIdentity := pc.idpLoginURL != nil
 	switch {
 	case options != nil:
-		return SessionDescription{}, fmt.Errorf("TODO handle options")
+		return SessionDescription{}, ErrOptionsNotSupported
 	case pc.RemoteDescription() == nil:
 		return SessionDescription{}, &rtcerr.InvalidStateError{Err: ErrNoRemoteDescription}
+	case pc.SignalingState() != SignalingStateHaveRemoteOffer && pc.SignalingState() != SignalingStateHaveLocalPranswer:
+		return SessionDescription{}, &rtcerr.InvalidStateError{Err: ErrIncorrectSignalingState}
 	case useIdentity:
-		return SessionDescription{}, fmt.Errorf("TODO handle identity provider")
+		return SessionDescription{}, ErrIdentityProviderNotSupported
 	case pc.isClosed.get():
 		return SessionDescription{}, &rtcerr.InvalidStateError{Err: ErrConnectionClosed}
 	}
@@ -610,95 +757,100 @@ func (pc *PeerConnection) setDescription(sd *SessionDescription, op stateChangeO
 		cur := pc.signalingState
 		setLocal := stateChangeOpSetLocal
 		setRemote := stateChangeOpSetRemote
-		newSDPDoesNotMatchOffer := &rtcerr.InvalidModificationError{Err: fmt.Errorf("new sdp does not match previous offer")}
-		newSDPDoesNotMatchAnswer := &rtcerr.InvalidModificationError{Err: fmt.Errorf("new sdp does not match previous answer")}
+		newSDPDoesNotMatchOffer := &rtcerr.InvalidModificationError{Err: ErrSDPDoesNotMatchOffer}
+		newSDPDoesNotMatchAnswer := &rtcerr.InvalidModificationError{Err: ErrSDPDoesNotMatchAnswer}
 
 		var nextState SignalingState
-		var err error
+		switch {
+		case sd.Type == SDPTypeOffer && op == setLocal:
+			nextState = SignalingStateHaveLocalOffer
+		case sd.Type == SDPTypeOffer && op == setRemote:
+			nextState = SignalingStateHaveRemoteOffer
+		case sd.Type == SDPTypePranswer && op == setLocal:
+			nextState = SignalingStateHaveLocalPranswer
+		case sd.Type == SDPTypePranswer && op == setRemote:
+			nextState = SignalingStateHaveRemotePranswer
+		case sd.Type == SDPTypeAnswer, sd.Type == SDPTypeRollback:
+			nextState = SignalingStateStable
+		default:
+			return cur, &rtcerr.OperationError{Err: fmt.Errorf("%w: %s(%s)", ErrSignalingStateChangeOpInvalid, op, sd.Type)}
+		}
+
+		// The signaling state is checked first, so calls made in the wrong
+		// order fail with an InvalidStateError regardless of the description
+		nextState, err := checkNextSignalingState(cur, nextState, op, sd.Type)
+		if err != nil {
+			return cur, err
+		}
+
 		switch op {
 		case setLocal:
 			switch sd.Type {
 			// stable->SetLocal(offer)->have-local-offer
 			case SDPTypeOffer:
 				if sd.SDP != pc.lastOffer {
-					return nextState, newSDPDoesNotMatchOffer
-				}
-				nextState, err = checkNextSignalingState(cur, SignalingStateHaveLocalOffer, setLocal, sd.Type)
-				if err == nil {
-					pc.pendingLocalDescription = sd
+					return cur, newSDPDoesNotMatchOffer
 				}
+				pc.pendingLocalDescription = sd
 			// have-remote-offer->SetLocal(answer)->stable
 			// have-local-pranswer->SetLocal(answer)->stable
 			case SDPTypeAnswer:
 				if sd.SDP != pc.lastAnswer {
-					return nextState, newSDPDoesNotMatchAnswer
-				}
-				nextState, err = checkNextSignalingState(cur, SignalingStateStable, setLocal, sd.Type)
-				if err == nil {
-					pc.currentLocalDescription = sd
-					pc.currentRemoteDescription = pc.pendingRemoteDescription
-					pc.pendingRemoteDescription = nil
-					pc.pendingLocalDescription = nil
+					return cur, newSDPDoesNotMatchAnswer
 				}
+				pc.currentLocalDescription = sd
+				pc.currentRemoteDescription = pc.pendingRemoteDescription
+				pc.pendingRemoteDescription = nil
+				pc.pendingLocalDescription = nil
+			// have-local-offer->SetLocal(rollback)->stable
 			case SDPTypeRollback:
-				nextState, err = checkNextSignalingState(cur, SignalingStateStable, setLocal, sd.Type)
-				if err == nil {
-					pc.pendingLocalDescription = nil
-				}
+				pc.pendingLocalDescription = nil
+				pc.pendingRemoteDescription = nil
 			// have-remote-offer->SetLocal(pranswer)->have-local-pranswer
 			case SDPTypePranswer:
 				if sd.SDP != pc.lastAnswer {
-					return nextState, newSDPDoesNotMatchAnswer
-				}
-				nextState, err = checkNextSignalingState(cur, SignalingStateHaveLocalPranswer, setLocal, sd.Type)
-				if err == nil {
-					pc.pendingLocalDescription = sd
+					return cur, newSDPDoesNotMatchAnswer
 				}
-			default:
-				return nextState, &rtcerr.OperationError{Err: fmt.Errorf("invalid state change op: %s(%s)", op, sd.Type)}
+				pc.pendingLocalDescription = sd
 			}
 		case setRemote:
 			switch sd.Type {
 			// stable->SetRemote(offer)->have-remote-offer
-			case SDPTypeOffer:
-				nextState, err = checkNextSignalingState(cur, SignalingStateHaveRemoteOffer, setRemote, sd.Type)
-				if err == nil {
-					pc.pendingRemoteDescription = sd
-				}
+			// have-local-offer->SetRemote(pranswer)->have-remote-pranswer
+			case SDPTypeOffer, SDPTypePranswer:
+				pc.pendingRemoteDescription = sd
 			// have-local-offer->SetRemote(answer)->stable
 			// have-remote-pranswer->SetRemote(answer)->stable
 			case SDPTypeAnswer:
-				nextState, err = checkNextSignalingState(cur, SignalingStateStable, setRemote, sd.Type)
-				if err == nil {
-					pc.currentRemoteDescription = sd
-					pc.currentLocalDescription = pc.pendingLocalDescription
-					pc.pendingRemoteDescription = nil
-					pc.pendingLocalDescription = nil
-				}
+				pc.currentRemoteDescription = sd
+				pc.currentLocalDescription = pc.pendingLocalDescription
+				pc.pendingRemoteDescription = nil
+				pc.pendingLocalDescription = nil
+			// have-remote-offer->SetRemote(rollback)->stable
 			case SDPTypeRollback:
-				nextState, err = checkNextSignalingState(cur, SignalingStateStable, setRemote, sd.Type)
-				if err == nil {
-					pc.pendingRemoteDescription = nil
-				}
-			// have-local-offer->SetRemote(pranswer)->have-remote-pranswer
-			case SDPTypePranswer:
-				nextState, err = checkNextSignalingState(cur, SignalingStateHaveRemotePranswer, setRemote, sd.Type)
-				if err == nil {
-					pc.pendingRemoteDescription = sd
-				}
-			default:
-				return nextState, &rtcerr.OperationError{Err: fmt.Errorf("invalid state change op: %s(%s)", op, sd.Type)}
+				pc.pendingLocalDescription = nil
+				pc.pendingRemoteDescription = nil
 			}
-		default:
-			return nextState, &rtcerr.OperationError{Err: fmt.Errorf("unhandled state change op: %q", op)}
 		}
 
+		pc.signalingState = nextState
 		return nextState, nil
 	}()
 
 	if err == nil {
-		pc.signalingState = nextState
 		pc.onSignalingStateChange(nextState)
+
+		// Changes made while negotiating may require another round
+		if nextState == SignalingStateStable {
+			if sd.Type == SDPTypeAnswer {
+				pc.removeRejectedTransceivers()
+			}
+
+			pc.mu.Lock()
+			pc.negotiationNeeded = false
+			pc.mu.Unlock()
+			pc.updateNegotiationNeeded()
+		}
 	}
 	return err
 }
@@ -709,6 +861,11 @@ func (pc *PeerConnection) SetLocalDescription(desc SessionDescription) error {
 		return &rtcerr.InvalidStateError{Err: ErrConnectionClosed}
 	}
 
+	// A rollback only discards the pending descriptions
+	if desc.Type == SDPTypeRollback {
+		return pc.setDescription(&desc, stateChangeOpSetLocal)
+	}
+
 	haveLocalDescription := pc.currentLocalDescription != nil
 
 	// JSEP 5.4
@@ -720,7 +877,7 @@ func (pc *PeerConnection) SetLocalDescription(desc SessionDescription) error {
 			desc.SDP = pc.lastOffer
 		default:
 			return &rtcerr.InvalidModificationError{
-				Err: fmt.Errorf("invalid SDP type supplied to SetLocalDescription(): %s", desc.Type),
+				Err: fmt.Errorf("%w supplied to SetLocalDescription(): %s", ErrSDPTypeInvalid, desc.Type),
 			}
 		}
 	}
@@ -776,6 +933,10 @@ func (pc *PeerConnection) SetRemoteDescription(desc SessionDescription) error {
 		return &rtcerr.InvalidStateError{Err: ErrConnectionClosed}
 	}
 
+	if desc.Type == SDPTypeRollback {
+		return pc.rollbackRemoteOffer(&desc)
+	}
+
 	haveRemoteDescription := pc.currentRemoteDescription != nil
 
 	desc.parsed = &sdp.SessionDescription{}
@@ -786,6 +947,12 @@ func (pc *PeerConnection) SetRemoteDescription(desc SessionDescription) error {
 		return err
 	}
 
+	if desc.Type == SDPTypeOffer {
+		pc.mu.Lock()
+		pc.remoteOfferChanges = remoteOfferChanges{}
+		pc.mu.Unlock()
+	}
+
 	weOffer := desc.Type == SDPTypeAnswer
 
 	var t *RTPTransceiver
@@ -796,13 +963,26 @@ func (pc *PeerConnection) SetRemoteDescription(desc SessionDescription) error {
 		for _, media := range pc.RemoteDescription().parsed.MediaDescriptions {
 			midValue := getMidValue(media)
 			if midValue == "" {
-				return fmt.Errorf("RemoteDescription contained media section without mid value")
+				return ErrMediaSectionWithoutMid
 			}
 
 			if media.MediaName.Media == mediaSectionApplication {
 				continue
 			}
 
+			// The remote stopped the transceiver of a rejected section, ours stops too
+			if isMediaSectionRejected(media) {
+				if t, localTransceivers = findByMid(midValue, localTransceivers); t != nil {
+					pc.mu.Lock()
+					pc.remoteOfferChanges.irreversible = true
+					pc.mu.Unlock()
+					if err := t.Stop(); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+
 			kind := NewRTPCodecType(media.MediaName.Media)
 			direction := getPeerDirection(media)
 			if kind == 0 || direction == RTPTransceiverDirection(Unknown) {
@@ -819,9 +999,17 @@ func (pc *PeerConnection) SetRemoteDescription(desc SessionDescription) error {
 					return err
 				}
 				t = pc.newRTPTransceiver(receiver, nil, RTPTransceiverDirectionRecvonly, kind)
+
+				pc.mu.Lock()
+				pc.remoteOfferChanges.createdTransceivers = append(pc.remoteOfferChanges.createdTransceivers, t)
+				pc.mu.Unlock()
 			}
 			if t.Mid() == "" {
 				_ = t.setMid(midValue)
+
+				pc.mu.Lock()
+				pc.remoteOfferChanges.assignedMids = append(pc.remoteOfferChanges.assignedMids, t)
+				pc.mu.Unlock()
 			}
 		}
 	}
@@ -864,6 +1052,10 @@ func (pc *PeerConnection) SetRemoteDescription(desc SessionDescription) error {
 		iceRole = ICERoleControlling
 	}
 
+	pc.mu.Lock()
+	pc.remoteOfferChanges.irreversible = true
+	pc.mu.Unlock()
+
 	// Start the networking in a new routine since it will block until
 	// the connection is actually established.
 	pc.ops.Enqueue(func() {
@@ -875,11 +1067,70 @@ func (pc *PeerConnection) SetRemoteDescription(desc SessionDescription) error {
 	return nil
 }
 
+// remoteOfferChanges are the changes made by a remote offer that are reverted
+// if it is rolled back
+type remoteOfferChanges struct {
+	createdTransceivers []*RTPTransceiver
+	assignedMids        []*RTPTransceiver
+
+	// The offer started the transports or stopped transceivers
+	irreversible bool
+}
+
+// rollbackRemoteOffer discards the pending remote offer, the transceivers it
+// created are removed and the ones it matched lose their mid. It fails if the
+// offer started the transports or stopped transceivers, they can't be restarted
+func (pc *PeerConnection) rollbackRemoteOffer(desc *SessionDescription) error {
+	pc.mu.RLock()
+	irreversible := pc.signalingState == SignalingStateHaveRemoteOffer && pc.remoteOfferChanges.irreversible
+	pc.mu.RUnlock()
+	if irreversible {
+		return &rtcerr.InvalidStateError{Err: ErrSignalingStateRollbackIrreversible}
+	}
+
+	if err := pc.setDescription(desc, stateChangeOpSetRemote); err != nil {
+		return err
+	}
+
+	pc.mu.Lock()
+	changes := pc.remoteOfferChanges
+	pc.remoteOfferChanges = remoteOfferChanges{}
+
+	transceivers := []*RTPTransceiver{}
+	for _, t := range pc.rtpTransceivers {
+		created := false
+		for _, c := range changes.createdTransceivers {
+			created = created || c == t
+		}
+		if !created {
+			transceivers = append(transceivers, t)
+		}
+	}
+	pc.rtpTransceivers = transceivers
+	pc.mu.Unlock()
+
+	for _, t := range changes.assignedMids {
+		t.mid.Store("")
+	}
+	for _, t := range changes.createdTransceivers {
+		if err := t.Stop(); err != nil {
+			pc.log.Warnf("Failed to stop RTPTransceiver: %s", err)
+		}
+	}
+	return nil
+}
+
 func (pc *PeerConnection) startReceiver(incoming trackDetails, receiver *RTPReceiver) {
+	parameters := RTPCodingParameters{SSRC: incoming.ssrc}
+	parameters.RTX.SSRC = incoming.rtxSSRC
+	// Lost packets are recovered from the FEC stream the remote announced, if FlexFEC is supported
+	if incoming.fecSSRC != 0 && len(pc.api.mediaEngine.GetCodecsByName(FlexFEC)) != 0 {
+		parameters.FEC.SSRC = incoming.fecSSRC
+	}
+
 	err := receiver.Receive(RTPReceiveParameters{
-		Encodings: RTPDecodingParameters{
-			RTPCodingParameters{SSRC: incoming.ssrc},
-		}})
+		Encodings: RTPDecodingParameters{parameters},
+	})
 	if err != nil {
 		pc.log.Warnf("RTPReceiver Receive failed %s", err)
 		return
@@ -892,32 +1143,129 @@ func (pc *PeerConnection) startReceiver(incoming trackDetails, receiver *RTPRece
 	receiver.Track().label = incoming.label
 	receiver.Track().mu.Unlock()
 
-	go func() {
+	pc.routines.Go(func() {
 		if err = receiver.Track().determinePayloadType(); err != nil {
 			pc.log.Warnf("Could not determine PayloadType for SSRC %d", receiver.Track().SSRC())
 			return
 		}
 
-		pc.mu.RLock()
-		defer pc.mu.RUnlock()
+		pc.onRemoteTrack(receiver.Track(), receiver)
+	})
+}
 
-		codec, err := pc.api.mediaEngine.getCodec(receiver.Track().PayloadType())
+// getRemoteCodec looks up the codec of a payload type used by the remote, among the codecs
+// matched in the remote description or else in the MediaEngine. Caller must hold pc.mu
+func (pc *PeerConnection) getRemoteCodec(payloadType uint8) (*RTPCodec, error) {
+	remoteDesc := pc.currentRemoteDescription
+	if pc.pendingRemoteDescription != nil {
+		remoteDesc = pc.pendingRemoteDescription
+	}
+
+	if remoteDesc != nil && remoteDesc.parsed != nil {
+		for _, media := range remoteDesc.parsed.MediaDescriptions {
+			for _, codec := range pc.api.mediaEngine.matchRemoteCodecs(media) {
+				if codec.PayloadType == payloadType {
+					return codec, nil
+				}
+			}
+		}
+	}
+	return pc.api.mediaEngine.getCodec(payloadType)
+}
+
+// onRemoteTrack looks up the codec of a remote Track from its PayloadType and fires OnTrack
+func (pc *PeerConnection) onRemoteTrack(track *Track, receiver *RTPReceiver) {
+	pc.mu.RLock()
+	defer pc.mu.RUnlock()
+
+	codec, err := pc.getRemoteCodec(track.PayloadType())
+	if err != nil {
+		pc.log.Warnf("no codec could be found for payloadType %d", track.PayloadType())
+		return
+	}
+
+	track.mu.Lock()
+	track.kind = codec.Type
+	track.codec = codec
+	track.mu.Unlock()
+
+	if pc.onTrackHandler != nil {
+		pc.onTrack(track, receiver)
+	} else {
+		pc.log.Warnf("OnTrack unset, unable to handle incoming media streams")
+	}
+}
+
+// handleIncomingSimulcastSSRC reads the first packets of an undeclared SSRC
+// looking for the MID and RID header extensions. If they match a media section
+// the remote is sending Simulcast on, the SSRC is received as a new layer of
+// that transceiver
+func (pc *PeerConnection) handleIncomingSimulcastSSRC(rtpStream *srtp.ReadStreamSRTP, ssrc uint32) error {
+	remoteDescription := pc.RemoteDescription()
+	if remoteDescription == nil {
+		return ErrNoRemoteDescription
+	}
+
+	var midExtensionID, ridExtensionID uint8
+	for _, media := range remoteDescription.parsed.MediaDescriptions {
+		if len(getRids(media)) == 0 {
+			continue
+		}
+
+		extMaps := getExtMaps(media)
+		midID, haveMid := extMaps[SDESMidURI]
+		ridID, haveRid := extMaps[SDESRTPStreamIDURI]
+		if haveMid && haveRid {
+			midExtensionID, ridExtensionID = uint8(midID), uint8(ridID)
+			break
+		}
+	}
+	if midExtensionID == 0 || ridExtensionID == 0 {
+		return ErrNoSimulcastMediaSection
+	}
+
+	// The packets read until the rid is known are replayed to the Track
+	var probed [][]byte
+	b := make([]byte, pc.api.settingEngine.getReceiveMTU())
+	for readCount := 0; readCount < simulcastProbeCount; readCount++ {
+		i, err := rtpStream.Read(b)
 		if err != nil {
-			pc.log.Warnf("no codec could be found for payloadType %d", receiver.Track().PayloadType())
-			return
+			return err
 		}
+		probed = append(probed, append([]byte{}, b[:i]...))
 
-		receiver.Track().mu.Lock()
-		receiver.Track().kind = codec.Type
-		receiver.Track().codec = codec
-		receiver.Track().mu.Unlock()
+		p := &rtp.Packet{}
+		if err = p.Unmarshal(b[:i]); err != nil {
+			return err
+		}
 
-		if pc.onTrackHandler != nil {
-			pc.onTrack(receiver.Track(), receiver)
-		} else {
-			pc.log.Warnf("OnTrack unset, unable to handle incoming media streams")
+		mid, rid := string(p.GetExtension(midExtensionID)), string(p.GetExtension(ridExtensionID))
+		if mid == "" || rid == "" {
+			continue
 		}
-	}()
+
+		for _, t := range pc.GetTransceivers() {
+			if t.Mid() != mid || t.Receiver() == nil {
+				continue
+			}
+
+			track, err := t.Receiver().receiveForRid(rid, ssrc, probed)
+			if err != nil {
+				return err
+			}
+
+			track.mu.Lock()
+			track.payloadType = p.PayloadType
+			track.mu.Unlock()
+
+			pc.onRemoteTrack(track, t.Receiver())
+			return nil
+		}
+
+		return fmt.Errorf("%w %s for rid %s", ErrTransceiverNotFound, mid, rid)
+	}
+
+	return fmt.Errorf("%w in the first %d packets", ErrSimulcastProbeFailed, simulcastProbeCount)
 }
 
 // startRTPReceivers opens knows inbound SRTP streams from the RemoteDescription
@@ -1071,7 +1419,7 @@ func (pc *PeerConnection) drainSRTP() {
 		return false
 	}
 
-	go func() {
+	pc.routines.Go(func() {
 		for {
 			srtpSession, err := pc.dtlsTransport.getSRTPSession()
 			if err != nil {
@@ -1079,19 +1427,25 @@ func (pc *PeerConnection) drainSRTP() {
 				return
 			}
 
-			_, ssrc, err := srtpSession.AcceptStream()
+			rtpStream, ssrc, err := srtpSession.AcceptStream()
 			if err != nil {
 				pc.log.Warnf("Failed to accept RTP %v", err)
 				return
 			}
 
-			if !handleUndeclaredSSRC(ssrc) {
-				pc.log.Warnf("Incoming unhandled RTP ssrc(%d), OnTrack will not be fired", ssrc)
+			if handleUndeclaredSSRC(ssrc) {
+				continue
 			}
+
+			pc.routines.Go(func() {
+				if err := pc.handleIncomingSimulcastSSRC(rtpStream, ssrc); err != nil {
+					pc.log.Warnf("Incoming unhandled RTP ssrc(%d), OnTrack will not be fired. %v", ssrc, err)
+				}
+			})
 		}
-	}()
+	})
 
-	go func() {
+	pc.routines.Go(func() {
 		for {
 			srtcpSession, err := pc.dtlsTransport.getSRTCPSession()
 			if err != nil {
@@ -1106,7 +1460,7 @@ func (pc *PeerConnection) drainSRTP() {
 			}
 			pc.log.Warnf("Incoming unhandled RTCP ssrc(%d), OnTrack will not be fired", ssrc)
 		}
-	}()
+	})
 }
 
 // RemoteDescription returns pendingRemoteDescription if it is not null and
@@ -1198,7 +1552,7 @@ func (pc *PeerConnection) AddTrack(track *Track) (*RTPSender, error) {
 
 	var transceiver *RTPTransceiver
 	for _, t := range pc.GetTransceivers() {
-		if !t.stopped && t.kind == track.Kind() && t.Sender() == nil {
+		if !t.Stopped() && t.kind == track.Kind() && t.Sender() == nil {
 			transceiver = t
 			break
 		}
@@ -1213,6 +1567,7 @@ func (pc *PeerConnection) AddTrack(track *Track) (*RTPSender, error) {
 		if err := transceiver.setSendingTrack(track); err != nil {
 			return nil, err
 		}
+		pc.updateNegotiationNeeded()
 		return sender, nil
 	}
 
@@ -1250,7 +1605,12 @@ func (pc *PeerConnection) RemoveTrack(sender *RTPSender) error {
 		return err
 	}
 
-	return transceiver.setSendingTrack(nil)
+	if err := transceiver.setSendingTrack(nil); err != nil {
+		return err
+	}
+
+	pc.updateNegotiationNeeded()
+	return nil
 }
 
 // AddTransceiverFromKind Create a new RTCRtpTransceiver(SendRecv or RecvOnly) and add it to the set of transceivers.
@@ -1261,7 +1621,7 @@ func (pc *PeerConnection) AddTransceiverFromKind(kind RTPCodecType, init ...RtpT
 
 	direction := RTPTransceiverDirectionSendrecv
 	if len(init) > 1 {
-		return nil, fmt.Errorf("AddTransceiverFromKind only accepts one RtpTransceiverInit")
+		return nil, ErrTransceiverInitTooMany
 	} else if len(init) == 1 {
 		direction = init[0].Direction
 	}
@@ -1270,7 +1630,7 @@ func (pc *PeerConnection) AddTransceiverFromKind(kind RTPCodecType, init ...RtpT
 	case RTPTransceiverDirectionSendrecv:
 		codecs := pc.api.mediaEngine.GetCodecsByKind(kind)
 		if len(codecs) == 0 {
-			return nil, fmt.Errorf("no %s codecs found", kind.String())
+			return nil, fmt.Errorf("%w: no %s codecs", ErrCodecNotFound, kind.String())
 		}
 
 		track, err := pc.NewTrack(codecs[0].PayloadType, mathRand.Uint32(), util.RandSeq(trackDefaultIDLength), util.RandSeq(trackDefaultLabelLength))
@@ -1286,14 +1646,16 @@ func (pc *PeerConnection) AddTransceiverFromKind(kind RTPCodecType, init ...RtpT
 			return nil, err
 		}
 
-		return pc.newRTPTransceiver(
+		t := pc.newRTPTransceiver(
 			receiver,
 			nil,
 			RTPTransceiverDirectionRecvonly,
 			kind,
-		), nil
+		)
+		pc.updateNegotiationNeeded()
+		return t, nil
 	default:
-		return nil, fmt.Errorf("AddTransceiverFromKind currently only supports recvonly and sendrecv")
+		return nil, fmt.Errorf("%w: AddTransceiverFromKind only supports recvonly and sendrecv", ErrTransceiverDirectionNotSupported)
 	}
 }
 
@@ -1305,7 +1667,7 @@ func (pc *PeerConnection) AddTransceiverFromTrack(track *Track, init ...RtpTrans
 
 	direction := RTPTransceiverDirectionSendrecv
 	if len(init) > 1 {
-		return nil, fmt.Errorf("AddTransceiverFromTrack only accepts one RtpTransceiverInit")
+		return nil, ErrTransceiverInitTooMany
 	} else if len(init) == 1 {
 		direction = init[0].Direction
 	}
@@ -1322,12 +1684,14 @@ func (pc *PeerConnection) AddTransceiverFromTrack(track *Track, init ...RtpTrans
 			return nil, err
 		}
 
-		return pc.newRTPTransceiver(
+		t := pc.newRTPTransceiver(
 			receiver,
 			sender,
 			RTPTransceiverDirectionSendrecv,
 			track.Kind(),
-		), nil
+		)
+		pc.updateNegotiationNeeded()
+		return t, nil
 
 	case RTPTransceiverDirectionSendonly:
 		sender, err := pc.api.NewRTPSender(track, pc.dtlsTransport)
@@ -1335,14 +1699,16 @@ func (pc *PeerConnection) AddTransceiverFromTrack(track *Track, init ...RtpTrans
 			return nil, err
 		}
 
-		return pc.newRTPTransceiver(
+		t := pc.newRTPTransceiver(
 			nil,
 			sender,
 			RTPTransceiverDirectionSendonly,
 			track.Kind(),
-		), nil
+		)
+		pc.updateNegotiationNeeded()
+		return t, nil
 	default:
-		return nil, fmt.Errorf("AddTransceiverFromTrack currently only supports sendonly and sendrecv")
+		return nil, fmt.Errorf("%w: AddTransceiverFromTrack only supports sendonly and sendrecv", ErrTransceiverDirectionNotSupported)
 	}
 }
 
@@ -1414,6 +1780,8 @@ func (pc *PeerConnection) CreateDataChannel(label string, options *DataChannelIn
 	pc.sctpTransport.dataChannelsRequested++
 	pc.sctpTransport.lock.Unlock()
 
+	pc.updateNegotiationNeeded()
+
 	// If SCTP already connected open all the channels
 	if pc.sctpTransport.State() == SCTPTransportStateConnected {
 		if err = d.open(pc.sctpTransport); err != nil {
@@ -1426,34 +1794,23 @@ func (pc *PeerConnection) CreateDataChannel(label string, options *DataChannelIn
 
 // SetIdentityProvider is used to configure an identity provider to generate identity assertions
 func (pc *PeerConnection) SetIdentityProvider(provider string) error {
-	return fmt.Errorf("TODO SetIdentityProvider")
+	return ErrIdentityProviderNotSupported
 }
 
 // WriteRTCP sends a user provided RTCP packet to the connected peer
 // If no peer is connected the packet is discarded
 func (pc *PeerConnection) WriteRTCP(pkts []rtcp.Packet) error {
-	raw, err := rtcp.Marshal(pkts)
-	if err != nil {
-		return err
-	}
-
-	srtcpSession, err := pc.dtlsTransport.getSRTCPSession()
-	if err != nil {
+	if _, err := pc.dtlsTransport.getSRTCPSession(); err != nil {
 		return nil
 	}
 
-	writeStream, err := srtcpSession.OpenWriteStream()
-	if err != nil {
-		return fmt.Errorf("WriteRTCP failed to open WriteStream: %v", err)
-	}
-
-	if _, err := writeStream.Write(raw); err != nil {
-		return err
-	}
-	return nil
+	_, err := pc.dtlsTransport.rtcpWriter.Write(pkts)
+	return err
 }
 
-// Close ends the PeerConnection
+// Close ends the PeerConnection. It returns once the transports are closed
+// and the goroutines of the PeerConnection have returned, apart from the
+// ones running event handlers.
 func (pc *PeerConnection) Close() error {
 	// https://www.w3.org/TR/webrtc/#dom-rtcpeerconnection-close (step #2)
 	if pc.isClosed.get() {
@@ -1464,7 +1821,9 @@ func (pc *PeerConnection) Close() error {
 	pc.isClosed.set(true)
 
 	// https://www.w3.org/TR/webrtc/#dom-rtcpeerconnection-close (step #4)
+	pc.mu.Lock()
 	pc.signalingState = SignalingStateClosed
+	pc.mu.Unlock()
 
 	// Try closing everything and collect the errors
 	// Shutdown strategy:
@@ -1501,6 +1860,9 @@ func (pc *PeerConnection) Close() error {
 		closeErrs = append(closeErrs, pc.iceTransport.Stop())
 	}
 
+	// The goroutines reading the transports return once they are closed
+	pc.routines.Close()
+
 	// https://www.w3.org/TR/webrtc/#dom-rtcpeerconnection-close (step #12)
 	pc.updateConnectionState(pc.ICEConnectionState(), pc.dtlsTransport.State())
 
@@ -1513,10 +1875,59 @@ func (pc *PeerConnection) NewTrack(payloadType uint8, ssrc uint32, id, label str
 	if err != nil {
 		return nil, err
 	} else if codec.Payloader == nil {
-		return nil, fmt.Errorf("codec payloader not set")
+		return nil, ErrCodecPayloaderNotSet
+	}
+
+	t, err := newTrack(payloadType, ssrc, id, label, codec, pc.api.settingEngine.getMTU())
+	if err != nil {
+		return nil, err
+	}
+
+	t.sendQueueSize = pc.api.settingEngine.sendQueue.TrackPackets
+	return t, nil
+}
+
+func (pc *PeerConnection) haveTransceiverWithMid(mid string) bool {
+	for _, t := range pc.GetTransceivers() {
+		if t.Mid() == mid {
+			return true
+		}
+	}
+	return false
+}
+
+// removeRejectedTransceivers stops and forgets the transceivers whose m= section
+// was rejected by the negotiation that completed, so their slot can be recycled
+func (pc *PeerConnection) removeRejectedTransceivers() {
+	pc.mu.Lock()
+	localDesc, remoteDesc := pc.currentLocalDescription, pc.currentRemoteDescription
+	if localDesc == nil || localDesc.parsed == nil || remoteDesc == nil || remoteDesc.parsed == nil {
+		pc.mu.Unlock()
+		return
+	}
+
+	isRejected := func(desc *sdp.SessionDescription, mid string) bool {
+		media := findMediaByMid(desc, mid)
+		return media != nil && isMediaSectionRejected(media)
 	}
 
-	return NewTrack(payloadType, ssrc, id, label, codec)
+	rejected := []*RTPTransceiver{}
+	transceivers := []*RTPTransceiver{}
+	for _, t := range pc.rtpTransceivers {
+		if mid := t.Mid(); mid != "" && (isRejected(localDesc.parsed, mid) || isRejected(remoteDesc.parsed, mid)) {
+			rejected = append(rejected, t)
+			continue
+		}
+		transceivers = append(transceivers, t)
+	}
+	pc.rtpTransceivers = transceivers
+	pc.mu.Unlock()
+
+	for _, t := range rejected {
+		if err := t.Stop(); err != nil {
+			pc.log.Warnf("Failed to stop RTPTransceiver: %s", err)
+		}
+	}
 }
 
 func (pc *PeerConnection) newRTPTransceiver(
@@ -1572,6 +1983,9 @@ func (pc *PeerConnection) PendingRemoteDescription() *SessionDescription {
 // SignalingState attribute returns the signaling state of the
 // PeerConnection instance.
 func (pc *PeerConnection) SignalingState() SignalingState {
+	pc.mu.RLock()
+	defer pc.mu.RUnlock()
+
 	return pc.signalingState
 }
 
@@ -1639,6 +2053,15 @@ func (pc *PeerConnection) GetStats() StatsReport {
 		pc.sctpTransport.collectStats(statsCollector)
 	}
 
+	for _, t := range pc.rtpTransceivers {
+		if sender := t.Sender(); sender != nil && sender.hasSent() {
+			sender.collectStats(statsCollector)
+		}
+		if receiver := t.Receiver(); receiver != nil && receiver.haveReceived() {
+			receiver.collectStats(statsCollector)
+		}
+	}
+
 	stats := PeerConnectionStats{
 		Timestamp:             statsTimestampNow(),
 		Type:                  StatsTypePeerConnection,
@@ -1684,11 +2107,17 @@ func (pc *PeerConnection) startTransports(iceRole ICERole, dtlsRole DTLSRole, re
 }
 
 func (pc *PeerConnection) startRTP(isRenegotiation bool, remoteDesc *SessionDescription) {
-	currentTransceivers := append([]*RTPTransceiver{}, pc.GetTransceivers()...)
+	currentTransceivers := []*RTPTransceiver{}
+	for _, t := range pc.GetTransceivers() {
+		if !t.Stopped() {
+			currentTransceivers = append(currentTransceivers, t)
+		}
+	}
 	trackDetails := trackDetailsFromSDP(pc.log, remoteDesc.parsed)
 	if isRenegotiation {
 		for _, t := range currentTransceivers {
-			if t.Receiver() == nil || t.Receiver().Track() == nil {
+			// Simulcast layers are not declared with a=ssrc, they stay until the transceiver is stopped
+			if t.Receiver() == nil || t.Receiver().Track() == nil || t.Receiver().Track().RID() != "" {
 				continue
 			}
 
@@ -1717,6 +2146,7 @@ func (pc *PeerConnection) startRTP(isRenegotiation bool, remoteDesc *SessionDesc
 		}
 	}
 
+	pc.setNegotiatedRTPParameters(remoteDesc, currentTransceivers)
 	pc.startRTPReceivers(trackDetails, currentTransceivers)
 	pc.startRTPSenders(currentTransceivers)
 
@@ -1728,6 +2158,64 @@ func (pc *PeerConnection) startRTP(isRenegotiation bool, remoteDesc *SessionDesc
 	}
 }
 
+// setNegotiatedRTPParameters stores the codecs, RTP header extensions, RTX and FlexFEC payload
+// types both sides agreed on in the RTPSender and RTPReceiver of each transceiver
+func (pc *PeerConnection) setNegotiatedRTPParameters(remoteDesc *SessionDescription, currentTransceivers []*RTPTransceiver) {
+	pc.mu.RLock()
+	localDesc := pc.currentLocalDescription
+	pc.mu.RUnlock()
+	if localDesc == nil || localDesc.parsed == nil || remoteDesc == nil || remoteDesc.parsed == nil {
+		return
+	}
+
+	for _, t := range currentTransceivers {
+		mid := t.Mid()
+		if mid == "" {
+			continue
+		}
+
+		localMedia, remoteMedia := findMediaByMid(localDesc.parsed, mid), findMediaByMid(remoteDesc.parsed, mid)
+		if localMedia == nil || remoteMedia == nil {
+			continue
+		}
+
+		headerExtensions := negotiatedHeaderExtensions(localMedia, remoteMedia)
+		negotiatedCodecs := pc.api.mediaEngine.matchRemoteCodecs(remoteMedia)
+		codecs := make([]RTPCodecParameters, 0, len(negotiatedCodecs))
+		for _, codec := range negotiatedCodecs {
+			codecs = append(codecs, RTPCodecParameters{RTPCodecCapability: codec.RTPCodecCapability, PayloadType: codec.PayloadType})
+		}
+
+		if sender := t.Sender(); sender != nil {
+			sender.setHeaderExtensions(headerExtensions)
+			sender.setCodecs(codecs)
+			if haveRTXSSRC(localMedia, sender.rtxSSRC) {
+				sender.setRTXPayloadTypes(negotiatedRTXPayloadTypes(localMedia, remoteMedia))
+			}
+			if haveFECSSRC(localMedia, sender.fecSSRC) {
+				sender.setFECPayloadType(negotiatedFlexFECPayloadType(localMedia, remoteMedia))
+			}
+			if track := sender.Track(); track != nil {
+				codec := track.Codec()
+				for _, negotiated := range negotiatedCodecs {
+					if sameCodec(negotiated, codec) && fmtpConsistent(codec.Name, negotiated.SDPFmtpLine, codec.SDPFmtpLine) {
+						sender.setPayloadType(negotiated.PayloadType)
+						break
+					}
+				}
+				if track.Kind() == RTPCodecTypeAudio {
+					sender.setDTMFPayloadType(negotiatedTelephoneEvent(localMedia, remoteMedia, codec.ClockRate))
+				}
+			}
+			sender.rebindLocalStream()
+		}
+		if receiver := t.Receiver(); receiver != nil {
+			receiver.setHeaderExtensions(headerExtensions)
+			receiver.setCodecs(codecs)
+		}
+	}
+}
+
 // GetRegisteredRTPCodecs gets a list of registered RTPCodec from the underlying constructed MediaEngine
 func (pc *PeerConnection) GetRegisteredRTPCodecs(kind RTPCodecType) []*RTPCodec {
 	return pc.api.mediaEngine.GetCodecsByKind(kind)
@@ -1777,11 +2265,12 @@ func (pc *PeerConnection) generateUnmatchedSDP(useIdentity bool) (*sdp.SessionDe
 		}
 		mediaSections = append(mediaSections, mediaSection{id: "data", data: true})
 	} else {
+		sessionExtMaps := pc.sessionExtMaps()
 		for _, t := range pc.GetTransceivers() {
 			if t.Sender() != nil {
 				t.Sender().setNegotiated()
 			}
-			mediaSections = append(mediaSections, mediaSection{id: t.Mid(), transceivers: []*RTPTransceiver{t}})
+			mediaSections = append(mediaSections, mediaSection{id: t.Mid(), transceivers: []*RTPTransceiver{t}, sessionExtMaps: sessionExtMaps})
 		}
 
 		mediaSections = append(mediaSections, mediaSection{id: strconv.Itoa(len(mediaSections)), data: true})
@@ -1811,12 +2300,13 @@ func (pc *PeerConnection) generateMatchedSDP(useIdentity bool, includeUnmatched
 	var t *RTPTransceiver
 	localTransceivers := append([]*RTPTransceiver{}, pc.GetTransceivers()...)
 	detectedPlanB := descriptionIsPlanB(pc.RemoteDescription())
+	sessionExtMaps := pc.sessionExtMaps()
 	mediaSections := []mediaSection{}
 
 	for _, media := range pc.RemoteDescription().parsed.MediaDescriptions {
 		midValue := getMidValue(media)
 		if midValue == "" {
-			return nil, fmt.Errorf("RemoteDescription contained media section without mid value")
+			return nil, ErrMediaSectionWithoutMid
 		}
 
 		if media.MediaName.Media == mediaSectionApplication {
@@ -1825,6 +2315,20 @@ func (pc *PeerConnection) generateMatchedSDP(useIdentity bool, includeUnmatched
 		}
 
 		kind := NewRTPCodecType(media.MediaName.Media)
+		if kind != 0 && !detectedPlanB && pc.isRemoteMediaSectionRejected(media, includeUnmatched) {
+			// A rejected section keeps its slot, it is recycled when we offer a new transceiver with its mid
+			t, localTransceivers = findByMid(midValue, localTransceivers)
+			if t == nil || t.Stopped() || !includeUnmatched {
+				t = &RTPTransceiver{kind: kind}
+				t.stopped.set(true)
+				t.setDirection(RTPTransceiverDirectionInactive)
+			} else if t.Sender() != nil {
+				t.Sender().setNegotiated()
+			}
+			mediaSections = append(mediaSections, mediaSection{id: midValue, transceivers: []*RTPTransceiver{t}, sessionExtMaps: sessionExtMaps})
+			continue
+		}
+
 		direction := getPeerDirection(media)
 		if kind == 0 || direction == RTPTransceiverDirection(Unknown) {
 			continue
@@ -1832,6 +2336,12 @@ func (pc *PeerConnection) generateMatchedSDP(useIdentity bool, includeUnmatched
 
 		sdpSemantics := pc.configuration.SDPSemantics
 
+		// Answers only contain the codecs both sides support
+		var codecs []*RTPCodec
+		if !includeUnmatched {
+			codecs = pc.api.mediaEngine.matchRemoteCodecs(media)
+		}
+
 		switch {
 		case sdpSemantics == SDPSemanticsPlanB || sdpSemantics == SDPSemanticsUnifiedPlanWithFallback && detectedPlanB:
 			if !detectedPlanB {
@@ -1856,20 +2366,26 @@ func (pc *PeerConnection) generateMatchedSDP(useIdentity bool, includeUnmatched
 				}
 				mediaTransceivers = append(mediaTransceivers, t)
 			}
-			mediaSections = append(mediaSections, mediaSection{id: midValue, transceivers: mediaTransceivers})
+			mediaSections = append(mediaSections, mediaSection{id: midValue, transceivers: mediaTransceivers, extMaps: getExtMaps(media), codecs: codecs})
 		case sdpSemantics == SDPSemanticsUnifiedPlan || sdpSemantics == SDPSemanticsUnifiedPlanWithFallback:
 			if detectedPlanB {
 				return nil, &rtcerr.TypeError{Err: ErrIncorrectSDPSemantics}
 			}
 			t, localTransceivers = findByMid(midValue, localTransceivers)
 			if t == nil {
-				return nil, fmt.Errorf("cannot find transceiver with mid %q", midValue)
+				return nil, fmt.Errorf("%w %q", ErrTransceiverNotFound, midValue)
 			}
 			if t.Sender() != nil {
 				t.Sender().setNegotiated()
 			}
 			mediaTransceivers := []*RTPTransceiver{t}
-			mediaSections = append(mediaSections, mediaSection{id: midValue, transceivers: mediaTransceivers})
+			mediaSections = append(mediaSections, mediaSection{
+				id:           midValue,
+				transceivers: mediaTransceivers,
+				rids:         getRids(media),
+				extMaps:      getExtMaps(media),
+				codecs:       codecs,
+			})
 		}
 	}
 
@@ -1879,7 +2395,7 @@ func (pc *PeerConnection) generateMatchedSDP(useIdentity bool, includeUnmatched
 			if t.Sender() != nil {
 				t.Sender().setNegotiated()
 			}
-			mediaSections = append(mediaSections, mediaSection{id: t.Mid(), transceivers: []*RTPTransceiver{t}})
+			mediaSections = append(mediaSections, mediaSection{id: t.Mid(), transceivers: []*RTPTransceiver{t}, sessionExtMaps: sessionExtMaps})
 		}
 	}
 
@@ -1889,3 +2405,41 @@ func (pc *PeerConnection) generateMatchedSDP(useIdentity bool, includeUnmatched
 
 	return populateSDP(d, detectedPlanB, pc.api.settingEngine.candidates.ICELite, pc.api.mediaEngine, connectionRole, candidates, iceParams, mediaSections, pc.ICEGatheringState())
 }
+
+// sessionExtMaps returns the IDs of the RTP header extensions announced in the current
+// descriptions. Our own IDs take precedence, an ID is only mapped to a single extension
+func (pc *PeerConnection) sessionExtMaps() map[string]int {
+	extMaps := map[string]int{}
+	inUse := map[int]bool{}
+	for _, desc := range []*SessionDescription{pc.currentLocalDescription, pc.currentRemoteDescription} {
+		if desc == nil || desc.parsed == nil {
+			continue
+		}
+
+		for _, media := range desc.parsed.MediaDescriptions {
+			for uri, id := range getExtMaps(media) {
+				if _, ok := extMaps[uri]; ok || inUse[id] {
+					continue
+				}
+				extMaps[uri] = id
+				inUse[id] = true
+			}
+		}
+	}
+	return extMaps
+}
+
+// isRemoteMediaSectionRejected reports if the remote m= section was rejected. When
+// offering the sections we rejected in our last answer stay rejected too
+func (pc *PeerConnection) isRemoteMediaSectionRejected(remoteMedia *sdp.MediaDescription, weOffer bool) bool {
+	if isMediaSectionRejected(remoteMedia) {
+		return true
+	}
+
+	localDesc := pc.currentLocalDescription
+	if !weOffer || localDesc == nil || localDesc.parsed == nil {
+		return false
+	}
+	localMedia := findMediaByMid(localDesc.parsed, getMidValue(remoteMedia))
+	return localMedia != nil && isMediaSectionRejected(localMedia)
+}
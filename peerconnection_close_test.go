@@ -3,9 +3,12 @@
 package webrtc
 
 import (
+	"math/rand"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/pion/rtp"
 	"github.com/pion/transport/test"
 	"github.com/stretchr/testify/assert"
 )
@@ -164,3 +167,70 @@ func TestPeerConnection_Close_DuringICE(t *testing.T) {
 		t.Error("pcOffer.Close() Timeout")
 	}
 }
+
+// Assert that Close releases everything before it returns, so PeerConnections
+// can be churned without their resources piling up
+func TestPeerConnection_Close_Churn(t *testing.T) {
+	// Limit runtime in case of deadlocks
+	lim := test.TimeOut(time.Second * 60)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	routines := atomic.LoadInt64(&resourceCounters.routines)
+	iceAgents := atomic.LoadInt64(&resourceCounters.iceAgents)
+
+	m := MediaEngine{}
+	m.RegisterDefaultCodecs()
+	api := NewAPI(WithMediaEngine(m))
+
+	for i := 0; i < 100; i++ {
+		pcOffer, err := api.NewPeerConnection(Configuration{})
+		assert.NoError(t, err)
+
+		pcAnswer, err := api.NewPeerConnection(Configuration{})
+		assert.NoError(t, err)
+
+		track, err := pcOffer.NewTrack(DefaultPayloadTypeVP8, rand.Uint32(), "video", "pion")
+		assert.NoError(t, err)
+
+		_, err = pcOffer.AddTrack(track)
+		assert.NoError(t, err)
+
+		trackReceived := make(chan struct{})
+		pcAnswer.OnTrack(func(*Track, *RTPReceiver) {
+			close(trackReceived)
+		})
+
+		dataChannelOpened := make(chan struct{})
+		pcAnswer.OnDataChannel(func(d *DataChannel) {
+			d.OnOpen(func() {
+				close(dataChannelOpened)
+			})
+		})
+
+		assert.NoError(t, signalPair(pcOffer, pcAnswer))
+
+		func() {
+			for sequenceNumber := uint16(0); ; sequenceNumber++ {
+				select {
+				case <-time.After(10 * time.Millisecond):
+					assert.NoError(t, track.WriteRTP(&rtp.Packet{
+						Header:  rtp.Header{Version: 2, SSRC: track.SSRC(), SequenceNumber: sequenceNumber},
+						Payload: []byte{0x00},
+					}))
+				case <-trackReceived:
+					return
+				}
+			}
+		}()
+		<-dataChannelOpened
+
+		assert.NoError(t, pcOffer.Close())
+		assert.NoError(t, pcAnswer.Close())
+
+		assert.Equal(t, routines, atomic.LoadInt64(&resourceCounters.routines))
+		assert.Equal(t, iceAgents, atomic.LoadInt64(&resourceCounters.iceAgents))
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/x509"
+	"errors"
 	"fmt"
 	"math/big"
 	"reflect"
@@ -381,6 +382,153 @@ func TestPeerConnection_AnswerWithoutOffer(t *testing.T) {
 	assert.NoError(t, pc.Close())
 }
 
+func TestPeerConnection_SignalingStateMachine(t *testing.T) {
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pcOffer, pcAnswer, err := newPair()
+	assert.NoError(t, err)
+
+	states := make(chan SignalingState, 8)
+	pcOffer.OnSignalingStateChange(func(s SignalingState) {
+		states <- s
+	})
+
+	_, err = pcOffer.CreateDataChannel("data", nil)
+	assert.NoError(t, err)
+
+	offer, err := pcOffer.CreateOffer(nil)
+	assert.NoError(t, err)
+
+	// An answer or a rollback without an offer fails without changing the state
+	assert.Equal(t, &rtcerr.InvalidStateError{Err: ErrSignalingStateProposedTransitionInvalid},
+		pcOffer.SetRemoteDescription(SessionDescription{Type: SDPTypeAnswer, SDP: offer.SDP}))
+	assert.Equal(t, &rtcerr.InvalidStateError{Err: ErrSignalingStateCannotRollback},
+		pcOffer.SetLocalDescription(SessionDescription{Type: SDPTypeRollback}))
+	assert.Equal(t, SignalingStateStable, pcOffer.SignalingState())
+
+	// A rollback discards the offer
+	assert.NoError(t, pcOffer.SetLocalDescription(offer))
+	assert.Equal(t, SignalingStateHaveLocalOffer, pcOffer.SignalingState())
+	assert.Equal(t, &rtcerr.InvalidStateError{Err: ErrSignalingStateCannotRollback},
+		pcOffer.SetRemoteDescription(SessionDescription{Type: SDPTypeRollback}))
+	assert.NoError(t, pcOffer.SetLocalDescription(SessionDescription{Type: SDPTypeRollback}))
+	assert.Equal(t, SignalingStateStable, pcOffer.SignalingState())
+	assert.Nil(t, pcOffer.PendingLocalDescription())
+	// The handler is run in its own goroutine, so the changes are unordered
+	assert.ElementsMatch(t, []SignalingState{SignalingStateHaveLocalOffer, SignalingStateStable}, []SignalingState{<-states, <-states})
+
+	assert.NoError(t, pcOffer.SetLocalDescription(offer))
+	assert.NoError(t, pcAnswer.SetRemoteDescription(offer))
+
+	// An offer can't be answered by the side that made it
+	_, err = pcOffer.CreateAnswer(nil)
+	assert.Equal(t, &rtcerr.InvalidStateError{Err: ErrNoRemoteDescription}, err)
+
+	answer, err := pcAnswer.CreateAnswer(nil)
+	assert.NoError(t, err)
+	assert.NoError(t, pcAnswer.SetLocalDescription(answer))
+	assert.NoError(t, pcOffer.SetRemoteDescription(answer))
+	assert.ElementsMatch(t, []SignalingState{SignalingStateHaveLocalOffer, SignalingStateStable}, []SignalingState{<-states, <-states})
+
+	// The answer can't be applied twice
+	assert.Equal(t, &rtcerr.InvalidStateError{Err: ErrSignalingStateProposedTransitionInvalid},
+		pcOffer.SetRemoteDescription(answer))
+	_, err = pcAnswer.CreateAnswer(nil)
+	assert.Equal(t, &rtcerr.InvalidStateError{Err: ErrIncorrectSignalingState}, err)
+
+	assert.NoError(t, pcOffer.Close())
+	assert.NoError(t, pcAnswer.Close())
+}
+
+// Assert that rolling back a remote offer removes the transceivers it created,
+// and that it is rejected once the offer started the transports
+func TestPeerConnection_RollbackRemoteOffer(t *testing.T) {
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pcOffer, pcAnswer, err := newPair()
+	assert.NoError(t, err)
+
+	_, err = pcOffer.AddTransceiverFromKind(RTPCodecTypeAudio)
+	assert.NoError(t, err)
+
+	negotiate := func() SessionDescription {
+		offer, offerErr := pcOffer.CreateOffer(nil)
+		assert.NoError(t, offerErr)
+		assert.NoError(t, pcOffer.SetLocalDescription(offer))
+		assert.NoError(t, pcAnswer.SetRemoteDescription(offer))
+		return offer
+	}
+	answer := func() {
+		desc, answerErr := pcAnswer.CreateAnswer(nil)
+		assert.NoError(t, answerErr)
+		assert.NoError(t, pcAnswer.SetLocalDescription(desc))
+		assert.NoError(t, pcOffer.SetRemoteDescription(desc))
+	}
+
+	negotiate()
+	assert.Equal(t, &rtcerr.InvalidStateError{Err: ErrSignalingStateRollbackIrreversible},
+		pcAnswer.SetRemoteDescription(SessionDescription{Type: SDPTypeRollback}))
+	assert.Equal(t, SignalingStateHaveRemoteOffer, pcAnswer.SignalingState())
+	answer()
+	assert.Len(t, pcAnswer.GetTransceivers(), 1)
+
+	_, err = pcOffer.AddTransceiverFromKind(RTPCodecTypeVideo)
+	assert.NoError(t, err)
+
+	offer := negotiate()
+	assert.Len(t, pcAnswer.GetTransceivers(), 2)
+	assert.NoError(t, pcAnswer.SetRemoteDescription(SessionDescription{Type: SDPTypeRollback}))
+	assert.Equal(t, SignalingStateStable, pcAnswer.SignalingState())
+	assert.Nil(t, pcAnswer.PendingRemoteDescription())
+	assert.Len(t, pcAnswer.GetTransceivers(), 1)
+
+	// The offer can be applied again once rolled back
+	assert.NoError(t, pcAnswer.SetRemoteDescription(offer))
+	assert.Len(t, pcAnswer.GetTransceivers(), 2)
+	answer()
+
+	assert.NoError(t, pcOffer.Close())
+	assert.NoError(t, pcAnswer.Close())
+}
+
+// Assert that the sentinels stay reachable through the rtcerr wrappers and the
+// details added to them
+func TestPeerConnection_ErrorsIs(t *testing.T) {
+	pc, err := NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	_, err = pc.CreateOffer(&OfferOptions{})
+	assert.True(t, errors.Is(err, ErrOptionsNotSupported))
+	assert.True(t, errors.Is(pc.SetIdentityProvider("idp"), ErrIdentityProviderNotSupported))
+
+	_, err = pc.AddTransceiverFromKind(RTPCodecTypeAudio, RtpTransceiverInit{}, RtpTransceiverInit{})
+	assert.True(t, errors.Is(err, ErrTransceiverInitTooMany))
+	_, err = pc.AddTransceiverFromKind(RTPCodecTypeAudio, RtpTransceiverInit{Direction: RTPTransceiverDirectionSendonly})
+	assert.True(t, errors.Is(err, ErrTransceiverDirectionNotSupported))
+
+	_, err = pc.CreateDataChannel("data", nil)
+	assert.NoError(t, err)
+	offer, err := pc.CreateOffer(nil)
+	assert.NoError(t, err)
+	_, err = pc.CreateOffer(nil)
+	assert.NoError(t, err)
+
+	// Only the last offer created can be set
+	var modificationErr *rtcerr.InvalidModificationError
+	err = pc.SetLocalDescription(offer)
+	assert.True(t, errors.As(err, &modificationErr))
+	assert.True(t, errors.Is(err, ErrSDPDoesNotMatchOffer))
+
+	err = pc.SetLocalDescription(SessionDescription{Type: SDPType(Unknown)})
+	assert.True(t, errors.As(err, &modificationErr))
+	assert.True(t, errors.Is(err, ErrSDPTypeInvalid))
+
+	assert.NoError(t, pc.Close())
+}
+
+
 func TestPeerConnection_satisfyTypeAndDirection(t *testing.T) {
 	createTransceiver := func(kind RTPCodecType, direction RTPTransceiverDirection) *RTPTransceiver {
 		r := &RTPTransceiver{kind: kind}
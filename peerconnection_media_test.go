@@ -5,6 +5,8 @@ package webrtc
 import (
 	"bufio"
 	"bytes"
+	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
 	"math/rand"
@@ -14,10 +16,16 @@ import (
 	"testing"
 	"time"
 
+	"github.com/pion/logging"
 	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
 	"github.com/pion/sdp/v2"
 	"github.com/pion/transport/test"
+	"github.com/pion/transport/vnet"
+	"github.com/pion/webrtc/v2/internal/util"
+	"github.com/pion/webrtc/v2/pkg/interceptor"
 	"github.com/pion/webrtc/v2/pkg/media"
+	"github.com/pion/webrtc/v2/pkg/rtcerr"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -803,7 +811,7 @@ func TestAddTransceiverAddTrack_NewRTPSender_Error(t *testing.T) {
 	assert.NoError(t, err)
 
 	_, err = pc.AddTrack(track)
-	assert.Error(t, err, "DTLSTransport must not be nil")
+	assert.True(t, errors.Is(err, ErrNilDTLSTransport))
 
 	assert.Equal(t, 1, len(pc.GetTransceivers()))
 
@@ -827,11 +835,11 @@ func TestRtpSenderReceiver_ReadClose_Error(t *testing.T) {
 	sender, receiver := tr.Sender(), tr.Receiver()
 	assert.NoError(t, sender.Stop())
 	_, err = sender.Read(make([]byte, 0, 1400))
-	assert.Error(t, err, "RtpSender has been stopped")
+	assert.True(t, errors.Is(err, ErrRTPSenderStopped))
 
 	assert.NoError(t, receiver.Stop())
 	_, err = receiver.Read(make([]byte, 0, 1400))
-	assert.Error(t, err, "RTPReceiver has been stopped")
+	assert.True(t, errors.Is(err, ErrRTPReceiverStopped))
 
 	assert.NoError(t, pc.Close())
 }
@@ -963,7 +971,7 @@ a=setup:actpass
 a=mid:1
 a=sendrecv
 a=rtpmap:96 H264/90000
-a=fmtp:96 level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=640c1f
+a=fmtp:96 level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=42001f
 `
 	lim := test.TimeOut(time.Second * 30)
 	defer lim.Stop()
@@ -1228,3 +1236,1327 @@ func TestPeerConnection_Start_Right_Receiver(t *testing.T) {
 	assert.NoError(t, pcOffer.Close())
 	assert.NoError(t, pcAnswer.Close())
 }
+
+func TestPeerConnection_Media_SenderReports(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	s := SettingEngine{}
+	s.SetSenderReportInterval(50 * time.Millisecond)
+
+	api := NewAPI(WithSettingEngine(s))
+	api.mediaEngine.RegisterDefaultCodecs()
+
+	pcOffer, pcAnswer, err := api.newPair(Configuration{})
+	assert.NoError(t, err)
+
+	track, err := pcOffer.NewTrack(DefaultPayloadTypeVP8, rand.Uint32(), "video", "pion")
+	assert.NoError(t, err)
+
+	rtpSender, err := pcOffer.AddTrack(track)
+	assert.NoError(t, err)
+
+	senderReportReceived := make(chan *rtcp.SenderReport)
+	pcAnswer.OnTrack(func(remoteTrack *Track, receiver *RTPReceiver) {
+		for {
+			pkts, routineErr := receiver.ReadRTCP()
+			if routineErr != nil {
+				return
+			}
+
+			for _, p := range pkts {
+				if sr, ok := p.(*rtcp.SenderReport); ok && sr.SSRC == remoteTrack.SSRC() {
+					senderReportReceived <- sr
+					return
+				}
+			}
+		}
+	})
+
+	assert.NoError(t, signalPair(pcOffer, pcAnswer))
+
+	var sr *rtcp.SenderReport
+	func() {
+		for {
+			select {
+			case <-time.After(20 * time.Millisecond):
+				assert.NoError(t, track.WriteSample(media.Sample{Data: []byte{0x00}, Samples: 1}))
+			case sr = <-senderReportReceived:
+				return
+			}
+		}
+	}()
+
+	assert.Equal(t, track.SSRC(), sr.SSRC)
+	assert.NotZero(t, sr.PacketCount)
+	assert.NotZero(t, sr.OctetCount)
+	assert.NotZero(t, sr.NTPTime)
+
+	stats, ok := pcOffer.GetStats().GetOutboundRTPStreamStats(rtpSender)
+	assert.True(t, ok)
+	assert.Equal(t, track.SSRC(), stats.SSRC)
+	assert.Equal(t, "video", stats.Kind)
+	assert.GreaterOrEqual(t, stats.PacketsSent, sr.PacketCount)
+	assert.GreaterOrEqual(t, stats.BytesSent, uint64(sr.OctetCount))
+
+	assert.NoError(t, pcOffer.Close())
+	assert.NoError(t, pcAnswer.Close())
+}
+
+func TestRTPSender_SenderReport(t *testing.T) {
+	api := NewAPI()
+	api.mediaEngine.RegisterDefaultCodecs()
+
+	track, err := NewTrack(DefaultPayloadTypeOpus, 1234, "audio", "pion", NewRTPOpusCodec(DefaultPayloadTypeOpus, 48000))
+	assert.NoError(t, err)
+
+	sender := &RTPSender{track: track, ssrc: 1234}
+	now := time.Now()
+	sender.stats.packetCount = 10
+	sender.stats.octetCount = 1000
+	sender.stats.lastRTPTimestamp = 48000
+	sender.stats.lastRTPTime = now
+
+	sr := sender.SenderReport(now.Add(500 * time.Millisecond))
+	assert.Equal(t, uint32(1234), sr.SSRC)
+	assert.Equal(t, uint32(48000+24000), sr.RTPTime)
+	assert.Equal(t, uint32(10), sr.PacketCount)
+	assert.Equal(t, uint32(1000), sr.OctetCount)
+}
+
+// Assert that the telephone-events continue the timestamps of the audio at its
+// clock rate, while their duration uses the telephone-event clock rate
+func TestRTPSender_DTMFClockRate(t *testing.T) {
+	track, err := NewTrack(DefaultPayloadTypeOpus, 1234, "audio", "pion", NewRTPOpusCodec(DefaultPayloadTypeOpus, 48000))
+	assert.NoError(t, err)
+
+	var headers []*rtp.Header
+	var payloads [][]byte
+	sender := &RTPSender{
+		track:           track,
+		ssrc:            1234,
+		stopCalled:      make(chan interface{}),
+		dtmfPayloadType: 101,
+		dtmfClockRate:   8000,
+		rtpWriter: interceptor.RTPWriterFunc(func(header *rtp.Header, payload []byte) (int, error) {
+			headers = append(headers, header)
+			payloads = append(payloads, payload)
+			return len(payload), nil
+		}),
+	}
+	sender.stats.lastRTPTimestamp = 48000
+	sender.stats.lastRTPTime = time.Now().Add(-time.Second)
+
+	assert.NoError(t, sender.sendDTMFEvent(1, 100*time.Millisecond))
+	assert.NotEmpty(t, headers)
+	assert.InDelta(t, 48000+48000, headers[0].Timestamp, 4800)
+
+	last := payloads[len(payloads)-1]
+	assert.Equal(t, uint16(800), binary.BigEndian.Uint16(last[2:]))
+}
+
+// Assert that Simulcast layers announced with a=rid are received as separate
+// Tracks on one RTPReceiver, demultiplexed by the MID and RID header extensions
+func TestPeerConnection_Simulcast_Receive(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	api := NewAPI()
+	api.mediaEngine.RegisterDefaultCodecs()
+
+	pcOffer, pcAnswer, err := api.newPair(Configuration{})
+	assert.NoError(t, err)
+
+	track, err := pcOffer.NewTrack(DefaultPayloadTypeVP8, rand.Uint32(), "video", "pion")
+	assert.NoError(t, err)
+
+	sender, err := pcOffer.AddTrack(track)
+	assert.NoError(t, err)
+
+	rids := []string{"a", "b"}
+	ssrcs := map[string]uint32{"a": rand.Uint32(), "b": rand.Uint32()}
+
+	var onTrackMu sync.Mutex
+	onTrackRids := map[string]uint32{}
+	firstSequenceNumbers := map[string]uint16{}
+	onTrackFired := make(chan struct{})
+	pcAnswer.OnTrack(func(remoteTrack *Track, receiver *RTPReceiver) {
+		pkt, routineErr := remoteTrack.ReadRTP()
+		if routineErr != nil {
+			return
+		}
+
+		onTrackMu.Lock()
+		defer onTrackMu.Unlock()
+
+		onTrackRids[remoteTrack.RID()] = pkt.SSRC
+		firstSequenceNumbers[remoteTrack.RID()] = pkt.SequenceNumber
+		if len(onTrackRids) == len(rids) {
+			close(onTrackFired)
+		}
+	})
+
+	// Announce the Simulcast layers instead of the SSRC of the Track
+	assert.NoError(t, signalPairWithModification(pcOffer, pcAnswer, func(sessionDescription string) string {
+		filtered := []string{}
+		for _, line := range strings.Split(sessionDescription, "\r\n") {
+			if strings.HasPrefix(line, "a=ssrc") {
+				continue
+			}
+
+			filtered = append(filtered, line)
+			if line == "a=mid:0" {
+				filtered = append(filtered,
+					"a=extmap:1 "+SDESMidURI,
+					"a=extmap:2 "+SDESRTPStreamIDURI,
+					"a=rid:a send",
+					"a=rid:b send",
+					"a=simulcast:send a;b",
+				)
+			}
+		}
+		return strings.Join(filtered, "\r\n")
+	}))
+
+	answer := pcAnswer.LocalDescription()
+	assert.Contains(t, answer.SDP, "a=rid:a recv")
+	assert.Contains(t, answer.SDP, "a=simulcast:recv a;b")
+	assert.Contains(t, answer.SDP, "a=extmap:2 "+SDESRTPStreamIDURI)
+
+	func() {
+		for sequenceNumber := uint16(0); ; sequenceNumber++ {
+			select {
+			case <-time.After(20 * time.Millisecond):
+				for _, rid := range rids {
+					header := &rtp.Header{
+						Version:        2,
+						SSRC:           ssrcs[rid],
+						SequenceNumber: sequenceNumber,
+					}
+					assert.NoError(t, header.SetExtension(1, []byte("0")))
+					assert.NoError(t, header.SetExtension(2, []byte(rid)))
+
+					_, err = sender.SendRTP(header, []byte{0x00})
+					assert.NoError(t, err)
+				}
+			case <-onTrackFired:
+				return
+			}
+		}
+	}()
+
+	// The packets read to learn the rid are read from the Track too
+	onTrackMu.Lock()
+	assert.Equal(t, ssrcs, onTrackRids)
+	assert.Equal(t, map[string]uint16{"a": 0, "b": 0}, firstSequenceNumbers)
+	onTrackMu.Unlock()
+
+	transceivers := pcAnswer.GetTransceivers()
+	assert.Equal(t, 1, len(transceivers))
+	assert.Equal(t, 2, len(transceivers[0].Receiver().Tracks()))
+
+	// The RTCP of every layer can be read
+	receiver := transceivers[0].Receiver()
+	_, err = receiver.ReadSimulcast(make([]byte, 1500), "c")
+	assert.True(t, errors.Is(err, ErrRTPReceiverStreamNotFound))
+
+	rtcpRead := make(chan []rtcp.Packet)
+	go func() {
+		pkts, routineErr := receiver.ReadSimulcastRTCP("b")
+		assert.NoError(t, routineErr)
+		rtcpRead <- pkts
+	}()
+	func() {
+		for {
+			select {
+			case <-time.After(20 * time.Millisecond):
+				assert.NoError(t, pcOffer.WriteRTCP([]rtcp.Packet{&rtcp.SourceDescription{Chunks: []rtcp.SourceDescriptionChunk{{
+					Source: ssrcs["b"],
+					Items:  []rtcp.SourceDescriptionItem{{Type: rtcp.SDESCNAME, Text: "pion"}},
+				}}}}))
+			case pkts := <-rtcpRead:
+				assert.Equal(t, []uint32{ssrcs["b"]}, pkts[0].DestinationSSRC())
+				return
+			}
+		}
+	}()
+
+	assert.NoError(t, pcOffer.Close())
+	assert.NoError(t, pcAnswer.Close())
+}
+
+// Assert that only header extensions registered on both sides are negotiated,
+// and that they can be written and read with the negotiated IDs
+func TestPeerConnection_Media_HeaderExtensions(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	newAPI := func(uris ...string) *API {
+		m := MediaEngine{}
+		m.RegisterDefaultCodecs()
+		for _, uri := range uris {
+			assert.NoError(t, m.RegisterHeaderExtension(RTPHeaderExtensionCapability{URI: uri}, RTPCodecTypeVideo))
+		}
+		return NewAPI(WithMediaEngine(m))
+	}
+
+	pcOffer, err := newAPI(AudioLevelURI, AbsSendTimeURI, TransportCCURI).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	// Registered in a different order, the IDs of the offer must still be used
+	pcAnswer, err := newAPI(TransportCCURI, AbsSendTimeURI).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	track, err := pcOffer.NewTrack(DefaultPayloadTypeVP8, rand.Uint32(), "video", "pion")
+	assert.NoError(t, err)
+
+	sender, err := pcOffer.AddTrack(track)
+	assert.NoError(t, err)
+
+	absSendTime := []byte{0x01, 0x02, 0x03}
+	extensionReceived := make(chan []byte)
+	pcAnswer.OnTrack(func(remoteTrack *Track, receiver *RTPReceiver) {
+		var id int
+		for _, e := range receiver.GetParameters().HeaderExtensions {
+			if e.URI == AbsSendTimeURI {
+				id = e.ID
+			}
+		}
+
+		for {
+			pkt, routineErr := remoteTrack.ReadRTP()
+			if routineErr != nil {
+				return
+			}
+
+			if payload := pkt.GetExtension(uint8(id)); payload != nil {
+				extensionReceived <- payload
+				return
+			}
+		}
+	})
+
+	assert.NoError(t, signalPair(pcOffer, pcAnswer))
+
+	var headerExtensions []RTPHeaderExtensionParameter
+	func() {
+		for sequenceNumber := uint16(0); ; sequenceNumber++ {
+			select {
+			case <-time.After(20 * time.Millisecond):
+				// The negotiated extensions are known once the answer has been applied
+				if headerExtensions = sender.GetParameters().HeaderExtensions; len(headerExtensions) == 0 {
+					continue
+				}
+
+				header := &rtp.Header{
+					Version:        2,
+					SSRC:           track.SSRC(),
+					SequenceNumber: sequenceNumber,
+				}
+				assert.NoError(t, header.SetExtension(uint8(headerExtensions[0].ID), absSendTime))
+				assert.NoError(t, track.WriteRTP(&rtp.Packet{Header: *header, Payload: []byte{0x00}}))
+			case payload := <-extensionReceived:
+				assert.Equal(t, absSendTime, payload)
+				return
+			}
+		}
+	}()
+
+	assert.Equal(t, []RTPHeaderExtensionParameter{
+		{URI: AbsSendTimeURI, ID: 2},
+		{URI: TransportCCURI, ID: 3},
+	}, headerExtensions)
+
+	assert.NoError(t, pcOffer.Close())
+	assert.NoError(t, pcAnswer.Close())
+}
+
+// Assert that the RTPSender and RTPReceiver describe the codecs, header
+// extensions and encoding both sides agreed on
+func TestPeerConnection_Media_GetParameters(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	m := MediaEngine{}
+	m.RegisterCodec(NewRTPVP8Codec(DefaultPayloadTypeVP8, 90000))
+	m.RegisterCodec(NewRTPRTXCodec(97, 90000, DefaultPayloadTypeVP8))
+	assert.NoError(t, m.RegisterHeaderExtension(RTPHeaderExtensionCapability{URI: TransportCCURI}, RTPCodecTypeVideo))
+	api := NewAPI(WithMediaEngine(m))
+
+	pcOffer, err := api.NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	pcAnswer, err := api.NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	track, err := pcOffer.NewTrack(DefaultPayloadTypeVP8, rand.Uint32(), "video", "pion")
+	assert.NoError(t, err)
+
+	sender, err := pcOffer.AddTrack(track)
+	assert.NoError(t, err)
+
+	assert.Empty(t, sender.GetParameters().Codecs)
+	encodings := sender.GetEncodings()
+	assert.Len(t, encodings, 1)
+	assert.Equal(t, track.SSRC(), encodings[0].SSRC)
+	assert.False(t, encodings[0].Active)
+
+	trackReceived := make(chan *RTPReceiver)
+	pcAnswer.OnTrack(func(remoteTrack *Track, receiver *RTPReceiver) {
+		trackReceived <- receiver
+	})
+
+	assert.NoError(t, signalPair(pcOffer, pcAnswer))
+
+	var receiver *RTPReceiver
+	func() {
+		for sequenceNumber := uint16(0); ; sequenceNumber++ {
+			select {
+			case <-time.After(20 * time.Millisecond):
+				assert.NoError(t, track.WriteRTP(&rtp.Packet{
+					Header:  rtp.Header{Version: 2, SSRC: track.SSRC(), SequenceNumber: sequenceNumber},
+					Payload: []byte{0x00},
+				}))
+			case receiver = <-trackReceived:
+				return
+			}
+		}
+	}()
+
+	vp8 := RTPCodecParameters{
+		RTPCodecCapability: NewRTPVP8Codec(DefaultPayloadTypeVP8, 90000).RTPCodecCapability,
+		PayloadType:        DefaultPayloadTypeVP8,
+	}
+	rtx := RTPCodecParameters{
+		RTPCodecCapability: NewRTPRTXCodec(97, 90000, DefaultPayloadTypeVP8).RTPCodecCapability,
+		PayloadType:        97,
+	}
+	headerExtensions := []RTPHeaderExtensionParameter{{URI: TransportCCURI, ID: 1}}
+
+	params := sender.GetParameters()
+	assert.Equal(t, []RTPCodecParameters{vp8, rtx}, params.Codecs)
+	assert.Equal(t, headerExtensions, params.HeaderExtensions)
+
+	encodings = sender.GetEncodings()
+	assert.Len(t, encodings, 1)
+	assert.Equal(t, "", encodings[0].RID)
+	assert.Equal(t, track.SSRC(), encodings[0].SSRC)
+	assert.Equal(t, uint8(DefaultPayloadTypeVP8), encodings[0].PayloadType)
+	assert.NotZero(t, encodings[0].RTX.SSRC)
+	assert.True(t, encodings[0].Active)
+
+	receiverParams := receiver.GetParameters()
+	assert.Equal(t, []RTPCodecParameters{vp8, rtx}, receiverParams.Codecs)
+	assert.Equal(t, headerExtensions, receiverParams.HeaderExtensions)
+
+	receiverEncodings := receiver.GetEncodings()
+	assert.Len(t, receiverEncodings, 1)
+	assert.Equal(t, "", receiverEncodings[0].RID)
+	assert.Equal(t, track.SSRC(), receiverEncodings[0].SSRC)
+	assert.Equal(t, uint8(DefaultPayloadTypeVP8), receiverEncodings[0].PayloadType)
+	assert.Equal(t, encodings[0].RTX.SSRC, receiverEncodings[0].RTX.SSRC)
+	assert.Zero(t, receiverEncodings[0].FEC.SSRC)
+
+	assert.NoError(t, pcOffer.RemoveTrack(sender))
+	assert.False(t, sender.GetEncodings()[0].Active)
+
+	assert.NoError(t, pcOffer.Close())
+	assert.NoError(t, pcAnswer.Close())
+}
+
+// Assert that the playout delay of the SettingEngine is sent on video when the
+// playout-delay header extension is negotiated
+func TestPeerConnection_Media_PlayoutDelay(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	m := MediaEngine{}
+	m.RegisterDefaultCodecs()
+	assert.NoError(t, m.RegisterHeaderExtension(RTPHeaderExtensionCapability{URI: PlayoutDelayURI}, RTPCodecTypeVideo))
+
+	s := SettingEngine{}
+	assert.NoError(t, s.SetPlayoutDelay(0, 100*time.Millisecond))
+
+	pcOffer, err := NewAPI(WithMediaEngine(m), WithSettingEngine(s)).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	pcAnswer, err := NewAPI(WithMediaEngine(m)).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	track, err := pcOffer.NewTrack(DefaultPayloadTypeVP8, rand.Uint32(), "video", "pion")
+	assert.NoError(t, err)
+
+	_, err = pcOffer.AddTrack(track)
+	assert.NoError(t, err)
+
+	playoutDelayReceived := make(chan PlayoutDelayExtension)
+	pcAnswer.OnTrack(func(remoteTrack *Track, receiver *RTPReceiver) {
+		var id int
+		for _, e := range receiver.GetParameters().HeaderExtensions {
+			if e.URI == PlayoutDelayURI {
+				id = e.ID
+			}
+		}
+
+		for {
+			pkt, routineErr := remoteTrack.ReadRTP()
+			if routineErr != nil {
+				return
+			}
+
+			playoutDelay := PlayoutDelayExtension{}
+			if routineErr = playoutDelay.Unmarshal(pkt.GetExtension(uint8(id))); routineErr == nil {
+				playoutDelayReceived <- playoutDelay
+				return
+			}
+		}
+	})
+
+	assert.NoError(t, signalPair(pcOffer, pcAnswer))
+
+	func() {
+		for sequenceNumber := uint16(0); ; sequenceNumber++ {
+			select {
+			case <-time.After(20 * time.Millisecond):
+				assert.NoError(t, track.WriteRTP(&rtp.Packet{
+					Header:  rtp.Header{Version: 2, SSRC: track.SSRC(), SequenceNumber: sequenceNumber},
+					Payload: []byte{0x00},
+				}))
+			case playoutDelay := <-playoutDelayReceived:
+				assert.Equal(t, PlayoutDelayExtension{Min: 0, Max: 100 * time.Millisecond}, playoutDelay)
+				return
+			}
+		}
+	}()
+
+	assert.NoError(t, pcOffer.Close())
+	assert.NoError(t, pcAnswer.Close())
+}
+
+func TestRTPSender_RemoteInboundStats(t *testing.T) {
+	track, err := NewTrack(DefaultPayloadTypeOpus, 1234, "audio", "pion", NewRTPOpusCodec(DefaultPayloadTypeOpus, 48000))
+	assert.NoError(t, err)
+
+	sender := &RTPSender{track: track, ssrc: 1234, statsID: "RTPSender-1"}
+
+	collector := newStatsReportCollector()
+	sender.collectStats(collector)
+	_, ok := collector.Ready().GetRemoteInboundRTPStreamStats(sender)
+	assert.False(t, ok)
+
+	// The SR was sent 300ms before the RR arrived, and the remote held it for 100ms
+	now := time.Now()
+	lastSenderReport := uint32(util.NTPTime(now.Add(-300*time.Millisecond)) >> 16)
+	raw, err := rtcp.Marshal([]rtcp.Packet{&rtcp.ReceiverReport{
+		SSRC: 5678,
+		Reports: []rtcp.ReceptionReport{
+			{SSRC: 4321, FractionLost: 128},
+			{
+				SSRC:             1234,
+				FractionLost:     64,
+				TotalLost:        0xFFFFFF, // -1
+				Jitter:           480,
+				LastSenderReport: lastSenderReport,
+				Delay:            65536 / 10,
+			},
+		},
+	}})
+	assert.NoError(t, err)
+	sender.handleRTCP(raw, now)
+
+	collector = newStatsReportCollector()
+	sender.collectStats(collector)
+	report := collector.Ready()
+
+	remoteStats, ok := report.GetRemoteInboundRTPStreamStats(sender)
+	assert.True(t, ok)
+	assert.Equal(t, StatsTypeRemoteInboundRTP, remoteStats.Type)
+	assert.Equal(t, uint32(1234), remoteStats.SSRC)
+	assert.Equal(t, sender.statsID, remoteStats.LocalID)
+	assert.Equal(t, 0.25, remoteStats.FractionLost)
+	assert.Equal(t, int32(-1), remoteStats.PacketsLost)
+	assert.Equal(t, 0.01, remoteStats.Jitter)
+	assert.InDelta(t, 0.2, remoteStats.RoundTripTime, 0.001)
+
+	senderStats, ok := report.GetOutboundRTPStreamStats(sender)
+	assert.True(t, ok)
+	assert.Equal(t, remoteStats.ID, senderStats.RemoteID)
+}
+
+// Assert that Receiver Reports sent by the remote end up in the remote-inbound-rtp stats
+func TestPeerConnection_Media_RemoteInboundStats(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	api := NewAPI()
+	api.mediaEngine.RegisterDefaultCodecs()
+
+	pcOffer, pcAnswer, err := api.newPair(Configuration{})
+	assert.NoError(t, err)
+
+	track, err := pcOffer.NewTrack(DefaultPayloadTypeVP8, rand.Uint32(), "video", "pion")
+	assert.NoError(t, err)
+
+	sender, err := pcOffer.AddTrack(track)
+	assert.NoError(t, err)
+
+	receiverReportRead := make(chan struct{})
+	go func() {
+		for {
+			pkts, routineErr := sender.ReadRTCP()
+			if routineErr != nil {
+				return
+			}
+
+			for _, p := range pkts {
+				if _, ok := p.(*rtcp.ReceiverReport); ok {
+					close(receiverReportRead)
+					return
+				}
+			}
+		}
+	}()
+
+	pcAnswer.OnTrack(func(remoteTrack *Track, receiver *RTPReceiver) {
+		assert.NoError(t, pcAnswer.WriteRTCP([]rtcp.Packet{&rtcp.ReceiverReport{
+			SSRC:    rand.Uint32(),
+			Reports: []rtcp.ReceptionReport{{SSRC: remoteTrack.SSRC(), FractionLost: 128, TotalLost: 5}},
+		}}))
+	})
+
+	assert.NoError(t, signalPair(pcOffer, pcAnswer))
+
+	func() {
+		for {
+			select {
+			case <-time.After(20 * time.Millisecond):
+				assert.NoError(t, track.WriteSample(media.Sample{Data: []byte{0x00}, Samples: 1}))
+			case <-receiverReportRead:
+				return
+			}
+		}
+	}()
+
+	remoteStats, ok := pcOffer.GetStats().GetRemoteInboundRTPStreamStats(sender)
+	assert.True(t, ok)
+	assert.Equal(t, track.SSRC(), remoteStats.SSRC)
+	assert.Equal(t, 0.5, remoteStats.FractionLost)
+	assert.Equal(t, int32(5), remoteStats.PacketsLost)
+
+	assert.NoError(t, pcOffer.Close())
+	assert.NoError(t, pcAnswer.Close())
+}
+
+// Assert that RTP written to a Track before the connection is established is
+// queued and sent once the RTPSender starts
+func TestPeerConnection_Media_TrackSendQueue(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	s := SettingEngine{}
+	s.SetTrackSendQueueSize(3)
+
+	api := NewAPI(WithSettingEngine(s))
+	api.mediaEngine.RegisterDefaultCodecs()
+
+	pcOffer, pcAnswer, err := api.newPair(Configuration{})
+	assert.NoError(t, err)
+
+	track, err := pcOffer.NewTrack(DefaultPayloadTypeVP8, rand.Uint32(), "video", "pion")
+	assert.NoError(t, err)
+
+	_, err = pcOffer.AddTrack(track)
+	assert.NoError(t, err)
+
+	writePacket := func(sequenceNumber uint16) {
+		assert.NoError(t, track.WriteRTP(&rtp.Packet{
+			Header:  rtp.Header{Version: 2, SSRC: track.SSRC(), PayloadType: DefaultPayloadTypeVP8, SequenceNumber: sequenceNumber},
+			Payload: []byte{0x00},
+		}))
+	}
+
+	// Only the last three are kept
+	for sequenceNumber := uint16(0); sequenceNumber < 5; sequenceNumber++ {
+		writePacket(sequenceNumber)
+	}
+
+	// The first packet is consumed before OnTrack fires
+	sequenceNumbers := make(chan uint16, 1)
+	pcAnswer.OnTrack(func(remoteTrack *Track, receiver *RTPReceiver) {
+		pkt, routineErr := remoteTrack.ReadRTP()
+		if routineErr != nil {
+			return
+		}
+		sequenceNumbers <- pkt.SequenceNumber
+	})
+
+	assert.NoError(t, signalPair(pcOffer, pcAnswer))
+
+	func() {
+		for sequenceNumber := uint16(1000); ; sequenceNumber++ {
+			select {
+			case <-time.After(20 * time.Millisecond):
+				writePacket(sequenceNumber)
+			case s := <-sequenceNumbers:
+				assert.Equal(t, uint16(3), s)
+				return
+			}
+		}
+	}()
+
+	assert.NoError(t, pcOffer.Close())
+	assert.NoError(t, pcAnswer.Close())
+}
+
+// Assert that a packet requested by a Generic NACK is retransmitted, on the RTX
+// SSRC when RTX has been negotiated
+func TestPeerConnection_Media_NACK(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	const rtxPayloadType = 97
+
+	for _, useRTX := range []bool{false, true} {
+		useRTX := useRTX
+		t.Run(fmt.Sprintf("RTX=%t", useRTX), func(t *testing.T) {
+			// The retransmitted packet wasn't actually lost
+			s := SettingEngine{}
+			s.DisableSRTPReplayProtection(true)
+
+			api := NewAPI(WithSettingEngine(s))
+			api.mediaEngine.RegisterCodec(NewRTPVP8CodecExt(DefaultPayloadTypeVP8, 90000, []RTCPFeedback{{Type: TypeRTCPFBNACK}}, ""))
+			if useRTX {
+				api.mediaEngine.RegisterCodec(NewRTPRTXCodec(rtxPayloadType, 90000, DefaultPayloadTypeVP8))
+			}
+
+			pcOffer, pcAnswer, err := api.newPair(Configuration{})
+			assert.NoError(t, err)
+
+			track, err := pcOffer.NewTrack(DefaultPayloadTypeVP8, rand.Uint32(), "video", "pion")
+			assert.NoError(t, err)
+
+			sender, err := pcOffer.AddTrack(track)
+			assert.NoError(t, err)
+
+			go func() {
+				for {
+					if _, routineErr := sender.ReadRTCP(); routineErr != nil {
+						return
+					}
+				}
+			}()
+
+			retransmitted := make(chan *rtp.Packet, 1)
+			pcAnswer.OnTrack(func(remoteTrack *Track, receiver *RTPReceiver) {
+				pkt, routineErr := remoteTrack.ReadRTP()
+				if routineErr != nil {
+					return
+				}
+				lost := pkt.SequenceNumber
+
+				var rtxStream interface {
+					ReadRTP([]byte) (int, *rtp.Header, error)
+				}
+				if useRTX {
+					srtpSession, sessionErr := pcAnswer.dtlsTransport.getSRTPSession()
+					assert.NoError(t, sessionErr)
+
+					rtxStream, sessionErr = srtpSession.OpenReadStream(sender.rtxSSRC)
+					assert.NoError(t, sessionErr)
+				}
+
+				assert.NoError(t, pcAnswer.WriteRTCP([]rtcp.Packet{&rtcp.TransportLayerNack{
+					SenderSSRC: rand.Uint32(),
+					MediaSSRC:  remoteTrack.SSRC(),
+					Nacks:      []rtcp.NackPair{{PacketID: lost}},
+				}}))
+
+				if useRTX {
+					buf := make([]byte, receiveMTU)
+					n, _, readErr := rtxStream.ReadRTP(buf)
+					if readErr != nil {
+						return
+					}
+
+					rtxPacket := &rtp.Packet{}
+					assert.NoError(t, rtxPacket.Unmarshal(buf[:n]))
+					retransmitted <- rtxPacket
+					return
+				}
+
+				for {
+					pkt, routineErr = remoteTrack.ReadRTP()
+					if routineErr != nil {
+						return
+					}
+					if pkt.SequenceNumber == lost {
+						retransmitted <- pkt
+						return
+					}
+				}
+			})
+
+			assert.NoError(t, signalPair(pcOffer, pcAnswer))
+
+			var pkt *rtp.Packet
+			func() {
+				for sequenceNumber := uint16(0); ; sequenceNumber++ {
+					select {
+					case <-time.After(20 * time.Millisecond):
+						assert.NoError(t, track.WriteRTP(&rtp.Packet{
+							Header:  rtp.Header{Version: 2, SSRC: track.SSRC(), PayloadType: DefaultPayloadTypeVP8, SequenceNumber: sequenceNumber},
+							Payload: []byte{0xAA, 0xBB},
+						}))
+					case pkt = <-retransmitted:
+						return
+					}
+				}
+			}()
+
+			if useRTX {
+				assert.Equal(t, uint8(rtxPayloadType), pkt.PayloadType)
+				assert.Equal(t, sender.rtxSSRC, pkt.SSRC)
+				assert.Equal(t, []byte{0xAA, 0xBB}, pkt.Payload[2:])
+			} else {
+				assert.Equal(t, uint8(DefaultPayloadTypeVP8), pkt.PayloadType)
+				assert.Equal(t, track.SSRC(), pkt.SSRC)
+				assert.Equal(t, []byte{0xAA, 0xBB}, pkt.Payload)
+			}
+
+			stats, ok := pcOffer.GetStats().GetOutboundRTPStreamStats(sender)
+			assert.True(t, ok)
+			assert.Equal(t, uint32(1), stats.NACKCount)
+
+			assert.NoError(t, pcOffer.Close())
+			assert.NoError(t, pcAnswer.Close())
+		})
+	}
+}
+
+// Assert that a packet lost on the network is requested with a Generic NACK by
+// the receiver, and retransmitted by the sender
+func TestPeerConnection_Media_NACKGenerator(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	const lostSequenceNumber = 5
+	trackSSRC := rand.Uint32()
+
+	// Only the first transmission of the lost packet is dropped
+	wan, err := vnet.NewRouter(&vnet.RouterConfig{
+		CIDR:          "1.2.3.0/24",
+		LoggerFactory: logging.NewDefaultLoggerFactory(),
+	})
+	assert.NoError(t, err)
+	var dropMu sync.Mutex
+	dropped := false
+	wan.AddChunkFilter(func(c vnet.Chunk) bool {
+		b := c.UserData()
+		if len(b) < 12 || binary.BigEndian.Uint32(b[8:]) != trackSSRC || binary.BigEndian.Uint16(b[2:]) != lostSequenceNumber {
+			return true
+		}
+
+		dropMu.Lock()
+		defer dropMu.Unlock()
+		if dropped {
+			return true
+		}
+		dropped = true
+		return false
+	})
+
+	m := MediaEngine{}
+	m.RegisterCodec(NewRTPVP8CodecExt(DefaultPayloadTypeVP8, 90000, []RTCPFeedback{{Type: TypeRTCPFBNACK}}, ""))
+
+	newPeerConnection := func(ip string) *PeerConnection {
+		n := vnet.NewNet(&vnet.NetConfig{StaticIPs: []string{ip}})
+		assert.NoError(t, wan.AddNet(n))
+
+		s := SettingEngine{}
+		s.SetVNet(n)
+
+		pc, newErr := NewAPI(WithMediaEngine(m), WithSettingEngine(s)).NewPeerConnection(Configuration{})
+		assert.NoError(t, newErr)
+		return pc
+	}
+	pcOffer, pcAnswer := newPeerConnection("1.2.3.4"), newPeerConnection("1.2.3.5")
+	assert.NoError(t, wan.Start())
+
+	track, err := pcOffer.NewTrack(DefaultPayloadTypeVP8, trackSSRC, "video", "pion")
+	assert.NoError(t, err)
+
+	sender, err := pcOffer.AddTrack(track)
+	assert.NoError(t, err)
+
+	// The NACKs are only handled while RTCP is read
+	go func() {
+		for {
+			if _, routineErr := sender.ReadRTCP(); routineErr != nil {
+				return
+			}
+		}
+	}()
+
+	retransmitted := make(chan *rtp.Packet)
+	pcAnswer.OnTrack(func(remoteTrack *Track, receiver *RTPReceiver) {
+		for {
+			p, readErr := remoteTrack.ReadRTP()
+			if readErr != nil {
+				return
+			}
+			if p.SequenceNumber == lostSequenceNumber {
+				retransmitted <- p
+				return
+			}
+		}
+	})
+
+	assert.NoError(t, signalPair(pcOffer, pcAnswer))
+
+	// Packets written before the RTPSender is sending would be dropped
+	for !sender.GetEncodings()[0].Active {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	func() {
+		for sequenceNumber := uint16(0); ; sequenceNumber++ {
+			select {
+			case <-time.After(20 * time.Millisecond):
+				assert.NoError(t, track.WriteRTP(&rtp.Packet{
+					Header: rtp.Header{
+						Version:        2,
+						SSRC:           trackSSRC,
+						SequenceNumber: sequenceNumber,
+					},
+					Payload: []byte{0x10, byte(sequenceNumber)},
+				}))
+			case p := <-retransmitted:
+				assert.Equal(t, []byte{0x10, lostSequenceNumber}, p.Payload)
+				return
+			}
+		}
+	}()
+
+	dropMu.Lock()
+	assert.True(t, dropped)
+	dropMu.Unlock()
+
+	stats, ok := pcOffer.GetStats().GetOutboundRTPStreamStats(sender)
+	assert.True(t, ok)
+	assert.NotZero(t, stats.NACKCount)
+
+	assert.NoError(t, pcOffer.Close())
+	assert.NoError(t, pcAnswer.Close())
+	assert.NoError(t, wan.Stop())
+}
+
+func TestPeerConnection_Media_ReceiverReports(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	s := SettingEngine{}
+	s.SetReceiverReportInterval(50 * time.Millisecond)
+	s.SetJitterBufferDepth(16)
+
+	api := NewAPI(WithSettingEngine(s))
+	api.mediaEngine.RegisterDefaultCodecs()
+
+	pcOffer, pcAnswer, err := api.newPair(Configuration{})
+	assert.NoError(t, err)
+
+	track, err := pcOffer.NewTrack(DefaultPayloadTypeVP8, rand.Uint32(), "video", "pion")
+	assert.NoError(t, err)
+
+	sender, err := pcOffer.AddTrack(track)
+	assert.NoError(t, err)
+
+	receptionReportRead := make(chan rtcp.ReceptionReport)
+	go func() {
+		for {
+			pkts, routineErr := sender.ReadRTCP()
+			if routineErr != nil {
+				return
+			}
+
+			for _, p := range pkts {
+				rr, ok := p.(*rtcp.ReceiverReport)
+				if !ok {
+					continue
+				}
+				for _, r := range rr.Reports {
+					if r.SSRC == track.SSRC() {
+						receptionReportRead <- r
+						return
+					}
+				}
+			}
+		}
+	}()
+
+	pcAnswer.OnTrack(func(remoteTrack *Track, receiver *RTPReceiver) {
+		for {
+			if _, routineErr := remoteTrack.ReadRTP(); routineErr != nil {
+				return
+			}
+		}
+	})
+
+	assert.NoError(t, signalPair(pcOffer, pcAnswer))
+
+	var receptionReport rtcp.ReceptionReport
+	func() {
+		for {
+			select {
+			case <-time.After(20 * time.Millisecond):
+				assert.NoError(t, track.WriteSample(media.Sample{Data: []byte{0x00}, Samples: 1}))
+			case receptionReport = <-receptionReportRead:
+				return
+			}
+		}
+	}()
+
+	assert.Equal(t, uint8(0), receptionReport.FractionLost)
+	assert.Equal(t, uint32(0), receptionReport.TotalLost)
+
+	remoteStats, ok := pcOffer.GetStats().GetRemoteInboundRTPStreamStats(sender)
+	assert.True(t, ok)
+	assert.Equal(t, track.SSRC(), remoteStats.SSRC)
+
+	assert.NoError(t, pcOffer.Close())
+	assert.NoError(t, pcAnswer.Close())
+}
+
+func TestPeerConnection_Media_DTMF(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	const telephoneEventPayloadType = 101
+
+	api := NewAPI()
+	api.mediaEngine.RegisterDefaultCodecs()
+	api.mediaEngine.RegisterCodec(NewRTPTelephoneEventCodec(telephoneEventPayloadType, 48000))
+
+	pcOffer, pcAnswer, err := api.newPair(Configuration{})
+	assert.NoError(t, err)
+
+	track, err := pcOffer.NewTrack(DefaultPayloadTypeOpus, rand.Uint32(), "audio", "pion")
+	assert.NoError(t, err)
+
+	sender, err := pcOffer.AddTrack(track)
+	assert.NoError(t, err)
+
+	var stateErr *rtcerr.InvalidStateError
+	err = sender.InsertDTMF("1", 0, 0)
+	assert.True(t, errors.As(err, &stateErr))
+	assert.True(t, errors.Is(err, ErrRTPSenderNotSending))
+
+	onTrackFired := make(chan struct{})
+	events := make(chan []*rtp.Packet)
+	pcAnswer.OnTrack(func(remoteTrack *Track, receiver *RTPReceiver) {
+		close(onTrackFired)
+
+		var event []*rtp.Packet
+		for {
+			pkt, routineErr := remoteTrack.ReadRTP()
+			if routineErr != nil {
+				return
+			}
+			if pkt.PayloadType != telephoneEventPayloadType {
+				continue
+			}
+
+			event = append(event, pkt)
+			if len(event) >= dtmfEndPackets && pkt.Payload[1]&dtmfEndBit != 0 && event[len(event)-dtmfEndPackets].Payload[1]&dtmfEndBit != 0 {
+				events <- event
+				event = nil
+			}
+		}
+	})
+
+	assert.NoError(t, signalPair(pcOffer, pcAnswer))
+
+	var received [][]*rtp.Packet
+	func() {
+		ticker := time.NewTicker(20 * time.Millisecond)
+		defer ticker.Stop()
+		for len(received) != 2 {
+			select {
+			case <-ticker.C:
+				assert.NoError(t, track.WriteSample(media.Sample{Data: []byte{0x00}, Samples: 960}))
+			case <-onTrackFired:
+				onTrackFired = nil
+				var syntaxErr *rtcerr.SyntaxError
+				assert.True(t, errors.As(sender.InsertDTMF("1X", 0, 0), &syntaxErr))
+				assert.NoError(t, sender.InsertDTMF("1#", 120*time.Millisecond, 0))
+			case event := <-events:
+				received = append(received, event)
+			}
+		}
+	}()
+
+	for i, code := range []uint8{1, 11} {
+		event := received[i]
+		assert.True(t, event[0].Marker)
+		for j, pkt := range event {
+			assert.Equal(t, track.SSRC(), pkt.SSRC)
+			assert.Equal(t, event[0].Timestamp, pkt.Timestamp)
+			assert.Equal(t, code, pkt.Payload[0])
+			assert.Equal(t, j >= len(event)-dtmfEndPackets, pkt.Payload[1]&dtmfEndBit != 0)
+			if j > 0 {
+				assert.False(t, pkt.Marker)
+				// Audio packets are interleaved, sharing the sequence numbers
+				assert.Less(t, pkt.SequenceNumber-event[j-1].SequenceNumber-1, uint16(1<<15))
+			}
+		}
+
+		// 120ms at 48kHz
+		last := event[len(event)-1].Payload
+		assert.Equal(t, uint16(5760), uint16(last[2])<<8|uint16(last[3]))
+	}
+	assert.Equal(t, "", sender.ToneBuffer())
+
+	assert.NoError(t, pcOffer.Close())
+	assert.NoError(t, pcAnswer.Close())
+}
+
+func TestPeerConnection_Media_RemotePayloadTypes(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	const offerPayloadType = 100
+
+	offerMediaEngine := MediaEngine{}
+	offerMediaEngine.RegisterCodec(NewRTPVP8Codec(offerPayloadType, 90000))
+	pcOffer, err := NewAPI(WithMediaEngine(offerMediaEngine)).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	answerMediaEngine := MediaEngine{}
+	answerMediaEngine.RegisterDefaultCodecs()
+	pcAnswer, err := NewAPI(WithMediaEngine(answerMediaEngine)).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	track, err := pcOffer.NewTrack(offerPayloadType, rand.Uint32(), "video", "pion")
+	assert.NoError(t, err)
+
+	_, err = pcOffer.AddTrack(track)
+	assert.NoError(t, err)
+
+	codecReceived := make(chan *RTPCodec)
+	pcAnswer.OnTrack(func(remoteTrack *Track, receiver *RTPReceiver) {
+		codecReceived <- remoteTrack.Codec()
+	})
+
+	assert.NoError(t, signalPair(pcOffer, pcAnswer))
+
+	// The answer only contains the codec that was offered, with the payload type of the offer
+	answer := sdp.SessionDescription{}
+	assert.NoError(t, answer.Unmarshal([]byte(pcAnswer.CurrentLocalDescription().SDP)))
+	var formats []string
+	for _, media := range answer.MediaDescriptions {
+		if media.MediaName.Media == "video" {
+			formats = media.MediaName.Formats
+		}
+	}
+	assert.Equal(t, []string{"100"}, formats)
+
+	var codec *RTPCodec
+	func() {
+		for {
+			select {
+			case <-time.After(20 * time.Millisecond):
+				assert.NoError(t, track.WriteSample(media.Sample{Data: []byte{0x00}, Samples: 1}))
+			case codec = <-codecReceived:
+				return
+			}
+		}
+	}()
+
+	assert.Equal(t, VP8, codec.Name)
+	assert.Equal(t, uint8(offerPayloadType), codec.PayloadType)
+
+	assert.NoError(t, pcOffer.Close())
+	assert.NoError(t, pcAnswer.Close())
+}
+
+// xorInterceptor flips the bits of the payloads it writes and reads back, and
+// records the streams it has seen
+type xorInterceptor struct {
+	interceptor.NoOp
+
+	mu            sync.Mutex
+	localStreams  []interceptor.StreamInfo
+	remoteStreams []interceptor.StreamInfo
+}
+
+func (x *xorInterceptor) BindLocalStream(info *interceptor.StreamInfo, writer interceptor.RTPWriter) interceptor.RTPWriter {
+	x.mu.Lock()
+	x.localStreams = append(x.localStreams, *info)
+	x.mu.Unlock()
+
+	return interceptor.RTPWriterFunc(func(header *rtp.Header, payload []byte) (int, error) {
+		flipped := make([]byte, len(payload))
+		for i := range payload {
+			flipped[i] = payload[i] ^ 0xff
+		}
+		return writer.Write(header, flipped)
+	})
+}
+
+func (x *xorInterceptor) BindRemoteStream(info *interceptor.StreamInfo, reader interceptor.RTPReader) interceptor.RTPReader {
+	x.mu.Lock()
+	x.remoteStreams = append(x.remoteStreams, *info)
+	x.mu.Unlock()
+
+	return interceptor.RTPReaderFunc(func(b []byte) (int, error) {
+		n, err := reader.Read(b)
+		if err != nil {
+			return n, err
+		}
+
+		p := &rtp.Packet{}
+		if err = p.Unmarshal(b[:n]); err != nil {
+			return 0, err
+		}
+		for i := range p.Payload {
+			p.Payload[i] ^= 0xff
+		}
+
+		raw, err := p.Marshal()
+		if err != nil {
+			return 0, err
+		}
+		return copy(b, raw), nil
+	})
+}
+
+func TestPeerConnection_Media_Interceptors(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	var interceptorsMu sync.Mutex
+	var interceptors []*xorInterceptor
+
+	registry := interceptor.Registry{}
+	registry.Add(func() (interceptor.Interceptor, error) {
+		i := &xorInterceptor{}
+		interceptorsMu.Lock()
+		interceptors = append(interceptors, i)
+		interceptorsMu.Unlock()
+		return i, nil
+	})
+
+	api := NewAPI(WithInterceptorRegistry(registry))
+	api.mediaEngine.RegisterDefaultCodecs()
+
+	pcOffer, pcAnswer, err := api.newPair(Configuration{})
+	assert.NoError(t, err)
+
+	interceptorsMu.Lock()
+	assert.Equal(t, 2, len(interceptors), "every PeerConnection builds its own interceptors")
+	interceptorsMu.Unlock()
+
+	track, err := pcOffer.NewTrack(DefaultPayloadTypeVP8, rand.Uint32(), "video", "pion")
+	assert.NoError(t, err)
+
+	sender, err := pcOffer.AddTrack(track)
+	assert.NoError(t, err)
+
+	payloadRead := make(chan []byte, 1)
+	pcAnswer.OnTrack(func(remoteTrack *Track, receiver *RTPReceiver) {
+		p, routineErr := remoteTrack.ReadRTP()
+		if routineErr != nil {
+			return
+		}
+		payloadRead <- p.Payload
+	})
+
+	assert.NoError(t, signalPair(pcOffer, pcAnswer))
+
+	payload := []byte{0x10, 0x20, 0x30}
+	var received []byte
+	func() {
+		for sequenceNumber := uint16(0); ; sequenceNumber++ {
+			select {
+			case <-time.After(20 * time.Millisecond):
+				assert.NoError(t, track.WriteRTP(&rtp.Packet{
+					Header:  rtp.Header{Version: 2, SSRC: track.SSRC(), SequenceNumber: sequenceNumber},
+					Payload: payload,
+				}))
+			case received = <-payloadRead:
+				return
+			}
+		}
+	}()
+	assert.Equal(t, payload, received)
+
+	interceptorsMu.Lock()
+	offerInterceptor, answerInterceptor := interceptors[0], interceptors[1]
+	interceptorsMu.Unlock()
+
+	offerInterceptor.mu.Lock()
+	assert.Equal(t, 1, len(offerInterceptor.localStreams))
+	assert.Equal(t, track.SSRC(), offerInterceptor.localStreams[0].SSRC)
+	assert.Equal(t, "video/VP8", offerInterceptor.localStreams[0].MimeType)
+	assert.Equal(t, uint32(90000), offerInterceptor.localStreams[0].ClockRate)
+	offerInterceptor.mu.Unlock()
+
+	// Remote streams are described with the negotiated codec
+	answerInterceptor.mu.Lock()
+	assert.Equal(t, 1, len(answerInterceptor.remoteStreams))
+	assert.Equal(t, track.SSRC(), answerInterceptor.remoteStreams[0].SSRC)
+	assert.Equal(t, uint8(DefaultPayloadTypeVP8), answerInterceptor.remoteStreams[0].PayloadType)
+	assert.Equal(t, uint32(90000), answerInterceptor.remoteStreams[0].ClockRate)
+	answerInterceptor.mu.Unlock()
+
+	// A renegotiation changing the payload type binds the stream again
+	sender.rebindLocalStream()
+	sender.setPayloadType(100)
+	sender.rebindLocalStream()
+
+	offerInterceptor.mu.Lock()
+	assert.Equal(t, 2, len(offerInterceptor.localStreams))
+	assert.Equal(t, uint8(100), offerInterceptor.localStreams[1].PayloadType)
+	offerInterceptor.mu.Unlock()
+
+	assert.NoError(t, pcOffer.Close())
+	assert.NoError(t, pcAnswer.Close())
+}
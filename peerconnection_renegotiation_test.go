@@ -13,6 +13,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/pion/sdp/v2"
 	"github.com/pion/transport/test"
 	"github.com/pion/webrtc/v2/internal/util"
 	"github.com/pion/webrtc/v2/pkg/media"
@@ -306,6 +307,9 @@ func TestPeerConnection_Transceiver_Mid(t *testing.T) {
 
 	assert.True(t, sdpMidHasSsrc(offer, "1", track2.SSRC()), "Expected mid %q with ssrc %d, offer.SDP: %s", "1", track2.SSRC(), offer.SDP)
 
+	assert.NoError(t, pcOffer.SetLocalDescription(offer))
+	assert.NoError(t, pcAnswer.SetRemoteDescription(offer))
+
 	answer, err = pcAnswer.CreateAnswer(nil)
 	assert.NoError(t, err)
 	assert.NoError(t, pcAnswer.SetLocalDescription(answer))
@@ -334,6 +338,75 @@ func TestPeerConnection_Transceiver_Mid(t *testing.T) {
 	assert.NoError(t, pcAnswer.Close())
 }
 
+// Assert that the m= section of a stopped transceiver is rejected and recycled
+// by the next transceiver, so add/stop cycles don't grow the SDP
+func TestPeerConnection_Renegotiation_RecycleRejected(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pcOffer, err := NewPeerConnection(Configuration{})
+	require.NoError(t, err)
+
+	pcAnswer, err := NewPeerConnection(Configuration{})
+	require.NoError(t, err)
+
+	_, err = pcOffer.CreateDataChannel("initial_data_channel", nil)
+	require.NoError(t, err)
+
+	negotiate := func() (SessionDescription, SessionDescription) {
+		offer, offerErr := pcOffer.CreateOffer(nil)
+		require.NoError(t, offerErr)
+		require.NoError(t, pcOffer.SetLocalDescription(offer))
+		require.NoError(t, pcAnswer.SetRemoteDescription(offer))
+
+		answer, answerErr := pcAnswer.CreateAnswer(nil)
+		require.NoError(t, answerErr)
+		require.NoError(t, pcAnswer.SetLocalDescription(answer))
+		require.NoError(t, pcOffer.SetRemoteDescription(answer))
+
+		<-pcOffer.ops.Done()
+		<-pcAnswer.ops.Done()
+		return offer, answer
+	}
+
+	for i := 0; i < 3; i++ {
+		track, err := pcOffer.NewTrack(DefaultPayloadTypeVP8, rand.Uint32(), "video", "pion"+strconv.Itoa(i))
+		require.NoError(t, err)
+
+		_, err = pcOffer.AddTrack(track)
+		require.NoError(t, err)
+
+		offer, _ := negotiate()
+		assert.Equal(t, 2, len(offer.parsed.MediaDescriptions))
+
+		media := offer.parsed.MediaDescriptions[0]
+		assert.Equal(t, "0", getMidValue(media))
+		assert.NotEqual(t, 0, media.MediaName.Port.Value)
+		assert.True(t, sdpMidHasSsrc(offer, getMidValue(media), track.SSRC()), "Expected mid %q with ssrc %d, offer.SDP: %s", getMidValue(media), track.SSRC(), offer.SDP)
+		assert.Equal(t, 1, len(pcAnswer.GetTransceivers()))
+
+		transceivers := pcOffer.GetTransceivers()
+		require.Equal(t, 1, len(transceivers))
+		assert.Equal(t, getMidValue(media), transceivers[0].Mid())
+		assert.NoError(t, transceivers[0].Stop())
+
+		offer, answer := negotiate()
+		assert.Equal(t, 2, len(offer.parsed.MediaDescriptions))
+		assert.Equal(t, 0, offer.parsed.MediaDescriptions[0].MediaName.Port.Value)
+		assert.Equal(t, 0, answer.parsed.MediaDescriptions[0].MediaName.Port.Value)
+		assert.Equal(t, "0", getMidValue(answer.parsed.MediaDescriptions[0]))
+
+		assert.Equal(t, 0, len(pcOffer.GetTransceivers()))
+		assert.Equal(t, 0, len(pcAnswer.GetTransceivers()))
+	}
+
+	assert.NoError(t, pcOffer.Close())
+	assert.NoError(t, pcAnswer.Close())
+}
+
 func TestPeerConnection_Renegotiation_CodecChange(t *testing.T) {
 	lim := test.TimeOut(time.Second * 30)
 	defer lim.Stop()
@@ -695,3 +768,115 @@ func TestPeerConnection_Renegotiation_NoApplication(t *testing.T) {
 	assert.NoError(t, pcOffer.Close())
 	assert.NoError(t, pcAnswer.Close())
 }
+
+// Assert that OnNegotiationNeeded fires when a Track is added after signaling,
+// and that completing the renegotiation it asked for is enough
+func TestPeerConnection_Renegotiation_OnNegotiationNeeded(t *testing.T) {
+	api := NewAPI()
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	api.mediaEngine.RegisterDefaultCodecs()
+	pcOffer, pcAnswer, err := api.newPair(Configuration{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.NoError(t, signalPair(pcOffer, pcAnswer))
+	<-pcOffer.ops.Done()
+	<-pcAnswer.ops.Done()
+
+	var negotiationNeededCount int32
+	negotiationNeeded := make(chan struct{}, 1)
+	pcOffer.OnNegotiationNeeded(func() {
+		atomic.AddInt32(&negotiationNeededCount, 1)
+		negotiationNeeded <- struct{}{}
+	})
+
+	vp8Track, err := pcOffer.NewTrack(DefaultPayloadTypeVP8, rand.Uint32(), "foo", "bar")
+	assert.NoError(t, err)
+
+	_, err = pcOffer.AddTrack(vp8Track)
+	assert.NoError(t, err)
+
+	<-negotiationNeeded
+	assert.Equal(t, int32(1), atomic.LoadInt32(&negotiationNeededCount))
+
+	// Further changes don't fire again until negotiation has happened
+	_, err = pcOffer.AddTransceiverFromKind(RTPCodecTypeAudio)
+	assert.NoError(t, err)
+
+	<-pcOffer.ops.Done()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&negotiationNeededCount))
+
+	assert.NoError(t, signalPair(pcOffer, pcAnswer))
+	<-pcOffer.ops.Done()
+	<-pcAnswer.ops.Done()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&negotiationNeededCount))
+
+	assert.NoError(t, pcOffer.Close())
+	assert.NoError(t, pcAnswer.Close())
+}
+
+// Assert that the header extension IDs negotiated when answering are kept when
+// offering later, even though the MediaEngine registered them with other IDs
+func TestPeerConnection_Renegotiation_HeaderExtensionIDs(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	newAPI := func(uris ...string) *API {
+		m := MediaEngine{}
+		m.RegisterDefaultCodecs()
+		for _, uri := range uris {
+			assert.NoError(t, m.RegisterHeaderExtension(RTPHeaderExtensionCapability{URI: uri}, RTPCodecTypeVideo))
+		}
+		return NewAPI(WithMediaEngine(m))
+	}
+
+	pcFirstOfferer, err := newAPI(AudioLevelURI, AbsSendTimeURI).NewPeerConnection(Configuration{})
+	require.NoError(t, err)
+
+	pcSecondOfferer, err := newAPI(AbsSendTimeURI, AudioLevelURI).NewPeerConnection(Configuration{})
+	require.NoError(t, err)
+
+	_, err = pcFirstOfferer.AddTransceiverFromKind(RTPCodecTypeVideo)
+	require.NoError(t, err)
+
+	require.NoError(t, signalPair(pcFirstOfferer, pcSecondOfferer))
+
+	// The new media section must use the IDs of the first offer too
+	_, err = pcSecondOfferer.AddTransceiverFromKind(RTPCodecTypeVideo)
+	require.NoError(t, err)
+
+	offer, err := pcSecondOfferer.CreateOffer(nil)
+	require.NoError(t, err)
+
+	assertExtMaps := func(desc *SessionDescription) {
+		parsed := &sdp.SessionDescription{}
+		require.NoError(t, parsed.Unmarshal([]byte(desc.SDP)))
+
+		videoSections := 0
+		for _, media := range parsed.MediaDescriptions {
+			if media.MediaName.Media != RTPCodecTypeVideo.String() {
+				continue
+			}
+			videoSections++
+			assert.Equal(t, map[string]int{AudioLevelURI: 1, AbsSendTimeURI: 2}, getExtMaps(media))
+		}
+		assert.Equal(t, 2, videoSections)
+	}
+	assertExtMaps(&offer)
+
+	require.NoError(t, signalPair(pcSecondOfferer, pcFirstOfferer))
+	assertExtMaps(pcSecondOfferer.CurrentLocalDescription())
+	assertExtMaps(pcFirstOfferer.CurrentLocalDescription())
+
+	require.NoError(t, pcFirstOfferer.Close())
+	require.NoError(t, pcSecondOfferer.Close())
+}
@@ -29,6 +29,12 @@ func newPair() (pcOffer *PeerConnection, pcAnswer *PeerConnection, err error) {
 }
 
 func signalPair(pcOffer *PeerConnection, pcAnswer *PeerConnection) error {
+	return signalPairWithModification(pcOffer, pcAnswer, func(sessionDescription string) string { return sessionDescription })
+}
+
+// signalPairWithModification performs the same signaling as signalPair, but the
+// offer is passed through modificationFunc before it is given to pcAnswer
+func signalPairWithModification(pcOffer *PeerConnection, pcAnswer *PeerConnection, modificationFunc func(string) string) error {
 	iceGatheringState := pcOffer.ICEGatheringState()
 	offerChan := make(chan SessionDescription, 1)
 
@@ -62,6 +68,7 @@ func signalPair(pcOffer *PeerConnection, pcAnswer *PeerConnection) error {
 	case <-time.After(3 * time.Second):
 		return fmt.Errorf("timed out waiting to receive offer")
 	case offer := <-offerChan:
+		offer.SDP = modificationFunc(offer.SDP)
 		if err := pcAnswer.SetRemoteDescription(offer); err != nil {
 			return err
 		}
@@ -0,0 +1,352 @@
+// Package cc provides sender side congestion control: an Estimator that turns
+// transport-wide congestion control feedback or REMB into a target bitrate, and
+// a Pacer that spaces outgoing RTP packets to that bitrate
+package cc
+
+import (
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+)
+
+const (
+	defaultInitialBitrate   = uint64(300000)
+	defaultMinBitrate       = uint64(30000)
+	defaultMaxBitrate       = uint64(2500000)
+	defaultOveruseThreshold = 25 * time.Millisecond
+
+	// Sent packets are remembered until this many newer ones have been sent
+	sentHistorySize = uint16(4096)
+
+	// ReferenceTime of transport-cc feedback is in multiples of 64ms
+	referenceTimeUnit = 64 * time.Millisecond
+
+	// Loss below lowLossRatio allows increasing, above highLossRatio the bitrate is reduced
+	lowLossRatio  = 0.02
+	highLossRatio = 0.1
+
+	// The bitrate grows by at most increaseFactor per second, and is multiplied
+	// by decreaseFactor when queuing delay builds up
+	increaseFactor = 1.08
+	decreaseFactor = 0.85
+
+	// The bitrate is not increased beyond this multiple of the rate acknowledged by the receiver
+	maxAckedRateFactor = 1.5
+)
+
+type sentPacket struct {
+	sendTime time.Time
+	size     int
+}
+
+// Estimator computes a target bitrate for everything sent over a transport.
+// Loss and queuing delay are taken from transport-wide congestion control
+// feedback, which requires every outgoing packet to carry a transport-wide
+// sequence number (see SetTransportSequenceNumber). REMB messages cap the
+// estimate, or provide it alone if the remote doesn't send transport-cc feedback.
+//
+// Estimator is safe for concurrent use.
+type Estimator struct {
+	mu sync.Mutex
+
+	minBitrate       uint64
+	maxBitrate       uint64
+	overuseThreshold time.Duration
+
+	target       uint64
+	delayBased   uint64
+	remb         uint64
+	haveFeedback bool
+	lastIncrease time.Time
+
+	nextSequenceNumber uint16
+	sent               map[uint16]sentPacket
+	haveBaseDelay      bool
+	baseDelay          time.Duration
+
+	onTargetBitrateChange func(uint64)
+}
+
+// NewEstimator creates an Estimator that starts at the initial bitrate
+func NewEstimator(opts ...EstimatorOption) *Estimator {
+	e := &Estimator{
+		minBitrate:       defaultMinBitrate,
+		maxBitrate:       defaultMaxBitrate,
+		overuseThreshold: defaultOveruseThreshold,
+		delayBased:       defaultInitialBitrate,
+		sent:             map[uint16]sentPacket{},
+	}
+	for _, o := range opts {
+		o(e)
+	}
+	e.delayBased = e.clamp(e.delayBased)
+	e.target = e.delayBased
+	return e
+}
+
+// OnTargetBitrateChange sets a handler that is called with the new target
+// bitrate in bits per second every time it changes. Encoders should be
+// reconfigured to produce at most this bitrate
+func (e *Estimator) OnTargetBitrateChange(f func(bitrate uint64)) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.onTargetBitrateChange = f
+}
+
+// TargetBitrate returns the current estimate in bits per second
+func (e *Estimator) TargetBitrate() uint64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.target
+}
+
+// SetTransportSequenceNumber writes the next transport-wide sequence number
+// into the transport-cc header extension of p, and records that p is sent now.
+// extensionID is the negotiated ID of the transport-cc extension
+func (e *Estimator) SetTransportSequenceNumber(p *rtp.Packet, extensionID uint8, now time.Time) error {
+	e.mu.Lock()
+	sequenceNumber := e.nextSequenceNumber
+	e.nextSequenceNumber++
+	e.mu.Unlock()
+
+	payload := make([]byte, 2)
+	binary.BigEndian.PutUint16(payload, sequenceNumber)
+	if err := p.SetExtension(extensionID, payload); err != nil {
+		return err
+	}
+
+	e.OnPacketSent(sequenceNumber, p.MarshalSize(), now)
+	return nil
+}
+
+// OnPacketSent records a packet carrying transport-wide sequenceNumber that is
+// size bytes large. Only needed when the header extension isn't written by
+// SetTransportSequenceNumber
+func (e *Estimator) OnPacketSent(sequenceNumber uint16, size int, now time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.sent[sequenceNumber] = sentPacket{sendTime: now, size: size}
+	delete(e.sent, sequenceNumber-sentHistorySize)
+}
+
+// OnRTCP feeds all transport-cc feedback and REMB contained in pkts to the Estimator
+func (e *Estimator) OnRTCP(pkts []rtcp.Packet, now time.Time) {
+	for _, pkt := range pkts {
+		switch p := pkt.(type) {
+		case *rtcp.TransportLayerCC:
+			e.OnTransportCC(p, now)
+		case *rtcp.ReceiverEstimatedMaximumBitrate:
+			e.OnREMB(p)
+		}
+	}
+}
+
+// OnREMB caps the target bitrate to the maximum the receiver estimated
+func (e *Estimator) OnREMB(p *rtcp.ReceiverEstimatedMaximumBitrate) {
+	e.mu.Lock()
+	e.remb = p.Bitrate
+	hdlr, bitrate, changed := e.updateTarget()
+	e.mu.Unlock()
+
+	if changed && hdlr != nil {
+		hdlr(bitrate)
+	}
+}
+
+// OnTransportCC updates the estimate from the loss and arrival times reported in p
+func (e *Estimator) OnTransportCC(p *rtcp.TransportLayerCC, now time.Time) {
+	e.mu.Lock()
+
+	received, lost := 0, 0
+	var delaySum time.Duration
+	var ackedBytes int
+	var firstArrival, lastArrival time.Duration
+
+	arrival := time.Duration(p.ReferenceTime) * referenceTimeUnit
+	deltas := p.RecvDeltas
+	sequenceNumber := p.BaseSequenceNumber
+	forEachStatus(p, func(status uint16) {
+		defer func() { sequenceNumber++ }()
+
+		if status == rtcp.TypeTCCPacketNotReceived {
+			if _, ok := e.sent[sequenceNumber]; ok {
+				lost++
+				delete(e.sent, sequenceNumber)
+			}
+			return
+		}
+
+		if (status == rtcp.TypeTCCPacketReceivedSmallDelta || status == rtcp.TypeTCCPacketReceivedLargeDelta) && len(deltas) != 0 {
+			arrival += time.Duration(deltas[0].Delta) * time.Microsecond
+			deltas = deltas[1:]
+		}
+
+		sent, ok := e.sent[sequenceNumber]
+		if !ok {
+			return
+		}
+		delete(e.sent, sequenceNumber)
+
+		// The clocks of both sides are not synchronized, only the variation of this matters
+		delay := arrival - time.Duration(sent.sendTime.UnixNano())
+		if !e.haveBaseDelay || delay < e.baseDelay {
+			e.haveBaseDelay = true
+			e.baseDelay = delay
+		}
+		delaySum += delay - e.baseDelay
+
+		if received == 0 {
+			firstArrival = arrival
+		}
+		lastArrival = arrival
+		ackedBytes += sent.size
+		received++
+	})
+
+	if received+lost == 0 {
+		e.mu.Unlock()
+		return
+	}
+	e.haveFeedback = true
+
+	lossRatio := float64(lost) / float64(received+lost)
+	var queuingDelay time.Duration
+	if received != 0 {
+		queuingDelay = delaySum / time.Duration(received)
+	}
+
+	switch {
+	case lossRatio > highLossRatio:
+		e.delayBased = uint64(float64(e.delayBased) * (1 - 0.5*lossRatio))
+		e.lastIncrease = now
+	case queuingDelay > e.overuseThreshold:
+		e.delayBased = uint64(float64(e.delayBased) * decreaseFactor)
+		e.lastIncrease = now
+	case lossRatio < lowLossRatio:
+		e.increase(now, ackedBytes, lastArrival-firstArrival)
+	}
+	e.delayBased = e.clamp(e.delayBased)
+
+	hdlr, bitrate, changed := e.updateTarget()
+	e.mu.Unlock()
+
+	if changed && hdlr != nil {
+		hdlr(bitrate)
+	}
+}
+
+// increase grows the delay based estimate by increaseFactor per second, as
+// long as it stays close to what the receiver acknowledged. Caller must hold e.mu
+func (e *Estimator) increase(now time.Time, ackedBytes int, ackedDuration time.Duration) {
+	if e.lastIncrease.IsZero() {
+		e.lastIncrease = now
+		return
+	}
+
+	elapsed := now.Sub(e.lastIncrease)
+	if elapsed > time.Second {
+		elapsed = time.Second
+	}
+	e.lastIncrease = now
+
+	increased := uint64(float64(e.delayBased) * (1 + (increaseFactor-1)*elapsed.Seconds()))
+	if ackedDuration > 0 {
+		ackedRate := uint64(float64(ackedBytes*8) / ackedDuration.Seconds())
+		if limit := uint64(float64(ackedRate) * maxAckedRateFactor); increased > limit {
+			if limit < e.delayBased {
+				return
+			}
+			increased = limit
+		}
+	}
+	e.delayBased = increased
+}
+
+// updateTarget combines the delay based estimate and REMB. Caller must hold e.mu
+func (e *Estimator) updateTarget() (func(uint64), uint64, bool) {
+	target := e.delayBased
+	switch {
+	case e.remb != 0 && !e.haveFeedback:
+		target = e.remb
+	case e.remb != 0 && e.remb < target:
+		target = e.remb
+	}
+	target = e.clamp(target)
+
+	if target == e.target {
+		return nil, target, false
+	}
+	e.target = target
+	return e.onTargetBitrateChange, target, true
+}
+
+func (e *Estimator) clamp(bitrate uint64) uint64 {
+	switch {
+	case bitrate < e.minBitrate:
+		return e.minBitrate
+	case bitrate > e.maxBitrate:
+		return e.maxBitrate
+	}
+	return bitrate
+}
+
+// forEachStatus calls f with the status of every packet described by p, in sequence number order
+func forEachStatus(p *rtcp.TransportLayerCC, f func(status uint16)) {
+	remaining := p.PacketStatusCount
+	emit := func(status uint16) {
+		if remaining == 0 {
+			return
+		}
+		remaining--
+		f(status)
+	}
+
+	for _, chunk := range p.PacketChunks {
+		switch c := chunk.(type) {
+		case *rtcp.RunLengthChunk:
+			for i := uint16(0); i < c.RunLength; i++ {
+				emit(c.PacketStatusSymbol)
+			}
+		case *rtcp.StatusVectorChunk:
+			for _, s := range c.SymbolList {
+				emit(s)
+			}
+		}
+	}
+}
+
+// EstimatorOption configures Estimator
+type EstimatorOption func(e *Estimator)
+
+// WithInitialBitrate sets the bitrate used until feedback has been received
+func WithInitialBitrate(bitrate uint64) EstimatorOption {
+	return func(e *Estimator) {
+		e.delayBased = bitrate
+	}
+}
+
+// WithMinBitrate sets the lowest bitrate the Estimator will return
+func WithMinBitrate(bitrate uint64) EstimatorOption {
+	return func(e *Estimator) {
+		e.minBitrate = bitrate
+	}
+}
+
+// WithMaxBitrate sets the highest bitrate the Estimator will return
+func WithMaxBitrate(bitrate uint64) EstimatorOption {
+	return func(e *Estimator) {
+		e.maxBitrate = bitrate
+	}
+}
+
+// WithOveruseThreshold sets how much queuing delay is tolerated before the
+// bitrate is reduced
+func WithOveruseThreshold(threshold time.Duration) EstimatorOption {
+	return func(e *Estimator) {
+		e.overuseThreshold = threshold
+	}
+}
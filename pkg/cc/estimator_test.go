@@ -0,0 +1,188 @@
+package cc
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/assert"
+)
+
+const lostPacket = time.Duration(-1)
+
+// feedback describes packets starting at base, deltas are the arrival times
+// relative to the previous packet or lostPacket
+func feedback(base uint16, deltas []time.Duration) *rtcp.TransportLayerCC {
+	fb := &rtcp.TransportLayerCC{
+		BaseSequenceNumber: base,
+		PacketStatusCount:  uint16(len(deltas)),
+	}
+	for _, d := range deltas {
+		if d == lostPacket {
+			fb.PacketChunks = append(fb.PacketChunks, &rtcp.RunLengthChunk{PacketStatusSymbol: rtcp.TypeTCCPacketNotReceived, RunLength: 1})
+			continue
+		}
+		fb.PacketChunks = append(fb.PacketChunks, &rtcp.RunLengthChunk{PacketStatusSymbol: rtcp.TypeTCCPacketReceivedSmallDelta, RunLength: 1})
+		fb.RecvDeltas = append(fb.RecvDeltas, &rtcp.RecvDelta{Type: rtcp.TypeTCCPacketReceivedSmallDelta, Delta: int64(d / time.Microsecond)})
+	}
+	return fb
+}
+
+// sendPackets records count packets of 1000 bytes sent 10ms apart
+func sendPackets(e *Estimator, base uint16, count int, start time.Time) {
+	for i := 0; i < count; i++ {
+		e.OnPacketSent(base+uint16(i), 1000, start.Add(time.Duration(i)*10*time.Millisecond))
+	}
+}
+
+func repeat(d time.Duration, count int) []time.Duration {
+	deltas := make([]time.Duration, count)
+	for i := range deltas {
+		deltas[i] = d
+	}
+	return deltas
+}
+
+func TestEstimator(t *testing.T) {
+	now := time.Now()
+
+	t.Run("Loss", func(t *testing.T) {
+		e := NewEstimator()
+		sendPackets(e, 0, 10, now)
+
+		deltas := repeat(10*time.Millisecond, 10)
+		for i := 0; i < 10; i += 2 {
+			deltas[i] = lostPacket
+		}
+		e.OnTransportCC(feedback(0, deltas), now)
+		assert.Equal(t, uint64(225000), e.TargetBitrate())
+	})
+
+	t.Run("Delay", func(t *testing.T) {
+		e := NewEstimator()
+		sendPackets(e, 0, 20, now)
+
+		// Packets arrive at the rate they are sent, which sets the base delay
+		e.OnTransportCC(feedback(0, repeat(10*time.Millisecond, 10)), now)
+		assert.Equal(t, uint64(300000), e.TargetBitrate())
+
+		// Each packet is delayed 10ms more than the one before it
+		e.OnTransportCC(feedback(10, repeat(20*time.Millisecond, 10)), now.Add(100*time.Millisecond))
+		assert.Equal(t, uint64(255000), e.TargetBitrate())
+	})
+
+	t.Run("Increase", func(t *testing.T) {
+		e := NewEstimator()
+		sendPackets(e, 0, 20, now)
+
+		e.OnTransportCC(feedback(0, repeat(10*time.Millisecond, 10)), now)
+		assert.Equal(t, uint64(300000), e.TargetBitrate())
+
+		e.OnTransportCC(feedback(10, repeat(10*time.Millisecond, 10)), now.Add(time.Second))
+		assert.Equal(t, uint64(324000), e.TargetBitrate())
+	})
+
+	t.Run("IncreaseLimitedByAckedRate", func(t *testing.T) {
+		e := NewEstimator(WithInitialBitrate(1500000))
+		sendPackets(e, 0, 20, now)
+
+		// The receiver acknowledged about 900kbps, which doesn't allow going above 1.3Mbps
+		e.OnTransportCC(feedback(0, repeat(10*time.Millisecond, 10)), now)
+		e.OnTransportCC(feedback(10, repeat(10*time.Millisecond, 10)), now.Add(time.Second))
+		assert.Equal(t, uint64(1500000), e.TargetBitrate())
+	})
+
+	t.Run("REMB", func(t *testing.T) {
+		e := NewEstimator()
+
+		// Without transport-cc feedback REMB is followed directly
+		e.OnREMB(&rtcp.ReceiverEstimatedMaximumBitrate{Bitrate: 1000000})
+		assert.Equal(t, uint64(1000000), e.TargetBitrate())
+
+		sendPackets(e, 0, 10, now)
+		e.OnRTCP([]rtcp.Packet{feedback(0, repeat(10*time.Millisecond, 10))}, now)
+		assert.Equal(t, uint64(300000), e.TargetBitrate())
+
+		e.OnRTCP([]rtcp.Packet{&rtcp.ReceiverEstimatedMaximumBitrate{Bitrate: 100000}}, now)
+		assert.Equal(t, uint64(100000), e.TargetBitrate())
+	})
+
+	t.Run("Clamp", func(t *testing.T) {
+		e := NewEstimator(WithMinBitrate(200000), WithMaxBitrate(500000))
+
+		e.OnREMB(&rtcp.ReceiverEstimatedMaximumBitrate{Bitrate: 1000000})
+		assert.Equal(t, uint64(500000), e.TargetBitrate())
+
+		e.OnREMB(&rtcp.ReceiverEstimatedMaximumBitrate{Bitrate: 1000})
+		assert.Equal(t, uint64(200000), e.TargetBitrate())
+	})
+
+	t.Run("OnTargetBitrateChange", func(t *testing.T) {
+		e := NewEstimator()
+
+		var changes []uint64
+		e.OnTargetBitrateChange(func(bitrate uint64) {
+			changes = append(changes, bitrate)
+		})
+
+		e.OnREMB(&rtcp.ReceiverEstimatedMaximumBitrate{Bitrate: 400000})
+		e.OnREMB(&rtcp.ReceiverEstimatedMaximumBitrate{Bitrate: 400000})
+		e.OnREMB(&rtcp.ReceiverEstimatedMaximumBitrate{Bitrate: 350000})
+		assert.Equal(t, []uint64{400000, 350000}, changes)
+	})
+
+	t.Run("UnknownPackets", func(t *testing.T) {
+		e := NewEstimator()
+		e.OnTransportCC(feedback(0, []time.Duration{lostPacket, lostPacket, lostPacket}), now)
+		assert.Equal(t, uint64(300000), e.TargetBitrate())
+	})
+}
+
+func TestEstimator_SetTransportSequenceNumber(t *testing.T) {
+	e := NewEstimator()
+
+	for i := 0; i < 3; i++ {
+		p := &rtp.Packet{Header: rtp.Header{Version: 2}, Payload: []byte{0x00}}
+		assert.NoError(t, e.SetTransportSequenceNumber(p, 5, time.Now()))
+
+		ext := p.GetExtension(5)
+		assert.Len(t, ext, 2)
+		assert.Equal(t, uint16(i), binary.BigEndian.Uint16(ext))
+	}
+	assert.Len(t, e.sent, 3)
+}
+
+func TestForEachStatus(t *testing.T) {
+	fb := &rtcp.TransportLayerCC{
+		PacketStatusCount: 5,
+		PacketChunks: []rtcp.PacketStatusChunk{
+			&rtcp.RunLengthChunk{PacketStatusSymbol: rtcp.TypeTCCPacketReceivedSmallDelta, RunLength: 2},
+			&rtcp.StatusVectorChunk{
+				SymbolSize: rtcp.TypeTCCSymbolSizeTwoBit,
+				SymbolList: []uint16{
+					rtcp.TypeTCCPacketNotReceived,
+					rtcp.TypeTCCPacketReceivedLargeDelta,
+					rtcp.TypeTCCPacketReceivedSmallDelta,
+					rtcp.TypeTCCPacketNotReceived,
+					rtcp.TypeTCCPacketNotReceived,
+					rtcp.TypeTCCPacketNotReceived,
+					rtcp.TypeTCCPacketNotReceived,
+				},
+			},
+		},
+	}
+
+	var statuses []uint16
+	forEachStatus(fb, func(status uint16) {
+		statuses = append(statuses, status)
+	})
+	assert.Equal(t, []uint16{
+		rtcp.TypeTCCPacketReceivedSmallDelta,
+		rtcp.TypeTCCPacketReceivedSmallDelta,
+		rtcp.TypeTCCPacketNotReceived,
+		rtcp.TypeTCCPacketReceivedLargeDelta,
+		rtcp.TypeTCCPacketReceivedSmallDelta,
+	}, statuses)
+}
@@ -0,0 +1,66 @@
+package cc
+
+import (
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v2/pkg/interceptor"
+)
+
+// transportCCURI identifies the header extension carrying transport-wide sequence numbers
+const transportCCURI = "http://www.ietf.org/id/draft-holmer-rmcat-transport-wide-cc-extensions-01"
+
+// Interceptor feeds an Estimator from the interceptor chain of a PeerConnection.
+// Packets of the streams that negotiated the transport-cc header extension get a
+// transport-wide sequence number, and the feedback and REMB that are read are
+// passed to the Estimator. An Estimator describes a single transport, so the
+// Interceptor must only be used by a single PeerConnection
+type Interceptor struct {
+	interceptor.NoOp
+	estimator *Estimator
+}
+
+// NewInterceptor creates an Interceptor for e
+func NewInterceptor(e *Estimator) *Interceptor {
+	return &Interceptor{estimator: e}
+}
+
+// BindLocalStream sets the transport-wide sequence number of the packets written
+// to streams that negotiated the transport-cc header extension
+func (i *Interceptor) BindLocalStream(info *interceptor.StreamInfo, writer interceptor.RTPWriter) interceptor.RTPWriter {
+	var extensionID uint8
+	for _, e := range info.RTPHeaderExtensions {
+		if e.URI == transportCCURI {
+			extensionID = uint8(e.ID)
+		}
+	}
+	if extensionID == 0 {
+		return writer
+	}
+
+	return interceptor.RTPWriterFunc(func(header *rtp.Header, payload []byte) (int, error) {
+		// The extensions of the caller are left untouched
+		p := &rtp.Packet{Header: *header, Payload: payload}
+		p.Extensions = append([]rtp.Extension{}, header.Extensions...)
+		if err := i.estimator.SetTransportSequenceNumber(p, extensionID, time.Now()); err != nil {
+			return 0, err
+		}
+		return writer.Write(&p.Header, p.Payload)
+	})
+}
+
+// BindRTCPReader passes the transport-cc feedback and REMB that are read to the Estimator
+func (i *Interceptor) BindRTCPReader(reader interceptor.RTCPReader) interceptor.RTCPReader {
+	return interceptor.RTCPReaderFunc(func(b []byte) (int, error) {
+		n, err := reader.Read(b)
+		if err != nil {
+			return n, err
+		}
+
+		if pkts, err := rtcp.Unmarshal(b[:n]); err == nil {
+			i.estimator.OnRTCP(pkts, time.Now())
+		}
+		return n, nil
+	})
+}
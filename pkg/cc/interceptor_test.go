@@ -0,0 +1,59 @@
+package cc
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v2/pkg/interceptor"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterceptor(t *testing.T) {
+	e := NewEstimator(WithInitialBitrate(1000000))
+	i := NewInterceptor(e)
+
+	var written []*rtp.Header
+	write := interceptor.RTPWriterFunc(func(header *rtp.Header, payload []byte) (int, error) {
+		written = append(written, header)
+		return len(payload), nil
+	})
+
+	t.Run("Stream without transport-cc", func(t *testing.T) {
+		writer := i.BindLocalStream(&interceptor.StreamInfo{SSRC: 1}, write)
+		_, err := writer.Write(&rtp.Header{SSRC: 1}, []byte{0x00})
+		assert.NoError(t, err)
+		assert.Nil(t, written[0].GetExtension(3))
+	})
+
+	t.Run("Stream with transport-cc", func(t *testing.T) {
+		writer := i.BindLocalStream(&interceptor.StreamInfo{
+			SSRC:                2,
+			RTPHeaderExtensions: []interceptor.RTPHeaderExtension{{URI: transportCCURI, ID: 3}},
+		}, write)
+
+		header := &rtp.Header{SSRC: 2}
+		for n := 0; n < 2; n++ {
+			_, err := writer.Write(header, []byte{0x00})
+			assert.NoError(t, err)
+		}
+		assert.Nil(t, header.GetExtension(3), "the header of the caller is left untouched")
+
+		for n, h := range written[1:] {
+			assert.Equal(t, uint16(n), binary.BigEndian.Uint16(h.GetExtension(3)))
+		}
+	})
+
+	t.Run("REMB", func(t *testing.T) {
+		raw, err := (&rtcp.ReceiverEstimatedMaximumBitrate{Bitrate: 500000, SSRCs: []uint32{2}}).Marshal()
+		assert.NoError(t, err)
+
+		reader := i.BindRTCPReader(interceptor.RTCPReaderFunc(func(b []byte) (int, error) {
+			return copy(b, raw), nil
+		}))
+		_, err = reader.Read(make([]byte, 1500))
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(500000), e.TargetBitrate())
+	})
+}
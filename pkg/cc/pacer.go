@@ -0,0 +1,185 @@
+package cc
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/pion/rtp"
+)
+
+const (
+	defaultPacingInterval = 5 * time.Millisecond
+	defaultPacingFactor   = 2.5
+	defaultPacerQueueSize = 1024
+)
+
+var (
+	errPacerQueueFull = errors.New("pacer queue is full")
+	errPacerClosed    = errors.New("pacer is closed")
+)
+
+// Pacer spaces out RTP packets so they leave at the target bitrate multiplied
+// by the pacing factor, instead of in bursts of whole frames
+type Pacer struct {
+	mu sync.Mutex
+
+	write        func(*rtp.Packet) error
+	interval     time.Duration
+	pacingFactor float64
+	queueSize    int
+
+	bitrate uint64
+	queue   []*rtp.Packet
+	budget  float64
+
+	onError func(error)
+
+	closeOnce sync.Once
+	closed    chan struct{}
+	done      chan struct{}
+}
+
+// NewPacer creates a Pacer that sends queued packets through write, starting
+// at the initial bitrate. Close must be called to stop the Pacer
+func NewPacer(write func(*rtp.Packet) error, opts ...PacerOption) *Pacer {
+	p := &Pacer{
+		write:        write,
+		interval:     defaultPacingInterval,
+		pacingFactor: defaultPacingFactor,
+		queueSize:    defaultPacerQueueSize,
+		bitrate:      defaultInitialBitrate,
+		closed:       make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+	for _, o := range opts {
+		o(p)
+	}
+
+	go p.run()
+	return p
+}
+
+// SetBitrate changes the bitrate packets are paced at, usually the target
+// bitrate of an Estimator
+func (p *Pacer) SetBitrate(bitrate uint64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.bitrate = bitrate
+}
+
+// OnError sets a handler that is called when write returns an error
+func (p *Pacer) OnError(f func(error)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.onError = f
+}
+
+// Enqueue adds pkt to the end of the queue
+func (p *Pacer) Enqueue(pkt *rtp.Packet) error {
+	select {
+	case <-p.closed:
+		return errPacerClosed
+	default:
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.queue) >= p.queueSize {
+		return errPacerQueueFull
+	}
+	p.queue = append(p.queue, pkt)
+	return nil
+}
+
+// Close stops the Pacer, packets that are still queued are discarded
+func (p *Pacer) Close() error {
+	p.closeOnce.Do(func() {
+		close(p.closed)
+	})
+	<-p.done
+	return nil
+}
+
+func (p *Pacer) run() {
+	defer close(p.done)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	last := time.Now()
+	for {
+		select {
+		case <-p.closed:
+			return
+		case now := <-ticker.C:
+			elapsed := now.Sub(last)
+			last = now
+			p.send(elapsed)
+		}
+	}
+}
+
+// send writes as many queued packets as the budget accumulated over elapsed allows
+func (p *Pacer) send(elapsed time.Duration) {
+	p.mu.Lock()
+
+	rate := float64(p.bitrate) * p.pacingFactor / 8
+	p.budget += rate * elapsed.Seconds()
+
+	// Don't save up budget while idle, it would be spent in a single burst
+	if maxBudget := rate * p.interval.Seconds(); len(p.queue) == 0 && p.budget > maxBudget {
+		p.budget = maxBudget
+	}
+
+	var pkts []*rtp.Packet
+	for len(p.queue) != 0 && p.budget > 0 {
+		pkt := p.queue[0]
+		p.queue[0] = nil
+		p.queue = p.queue[1:]
+
+		p.budget -= float64(pkt.MarshalSize())
+		pkts = append(pkts, pkt)
+	}
+	onError := p.onError
+	p.mu.Unlock()
+
+	for _, pkt := range pkts {
+		if err := p.write(pkt); err != nil && onError != nil {
+			onError(err)
+		}
+	}
+}
+
+// PacerOption configures Pacer
+type PacerOption func(p *Pacer)
+
+// WithPacingFactor sets the multiple of the bitrate packets are sent at, to
+// allow draining the queue faster than the encoder fills it
+func WithPacingFactor(factor float64) PacerOption {
+	return func(p *Pacer) {
+		p.pacingFactor = factor
+	}
+}
+
+// WithPacingInterval sets how often queued packets are sent
+func WithPacingInterval(interval time.Duration) PacerOption {
+	return func(p *Pacer) {
+		p.interval = interval
+	}
+}
+
+// WithQueueSize sets how many packets can be queued before Enqueue fails
+func WithQueueSize(size int) PacerOption {
+	return func(p *Pacer) {
+		p.queueSize = size
+	}
+}
+
+// WithPacerBitrate sets the bitrate used until SetBitrate is called
+func WithPacerBitrate(bitrate uint64) PacerOption {
+	return func(p *Pacer) {
+		p.bitrate = bitrate
+	}
+}
@@ -0,0 +1,130 @@
+package cc
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestPacket(sequenceNumber uint16, payloadSize int) *rtp.Packet {
+	return &rtp.Packet{
+		Header:  rtp.Header{Version: 2, SequenceNumber: sequenceNumber},
+		Payload: make([]byte, payloadSize),
+	}
+}
+
+func TestPacer(t *testing.T) {
+	t.Run("Paced", func(t *testing.T) {
+		var mu sync.Mutex
+		var sent []uint16
+		done := make(chan struct{})
+
+		// 10000 bytes per second, 10 packets of ~500 bytes take about half a second
+		p := NewPacer(func(pkt *rtp.Packet) error {
+			mu.Lock()
+			defer mu.Unlock()
+			sent = append(sent, pkt.SequenceNumber)
+			if len(sent) == 10 {
+				close(done)
+			}
+			return nil
+		}, WithPacerBitrate(80000), WithPacingFactor(1))
+
+		start := time.Now()
+		for i := uint16(0); i < 10; i++ {
+			assert.NoError(t, p.Enqueue(newTestPacket(i, 488)))
+		}
+
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			assert.Fail(t, "timed out waiting for paced packets")
+		}
+		assert.True(t, time.Since(start) > 350*time.Millisecond)
+		assert.NoError(t, p.Close())
+
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Equal(t, []uint16{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, sent)
+	})
+
+	t.Run("SetBitrate", func(t *testing.T) {
+		done := make(chan struct{})
+		count := 0
+
+		p := NewPacer(func(pkt *rtp.Packet) error {
+			count++
+			if count == 10 {
+				close(done)
+			}
+			return nil
+		}, WithPacerBitrate(8000))
+		p.SetBitrate(10000000)
+
+		start := time.Now()
+		for i := uint16(0); i < 10; i++ {
+			assert.NoError(t, p.Enqueue(newTestPacket(i, 488)))
+		}
+
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			assert.Fail(t, "timed out waiting for paced packets")
+		}
+		assert.True(t, time.Since(start) < 350*time.Millisecond)
+		assert.NoError(t, p.Close())
+	})
+
+	t.Run("QueueFull", func(t *testing.T) {
+		p := NewPacer(func(pkt *rtp.Packet) error { return nil }, WithQueueSize(2), WithPacerBitrate(0))
+
+		assert.NoError(t, p.Enqueue(newTestPacket(0, 10)))
+		assert.NoError(t, p.Enqueue(newTestPacket(1, 10)))
+		assert.Equal(t, errPacerQueueFull, p.Enqueue(newTestPacket(2, 10)))
+		assert.NoError(t, p.Close())
+	})
+
+	t.Run("Closed", func(t *testing.T) {
+		p := NewPacer(func(pkt *rtp.Packet) error { return nil })
+		assert.NoError(t, p.Close())
+		assert.NoError(t, p.Close())
+		assert.Equal(t, errPacerClosed, p.Enqueue(newTestPacket(0, 10)))
+	})
+
+	t.Run("ConcurrentClose", func(t *testing.T) {
+		p := NewPacer(func(pkt *rtp.Packet) error { return nil })
+
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				assert.NoError(t, p.Close())
+			}()
+		}
+		wg.Wait()
+	})
+
+	t.Run("OnError", func(t *testing.T) {
+		errWrite := errors.New("write failed")
+		errs := make(chan error, 1)
+
+		p := NewPacer(func(pkt *rtp.Packet) error { return errWrite })
+		p.OnError(func(err error) {
+			errs <- err
+		})
+		assert.NoError(t, p.Enqueue(newTestPacket(0, 10)))
+
+		select {
+		case err := <-errs:
+			assert.Equal(t, errWrite, err)
+		case <-time.After(5 * time.Second):
+			assert.Fail(t, "timed out waiting for error")
+		}
+		assert.NoError(t, p.Close())
+	})
+}
@@ -0,0 +1,181 @@
+package fec
+
+import (
+	"encoding/binary"
+)
+
+const (
+	defaultHistorySize = uint16(256)
+
+	// FEC packets that can't recover a packet yet are kept until their
+	// protected packets fall out of the history, or until there are too many
+	maxPendingFEC = 64
+)
+
+type fecPacket struct {
+	header  header
+	payload []byte
+}
+
+// Decoder recovers the lost packets of a single media stream from the FEC
+// packets protecting it. The last received media packets are kept, a packet
+// is recovered once the FEC packet protecting it and all the other packets it
+// protects have been received. A packet is only considered lost once a media
+// packet following it has been received, so packets arriving late are not
+// recovered needlessly.
+type Decoder struct {
+	ssrc uint32
+	size uint16
+
+	started bool
+	highest uint16
+	packets [][]byte
+	fec     []*fecPacket
+}
+
+// DecoderOption can be used to configure a Decoder
+type DecoderOption func(*Decoder)
+
+// WithHistorySize sets how many of the last media packets are kept to recover
+// the others. The default is 256 packets.
+func WithHistorySize(packets uint16) DecoderOption {
+	return func(d *Decoder) {
+		if packets != 0 {
+			d.size = packets
+		}
+	}
+}
+
+// NewDecoder constructs a new Decoder for the stream identified by ssrc
+func NewDecoder(ssrc uint32, opts ...DecoderOption) *Decoder {
+	d := &Decoder{
+		ssrc: ssrc,
+		size: defaultHistorySize,
+	}
+	for _, o := range opts {
+		o(d)
+	}
+	d.packets = make([][]byte, d.size)
+	return d
+}
+
+// Push records a received media packet, and returns the packets that could be
+// recovered with it. Packets of other SSRCs are ignored.
+func (d *Decoder) Push(packet []byte) [][]byte {
+	if len(packet) < rtpHeaderSize || binary.BigEndian.Uint32(packet[8:]) != d.ssrc {
+		return nil
+	}
+
+	d.store(append([]byte{}, packet...))
+	return d.recover()
+}
+
+// PushFEC records the payload of a received FEC packet, and returns the
+// packets that could be recovered with it
+func (d *Decoder) PushFEC(payload []byte) ([][]byte, error) {
+	f := &fecPacket{}
+	n, err := f.header.unmarshal(payload)
+	if err != nil {
+		return nil, err
+	} else if f.header.ssrc != d.ssrc {
+		return nil, nil
+	}
+	f.payload = append([]byte{}, payload[n:]...)
+
+	if len(d.fec) == maxPendingFEC {
+		d.fec = d.fec[1:]
+	}
+	d.fec = append(d.fec, f)
+	return d.recover(), nil
+}
+
+func (d *Decoder) store(packet []byte) {
+	sequenceNumber := sequenceNumberOf(packet)
+	if !d.started || isNewer(sequenceNumber, d.highest) {
+		d.started = true
+		d.highest = sequenceNumber
+	} else if d.tooOld(sequenceNumber) {
+		return
+	}
+	d.packets[sequenceNumber%d.size] = packet
+}
+
+func (d *Decoder) get(sequenceNumber uint16) []byte {
+	packet := d.packets[sequenceNumber%d.size]
+	if packet == nil || sequenceNumberOf(packet) != sequenceNumber || d.tooOld(sequenceNumber) {
+		return nil
+	}
+	return packet
+}
+
+// tooOld reports if sequenceNumber has fallen out of the history
+func (d *Decoder) tooOld(sequenceNumber uint16) bool {
+	return d.highest-sequenceNumber >= d.size && isNewer(d.highest, sequenceNumber)
+}
+
+// recover recovers the packets that are the only missing packet protected by
+// a FEC packet, until no more can be. A recovered packet can allow another FEC
+// packet to recover the packet it is still missing.
+func (d *Decoder) recover() [][]byte {
+	if !d.started {
+		return nil
+	}
+
+	var recovered [][]byte
+	for progress := true; progress; {
+		progress = false
+
+		pending := d.fec[:0]
+		for _, f := range d.fec {
+			var missing []uint16
+			tooOld := false
+			for _, sequenceNumber := range f.header.protected() {
+				if d.tooOld(sequenceNumber) {
+					tooOld = true
+					break
+				} else if d.get(sequenceNumber) == nil {
+					missing = append(missing, sequenceNumber)
+				}
+			}
+
+			switch {
+			case tooOld || len(missing) == 0:
+			case len(missing) == 1 && isNewer(d.highest, missing[0]):
+				if packet := d.recoverPacket(f, missing[0]); packet != nil {
+					d.store(packet)
+					recovered = append(recovered, packet)
+					progress = true
+				}
+			default:
+				pending = append(pending, f)
+			}
+		}
+		d.fec = pending
+	}
+	return recovered
+}
+
+// recoverPacket XORs the FEC packet with the other packets it protects
+func (d *Decoder) recoverPacket(f *fecPacket, sequenceNumber uint16) []byte {
+	h := f.header
+	payload := append([]byte{}, f.payload...)
+	for _, protected := range f.header.protected() {
+		if protected != sequenceNumber {
+			payload = xorPacket(&h, payload, d.get(protected))
+		}
+	}
+
+	length := int(h.recoveryLength)
+	if length > len(payload) {
+		return nil
+	}
+
+	packet := make([]byte, rtpHeaderSize+length)
+	packet[0] = h.recoveryBits[0]&0x3f | 0x80
+	packet[1] = h.recoveryBits[1]
+	binary.BigEndian.PutUint16(packet[2:], sequenceNumber)
+	binary.BigEndian.PutUint32(packet[4:], h.recoveryTimestamp)
+	binary.BigEndian.PutUint32(packet[8:], d.ssrc)
+	copy(packet[rtpHeaderSize:], payload[:length])
+	return packet
+}
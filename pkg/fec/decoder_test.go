@@ -0,0 +1,111 @@
+package fec
+
+import (
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecoder(t *testing.T) {
+	// Packets of various lengths, flags and extensions
+	packets := make([]*rtp.Packet, 8)
+	for i := range packets {
+		packets[i] = &rtp.Packet{
+			Header: rtp.Header{
+				Version:        2,
+				Marker:         i%3 == 0,
+				PayloadType:    96,
+				SequenceNumber: uint16(65532 + i),
+				Timestamp:      uint32(3000 * (i / 2)),
+				SSRC:           5000,
+			},
+			Payload: make([]byte, 10+i*7),
+		}
+		for j := range packets[i].Payload {
+			packets[i].Payload[j] = byte(i + j)
+		}
+		if i == 5 {
+			assert.NoError(t, packets[i].SetExtension(1, []byte{0xaa, 0xbb}))
+		}
+	}
+
+	raw := make([][]byte, len(packets))
+	encoder := NewEncoder(5000, WithProtectionRate(0.25))
+	var fec [][]byte
+	for i, p := range packets {
+		var err error
+		raw[i], err = p.Marshal()
+		assert.NoError(t, err)
+
+		payload, err := encoder.Push(&p.Header, p.Payload)
+		assert.NoError(t, err)
+		if payload != nil {
+			fec = append(fec, payload)
+		}
+	}
+	assert.Equal(t, 2, len(fec))
+
+	t.Run("Recover", func(t *testing.T) {
+		d := NewDecoder(5000)
+		var recovered [][]byte
+		for i := range raw {
+			if i == 1 || i == 6 {
+				continue
+			}
+			recovered = append(recovered, d.Push(raw[i])...)
+			if i == 3 || i == 7 {
+				r, err := d.PushFEC(fec[i/4])
+				assert.NoError(t, err)
+				recovered = append(recovered, r...)
+			}
+		}
+		assert.Equal(t, [][]byte{raw[1], raw[6]}, recovered)
+	})
+
+	t.Run("LastOfGroup", func(t *testing.T) {
+		// The packet is only recovered once it is known to be lost
+		d := NewDecoder(5000)
+		for i := 0; i < 3; i++ {
+			assert.Empty(t, d.Push(raw[i]))
+		}
+		r, err := d.PushFEC(fec[0])
+		assert.NoError(t, err)
+		assert.Empty(t, r)
+
+		assert.Equal(t, [][]byte{raw[3]}, d.Push(raw[4]))
+		assert.Empty(t, d.Push(raw[3]))
+	})
+
+	t.Run("TwoLost", func(t *testing.T) {
+		d := NewDecoder(5000)
+		for _, i := range []int{0, 3, 4, 5, 6, 7} {
+			assert.Empty(t, d.Push(raw[i]))
+		}
+		r, err := d.PushFEC(fec[0])
+		assert.NoError(t, err)
+		assert.Empty(t, r)
+	})
+
+	t.Run("TooOld", func(t *testing.T) {
+		d := NewDecoder(5000, WithHistorySize(4))
+		for _, i := range []int{0, 2, 3, 4, 5, 6, 7} {
+			assert.Empty(t, d.Push(raw[i]))
+		}
+		r, err := d.PushFEC(fec[0])
+		assert.NoError(t, err)
+		assert.Empty(t, r)
+		assert.Empty(t, d.fec)
+	})
+
+	t.Run("OtherSSRC", func(t *testing.T) {
+		d := NewDecoder(6000)
+		assert.Empty(t, d.Push(raw[0]))
+		r, err := d.PushFEC(fec[0])
+		assert.NoError(t, err)
+		assert.Empty(t, r)
+
+		_, err = d.PushFEC([]byte{0x00})
+		assert.Error(t, err)
+	})
+}
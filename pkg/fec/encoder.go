@@ -0,0 +1,115 @@
+package fec
+
+import (
+	"encoding/binary"
+	"math"
+
+	"github.com/pion/rtp"
+)
+
+const defaultProtectionRate = 0.1
+
+// Encoder generates the FEC packets protecting the packets of a single media
+// stream. Consecutive packets are protected in groups, and a FEC packet is
+// generated once every packet of a group has been pushed. A single packet
+// lost in a group can be recovered, the protection rate trades bandwidth for
+// resilience to bursts of losses.
+type Encoder struct {
+	ssrc      uint32
+	groupSize int
+
+	started         bool
+	lastSequence    uint16
+	sequenceNumbers []uint16
+	packets         [][]byte
+}
+
+// EncoderOption can be used to configure an Encoder
+type EncoderOption func(*Encoder)
+
+// WithProtectionRate sets the number of FEC packets generated per media
+// packet, between 0 and 1. A FEC packet protects round(1/rate) media packets,
+// up to MaxGroupSize. The default is 0.1, a FEC packet every 10 media packets.
+func WithProtectionRate(rate float64) EncoderOption {
+	return func(e *Encoder) {
+		if rate <= 0 {
+			return
+		}
+		groupSize := int(math.Round(1 / rate))
+		if groupSize < 1 {
+			groupSize = 1
+		} else if groupSize > MaxGroupSize {
+			groupSize = MaxGroupSize
+		}
+		e.groupSize = groupSize
+	}
+}
+
+// NewEncoder constructs a new Encoder protecting the stream identified by ssrc
+func NewEncoder(ssrc uint32, opts ...EncoderOption) *Encoder {
+	e := &Encoder{ssrc: ssrc}
+	WithProtectionRate(defaultProtectionRate)(e)
+	for _, o := range opts {
+		o(e)
+	}
+	return e
+}
+
+// Push adds a media packet to the group being protected, and returns the
+// payload of the FEC packet protecting the group once it is complete, nil
+// otherwise. Packets of other SSRCs and retransmissions are ignored.
+func (e *Encoder) Push(header *rtp.Header, payload []byte) ([]byte, error) {
+	if header.SSRC != e.ssrc || (e.started && !isNewer(header.SequenceNumber, e.lastSequence)) {
+		return nil, nil
+	}
+
+	packet := rtp.Packet{Header: *header, Payload: payload}
+	raw, err := packet.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	e.started = true
+	e.lastSequence = header.SequenceNumber
+
+	// A gap in the sequence numbers too large for the packet mask ends the group early
+	var fec []byte
+	if len(e.packets) != 0 && header.SequenceNumber-e.sequenceNumbers[0] >= MaxGroupSize {
+		fec = e.protect()
+	}
+
+	e.sequenceNumbers = append(e.sequenceNumbers, header.SequenceNumber)
+	e.packets = append(e.packets, raw)
+	if len(e.packets) == e.groupSize {
+		fec = e.protect()
+	}
+	return fec, nil
+}
+
+// protect returns the payload of the FEC packet protecting the current group
+// and starts a new one
+func (e *Encoder) protect() []byte {
+	base := e.sequenceNumbers[0]
+	h := &header{
+		ssrc:                 e.ssrc,
+		sequenceNumberBase:   base,
+		protectedPacketsMask: make([]bool, e.sequenceNumbers[len(e.sequenceNumbers)-1]-base+1),
+	}
+
+	var payload []byte
+	for i, packet := range e.packets {
+		h.protectedPacketsMask[e.sequenceNumbers[i]-base] = true
+		payload = xorPacket(h, payload, packet)
+	}
+
+	e.sequenceNumbers, e.packets = nil, nil
+	return append(h.marshal(), payload...)
+}
+
+// isNewer reports if sequence number a comes after b, taking wrap-around into account
+func isNewer(a, b uint16) bool {
+	return a != b && a-b < math.MaxUint16/2
+}
+
+func sequenceNumberOf(packet []byte) uint16 {
+	return binary.BigEndian.Uint16(packet[2:])
+}
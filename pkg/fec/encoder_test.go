@@ -0,0 +1,58 @@
+package fec
+
+import (
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncoder(t *testing.T) {
+	push := func(e *Encoder, sequenceNumber uint16) []byte {
+		fec, err := e.Push(&rtp.Header{Version: 2, SSRC: 5000, SequenceNumber: sequenceNumber}, []byte{0x01})
+		assert.NoError(t, err)
+		return fec
+	}
+
+	t.Run("ProtectionRate", func(t *testing.T) {
+		for rate, groupSize := range map[float64]int{0: 10, 0.25: 4, 1: 1, 0.001: MaxGroupSize} {
+			e := NewEncoder(5000, WithProtectionRate(rate))
+			for i := 1; i < groupSize; i++ {
+				assert.Nil(t, push(e, uint16(i)))
+			}
+
+			fec := push(e, uint16(groupSize))
+			h := &header{}
+			_, err := h.unmarshal(fec)
+			assert.NoError(t, err)
+			assert.Equal(t, uint16(1), h.sequenceNumberBase)
+			assert.Equal(t, groupSize, len(h.protected()))
+		}
+	})
+
+	t.Run("Ignored", func(t *testing.T) {
+		e := NewEncoder(5000, WithProtectionRate(0.5))
+		assert.Nil(t, push(e, 10))
+		assert.Nil(t, push(e, 10))
+		assert.Nil(t, push(e, 9))
+
+		fec, err := e.Push(&rtp.Header{Version: 2, SSRC: 6000, SequenceNumber: 11}, []byte{0x01})
+		assert.NoError(t, err)
+		assert.Nil(t, fec)
+
+		assert.NotNil(t, push(e, 11))
+	})
+
+	t.Run("Gap", func(t *testing.T) {
+		e := NewEncoder(5000, WithProtectionRate(0.25))
+		assert.Nil(t, push(e, 65535))
+		assert.Nil(t, push(e, 0))
+
+		// The group can't span more than the packet mask
+		fec := push(e, MaxGroupSize)
+		h := &header{}
+		_, err := h.unmarshal(fec)
+		assert.NoError(t, err)
+		assert.Equal(t, []uint16{65535, 0}, h.protected())
+	})
+}
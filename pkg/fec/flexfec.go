@@ -0,0 +1,151 @@
+// Package fec implements the forward error correction of FlexFEC
+// (draft-ietf-payload-flexible-fec-scheme-03), as negotiated by browsers with
+// the flexfec-03 codec. A FEC packet is the XOR of a group of media packets,
+// any single packet of the group can be recovered from the others.
+package fec
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	rtpHeaderSize = 12
+
+	// Fixed header of a FEC packet, followed by the SSRC and the sequence
+	// number base of the protected stream
+	headerSize = 12 + 4 + 2
+
+	// MaxGroupSize is the largest number of media packets a FEC packet can
+	// protect, the sequence numbers are announced by a mask of up to 109 bits
+	MaxGroupSize = 48
+)
+
+// The packet mask is split in chunks, the k bit of a chunk is set if it is the last one
+var maskChunkBits = []int{15, 31, 63}
+
+// header is the FlexFEC header protecting the media packets of a single SSRC
+type header struct {
+	// XOR of the protected packets' first two bytes, length of the data
+	// following the fixed RTP header and timestamp
+	recoveryBits      [2]byte
+	recoveryLength    uint16
+	recoveryTimestamp uint32
+
+	ssrc                 uint32
+	sequenceNumberBase   uint16
+	protectedPacketsMask []bool
+}
+
+// protected returns the sequence numbers of the protected packets
+func (h *header) protected() []uint16 {
+	var sequenceNumbers []uint16
+	for i, protected := range h.protectedPacketsMask {
+		if protected {
+			sequenceNumbers = append(sequenceNumbers, h.sequenceNumberBase+uint16(i))
+		}
+	}
+	return sequenceNumbers
+}
+
+func (h *header) marshal() []byte {
+	chunks := 1
+	for maskLen := maskChunkBits[0]; maskLen < len(h.protectedPacketsMask); maskLen += maskChunkBits[chunks-1] {
+		chunks++
+	}
+
+	b := make([]byte, headerSize, headerSize+2+4+8)
+	// R and F are zero, the mask is flexible and the packet not retransmitted
+	b[0] = h.recoveryBits[0] & 0x3f
+	b[1] = h.recoveryBits[1]
+	binary.BigEndian.PutUint16(b[2:], h.recoveryLength)
+	binary.BigEndian.PutUint32(b[4:], h.recoveryTimestamp)
+	b[8] = 1 // SSRCCount
+	binary.BigEndian.PutUint32(b[12:], h.ssrc)
+	binary.BigEndian.PutUint16(b[16:], h.sequenceNumberBase)
+
+	// The chunks are 16, 32 and 64 bits, each led by its k bit
+	mask := h.protectedPacketsMask
+	for chunk := 0; chunk < chunks; chunk++ {
+		bits := maskChunkBits[chunk]
+		var value uint64
+		if chunk == chunks-1 {
+			value = 1 << uint(bits)
+		}
+		for i := 0; i < bits && i < len(mask); i++ {
+			if mask[i] {
+				value |= 1 << uint(bits-1-i)
+			}
+		}
+		if len(mask) > bits {
+			mask = mask[bits:]
+		} else {
+			mask = nil
+		}
+
+		for shift := bits + 1 - 8; shift >= 0; shift -= 8 {
+			b = append(b, byte(value>>uint(shift)))
+		}
+	}
+	return b
+}
+
+// unmarshal parses the header of a FEC packet and returns the size of the header
+func (h *header) unmarshal(b []byte) (int, error) {
+	if len(b) < headerSize+2 {
+		return 0, fmt.Errorf("FlexFEC packet too short: %d", len(b))
+	}
+	if b[0]&0x80 != 0 {
+		return 0, fmt.Errorf("FlexFEC retransmissions are not supported")
+	} else if b[0]&0x40 != 0 {
+		return 0, fmt.Errorf("FlexFEC fixed packet masks are not supported")
+	} else if b[8] != 1 {
+		return 0, fmt.Errorf("FlexFEC packets protecting %d SSRCs are not supported", b[8])
+	}
+
+	h.recoveryBits = [2]byte{b[0], b[1]}
+	h.recoveryLength = binary.BigEndian.Uint16(b[2:])
+	h.recoveryTimestamp = binary.BigEndian.Uint32(b[4:])
+	h.ssrc = binary.BigEndian.Uint32(b[12:])
+	h.sequenceNumberBase = binary.BigEndian.Uint16(b[16:])
+	h.protectedPacketsMask = nil
+
+	offset := headerSize
+	for _, bits := range maskChunkBits {
+		size := (bits + 1) / 8
+		if len(b) < offset+size {
+			return 0, fmt.Errorf("FlexFEC packet mask is truncated")
+		}
+
+		var value uint64
+		for _, c := range b[offset : offset+size] {
+			value = value<<8 | uint64(c)
+		}
+		offset += size
+
+		for i := 0; i < bits; i++ {
+			h.protectedPacketsMask = append(h.protectedPacketsMask, value&(1<<uint(bits-1-i)) != 0)
+		}
+		if value&(1<<uint(bits)) != 0 {
+			return offset, nil
+		}
+	}
+	return 0, fmt.Errorf("FlexFEC packet mask has no last chunk")
+}
+
+// xorPacket folds the raw RTP packet into the recovery fields and payload
+func xorPacket(h *header, payload []byte, packet []byte) []byte {
+	h.recoveryBits[0] ^= packet[0]
+	h.recoveryBits[1] ^= packet[1]
+	h.recoveryLength ^= uint16(len(packet) - rtpHeaderSize)
+	h.recoveryTimestamp ^= binary.BigEndian.Uint32(packet[4:])
+
+	data := packet[rtpHeaderSize:]
+	if len(payload) < len(data) {
+		payload = append(payload, make([]byte, len(data)-len(payload))...)
+	}
+	for i := range data {
+		payload[i] ^= data[i]
+	}
+	return payload
+}
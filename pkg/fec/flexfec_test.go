@@ -0,0 +1,70 @@
+package fec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHeader(t *testing.T) {
+	for _, test := range []struct {
+		name      string
+		protected []uint16
+		maskSize  int
+	}{
+		{"OneChunk", []uint16{0, 1, 14}, 15},
+		{"TwoChunks", []uint16{0, 15, 45}, 46},
+		{"ThreeChunks", []uint16{0, 46, 47}, 109},
+	} {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			mask := make([]bool, test.protected[len(test.protected)-1]+1)
+			for _, i := range test.protected {
+				mask[i] = true
+			}
+
+			h := &header{
+				recoveryBits:         [2]byte{0x12, 0x34},
+				recoveryLength:       0x5678,
+				recoveryTimestamp:    0x9abcdef0,
+				ssrc:                 5000,
+				sequenceNumberBase:   65530,
+				protectedPacketsMask: mask,
+			}
+			raw := h.marshal()
+
+			parsed := &header{}
+			n, err := parsed.unmarshal(append(raw, 0xff))
+			assert.NoError(t, err)
+			assert.Equal(t, len(raw), n)
+			assert.Equal(t, test.maskSize, len(parsed.protectedPacketsMask))
+			assert.Equal(t, h.recoveryBits, parsed.recoveryBits)
+			assert.Equal(t, h.recoveryLength, parsed.recoveryLength)
+			assert.Equal(t, h.recoveryTimestamp, parsed.recoveryTimestamp)
+			assert.Equal(t, h.ssrc, parsed.ssrc)
+
+			var expected []uint16
+			for _, i := range test.protected {
+				expected = append(expected, 65530+i)
+			}
+			assert.Equal(t, expected, parsed.protected())
+		})
+	}
+
+	t.Run("Invalid", func(t *testing.T) {
+		raw := (&header{ssrc: 5000, protectedPacketsMask: []bool{true}}).marshal()
+
+		_, err := (&header{}).unmarshal(raw[:headerSize])
+		assert.Error(t, err)
+
+		retransmission := append([]byte{}, raw...)
+		retransmission[0] |= 0x80
+		_, err = (&header{}).unmarshal(retransmission)
+		assert.Error(t, err)
+
+		unterminated := append([]byte{}, raw...)
+		unterminated[headerSize] &^= 0x80
+		_, err = (&header{}).unmarshal(unterminated)
+		assert.Error(t, err)
+	})
+}
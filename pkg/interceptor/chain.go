@@ -0,0 +1,73 @@
+package interceptor
+
+import (
+	"github.com/pion/webrtc/v2/internal/util"
+)
+
+// Chain is an Interceptor made of several others. The first one is the
+// closest to the network: it wraps the transport, and each of the following
+// wraps the previous one, so the last one is the first to see the packets
+// written by the application and the last to see the packets it reads
+type Chain struct {
+	interceptors []Interceptor
+}
+
+// NewChain creates a Chain of interceptors, in order from the network
+func NewChain(interceptors []Interceptor) *Chain {
+	return &Chain{interceptors: interceptors}
+}
+
+// BindRTCPReader binds reader to every interceptor of the Chain
+func (c *Chain) BindRTCPReader(reader RTCPReader) RTCPReader {
+	for _, i := range c.interceptors {
+		reader = i.BindRTCPReader(reader)
+	}
+	return reader
+}
+
+// BindRTCPWriter binds writer to every interceptor of the Chain
+func (c *Chain) BindRTCPWriter(writer RTCPWriter) RTCPWriter {
+	for _, i := range c.interceptors {
+		writer = i.BindRTCPWriter(writer)
+	}
+	return writer
+}
+
+// BindLocalStream binds writer to every interceptor of the Chain
+func (c *Chain) BindLocalStream(info *StreamInfo, writer RTPWriter) RTPWriter {
+	for _, i := range c.interceptors {
+		writer = i.BindLocalStream(info, writer)
+	}
+	return writer
+}
+
+// UnbindLocalStream unbinds info from every interceptor of the Chain
+func (c *Chain) UnbindLocalStream(info *StreamInfo) {
+	for _, i := range c.interceptors {
+		i.UnbindLocalStream(info)
+	}
+}
+
+// BindRemoteStream binds reader to every interceptor of the Chain
+func (c *Chain) BindRemoteStream(info *StreamInfo, reader RTPReader) RTPReader {
+	for _, i := range c.interceptors {
+		reader = i.BindRemoteStream(info, reader)
+	}
+	return reader
+}
+
+// UnbindRemoteStream unbinds info from every interceptor of the Chain
+func (c *Chain) UnbindRemoteStream(info *StreamInfo) {
+	for _, i := range c.interceptors {
+		i.UnbindRemoteStream(info)
+	}
+}
+
+// Close closes every interceptor of the Chain
+func (c *Chain) Close() error {
+	var closeErrs []error
+	for _, i := range c.interceptors {
+		closeErrs = append(closeErrs, i.Close())
+	}
+	return util.FlattenErrs(closeErrs)
+}
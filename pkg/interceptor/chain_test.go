@@ -0,0 +1,110 @@
+package interceptor
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/assert"
+)
+
+// appender appends its name to the payload of the written packets, and to
+// what is read
+type appender struct {
+	NoOp
+	name   byte
+	closed bool
+}
+
+func (a *appender) BindLocalStream(info *StreamInfo, writer RTPWriter) RTPWriter {
+	return RTPWriterFunc(func(header *rtp.Header, payload []byte) (int, error) {
+		return writer.Write(header, append(payload, a.name))
+	})
+}
+
+func (a *appender) BindRemoteStream(info *StreamInfo, reader RTPReader) RTPReader {
+	return RTPReaderFunc(func(b []byte) (int, error) {
+		n, err := reader.Read(b)
+		if err != nil {
+			return n, err
+		}
+		b[n] = a.name
+		return n + 1, nil
+	})
+}
+
+func (a *appender) Close() error {
+	a.closed = true
+	return nil
+}
+
+func TestChain(t *testing.T) {
+	first, second := &appender{name: '1'}, &appender{name: '2'}
+	chain := NewChain([]Interceptor{first, second})
+	info := &StreamInfo{SSRC: 5000}
+
+	var written []byte
+	writer := chain.BindLocalStream(info, RTPWriterFunc(func(header *rtp.Header, payload []byte) (int, error) {
+		written = payload
+		return len(payload), nil
+	}))
+	_, err := writer.Write(&rtp.Header{}, []byte{'a'})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("a21"), written, "the first interceptor is the closest to the network")
+
+	reader := chain.BindRemoteStream(info, RTPReaderFunc(func(b []byte) (int, error) {
+		return copy(b, "a"), nil
+	}))
+	b := make([]byte, 8)
+	n, err := reader.Read(b)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("a12"), b[:n])
+
+	var rtcpWritten []rtcp.Packet
+	rtcpWriter := chain.BindRTCPWriter(RTCPWriterFunc(func(pkts []rtcp.Packet) (int, error) {
+		rtcpWritten = pkts
+		return 0, nil
+	}))
+	_, err = rtcpWriter.Write([]rtcp.Packet{&rtcp.PictureLossIndication{MediaSSRC: 5000}})
+	assert.NoError(t, err)
+	assert.Equal(t, []rtcp.Packet{&rtcp.PictureLossIndication{MediaSSRC: 5000}}, rtcpWritten)
+
+	assert.NoError(t, chain.Close())
+	assert.True(t, first.closed)
+	assert.True(t, second.closed)
+}
+
+func TestRegistry(t *testing.T) {
+	t.Run("Build", func(t *testing.T) {
+		built := 0
+		r := Registry{}
+		r.Add(func() (Interceptor, error) {
+			built++
+			return &NoOp{}, nil
+		})
+
+		for i := 0; i < 2; i++ {
+			_, err := r.Build()
+			assert.NoError(t, err)
+		}
+		assert.Equal(t, 2, built, "every Build creates new interceptors")
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		errFactory := errors.New("factory failed")
+		built := &appender{}
+
+		r := Registry{}
+		r.Add(func() (Interceptor, error) {
+			return built, nil
+		})
+		r.Add(func() (Interceptor, error) {
+			return nil, errFactory
+		})
+
+		_, err := r.Build()
+		assert.Equal(t, errFactory, err)
+		assert.True(t, built.closed, "interceptors built before the error are closed")
+	})
+}
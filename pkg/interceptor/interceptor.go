@@ -0,0 +1,164 @@
+// Package interceptor provides a chain of middlewares that see every RTP and
+// RTCP packet sent or received by a PeerConnection. Interceptors can inspect,
+// modify, drop or generate packets, which allows metrics, FEC or encryption
+// experiments without forking the media pipeline
+package interceptor
+
+import (
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+)
+
+// Interceptor wraps the readers and writers of the RTP streams and of RTCP.
+// Each Bind method receives the next element of the pipeline and returns the
+// one that is used instead, returning it unchanged leaves the packets untouched
+type Interceptor interface {
+	// BindRTCPReader is called for every RTCP reader, the RTPSenders and
+	// RTPReceivers each read the RTCP about their own SSRC
+	BindRTCPReader(reader RTCPReader) RTCPReader
+
+	// BindRTCPWriter is called once, every RTCP packet sent is written to it
+	BindRTCPWriter(writer RTCPWriter) RTCPWriter
+
+	// BindLocalStream is called when a RTPSender starts sending
+	BindLocalStream(info *StreamInfo, writer RTPWriter) RTPWriter
+
+	// UnbindLocalStream is called when the RTPSender of info is stopped
+	UnbindLocalStream(info *StreamInfo)
+
+	// BindRemoteStream is called when a RTPReceiver starts receiving a SSRC
+	BindRemoteStream(info *StreamInfo, reader RTPReader) RTPReader
+
+	// UnbindRemoteStream is called when the RTPReceiver of info is stopped
+	UnbindRemoteStream(info *StreamInfo)
+
+	// Close is called when the transport the Interceptor was built for is
+	// stopped, goroutines started by the Interceptor must be stopped
+	Close() error
+}
+
+// RTPWriter writes a RTP packet
+type RTPWriter interface {
+	Write(header *rtp.Header, payload []byte) (int, error)
+}
+
+// RTPReader reads a marshaled RTP packet into b
+type RTPReader interface {
+	Read(b []byte) (int, error)
+}
+
+// RTCPWriter writes RTCP packets as a compound packet
+type RTCPWriter interface {
+	Write(pkts []rtcp.Packet) (int, error)
+}
+
+// RTCPReader reads a marshaled RTCP compound packet into b
+type RTCPReader interface {
+	Read(b []byte) (int, error)
+}
+
+// RTPWriterFunc is an adapter for RTPWriter
+type RTPWriterFunc func(header *rtp.Header, payload []byte) (int, error)
+
+// Write calls f(header, payload)
+func (f RTPWriterFunc) Write(header *rtp.Header, payload []byte) (int, error) {
+	return f(header, payload)
+}
+
+// RTPReaderFunc is an adapter for RTPReader
+type RTPReaderFunc func(b []byte) (int, error)
+
+// Read calls f(b)
+func (f RTPReaderFunc) Read(b []byte) (int, error) {
+	return f(b)
+}
+
+// RTCPWriterFunc is an adapter for RTCPWriter
+type RTCPWriterFunc func(pkts []rtcp.Packet) (int, error)
+
+// Write calls f(pkts)
+func (f RTCPWriterFunc) Write(pkts []rtcp.Packet) (int, error) {
+	return f(pkts)
+}
+
+// RTCPReaderFunc is an adapter for RTCPReader
+type RTCPReaderFunc func(b []byte) (int, error)
+
+// Read calls f(b)
+func (f RTCPReaderFunc) Read(b []byte) (int, error) {
+	return f(b)
+}
+
+// RTCPFeedback is a feedback mechanism negotiated for a stream, such as
+// Type "nack" with an empty Parameter for Generic NACK
+type RTCPFeedback struct {
+	Type      string
+	Parameter string
+}
+
+// RTPHeaderExtension is a header extension negotiated for a stream
+type RTPHeaderExtension struct {
+	URI string
+	ID  int
+}
+
+// StreamInfo describes a RTP stream. The codec fields of a remote stream
+// describe the codec the remote prefers among the negotiated ones, the payload
+// type of each packet identifies the codec it was actually sent with. When a
+// renegotiation changes the payload types of a local stream, it is unbound and
+// bound again with a new StreamInfo
+type StreamInfo struct {
+	SSRC                uint32
+	PayloadType         uint8
+	MimeType            string
+	ClockRate           uint32
+	Channels            uint16
+	SDPFmtpLine         string
+	RTCPFeedback        []RTCPFeedback
+	RTPHeaderExtensions []RTPHeaderExtension
+
+	// Retransmissions are sent with RTXPayloadType on RTXSSRC when RTX has
+	// been negotiated, RTXPayloadType is zero otherwise
+	RTXSSRC        uint32
+	RTXPayloadType uint8
+
+	// FEC packets protecting the stream are sent with FECPayloadType on
+	// FECSSRC when FlexFEC has been negotiated, FECPayloadType is zero otherwise
+	FECSSRC        uint32
+	FECPayloadType uint8
+}
+
+// NoOp is an Interceptor that leaves every packet untouched, it can be
+// embedded to only implement some of the methods
+type NoOp struct{}
+
+// BindRTCPReader returns reader
+func (NoOp) BindRTCPReader(reader RTCPReader) RTCPReader {
+	return reader
+}
+
+// BindRTCPWriter returns writer
+func (NoOp) BindRTCPWriter(writer RTCPWriter) RTCPWriter {
+	return writer
+}
+
+// BindLocalStream returns writer
+func (NoOp) BindLocalStream(info *StreamInfo, writer RTPWriter) RTPWriter {
+	return writer
+}
+
+// UnbindLocalStream does nothing
+func (NoOp) UnbindLocalStream(info *StreamInfo) {}
+
+// BindRemoteStream returns reader
+func (NoOp) BindRemoteStream(info *StreamInfo, reader RTPReader) RTPReader {
+	return reader
+}
+
+// UnbindRemoteStream does nothing
+func (NoOp) UnbindRemoteStream(info *StreamInfo) {}
+
+// Close does nothing
+func (NoOp) Close() error {
+	return nil
+}
@@ -0,0 +1,31 @@
+package interceptor
+
+// Factory creates an Interceptor. Interceptors keep state about the streams
+// of a single transport, so a new one is created for every PeerConnection
+type Factory func() (Interceptor, error)
+
+// Registry holds the factories of the interceptors to build for every
+// PeerConnection, see webrtc.WithInterceptorRegistry
+type Registry struct {
+	factories []Factory
+}
+
+// Add appends an interceptor to the Registry, interceptors added later are
+// further from the network
+func (r *Registry) Add(f Factory) {
+	r.factories = append(r.factories, f)
+}
+
+// Build creates a Chain of the interceptors of the Registry
+func (r *Registry) Build() (*Chain, error) {
+	interceptors := make([]Interceptor, 0, len(r.factories))
+	for _, f := range r.factories {
+		i, err := f()
+		if err != nil {
+			_ = NewChain(interceptors).Close()
+			return nil, err
+		}
+		interceptors = append(interceptors, i)
+	}
+	return NewChain(interceptors), nil
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
+	"io/ioutil"
 )
 
 const (
@@ -87,15 +88,18 @@ func (i *IVFReader) ParseNextFrame() ([]byte, *IVFFrameHeader, error) {
 		Timestamp: binary.LittleEndian.Uint64(buffer[4:12]),
 	}
 
-	payload := make([]byte, header.FrameSize)
-	bytesRead, err = io.ReadFull(i.stream, payload)
-	if err == io.ErrUnexpectedEOF {
-		return nil, nil, fmt.Errorf("incomplete frame data")
-	} else if err != nil {
+	// FrameSize is not trusted to allocate the payload up front, a corrupt
+	// header would otherwise allocate up to 4GB
+	payload, err := ioutil.ReadAll(io.LimitReader(i.stream, int64(header.FrameSize)))
+	if err != nil {
 		return nil, nil, err
+	} else if len(payload) == 0 && header.FrameSize != 0 {
+		return nil, nil, io.EOF
+	} else if len(payload) != int(header.FrameSize) {
+		return nil, nil, fmt.Errorf("incomplete frame data")
 	}
 
-	i.bytesReadSuccesfully += int64(headerBytesRead) + int64(bytesRead)
+	i.bytesReadSuccesfully += int64(headerBytesRead) + int64(len(payload))
 	return payload, header, nil
 }
 
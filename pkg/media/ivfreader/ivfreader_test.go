@@ -153,6 +153,28 @@ func TestIVFReader_ParseIncompleteFramePayload(t *testing.T) {
 	assert.Equal(fmt.Errorf("incomplete frame data"), err)
 }
 
+func TestIVFReader_ParseOversizedFrame(t *testing.T) {
+	assert := assert.New(t)
+
+	// frame with header defining the maximum frameSize
+	// but only 2 bytes available
+	oversizedFrame := []byte{
+		0xFF, 0xFF, 0xFF, 0xFF, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0xDE, 0xAD,
+	}
+
+	ivf := buildIVFContainer(&oversizedFrame)
+	reader, _, err := NewWith(ivf)
+	assert.Nil(err, "IVFReader should be created")
+	assert.NotNil(reader, "Reader shouldn't be nil")
+
+	payload, header, err := reader.ParseNextFrame()
+
+	assert.Nil(payload, "Incomplete payload should be nil")
+	assert.Nil(header, "Header should be nil")
+	assert.Equal(fmt.Errorf("incomplete frame data"), err)
+}
+
 func TestIVFReader_EOFWhenNoFramesLeft(t *testing.T) {
 	assert := assert.New(t)
 
@@ -11,6 +11,16 @@ import (
 type Sample struct {
 	Data    []byte
 	Samples uint32
+
+	// Duration is the playback duration of the media. Samples is computed
+	// from it when zero, and a RealtimeWriter waits it before the next Sample
+	Duration time.Duration
+
+	// PrevDroppedPackets is the number of RTP packets that were lost, or
+	// dropped because they arrived too late, between the previous Sample and
+	// this one. Audio decoders can use it to run packet loss concealment
+	// instead of silently skipping time
+	PrevDroppedPackets uint16
 }
 
 // NSamples calculates the number of samples in media of length d with sampling frequency f.
@@ -20,6 +30,11 @@ func NSamples(d time.Duration, freq int) uint32 {
 	return uint32(time.Duration(freq) * d / time.Second)
 }
 
+// SampleWriter writes whole encoded frames, which it packetizes and sends
+type SampleWriter interface {
+	WriteSample(s Sample) error
+}
+
 // Writer defines an interface to handle
 // the creation of media files
 type Writer interface {
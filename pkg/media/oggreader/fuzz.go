@@ -0,0 +1,30 @@
+// +build gofuzz
+
+package oggreader
+
+import (
+	"bytes"
+	"io"
+)
+
+// Fuzz implements a randomized fuzz test of the Ogg reader using go-fuzz,
+// see github.com/pion/webrtc/v2 Fuzz for how to run it. The seeds in
+// testdata/fuzz were written by the oggwriter package.
+func Fuzz(data []byte) int {
+	r, _, err := NewWith(bytes.NewReader(data))
+	if err != nil {
+		return 0
+	}
+
+	for {
+		_, _, err := r.ParseNextPacket()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0
+		}
+	}
+
+	return 1
+}
@@ -0,0 +1,291 @@
+// Package oggreader implements the Ogg media container reader
+package oggreader
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+)
+
+const (
+	pageHeaderTypeBeginningOfStream = 0x02
+	pageHeaderSignature             = "OggS"
+	pageHeaderSize                  = 27
+
+	idPageSignature      = "OpusHead"
+	idPagePayloadSize    = 19
+	commentPageSignature = "OpusTags"
+)
+
+// OggReader is used to read Ogg files and return page payloads or Opus packets
+type OggReader struct {
+	stream               io.Reader
+	bytesReadSuccesfully int64
+	checksumTable        *[256]uint32
+
+	// The packets of the last page not returned by ParseNextPacket yet, and
+	// the start of a packet that continues on the next page
+	packets [][]byte
+	partial []byte
+}
+
+// OggHeader is the metadata from the ID header of an Ogg Opus stream
+// https://tools.ietf.org/html/rfc7845.html#section-5.1
+type OggHeader struct {
+	Version    uint8
+	Channels   uint8
+	PreSkip    uint16
+	SampleRate uint32
+	OutputGain uint16
+	ChannelMap uint8
+}
+
+// OggPageHeader is the metadata of an Ogg page
+// https://tools.ietf.org/html/rfc3533#section-6
+type OggPageHeader struct {
+	// GranulePosition is the number of samples at 48kHz decoded once the
+	// page is, the difference with the previous page gives its duration
+	GranulePosition uint64
+
+	sig           string // 0-3
+	version       uint8  // 4
+	headerType    uint8  // 5
+	serial        uint32 // 14-17
+	index         uint32 // 18-21
+	segmentsCount uint8  // 26
+}
+
+// NewWith returns a new Ogg reader and the Ogg Opus header of the stream
+// with an io.Reader input. The ID and comment header pages are read, so
+// ParseNextPage returns the audio pages.
+func NewWith(in io.Reader) (*OggReader, *OggHeader, error) {
+	if in == nil {
+		return nil, nil, fmt.Errorf("stream is nil")
+	}
+
+	reader := &OggReader{
+		stream:        in,
+		checksumTable: generateChecksumTable(),
+	}
+
+	header, err := reader.readHeaders()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return reader, header, nil
+}
+
+// ResetReader resets the internal stream of OggReader. This is useful
+// for live streams, where the end of the file might be read without the
+// data being finished.
+func (o *OggReader) ResetReader(reset func(bytesRead int64) io.Reader) {
+	o.stream = reset(o.bytesReadSuccesfully)
+}
+
+// ParseNextPage reads from stream and returns the payload and header of the
+// next Ogg page, and an error if there is incomplete page data. The payload
+// is the concatenation of the packets of the page, use ParseNextPacket to
+// read the packets one by one. Both must not be mixed on an OggReader.
+// Returns io.EOF when no more pages are available.
+func (o *OggReader) ParseNextPage() ([]byte, *OggPageHeader, error) {
+	payload, pageHeader, _, err := o.parsePage()
+	return payload, pageHeader, err
+}
+
+// ParseNextPacket reads from stream and returns the next Opus packet and its
+// duration, so it can be written as a media.Sample. Pages are split into
+// packets with their segment table, and packets that continue on the next
+// page are joined. The duration is read from the TOC byte of the packet, it is
+// zero if the packet is malformed.
+// Returns io.EOF when no more packets are available.
+func (o *OggReader) ParseNextPacket() ([]byte, time.Duration, error) {
+	for len(o.packets) == 0 {
+		payload, _, segmentTable, err := o.parsePage()
+		if err != nil {
+			return nil, 0, err
+		}
+		o.splitPackets(payload, segmentTable)
+	}
+
+	packet := o.packets[0]
+	o.packets = o.packets[1:]
+	return packet, opusPacketDuration(packet), nil
+}
+
+// splitPackets queues the packets completed by a page. Segments of 255 bytes
+// are followed by more of the same packet, possibly on the next page
+// https://tools.ietf.org/html/rfc3533#section-5
+func (o *OggReader) splitPackets(payload, segmentTable []byte) {
+	offset := 0
+	for _, size := range segmentTable {
+		o.partial = append(o.partial, payload[offset:offset+int(size)]...)
+		offset += int(size)
+
+		if size < 255 {
+			if len(o.partial) != 0 {
+				o.packets = append(o.packets, o.partial)
+			}
+			o.partial = nil
+		}
+	}
+}
+
+// opusPacketDuration returns the duration of an Opus packet from the
+// configuration and frame count in its TOC byte
+// https://tools.ietf.org/html/rfc6716#section-3.1
+func opusPacketDuration(packet []byte) time.Duration {
+	if len(packet) < 1 {
+		return 0
+	}
+
+	var frameDuration time.Duration
+	switch config := packet[0] >> 3; {
+	case config < 12: // SILK-only
+		frameDuration = [...]time.Duration{10, 20, 40, 60}[config%4] * time.Millisecond
+	case config < 16: // Hybrid
+		frameDuration = [...]time.Duration{10, 20}[config%2] * time.Millisecond
+	default: // CELT-only
+		frameDuration = [...]time.Duration{2500, 5000, 10000, 20000}[config%4] * time.Microsecond
+	}
+
+	frames := 1
+	switch packet[0] & 0x03 {
+	case 1, 2:
+		frames = 2
+	case 3:
+		if len(packet) < 2 {
+			return 0
+		}
+		frames = int(packet[1] & 0x3f)
+	}
+	return frameDuration * time.Duration(frames)
+}
+
+// parsePage reads the next page, and also returns its segment table
+func (o *OggReader) parsePage() ([]byte, *OggPageHeader, []byte, error) {
+	h := make([]byte, pageHeaderSize)
+
+	bytesRead, err := io.ReadFull(o.stream, h)
+	if err == io.ErrUnexpectedEOF {
+		return nil, nil, nil, fmt.Errorf("incomplete page header")
+	} else if err != nil {
+		return nil, nil, nil, err
+	}
+
+	pageHeader := &OggPageHeader{
+		sig:             string(h[0:4]),
+		version:         h[4],
+		headerType:      h[5],
+		GranulePosition: binary.LittleEndian.Uint64(h[6:14]),
+		serial:          binary.LittleEndian.Uint32(h[14:18]),
+		index:           binary.LittleEndian.Uint32(h[18:22]),
+		segmentsCount:   h[26],
+	}
+	if pageHeader.sig != pageHeaderSignature {
+		return nil, nil, nil, fmt.Errorf("Ogg page signature mismatch")
+	}
+
+	segmentTable := make([]byte, pageHeader.segmentsCount)
+	n, err := io.ReadFull(o.stream, segmentTable)
+	bytesRead += n
+	if err == io.ErrUnexpectedEOF || err == io.EOF {
+		return nil, nil, nil, fmt.Errorf("incomplete page data")
+	} else if err != nil {
+		return nil, nil, nil, err
+	}
+
+	payloadSize := 0
+	for _, s := range segmentTable {
+		payloadSize += int(s)
+	}
+
+	payload := make([]byte, payloadSize)
+	n, err = io.ReadFull(o.stream, payload)
+	bytesRead += n
+	if err == io.ErrUnexpectedEOF || (err == io.EOF && payloadSize != 0) {
+		return nil, nil, nil, fmt.Errorf("incomplete page data")
+	} else if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// The checksum is computed with its own field set to zero
+	var checksum uint32
+	updateChecksum := func(b []byte) {
+		for i := range b {
+			checksum = (checksum << 8) ^ o.checksumTable[byte(checksum>>24)^b[i]]
+		}
+	}
+	expectedChecksum := binary.LittleEndian.Uint32(h[22:26])
+	binary.LittleEndian.PutUint32(h[22:26], 0)
+	updateChecksum(h)
+	updateChecksum(segmentTable)
+	updateChecksum(payload)
+	if checksum != expectedChecksum {
+		return nil, nil, nil, fmt.Errorf("Ogg page checksum mismatch")
+	}
+
+	o.bytesReadSuccesfully += int64(bytesRead)
+	return payload, pageHeader, segmentTable, nil
+}
+
+// readHeaders reads the ID header page and the comment header pages of the
+// stream. This is always called before ParseNextPage()
+func (o *OggReader) readHeaders() (*OggHeader, error) {
+	payload, pageHeader, err := o.ParseNextPage()
+	if err != nil {
+		return nil, err
+	}
+
+	if pageHeader.headerType != pageHeaderTypeBeginningOfStream {
+		return nil, fmt.Errorf("Ogg stream does not begin with a beginning of stream page")
+	} else if len(payload) < idPagePayloadSize || string(payload[0:8]) != idPageSignature {
+		return nil, fmt.Errorf("Ogg Opus ID header signature mismatch")
+	}
+
+	header := &OggHeader{
+		Version:    payload[8],
+		Channels:   payload[9],
+		PreSkip:    binary.LittleEndian.Uint16(payload[10:12]),
+		SampleRate: binary.LittleEndian.Uint32(payload[12:16]),
+		OutputGain: binary.LittleEndian.Uint16(payload[16:18]),
+		ChannelMap: payload[18],
+	}
+
+	payload, _, segmentTable, err := o.parsePage()
+	if err != nil {
+		return nil, err
+	} else if len(payload) < len(commentPageSignature) || string(payload[0:8]) != commentPageSignature {
+		return nil, fmt.Errorf("Ogg Opus comment header signature mismatch")
+	}
+
+	// The comment header can span several pages, a packet continues on the
+	// next page when the last segment of the page is full. The audio starts
+	// on a new page.
+	for len(segmentTable) != 0 && segmentTable[len(segmentTable)-1] == 255 {
+		if _, _, segmentTable, err = o.parsePage(); err != nil {
+			return nil, err
+		}
+	}
+
+	return header, nil
+}
+
+func generateChecksumTable() *[256]uint32 {
+	var table [256]uint32
+	const poly = 0x04c11db7
+
+	for i := range table {
+		r := uint32(i) << 24
+		for j := 0; j < 8; j++ {
+			if (r & 0x80000000) != 0 {
+				r = (r << 1) ^ poly
+			} else {
+				r <<= 1
+			}
+			table[i] = (r & 0xffffffff)
+		}
+	}
+	return &table
+}
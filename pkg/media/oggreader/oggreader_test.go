@@ -0,0 +1,147 @@
+package oggreader
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v2/pkg/media/oggwriter"
+	"github.com/stretchr/testify/assert"
+)
+
+// buildOggContainer writes an Ogg Opus stream with a page per payload, the
+// timestamps of the payloads are 960 samples apart
+func buildOggContainer(payloads ...[]byte) []byte {
+	buffer := &bytes.Buffer{}
+	writer, err := oggwriter.NewWith(buffer, 48000, 2)
+	if err != nil {
+		panic(err)
+	}
+
+	for i, payload := range payloads {
+		if err = writer.WriteRTP(&rtp.Packet{
+			Header:  rtp.Header{Version: 2, Timestamp: 1 + uint32(i)*960},
+			Payload: payload,
+		}); err != nil {
+			panic(err)
+		}
+	}
+	return buffer.Bytes()
+}
+
+func TestOggReader_ParseValidHeader(t *testing.T) {
+	assert := assert.New(t)
+
+	reader, header, err := NewWith(bytes.NewReader(buildOggContainer()))
+	assert.Nil(err, "OggReader should be created")
+	assert.NotNil(reader, "Reader shouldn't be nil")
+	assert.Equal(&OggHeader{
+		Version:    1,
+		Channels:   2,
+		PreSkip:    3840,
+		SampleRate: 48000,
+	}, header)
+}
+
+func TestOggReader_ParseNextPage(t *testing.T) {
+	assert := assert.New(t)
+
+	reader, _, err := NewWith(bytes.NewReader(buildOggContainer([]byte{0x98, 0x36}, []byte{0xbe, 0x88, 0x9e})))
+	assert.Nil(err, "OggReader should be created")
+
+	payload, pageHeader, err := reader.ParseNextPage()
+	assert.Nil(err, "Should have parsed page #1")
+	assert.Equal([]byte{0x98, 0x36}, payload)
+	firstGranulePosition := pageHeader.GranulePosition
+
+	payload, pageHeader, err = reader.ParseNextPage()
+	assert.Nil(err, "Should have parsed page #2")
+	assert.Equal([]byte{0xbe, 0x88, 0x9e}, payload)
+	assert.Equal(uint64(960), pageHeader.GranulePosition-firstGranulePosition)
+
+	_, _, err = reader.ParseNextPage()
+	assert.Equal(io.EOF, err)
+}
+
+// buildOggPage returns a page of the stream written by buildOggContainer with
+// the given segment table
+func buildOggPage(index uint32, segmentTable []byte, payload []byte) []byte {
+	page := make([]byte, pageHeaderSize, pageHeaderSize+len(segmentTable)+len(payload))
+	copy(page, pageHeaderSignature)
+	binary.LittleEndian.PutUint32(page[18:22], index)
+	page[26] = uint8(len(segmentTable))
+	page = append(append(page, segmentTable...), payload...)
+
+	var checksum uint32
+	table := generateChecksumTable()
+	for i := range page {
+		checksum = (checksum << 8) ^ table[byte(checksum>>24)^page[i]]
+	}
+	binary.LittleEndian.PutUint32(page[22:26], checksum)
+	return page
+}
+
+func TestOggReader_ParseNextPacket(t *testing.T) {
+	assert := assert.New(t)
+
+	// CELT 20ms, SILK 3 x 20ms, Hybrid 20ms spanning two pages, CELT 2 x 2.5ms
+	celt := []byte{0xf8, 0x01, 0x02}
+	silk := []byte{0x0b, 0x03, 0x04}
+	hybrid := append([]byte{0x78}, bytes.Repeat([]byte{0x05}, 299)...)
+	celtShort := []byte{0xe1, 0x06}
+
+	stream := buildOggContainer()
+	stream = append(stream, buildOggPage(2, []byte{3, 3, 255}, append(append(append([]byte{}, celt...), silk...), hybrid[:255]...))...)
+	stream = append(stream, buildOggPage(3, []byte{45, 2}, append(append([]byte{}, hybrid[255:]...), celtShort...))...)
+
+	reader, _, err := NewWith(bytes.NewReader(stream))
+	assert.NoError(err)
+
+	for _, expected := range []struct {
+		packet   []byte
+		duration time.Duration
+	}{
+		{celt, 20 * time.Millisecond},
+		{silk, 60 * time.Millisecond},
+		{hybrid, 20 * time.Millisecond},
+		{celtShort, 5 * time.Millisecond},
+	} {
+		packet, duration, err := reader.ParseNextPacket()
+		assert.NoError(err)
+		assert.Equal(expected.packet, packet)
+		assert.Equal(expected.duration, duration)
+	}
+
+	_, _, err = reader.ParseNextPacket()
+	assert.Equal(io.EOF, err)
+}
+
+func TestOggReader_ParseErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	_, _, err := NewWith(nil)
+	assert.Equal(fmt.Errorf("stream is nil"), err)
+
+	_, _, err = NewWith(bytes.NewReader([]byte("OggS")))
+	assert.Equal(fmt.Errorf("incomplete page header"), err)
+
+	stream := buildOggContainer([]byte{0x98, 0x36})
+	badSignature := append([]byte{}, stream...)
+	badSignature[0] = 'P'
+	_, _, err = NewWith(bytes.NewReader(badSignature))
+	assert.Equal(fmt.Errorf("Ogg page signature mismatch"), err)
+
+	badChecksum := append([]byte{}, stream...)
+	badChecksum[22]++
+	_, _, err = NewWith(bytes.NewReader(badChecksum))
+	assert.Equal(fmt.Errorf("Ogg page checksum mismatch"), err)
+
+	reader, _, err := NewWith(bytes.NewReader(stream[:len(stream)-1]))
+	assert.Nil(err, "OggReader should be created")
+	_, _, err = reader.ParseNextPage()
+	assert.Equal(fmt.Errorf("incomplete page data"), err)
+}
@@ -0,0 +1,38 @@
+package media
+
+import (
+	"sync"
+	"time"
+)
+
+// RealtimeWriter paces the Samples written to a SampleWriter, so they are sent
+// at the speed they are played back instead of as fast as they are read, e.g.
+// from a file. Each Sample is written once the Duration of the previous ones
+// has elapsed. A Sample written late is written at once, and the following
+// ones are paced from it.
+type RealtimeWriter struct {
+	mu     sync.Mutex
+	writer SampleWriter
+	next   time.Time
+}
+
+// NewRealtimeWriter creates a RealtimeWriter writing to w
+func NewRealtimeWriter(w SampleWriter) *RealtimeWriter {
+	return &RealtimeWriter{writer: w}
+}
+
+// WriteSample waits until s is due and writes it
+func (r *RealtimeWriter) WriteSample(s Sample) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	if wait := r.next.Sub(now); wait > 0 {
+		time.Sleep(wait)
+	} else {
+		r.next = now
+	}
+	r.next = r.next.Add(s.Duration)
+
+	return r.writer.WriteSample(s)
+}
@@ -0,0 +1,41 @@
+package media_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pion/webrtc/v2/pkg/media"
+	"github.com/stretchr/testify/assert"
+)
+
+type sampleWriterFunc func(media.Sample) error
+
+func (f sampleWriterFunc) WriteSample(s media.Sample) error {
+	return f(s)
+}
+
+func TestRealtimeWriter(t *testing.T) {
+	var written []time.Time
+	w := media.NewRealtimeWriter(sampleWriterFunc(func(media.Sample) error {
+		written = append(written, time.Now())
+		return nil
+	}))
+
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, w.WriteSample(media.Sample{Data: []byte{0x00}, Duration: 20 * time.Millisecond}))
+	}
+	assert.True(t, written[4].Sub(written[0]) >= 80*time.Millisecond)
+
+	// A late Sample is written at once
+	time.Sleep(50 * time.Millisecond)
+	start := time.Now()
+	assert.NoError(t, w.WriteSample(media.Sample{Data: []byte{0x00}, Duration: 20 * time.Millisecond}))
+	assert.True(t, time.Since(start) < 20*time.Millisecond)
+
+	errWrite := errors.New("write failed")
+	w = media.NewRealtimeWriter(sampleWriterFunc(func(media.Sample) error {
+		return errWrite
+	}))
+	assert.Equal(t, errWrite, w.WriteSample(media.Sample{}))
+}
@@ -0,0 +1,40 @@
+// +build gofuzz
+
+package rtpdump
+
+import (
+	"bytes"
+	"io"
+)
+
+// Fuzz implements a randomized fuzz test of the rtpdump reader using
+// go-fuzz, see github.com/pion/webrtc/v2 Fuzz for how to run it. The seeds
+// in testdata/fuzz are a recording of a VP8 stream and its Sender Report.
+func Fuzz(data []byte) int {
+	r, _, err := NewReader(bytes.NewReader(data))
+	if err != nil {
+		return 0
+	}
+
+	for {
+		packet, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0
+		}
+
+		raw, err := packet.Marshal()
+		if err != nil {
+			panic(err)
+		}
+
+		var unmarshaled Packet
+		if err := unmarshaled.Unmarshal(raw); err != nil {
+			panic(err)
+		}
+	}
+
+	return 1
+}
@@ -82,7 +82,8 @@ func (r *Reader) Next() (Packet, error) {
 		return Packet{}, err
 	}
 
-	if h.Length == 0 {
+	// The length includes the packet header, anything shorter would underflow
+	if h.Length < pktHeaderLen {
 		return Packet{}, errMalformed
 	}
 
@@ -122,6 +122,26 @@ func TestReader(t *testing.T) {
 			},
 			WantErr: errMalformed,
 		},
+		{
+			Name: "packet length shorter than header",
+			Data: append(
+				validPreamble,
+				// header
+				0x00, 0x00, 0x00, 0x00,
+				0x00, 0x00, 0x00, 0x00,
+				0x00, 0x00, 0x00, 0x00,
+				0x00, 0x00, 0x00, 0x00,
+				// packet header len=4
+				0x00, 0x04, 0x00, 0x00,
+				0x00, 0x00, 0x00, 0x00,
+			),
+			WantHeader: Header{
+				Start:  time.Unix(0, 0).UTC(),
+				Source: net.IPv4(0, 0, 0, 0),
+				Port:   0,
+			},
+			WantErr: errMalformed,
+		},
 		{
 			Name: "valid rtcp packet",
 			Data: append(
@@ -28,6 +28,25 @@ type SampleBuilder struct {
 
 	// Interface that checks whether the packet is the first fragment of the frame or not
 	partitionHeadChecker rtp.PartitionHeadChecker
+
+	// hasPopped is true once a sample has been emitted, losses can only be
+	// measured relative to a previous sample
+	hasPopped bool
+
+	// Handler that is notified about packets lost between two samples
+	packetLossHandler func(PacketLoss)
+}
+
+// PacketLoss describes RTP packets that were missing between two samples
+type PacketLoss struct {
+	// Packets is the number of RTP packets that were lost or arrived too late
+	Packets uint16
+
+	// LastTimestamp is the RTP timestamp of the sample emitted before the loss
+	LastTimestamp uint32
+
+	// NextTimestamp is the RTP timestamp of the sample emitted after the loss
+	NextTimestamp uint32
 }
 
 // New constructs a new SampleBuilder
@@ -137,7 +156,24 @@ func (s *SampleBuilder) PopWithTimestamp() (*media.Sample, uint32) {
 		}
 
 		// Initial validity checks have passed, walk forward
-		return s.buildSample(i)
+		lastPopSeq, lastPopTimestamp := s.lastPopSeq, s.lastPopTimestamp
+		sample, timestamp := s.buildSample(i)
+		if sample != nil && s.hasPopped {
+			if dropped := i - lastPopSeq - 1; dropped != 0 {
+				sample.PrevDroppedPackets = dropped
+				if s.packetLossHandler != nil {
+					s.packetLossHandler(PacketLoss{
+						Packets:       dropped,
+						LastTimestamp: lastPopTimestamp,
+						NextTimestamp: timestamp,
+					})
+				}
+			}
+		}
+		if sample != nil {
+			s.hasPopped = true
+		}
+		return sample, timestamp
 	}
 	return nil, 0
 }
@@ -151,3 +187,12 @@ func WithPartitionHeadChecker(checker rtp.PartitionHeadChecker) Option {
 		o.partitionHeadChecker = checker
 	}
 }
+
+// WithPacketLossHandler sets a handler that is called with the details of RTP
+// packets that were lost between two samples. It is called from Pop before the
+// sample following the loss is returned.
+func WithPacketLossHandler(h func(PacketLoss)) Option {
+	return func(o *SampleBuilder) {
+		o.packetLossHandler = h
+	}
+}
@@ -213,7 +213,30 @@ func TestSampleBuilderMaxLate(t *testing.T) {
 	s.Push(&rtp.Packet{Header: rtp.Header{SequenceNumber: 5000, Timestamp: 500}, Payload: []byte{0x02}})
 	s.Push(&rtp.Packet{Header: rtp.Header{SequenceNumber: 5001, Timestamp: 501}, Payload: []byte{0x02}})
 	s.Push(&rtp.Packet{Header: rtp.Header{SequenceNumber: 5002, Timestamp: 502}, Payload: []byte{0x02}})
-	assert.Equal(s.Pop(), &media.Sample{Data: []byte{0x02}, Samples: 1}, "Failed to build samples after large gap")
+	assert.Equal(s.Pop(), &media.Sample{Data: []byte{0x02}, Samples: 1, PrevDroppedPackets: 4999}, "Failed to build samples after large gap")
+}
+
+func TestSampleBuilderPacketLoss(t *testing.T) {
+	assert := assert.New(t)
+
+	losses := []PacketLoss{}
+	s := New(4, &fakeDepacketizer{}, WithPacketLossHandler(func(l PacketLoss) {
+		losses = append(losses, l)
+	}))
+
+	s.Push(&rtp.Packet{Header: rtp.Header{SequenceNumber: 5000, Timestamp: 960}, Payload: []byte{0x01}})
+	s.Push(&rtp.Packet{Header: rtp.Header{SequenceNumber: 5001, Timestamp: 1920}, Payload: []byte{0x02}})
+	s.Push(&rtp.Packet{Header: rtp.Header{SequenceNumber: 5002, Timestamp: 2880}, Payload: []byte{0x03}})
+	assert.Equal(&media.Sample{Data: []byte{0x02}, Samples: 960}, s.Pop())
+	assert.Nil(s.Pop())
+	assert.Empty(losses)
+
+	// 5003 and 5004 never arrive, 5002 can't be completed before maxLate
+	s.Push(&rtp.Packet{Header: rtp.Header{SequenceNumber: 5005, Timestamp: 5760}, Payload: []byte{0x06}})
+	s.Push(&rtp.Packet{Header: rtp.Header{SequenceNumber: 5006, Timestamp: 6720}, Payload: []byte{0x07}})
+	s.Push(&rtp.Packet{Header: rtp.Header{SequenceNumber: 5007, Timestamp: 7680}, Payload: []byte{0x08}})
+	assert.Equal(&media.Sample{Data: []byte{0x07}, Samples: 960, PrevDroppedPackets: 4}, s.Pop())
+	assert.Equal([]PacketLoss{{Packets: 4, LastTimestamp: 1920, NextTimestamp: 6720}}, losses)
 }
 
 func TestSeqnumDistance(t *testing.T) {
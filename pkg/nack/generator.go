@@ -0,0 +1,176 @@
+// Package nack provides helpers to generate and rate limit RTCP Generic NACK feedback
+package nack
+
+import (
+	"sort"
+	"time"
+
+	"github.com/pion/rtcp"
+)
+
+const (
+	defaultSize       = uint16(512)
+	defaultInterval   = 50 * time.Millisecond
+	defaultMaxRetries = 3
+
+	// A NackPair can describe its PacketID plus the 16 following sequence numbers
+	nackPairSpan = 17
+)
+
+type missingPacket struct {
+	retries int
+}
+
+// Generator keeps track of missing RTP sequence numbers of a single SSRC and
+// batches them into combined TransportLayerNack packets. NACKs are emitted at
+// most once per interval, and a sequence number is dropped from the missing
+// set once it is received (either directly or recovered through RTX), once it
+// falls out of the tracking window or once it has been requested maxRetries times
+type Generator struct {
+	senderSSRC uint32
+	mediaSSRC  uint32
+
+	size       uint16
+	interval   time.Duration
+	maxRetries int
+
+	started  bool
+	lastSeq  uint16
+	lastSent time.Time
+	missing  map[uint16]*missingPacket
+}
+
+// NewGenerator constructs a new Generator for the stream identified by mediaSSRC
+func NewGenerator(senderSSRC, mediaSSRC uint32, opts ...Option) *Generator {
+	g := &Generator{
+		senderSSRC: senderSSRC,
+		mediaSSRC:  mediaSSRC,
+		size:       defaultSize,
+		interval:   defaultInterval,
+		maxRetries: defaultMaxRetries,
+		missing:    map[uint16]*missingPacket{},
+	}
+	for _, o := range opts {
+		o(g)
+	}
+	return g
+}
+
+// Push records the arrival of a RTP packet. Packets recovered through RTX
+// should be pushed with their original sequence number, this suppresses
+// further NACKs for them.
+func (g *Generator) Push(seq uint16) {
+	if !g.started {
+		g.started = true
+		g.lastSeq = seq
+		return
+	}
+
+	if !isNewer(seq, g.lastSeq) {
+		delete(g.missing, seq)
+		return
+	}
+
+	// Only the last size sequence numbers can still be recovered, don't
+	// bother to walk a gap that is larger than the tracking window
+	first := g.lastSeq + 1
+	if seq-first > g.size {
+		first = seq - g.size
+	}
+	for s := first; s != seq; s++ {
+		g.missing[s] = &missingPacket{}
+	}
+	g.lastSeq = seq
+
+	for s := range g.missing {
+		if g.lastSeq-s > g.size {
+			delete(g.missing, s)
+		}
+	}
+}
+
+// Missing returns the sequence numbers that are currently considered lost,
+// oldest first
+func (g *Generator) Missing() []uint16 {
+	seqs := make([]uint16, 0, len(g.missing))
+	for s := range g.missing {
+		seqs = append(seqs, s)
+	}
+	sort.Slice(seqs, func(i, j int) bool {
+		return g.lastSeq-seqs[i] > g.lastSeq-seqs[j]
+	})
+	return seqs
+}
+
+// Generate returns a TransportLayerNack requesting every missing sequence
+// number, or nil if nothing is missing or the previous NACK was sent less
+// than interval ago
+func (g *Generator) Generate(now time.Time) *rtcp.TransportLayerNack {
+	if len(g.missing) == 0 || (!g.lastSent.IsZero() && now.Sub(g.lastSent) < g.interval) {
+		return nil
+	}
+
+	seqs := g.Missing()
+	for _, s := range seqs {
+		m := g.missing[s]
+		m.retries++
+		if m.retries >= g.maxRetries {
+			delete(g.missing, s)
+		}
+	}
+	g.lastSent = now
+
+	return &rtcp.TransportLayerNack{
+		SenderSSRC: g.senderSSRC,
+		MediaSSRC:  g.mediaSSRC,
+		Nacks:      nackPairs(seqs),
+	}
+}
+
+// nackPairs packs sorted sequence numbers into the smallest amount of NackPairs
+func nackPairs(seqs []uint16) []rtcp.NackPair {
+	pairs := []rtcp.NackPair{}
+	for _, s := range seqs {
+		if len(pairs) != 0 {
+			last := &pairs[len(pairs)-1]
+			if diff := s - last.PacketID; diff != 0 && diff < nackPairSpan {
+				last.LostPackets |= rtcp.PacketBitmap(1 << (diff - 1))
+				continue
+			}
+		}
+		pairs = append(pairs, rtcp.NackPair{PacketID: s})
+	}
+	return pairs
+}
+
+// isNewer returns true if a is ahead of b, taking wrap around into account
+func isNewer(a, b uint16) bool {
+	return a != b && a-b < 0x8000
+}
+
+// Option configures Generator
+type Option func(g *Generator)
+
+// WithSize sets how many sequence numbers behind the newest received packet
+// are still tracked and requested
+func WithSize(size uint16) Option {
+	return func(g *Generator) {
+		g.size = size
+	}
+}
+
+// WithInterval sets the minimum time between two generated NACKs, capping
+// the feedback rate on high loss links
+func WithInterval(interval time.Duration) Option {
+	return func(g *Generator) {
+		g.interval = interval
+	}
+}
+
+// WithMaxRetries sets how many times a missing packet is requested before
+// giving up on it
+func WithMaxRetries(maxRetries int) Option {
+	return func(g *Generator) {
+		g.maxRetries = maxRetries
+	}
+}
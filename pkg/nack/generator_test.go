@@ -0,0 +1,92 @@
+package nack
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerator(t *testing.T) {
+	now := time.Now()
+
+	t.Run("Batching", func(t *testing.T) {
+		g := NewGenerator(1, 2)
+		for _, s := range []uint16{10, 11, 13, 15, 40} {
+			g.Push(s)
+		}
+
+		nack := g.Generate(now)
+		assert.NotNil(t, nack)
+		assert.Equal(t, uint32(1), nack.SenderSSRC)
+		assert.Equal(t, uint32(2), nack.MediaSSRC)
+
+		var lost []uint16
+		for _, p := range nack.Nacks {
+			lost = append(lost, p.PacketList()...)
+		}
+		expected := []uint16{12, 14}
+		for s := uint16(16); s < 40; s++ {
+			expected = append(expected, s)
+		}
+		assert.Equal(t, expected, lost)
+		assert.Equal(t, []rtcp.NackPair{
+			{PacketID: 12, LostPackets: 0xfffa},
+			{PacketID: 29, LostPackets: 0x03ff},
+		}, nack.Nacks)
+	})
+
+	t.Run("RateLimit", func(t *testing.T) {
+		g := NewGenerator(1, 2, WithInterval(100*time.Millisecond))
+		g.Push(1)
+		g.Push(3)
+
+		assert.NotNil(t, g.Generate(now))
+		assert.Nil(t, g.Generate(now.Add(50*time.Millisecond)))
+		assert.NotNil(t, g.Generate(now.Add(100*time.Millisecond)))
+	})
+
+	t.Run("Recovered", func(t *testing.T) {
+		g := NewGenerator(1, 2)
+		g.Push(1)
+		g.Push(4)
+		assert.Equal(t, []uint16{2, 3}, g.Missing())
+
+		g.Push(2)
+		g.Push(3)
+		assert.Empty(t, g.Missing())
+		assert.Nil(t, g.Generate(now))
+	})
+
+	t.Run("MaxRetries", func(t *testing.T) {
+		g := NewGenerator(1, 2, WithMaxRetries(2), WithInterval(0))
+		g.Push(1)
+		g.Push(3)
+
+		assert.NotNil(t, g.Generate(now))
+		assert.NotNil(t, g.Generate(now))
+		assert.Nil(t, g.Generate(now))
+	})
+
+	t.Run("Window", func(t *testing.T) {
+		g := NewGenerator(1, 2, WithSize(4))
+		g.Push(1)
+		g.Push(100)
+		assert.Equal(t, []uint16{96, 97, 98, 99}, g.Missing())
+
+		g.Push(103)
+		assert.Equal(t, []uint16{99, 101, 102}, g.Missing())
+	})
+
+	t.Run("Wraparound", func(t *testing.T) {
+		g := NewGenerator(1, 2)
+		g.Push(65534)
+		g.Push(1)
+		assert.Equal(t, []uint16{65535, 0}, g.Missing())
+
+		// Reordered packet from before the wrap is not a gap
+		g.Push(65535)
+		assert.Equal(t, []uint16{0}, g.Missing())
+	})
+}
@@ -1,5 +1,6 @@
 // Package rtcerr implements the error wrappers defined throughout the
-// WebRTC 1.0 specifications.
+// WebRTC 1.0 specifications. The wrapped errors can be matched with
+// errors.Is and errors.As.
 package rtcerr
 
 import (
@@ -15,6 +16,11 @@ func (e *UnknownError) Error() string {
 	return fmt.Sprintf("UnknownError: %v", e.Err)
 }
 
+// Unwrap returns the wrapped error
+func (e *UnknownError) Unwrap() error {
+	return e.Err
+}
+
 // InvalidStateError indicates the object is in an invalid state.
 type InvalidStateError struct {
 	Err error
@@ -24,6 +30,11 @@ func (e *InvalidStateError) Error() string {
 	return fmt.Sprintf("InvalidStateError: %v", e.Err)
 }
 
+// Unwrap returns the wrapped error
+func (e *InvalidStateError) Unwrap() error {
+	return e.Err
+}
+
 // InvalidAccessError indicates the object does not support the operation or
 // argument.
 type InvalidAccessError struct {
@@ -34,6 +45,11 @@ func (e *InvalidAccessError) Error() string {
 	return fmt.Sprintf("InvalidAccessError: %v", e.Err)
 }
 
+// Unwrap returns the wrapped error
+func (e *InvalidAccessError) Unwrap() error {
+	return e.Err
+}
+
 // NotSupportedError indicates the operation is not supported.
 type NotSupportedError struct {
 	Err error
@@ -43,6 +59,11 @@ func (e *NotSupportedError) Error() string {
 	return fmt.Sprintf("NotSupportedError: %v", e.Err)
 }
 
+// Unwrap returns the wrapped error
+func (e *NotSupportedError) Unwrap() error {
+	return e.Err
+}
+
 // InvalidModificationError indicates the object cannot be modified in this way.
 type InvalidModificationError struct {
 	Err error
@@ -52,6 +73,11 @@ func (e *InvalidModificationError) Error() string {
 	return fmt.Sprintf("InvalidModificationError: %v", e.Err)
 }
 
+// Unwrap returns the wrapped error
+func (e *InvalidModificationError) Unwrap() error {
+	return e.Err
+}
+
 // SyntaxError indicates the string did not match the expected pattern.
 type SyntaxError struct {
 	Err error
@@ -61,6 +87,11 @@ func (e *SyntaxError) Error() string {
 	return fmt.Sprintf("SyntaxError: %v", e.Err)
 }
 
+// Unwrap returns the wrapped error
+func (e *SyntaxError) Unwrap() error {
+	return e.Err
+}
+
 // TypeError indicates an error when a value is not of the expected type.
 type TypeError struct {
 	Err error
@@ -70,6 +101,11 @@ func (e *TypeError) Error() string {
 	return fmt.Sprintf("TypeError: %v", e.Err)
 }
 
+// Unwrap returns the wrapped error
+func (e *TypeError) Unwrap() error {
+	return e.Err
+}
+
 // OperationError indicates the operation failed for an operation-specific
 // reason.
 type OperationError struct {
@@ -80,6 +116,11 @@ func (e *OperationError) Error() string {
 	return fmt.Sprintf("OperationError: %v", e.Err)
 }
 
+// Unwrap returns the wrapped error
+func (e *OperationError) Unwrap() error {
+	return e.Err
+}
+
 // NotReadableError indicates the input/output read operation failed.
 type NotReadableError struct {
 	Err error
@@ -89,6 +130,11 @@ func (e *NotReadableError) Error() string {
 	return fmt.Sprintf("NotReadableError: %v", e.Err)
 }
 
+// Unwrap returns the wrapped error
+func (e *NotReadableError) Unwrap() error {
+	return e.Err
+}
+
 // RangeError indicates an error when a value is not in the set or range
 // of allowed values.
 type RangeError struct {
@@ -98,3 +144,8 @@ type RangeError struct {
 func (e *RangeError) Error() string {
 	return fmt.Sprintf("RangeError: %v", e.Err)
 }
+
+// Unwrap returns the wrapped error
+func (e *RangeError) Unwrap() error {
+	return e.Err
+}
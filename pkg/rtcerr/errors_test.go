@@ -0,0 +1,35 @@
+package rtcerr
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnwrap(t *testing.T) {
+	errWrapped := errors.New("wrapped")
+	detailed := fmt.Errorf("%w: details", errWrapped)
+
+	for _, err := range []error{
+		&UnknownError{Err: detailed},
+		&InvalidStateError{Err: detailed},
+		&InvalidAccessError{Err: detailed},
+		&NotSupportedError{Err: detailed},
+		&InvalidModificationError{Err: detailed},
+		&SyntaxError{Err: detailed},
+		&TypeError{Err: detailed},
+		&OperationError{Err: detailed},
+		&NotReadableError{Err: detailed},
+		&RangeError{Err: detailed},
+	} {
+		assert.True(t, errors.Is(err, errWrapped), "%T", err)
+		assert.True(t, errors.Is(fmt.Errorf("outer: %w", err), errWrapped), "%T", err)
+	}
+
+	var stateErr *InvalidStateError
+	assert.True(t, errors.As(fmt.Errorf("outer: %w", &InvalidStateError{Err: errWrapped}), &stateErr))
+	assert.Equal(t, errWrapped, stateErr.Err)
+	assert.False(t, errors.As(&RangeError{Err: errWrapped}, &stateErr))
+}
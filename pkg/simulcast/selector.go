@@ -0,0 +1,184 @@
+// Package simulcast provides helpers for SFUs that forward one of several
+// simulcast layers of a remote Track to each subscriber
+package simulcast
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/pion/rtp"
+)
+
+var (
+	errNoLayers      = errors.New("simulcast: at least one layer is required")
+	errDuplicateSSRC = errors.New("simulcast: layers must have distinct SSRCs")
+)
+
+// Layer describes a single simulcast encoding of a Track
+type Layer struct {
+	// RID is the restriction identifier of the layer as negotiated in the SDP
+	RID string
+
+	// SSRC of the RTP stream carrying the layer
+	SSRC uint32
+
+	// Bitrate is the expected bitrate of the layer in bits per second
+	Bitrate uint64
+}
+
+// KeyframeChecker reports if a RTP packet starts a keyframe. Switching to a
+// different layer can only happen on a keyframe
+type KeyframeChecker func(p *rtp.Packet) bool
+
+// Selector picks the layer to forward to a single subscriber based on the
+// bitrate available to it, and rewrites the forwarded packets so the
+// subscriber sees one continuous stream. Switches are delayed until the new
+// layer delivers a keyframe.
+//
+// Only whole simulcast layers are selected. Temporal layers inside a layer,
+// such as the VP8 TID or H264 SVC ones, are all forwarded: dropping some of
+// them would need the codec's picture IDs to be rewritten, which Selector
+// doesn't do.
+//
+// Selector is not safe for concurrent use.
+type Selector struct {
+	layers     []Layer
+	isKeyframe KeyframeChecker
+	ssrc       uint32
+	clockRate  uint32
+
+	current int
+	target  int
+
+	started     bool
+	seqOffset   uint16
+	tsOffset    uint32
+	lastSeq     uint16
+	lastTS      uint32
+	lastArrival time.Time
+}
+
+// NewSelector creates a Selector that forwards the given layers as a single
+// stream using ssrc. clockRate is the RTP clock rate of the codec of the
+// layers. The lowest bitrate layer is selected until SetTargetBitrate is called
+func NewSelector(ssrc, clockRate uint32, layers []Layer, isKeyframe KeyframeChecker) (*Selector, error) {
+	if len(layers) == 0 {
+		return nil, errNoLayers
+	}
+
+	sorted := append([]Layer{}, layers...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Bitrate < sorted[j].Bitrate
+	})
+	for i := 1; i < len(sorted); i++ {
+		for j := 0; j < i; j++ {
+			if sorted[i].SSRC == sorted[j].SSRC {
+				return nil, errDuplicateSSRC
+			}
+		}
+	}
+
+	return &Selector{
+		layers:     sorted,
+		isKeyframe: isKeyframe,
+		ssrc:       ssrc,
+		clockRate:  clockRate,
+		current:    -1,
+		target:     0,
+	}, nil
+}
+
+// SetTargetBitrate selects the highest layer that fits into bitrate, or the
+// lowest layer if none does. It returns the selected layer
+func (s *Selector) SetTargetBitrate(bitrate uint64) Layer {
+	s.target = 0
+	for i, l := range s.layers {
+		if l.Bitrate <= bitrate {
+			s.target = i
+		}
+	}
+	return s.layers[s.target]
+}
+
+// Current returns the layer currently being forwarded, and false if nothing
+// has been forwarded yet
+func (s *Selector) Current() (Layer, bool) {
+	if s.current == -1 {
+		return Layer{}, false
+	}
+	return s.layers[s.current], true
+}
+
+// NeedsKeyframe returns the SSRC of the layer a keyframe should be requested
+// for (with a PLI or FIR), and false if no switch is pending
+func (s *Selector) NeedsKeyframe() (uint32, bool) {
+	if s.current == s.target {
+		return 0, false
+	}
+	return s.layers[s.target].SSRC, true
+}
+
+// Forward consumes a packet received on any layer at arrival. It returns the
+// packet rewritten for the subscriber and true when it should be forwarded, or
+// false when it must be dropped. The passed packet is not modified.
+func (s *Selector) Forward(p *rtp.Packet, arrival time.Time) (*rtp.Packet, bool) {
+	layer := s.layerIndex(p.SSRC)
+	if layer == -1 {
+		return nil, false
+	}
+
+	if layer != s.current {
+		if layer != s.target || !s.isKeyframe(p) {
+			return nil, false
+		}
+		s.switchTo(layer, p, arrival)
+	}
+
+	out := *p
+	out.Header.SSRC = s.ssrc
+	out.Header.SequenceNumber = p.SequenceNumber + s.seqOffset
+	out.Header.Timestamp = p.Timestamp + s.tsOffset
+
+	if !s.started || isNewerSeq(out.SequenceNumber, s.lastSeq) {
+		s.lastSeq = out.SequenceNumber
+		s.lastTS = out.Timestamp
+		s.lastArrival = arrival
+	}
+	s.started = true
+
+	return &out, true
+}
+
+// switchTo makes layer the current one, starting with the keyframe p that
+// arrived at arrival. The first packet of the new layer directly follows the
+// last forwarded one. Layers don't share a timestamp base, so the timestamp
+// advances by the time elapsed between the arrival of both packets
+func (s *Selector) switchTo(layer int, p *rtp.Packet, arrival time.Time) {
+	s.current = layer
+	if !s.started {
+		return
+	}
+
+	elapsed := uint32(1)
+	if ticks := arrival.Sub(s.lastArrival).Seconds() * float64(s.clockRate); ticks >= 1 {
+		elapsed = uint32(ticks)
+	}
+
+	s.seqOffset = s.lastSeq + 1 - p.SequenceNumber
+	s.tsOffset = s.lastTS + elapsed - p.Timestamp
+}
+
+func (s *Selector) layerIndex(ssrc uint32) int {
+	for i, l := range s.layers {
+		if l.SSRC == ssrc {
+			return i
+		}
+	}
+	return -1
+}
+
+// isNewerSeq returns true if a is ahead of b, taking wrap around into account
+func isNewerSeq(a, b uint16) bool {
+	return a != b && a-b < 0x8000
+}
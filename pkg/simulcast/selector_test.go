@@ -0,0 +1,161 @@
+package simulcast
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	keyframe = byte(0x01)
+	delta    = byte(0x00)
+)
+
+func isTestKeyframe(p *rtp.Packet) bool {
+	return p.Payload[0] == keyframe
+}
+
+func testPacket(ssrc uint32, seq uint16, ts uint32, kind byte) *rtp.Packet {
+	return &rtp.Packet{
+		Header:  rtp.Header{SSRC: ssrc, SequenceNumber: seq, Timestamp: ts},
+		Payload: []byte{kind},
+	}
+}
+
+func TestNewSelector(t *testing.T) {
+	_, err := NewSelector(1, 90000, nil, isTestKeyframe)
+	assert.Equal(t, errNoLayers, err)
+
+	_, err = NewSelector(1, 90000, []Layer{{SSRC: 2}, {SSRC: 2}}, isTestKeyframe)
+	assert.Equal(t, errDuplicateSSRC, err)
+}
+
+func TestSelector_SetTargetBitrate(t *testing.T) {
+	s, err := NewSelector(1, 90000, []Layer{
+		{RID: "f", SSRC: 30, Bitrate: 2500000},
+		{RID: "q", SSRC: 10, Bitrate: 150000},
+		{RID: "h", SSRC: 20, Bitrate: 500000},
+	}, isTestKeyframe)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "q", s.SetTargetBitrate(0).RID)
+	assert.Equal(t, "q", s.SetTargetBitrate(499999).RID)
+	assert.Equal(t, "h", s.SetTargetBitrate(500000).RID)
+	assert.Equal(t, "f", s.SetTargetBitrate(10000000).RID)
+}
+
+func TestSelector_Forward(t *testing.T) {
+	now := time.Now()
+	s, err := NewSelector(1, 90000, []Layer{
+		{RID: "q", SSRC: 10, Bitrate: 150000},
+		{RID: "h", SSRC: 20, Bitrate: 500000},
+	}, isTestKeyframe)
+	assert.NoError(t, err)
+
+	_, ok := s.Current()
+	assert.False(t, ok)
+
+	// Nothing is forwarded before a keyframe on the target layer
+	_, ok = s.Forward(testPacket(10, 100, 1000, delta), now)
+	assert.False(t, ok)
+	ssrc, ok := s.NeedsKeyframe()
+	assert.True(t, ok)
+	assert.Equal(t, uint32(10), ssrc)
+
+	// Unknown SSRCs are dropped
+	_, ok = s.Forward(testPacket(99, 100, 1000, keyframe), now)
+	assert.False(t, ok)
+
+	out, ok := s.Forward(testPacket(10, 101, 1000, keyframe), now)
+	assert.True(t, ok)
+	assert.Equal(t, uint32(1), out.SSRC)
+	assert.Equal(t, uint16(101), out.SequenceNumber)
+	assert.Equal(t, uint32(1000), out.Timestamp)
+
+	_, ok = s.NeedsKeyframe()
+	assert.False(t, ok)
+
+	out, ok = s.Forward(testPacket(10, 102, 4000, delta), now)
+	assert.True(t, ok)
+	assert.Equal(t, uint16(102), out.SequenceNumber)
+
+	// Switching up keeps forwarding the old layer until the new one has a keyframe
+	s.SetTargetBitrate(1000000)
+	ssrc, ok = s.NeedsKeyframe()
+	assert.True(t, ok)
+	assert.Equal(t, uint32(20), ssrc)
+
+	_, ok = s.Forward(testPacket(20, 5000, 90000, delta), now)
+	assert.False(t, ok)
+	out, ok = s.Forward(testPacket(10, 103, 7000, delta), now)
+	assert.True(t, ok)
+	assert.Equal(t, uint16(103), out.SequenceNumber)
+
+	// The timestamp advances by the 100ms elapsed since the last forwarded packet
+	now = now.Add(100 * time.Millisecond)
+	out, ok = s.Forward(testPacket(20, 5001, 93000, keyframe), now)
+	assert.True(t, ok)
+	assert.Equal(t, uint32(1), out.SSRC)
+	assert.Equal(t, uint16(104), out.SequenceNumber)
+	assert.Equal(t, uint32(16000), out.Timestamp)
+
+	current, ok := s.Current()
+	assert.True(t, ok)
+	assert.Equal(t, "h", current.RID)
+
+	// Old layer is dropped once switched
+	_, ok = s.Forward(testPacket(10, 104, 10000, delta), now)
+	assert.False(t, ok)
+
+	out, ok = s.Forward(testPacket(20, 5002, 96000, delta), now)
+	assert.True(t, ok)
+	assert.Equal(t, uint16(105), out.SequenceNumber)
+	assert.Equal(t, uint32(19000), out.Timestamp)
+}
+
+func TestSelector_ForwardDoesNotModifyInput(t *testing.T) {
+	now := time.Now()
+	s, err := NewSelector(1, 90000, []Layer{{SSRC: 10}}, isTestKeyframe)
+	assert.NoError(t, err)
+
+	in := testPacket(10, 100, 1000, keyframe)
+	out, ok := s.Forward(in, now)
+	assert.True(t, ok)
+	assert.Equal(t, uint32(1), out.SSRC)
+	assert.Equal(t, uint32(10), in.SSRC)
+}
+
+func TestIsVP8Keyframe(t *testing.T) {
+	testCases := []struct {
+		payload  []byte
+		keyframe bool
+	}{
+		{[]byte{}, false},
+		// S bit, no extension, keyframe
+		{[]byte{0x10, 0x00}, true},
+		// S bit, no extension, interframe
+		{[]byte{0x10, 0x01}, false},
+		// No S bit
+		{[]byte{0x00, 0x00}, false},
+		// Partition 1
+		{[]byte{0x11, 0x00}, false},
+		// Extension with 15 bit PictureID, TL0PICIDX and TID
+		{[]byte{0x90, 0xe0, 0x80, 0x01, 0x02, 0x03, 0x00}, true},
+		{[]byte{0x90, 0xe0, 0x80, 0x01, 0x02, 0x03, 0x01}, false},
+		// Extension with 7 bit PictureID
+		{[]byte{0x90, 0x80, 0x01, 0x00}, true},
+		// Truncated
+		{[]byte{0x90, 0x80, 0x01}, false},
+		{[]byte{0x90}, false},
+	}
+
+	for i, testCase := range testCases {
+		assert.Equal(t,
+			testCase.keyframe,
+			IsVP8Keyframe(&rtp.Packet{Payload: testCase.payload}),
+			"testCase: %d %v", i, testCase,
+		)
+	}
+}
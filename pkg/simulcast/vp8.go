@@ -0,0 +1,58 @@
+package simulcast
+
+import (
+	"github.com/pion/rtp"
+)
+
+// IsVP8Keyframe is a KeyframeChecker for VP8, it reports if p is the first
+// packet of a VP8 keyframe. See RFC 7741 Section 4.
+func IsVP8Keyframe(p *rtp.Packet) bool {
+	payload := p.Payload
+	if len(payload) < 1 {
+		return false
+	}
+
+	// Only the start of partition 0 carries the VP8 payload header
+	x := payload[0]&0x80 != 0
+	s := payload[0]&0x10 != 0
+	partitionID := payload[0] & 0x07
+	if !s || partitionID != 0 {
+		return false
+	}
+
+	offset := 1
+	if x {
+		if len(payload) < offset+1 {
+			return false
+		}
+		i := payload[offset]&0x80 != 0
+		l := payload[offset]&0x40 != 0
+		t := payload[offset]&0x20 != 0
+		k := payload[offset]&0x10 != 0
+		offset++
+
+		if i {
+			if len(payload) < offset+1 {
+				return false
+			}
+			// M bit signals a 15 bit PictureID
+			if payload[offset]&0x80 != 0 {
+				offset++
+			}
+			offset++
+		}
+		if l {
+			offset++
+		}
+		if t || k {
+			offset++
+		}
+	}
+
+	if len(payload) < offset+1 {
+		return false
+	}
+
+	// P bit of the VP8 payload header is 0 for keyframes
+	return payload[offset]&0x01 == 0
+}
@@ -0,0 +1,113 @@
+// +build !js
+
+package testutil
+
+import (
+	mathRand "math/rand"
+	"sync"
+	"time"
+
+	"github.com/pion/webrtc/v2"
+)
+
+// candidateSignaler delivers the ICE candidates gathered by one PeerConnection
+// to the other, in order and one at a time, once its remote description is set
+type candidateSignaler struct {
+	remote  *webrtc.PeerConnection
+	delay   time.Duration
+	shuffle *mathRand.Rand
+
+	mu       sync.Mutex
+	ready    bool
+	complete bool
+	pending  []webrtc.ICECandidateInit
+
+	wake   chan struct{}
+	closed chan struct{}
+	done   chan struct{}
+}
+
+func newCandidateSignaler(remote *webrtc.PeerConnection, delay time.Duration, shuffle *mathRand.Rand, closed chan struct{}) *candidateSignaler {
+	s := &candidateSignaler{
+		remote:  remote,
+		delay:   delay,
+		shuffle: shuffle,
+		wake:    make(chan struct{}, 1),
+		closed:  closed,
+		done:    make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// add is the OnICECandidate handler of the local PeerConnection
+func (s *candidateSignaler) add(c *webrtc.ICECandidate) {
+	s.mu.Lock()
+	if c == nil {
+		s.complete = true
+	} else {
+		s.pending = append(s.pending, c.ToJSON())
+	}
+	s.mu.Unlock()
+	s.signal()
+}
+
+// setReady is called once the remote description of the remote PeerConnection is set
+func (s *candidateSignaler) setReady() {
+	s.mu.Lock()
+	s.ready = true
+	s.mu.Unlock()
+	s.signal()
+}
+
+func (s *candidateSignaler) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// next takes the candidates that can be delivered now
+func (s *candidateSignaler) next() []webrtc.ICECandidateInit {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Shuffled candidates are held until gathering completes
+	if !s.ready || (s.shuffle != nil && !s.complete) {
+		return nil
+	}
+
+	candidates := s.pending
+	s.pending = nil
+	if s.shuffle != nil {
+		s.shuffle.Shuffle(len(candidates), func(i, j int) {
+			candidates[i], candidates[j] = candidates[j], candidates[i]
+		})
+	}
+	return candidates
+}
+
+func (s *candidateSignaler) run() {
+	defer close(s.done)
+
+	for {
+		select {
+		case <-s.wake:
+		case <-s.closed:
+			return
+		}
+
+		for _, c := range s.next() {
+			if s.delay != 0 {
+				select {
+				case <-time.After(s.delay):
+				case <-s.closed:
+					return
+				}
+			}
+
+			// A failure to add a candidate surfaces as a failed connection
+			_ = s.remote.AddICECandidate(c)
+		}
+	}
+}
@@ -0,0 +1,270 @@
+// +build !js
+
+// Package testutil provides two PeerConnections that signal each other and
+// exchange media in memory, so applications built on pion/webrtc can write
+// fast and deterministic unit tests without real sockets or a signaling server
+package testutil
+
+import (
+	"errors"
+	mathRand "math/rand"
+	"sync"
+	"time"
+
+	"github.com/pion/logging"
+	"github.com/pion/transport/vnet"
+	"github.com/pion/webrtc/v2"
+)
+
+const (
+	routerCIDR  = "1.2.3.0/24"
+	offererIP   = "1.2.3.4"
+	answererIP  = "1.2.3.5"
+	pollingRate = 10 * time.Millisecond
+)
+
+var (
+	errPairClosed     = errors.New("testutil: Pair has been closed")
+	errConnectTimeout = errors.New("testutil: timed out waiting for the PeerConnections to connect")
+	errConnectFailed  = errors.New("testutil: PeerConnection failed to connect")
+)
+
+// Pair is two PeerConnections connected through an in-memory network. The
+// offer, answer and trickled ICE candidates are exchanged by the Pair, the
+// OnICECandidate handlers of the PeerConnections must not be replaced
+type Pair struct {
+	Offerer  *webrtc.PeerConnection
+	Answerer *webrtc.PeerConnection
+
+	settingEngine  webrtc.SettingEngine
+	mediaEngine    *webrtc.MediaEngine
+	configuration  webrtc.Configuration
+	candidateDelay time.Duration
+	shuffleSeed    *int64
+
+	router        *vnet.Router
+	routerStarted bool
+	toOfferer     *candidateSignaler
+	toAnswerer    *candidateSignaler
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+// NewPair creates two PeerConnections attached to a new in-memory network.
+// Tracks and DataChannels can be added before calling Negotiate or Connect
+func NewPair(opts ...Option) (*Pair, error) {
+	p := &Pair{closed: make(chan struct{})}
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	if p.mediaEngine == nil {
+		p.mediaEngine = &webrtc.MediaEngine{}
+		p.mediaEngine.RegisterDefaultCodecs()
+	}
+
+	loggerFactory := p.settingEngine.LoggerFactory
+	if loggerFactory == nil {
+		loggerFactory = logging.NewDefaultLoggerFactory()
+	}
+
+	var err error
+	if p.router, err = vnet.NewRouter(&vnet.RouterConfig{
+		CIDR:          routerCIDR,
+		LoggerFactory: loggerFactory,
+	}); err != nil {
+		return nil, err
+	}
+
+	if p.Offerer, err = p.newPeerConnection(offererIP); err != nil {
+		return nil, err
+	}
+	if p.Answerer, err = p.newPeerConnection(answererIP); err != nil {
+		return nil, p.closeOnError(err)
+	}
+
+	if err = p.router.Start(); err != nil {
+		return nil, p.closeOnError(err)
+	}
+	p.routerStarted = true
+
+	p.toAnswerer = p.newCandidateSignaler(p.Answerer, 0)
+	p.toOfferer = p.newCandidateSignaler(p.Offerer, 1)
+	p.Offerer.OnICECandidate(p.toAnswerer.add)
+	p.Answerer.OnICECandidate(p.toOfferer.add)
+
+	return p, nil
+}
+
+func (p *Pair) newPeerConnection(ip string) (*webrtc.PeerConnection, error) {
+	n := vnet.NewNet(&vnet.NetConfig{StaticIPs: []string{ip}})
+	if err := p.router.AddNet(n); err != nil {
+		return nil, err
+	}
+
+	// Candidates are always trickled, so they can be delayed and shuffled
+	s := p.settingEngine
+	s.SetVNet(n)
+	s.SetTrickle(true)
+
+	api := webrtc.NewAPI(webrtc.WithSettingEngine(s), webrtc.WithMediaEngine(*p.mediaEngine))
+	return api.NewPeerConnection(p.configuration)
+}
+
+func (p *Pair) newCandidateSignaler(remote *webrtc.PeerConnection, seedOffset int64) *candidateSignaler {
+	var shuffle *mathRand.Rand
+	if p.shuffleSeed != nil {
+		shuffle = mathRand.New(mathRand.NewSource(*p.shuffleSeed + seedOffset)) // nolint:gosec
+	}
+	return newCandidateSignaler(remote, p.candidateDelay, shuffle, p.closed)
+}
+
+func (p *Pair) closeOnError(err error) error {
+	if closeErr := p.Close(); closeErr != nil {
+		return closeErr
+	}
+	return err
+}
+
+// Negotiate exchanges an offer and an answer between the PeerConnections,
+// it can be called again to renegotiate after adding Tracks or DataChannels.
+// Candidates are delivered once the remote description has been set
+func (p *Pair) Negotiate() error {
+	select {
+	case <-p.closed:
+		return errPairClosed
+	default:
+	}
+
+	offer, err := p.Offerer.CreateOffer(nil)
+	if err != nil {
+		return err
+	}
+	if err = p.Offerer.SetLocalDescription(offer); err != nil {
+		return err
+	}
+	if err = p.Answerer.SetRemoteDescription(offer); err != nil {
+		return err
+	}
+	p.toAnswerer.setReady()
+
+	answer, err := p.Answerer.CreateAnswer(nil)
+	if err != nil {
+		return err
+	}
+	if err = p.Answerer.SetLocalDescription(answer); err != nil {
+		return err
+	}
+	if err = p.Offerer.SetRemoteDescription(answer); err != nil {
+		return err
+	}
+	p.toOfferer.setReady()
+
+	return nil
+}
+
+// Connect negotiates and waits until both PeerConnections are connected, or
+// until timeout has elapsed
+func (p *Pair) Connect(timeout time.Duration) error {
+	if err := p.Negotiate(); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(pollingRate)
+	defer ticker.Stop()
+	deadline := time.After(timeout)
+
+	for {
+		offererState, answererState := p.Offerer.ConnectionState(), p.Answerer.ConnectionState()
+		if offererState == webrtc.PeerConnectionStateFailed || answererState == webrtc.PeerConnectionStateFailed {
+			return errConnectFailed
+		}
+		if offererState == webrtc.PeerConnectionStateConnected && answererState == webrtc.PeerConnectionStateConnected {
+			return nil
+		}
+
+		select {
+		case <-ticker.C:
+		case <-deadline:
+			return errConnectTimeout
+		case <-p.closed:
+			return errPairClosed
+		}
+	}
+}
+
+// Close closes both PeerConnections and the in-memory network
+func (p *Pair) Close() error {
+	var closeErrs []error
+	p.closeOnce.Do(func() {
+		close(p.closed)
+		for _, s := range []*candidateSignaler{p.toAnswerer, p.toOfferer} {
+			if s != nil {
+				<-s.done
+			}
+		}
+
+		for _, pc := range []*webrtc.PeerConnection{p.Offerer, p.Answerer} {
+			if pc == nil {
+				continue
+			}
+			if err := pc.Close(); err != nil {
+				closeErrs = append(closeErrs, err)
+			}
+		}
+
+		if p.routerStarted {
+			if err := p.router.Stop(); err != nil {
+				closeErrs = append(closeErrs, err)
+			}
+		}
+	})
+
+	if len(closeErrs) != 0 {
+		return closeErrs[0]
+	}
+	return nil
+}
+
+// Option configures Pair
+type Option func(p *Pair)
+
+// WithSettingEngine sets the SettingEngine both PeerConnections are created
+// with. Its VNet is replaced by the in-memory network and trickle is enabled
+func WithSettingEngine(s webrtc.SettingEngine) Option {
+	return func(p *Pair) {
+		p.settingEngine = s
+	}
+}
+
+// WithMediaEngine sets the MediaEngine both PeerConnections are created with.
+// The default registers the default codecs
+func WithMediaEngine(m webrtc.MediaEngine) Option {
+	return func(p *Pair) {
+		p.mediaEngine = &m
+	}
+}
+
+// WithConfiguration sets the Configuration both PeerConnections are created with
+func WithConfiguration(configuration webrtc.Configuration) Option {
+	return func(p *Pair) {
+		p.configuration = configuration
+	}
+}
+
+// WithCandidateDelay delays the delivery of every ICE candidate to the remote
+// PeerConnection, as a slow signaling server would
+func WithCandidateDelay(delay time.Duration) Option {
+	return func(p *Pair) {
+		p.candidateDelay = delay
+	}
+}
+
+// WithShuffledCandidates holds the ICE candidates of each PeerConnection until
+// it has finished gathering, and delivers them in a random order derived from seed
+func WithShuffledCandidates(seed int64) Option {
+	return func(p *Pair) {
+		p.shuffleSeed = &seed
+	}
+}
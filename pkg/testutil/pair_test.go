@@ -0,0 +1,54 @@
+// +build !js
+
+package testutil
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/transport/test"
+	"github.com/pion/webrtc/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPair(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		opts []Option
+	}{
+		{"Default", nil},
+		{"Delayed", []Option{WithCandidateDelay(50 * time.Millisecond)}},
+		{"Shuffled", []Option{WithShuffledCandidates(1234)}},
+	} {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			lim := test.TimeOut(time.Second * 20)
+			defer lim.Stop()
+
+			report := test.CheckRoutines(t)
+			defer report()
+
+			p, err := NewPair(c.opts...)
+			assert.NoError(t, err)
+
+			dc, err := p.Offerer.CreateDataChannel("data", nil)
+			assert.NoError(t, err)
+
+			received := make(chan string)
+			p.Answerer.OnDataChannel(func(d *webrtc.DataChannel) {
+				d.OnMessage(func(msg webrtc.DataChannelMessage) {
+					received <- string(msg.Data)
+				})
+			})
+			dc.OnOpen(func() {
+				assert.NoError(t, dc.SendText("hello"))
+			})
+
+			assert.NoError(t, p.Connect(10*time.Second))
+			assert.Equal(t, "hello", <-received)
+
+			assert.NoError(t, p.Close())
+			assert.Equal(t, errPairClosed, p.Negotiate())
+		})
+	}
+}
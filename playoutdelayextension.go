@@ -0,0 +1,48 @@
+package webrtc
+
+import "time"
+
+const (
+	playoutDelayExtensionSize = 3
+
+	// The delays are carried in units of 10ms
+	playoutDelayGranularity = 10 * time.Millisecond
+	playoutDelayMax         = 0x0FFF * playoutDelayGranularity
+)
+
+// PlayoutDelayExtension is the payload of the playout-delay RTP header
+// extension, identified by PlayoutDelayURI. The sender uses it to ask the
+// receiver to keep its playout delay between Min and Max, a zero Max requests
+// that frames are rendered as soon as possible
+//
+// https://webrtc.googlesource.com/src/+/refs/heads/master/docs/native-code/rtp-hdrext/playout-delay
+//
+// 0                   1                   2
+// 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3
+// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+// |       MIN delay       |       MAX delay       |
+// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+type PlayoutDelayExtension struct {
+	Min, Max time.Duration
+}
+
+// Marshal serializes the members to buffer, the delays are rounded down to 10ms
+func (p PlayoutDelayExtension) Marshal() ([]byte, error) {
+	if p.Min < 0 || p.Min > p.Max || p.Max > playoutDelayMax {
+		return nil, ErrPlayoutDelayInvalid
+	}
+
+	min, max := uint16(p.Min/playoutDelayGranularity), uint16(p.Max/playoutDelayGranularity)
+	return []byte{byte(min >> 4), byte(min<<4) | byte(max>>8), byte(max)}, nil
+}
+
+// Unmarshal parses the passed byte slice and stores the result in the members
+func (p *PlayoutDelayExtension) Unmarshal(rawData []byte) error {
+	if len(rawData) < playoutDelayExtensionSize {
+		return ErrHeaderExtensionTooSmall
+	}
+
+	p.Min = time.Duration(uint16(rawData[0])<<4|uint16(rawData[1])>>4) * playoutDelayGranularity
+	p.Max = time.Duration(uint16(rawData[1]&0x0F)<<8|uint16(rawData[2])) * playoutDelayGranularity
+	return nil
+}
@@ -0,0 +1,30 @@
+package webrtc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlayoutDelayExtension(t *testing.T) {
+	p := PlayoutDelayExtension{Min: 100 * time.Millisecond, Max: 40950 * time.Millisecond}
+	raw, err := p.Marshal()
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x00, 0xAF, 0xFF}, raw)
+
+	parsed := PlayoutDelayExtension{}
+	assert.NoError(t, parsed.Unmarshal(raw))
+	assert.Equal(t, p, parsed)
+
+	assert.Equal(t, ErrHeaderExtensionTooSmall, parsed.Unmarshal(raw[:2]))
+
+	for _, invalid := range []PlayoutDelayExtension{
+		{Min: 20 * time.Millisecond, Max: 10 * time.Millisecond},
+		{Min: -10 * time.Millisecond},
+		{Max: 40960 * time.Millisecond},
+	} {
+		_, err = invalid.Marshal()
+		assert.Equal(t, ErrPlayoutDelayInvalid, err)
+	}
+}
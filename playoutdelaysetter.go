@@ -0,0 +1,54 @@
+// +build !js
+
+package webrtc
+
+import (
+	"strings"
+
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v2/pkg/interceptor"
+)
+
+// playoutDelaySetter is the built-in interceptor that adds the playout-delay
+// header extension to the packets of the video streams that negotiated it
+type playoutDelaySetter struct {
+	interceptor.NoOp
+
+	payload []byte
+}
+
+func newPlayoutDelaySetter(playoutDelay PlayoutDelayExtension) (*playoutDelaySetter, error) {
+	payload, err := playoutDelay.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	return &playoutDelaySetter{payload: payload}, nil
+}
+
+// BindLocalStream sets the playout delay on the packets written to video
+// streams that negotiated the playout-delay header extension
+func (p *playoutDelaySetter) BindLocalStream(info *interceptor.StreamInfo, writer interceptor.RTPWriter) interceptor.RTPWriter {
+	if !strings.HasPrefix(info.MimeType, mediaNameVideo+"/") {
+		return writer
+	}
+
+	var extensionID uint8
+	for _, e := range info.RTPHeaderExtensions {
+		if e.URI == PlayoutDelayURI {
+			extensionID = uint8(e.ID)
+		}
+	}
+	if extensionID == 0 {
+		return writer
+	}
+
+	return interceptor.RTPWriterFunc(func(header *rtp.Header, payload []byte) (int, error) {
+		// The extensions of the caller are left untouched
+		h := *header
+		h.Extensions = append([]rtp.Extension{}, header.Extensions...)
+		if err := h.SetExtension(extensionID, p.payload); err != nil {
+			return 0, err
+		}
+		return writer.Write(&h, payload)
+	})
+}
@@ -0,0 +1,168 @@
+// +build !js
+
+package webrtc
+
+import (
+	mathRand "math/rand"
+	"sync"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v2/pkg/interceptor"
+)
+
+// receiverReporter is the built-in interceptor that keeps the reception
+// statistics of the remote streams, and sends a Receiver Report about them
+// every interval. The Sender Reports of the remote are used to compute the
+// round trip time in the reports, so this only happens if RTCP is read
+type receiverReporter struct {
+	interceptor.NoOp
+
+	interval time.Duration
+	arrivals *arrivalTimes
+
+	// SSRC that Receiver Reports are sent from
+	ssrc uint32
+
+	mu      sync.Mutex
+	streams []*receiverReporterStream
+
+	closeOnce sync.Once
+	closed    chan struct{}
+	routines  routineGroup
+}
+
+type receiverReporterStream struct {
+	info  *interceptor.StreamInfo
+	stats *receptionStats
+}
+
+func newReceiverReporter(interval time.Duration, arrivals *arrivalTimes) *receiverReporter {
+	return &receiverReporter{
+		interval: interval,
+		arrivals: arrivals,
+		ssrc:     mathRand.Uint32(),
+		closed:   make(chan struct{}),
+	}
+}
+
+// BindRTCPWriter starts sending Receiver Reports to writer
+func (r *receiverReporter) BindRTCPWriter(writer interceptor.RTCPWriter) interceptor.RTCPWriter {
+	r.routines.Go(func() { r.sendReceiverReports(writer) })
+	return writer
+}
+
+// BindRemoteStream accounts for every packet read from the stream, at the time
+// it arrived rather than when it is read
+func (r *receiverReporter) BindRemoteStream(info *interceptor.StreamInfo, reader interceptor.RTPReader) interceptor.RTPReader {
+	stream := &receiverReporterStream{info: info, stats: &receptionStats{}}
+
+	r.mu.Lock()
+	r.streams = append(r.streams, stream)
+	r.mu.Unlock()
+
+	return interceptor.RTPReaderFunc(func(b []byte) (int, error) {
+		i, err := reader.Read(b)
+		if err != nil {
+			return i, err
+		}
+
+		header := &rtp.Header{}
+		if err := header.Unmarshal(b[:i]); err == nil {
+			stream.stats.update(header, info.ClockRate, r.arrivals.get(info.SSRC, header.SequenceNumber))
+		}
+		return i, nil
+	})
+}
+
+// UnbindRemoteStream stops reporting about the stream
+func (r *receiverReporter) UnbindRemoteStream(info *interceptor.StreamInfo) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.streams {
+		if r.streams[i].info == info {
+			r.streams = append(r.streams[:i], r.streams[i+1:]...)
+			return
+		}
+	}
+}
+
+// BindRTCPReader remembers the Sender Reports about the remote streams
+func (r *receiverReporter) BindRTCPReader(reader interceptor.RTCPReader) interceptor.RTCPReader {
+	return interceptor.RTCPReaderFunc(func(b []byte) (int, error) {
+		i, err := reader.Read(b)
+		if err != nil {
+			return i, err
+		}
+
+		pkts, err := rtcp.Unmarshal(b[:i])
+		if err != nil {
+			return i, nil
+		}
+
+		now := time.Now()
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		for _, pkt := range pkts {
+			sr, ok := pkt.(*rtcp.SenderReport)
+			if !ok {
+				continue
+			}
+
+			for _, stream := range r.streams {
+				if stream.info.SSRC == sr.SSRC {
+					stream.stats.updateSenderReport(sr, now)
+				}
+			}
+		}
+		return i, nil
+	})
+}
+
+// Close stops sending Receiver Reports
+func (r *receiverReporter) Close() error {
+	r.closeOnce.Do(func() {
+		close(r.closed)
+	})
+	r.routines.Close()
+	return nil
+}
+
+// receiverReport builds a Receiver Report about every stream that has been received,
+// it returns nil if nothing has been received yet
+func (r *receiverReporter) receiverReport(now time.Time) *rtcp.ReceiverReport {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rr := &rtcp.ReceiverReport{SSRC: r.ssrc}
+	for _, stream := range r.streams {
+		if report, ok := stream.stats.receptionReport(stream.info.SSRC, now); ok {
+			rr.Reports = append(rr.Reports, report)
+		}
+	}
+
+	if len(rr.Reports) == 0 {
+		return nil
+	}
+	return rr
+}
+
+// sendReceiverReports runs until Close. Reports that can't be written are
+// dropped, the next one carries the same statistics
+func (r *receiverReporter) sendReceiverReports(writer interceptor.RTCPWriter) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.closed:
+			return
+		case now := <-ticker.C:
+			if rr := r.receiverReport(now); rr != nil {
+				_, _ = writer.Write([]rtcp.Packet{rr})
+			}
+		}
+	}
+}
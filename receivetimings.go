@@ -0,0 +1,159 @@
+// +build !js
+
+package webrtc
+
+import (
+	"math/bits"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/pion/webrtc/v2/pkg/interceptor"
+)
+
+// timingHistogramBuckets is the number of buckets of a TimingHistogram, the
+// first holds durations up to 64ns and every following one doubles that
+const timingHistogramBuckets = 32
+
+// timingEpoch is the reference of the monotonic timestamps of the timers
+var timingEpoch = time.Now()
+
+// ReceiveTimings measures how long every layer of the receive pipeline spends
+// on a packet. A layer is timed from the moment it reads a packet until it
+// reads the next one, so this also includes the time it is blocked handing the
+// packet to the next layer. Set it with SettingEngine.SetReceiveTimings, it
+// can be shared by many PeerConnections and read while they are running.
+type ReceiveTimings struct {
+	// Demux is the time to match a packet read from the ICE transport and
+	// hand it to DTLS, SRTP or SRTCP
+	Demux TimingHistogram
+
+	// DTLS is the time to decrypt a DTLS record and hand its data to SCTP
+	DTLS TimingHistogram
+
+	// SCTP is the time to process an SCTP packet and deliver its messages
+	// to the DataChannels
+	SCTP TimingHistogram
+
+	// SRTP and SRTCP are the time to decrypt a packet and hand it to the
+	// stream of its SSRC
+	SRTP  TimingHistogram
+	SRTCP TimingHistogram
+
+	// Interceptors is the time the interceptors spend on an RTP packet before
+	// it is returned by Track.Read
+	Interceptors TimingHistogram
+}
+
+// TimingHistogram counts durations in buckets whose upper bounds are powers
+// of two, it is safe for concurrent use
+type TimingHistogram struct {
+	count   uint64
+	sum     uint64
+	buckets [timingHistogramBuckets]uint64
+}
+
+// TimingBucket is the number of durations that were at most UpperBound and
+// more than the UpperBound of the previous bucket
+type TimingBucket struct {
+	UpperBound time.Duration
+	Count      uint64
+}
+
+// Observe adds d to the histogram
+func (h *TimingHistogram) Observe(d time.Duration) {
+	i := 0
+	if d > 0 {
+		i = bits.Len64(uint64(d-1) >> 6)
+	}
+	if i >= timingHistogramBuckets {
+		i = timingHistogramBuckets - 1
+	}
+
+	atomic.AddUint64(&h.buckets[i], 1)
+	atomic.AddUint64(&h.count, 1)
+	if d > 0 {
+		atomic.AddUint64(&h.sum, uint64(d))
+	}
+}
+
+// Count returns the number of observed durations
+func (h *TimingHistogram) Count() uint64 {
+	return atomic.LoadUint64(&h.count)
+}
+
+// Sum returns the sum of the observed durations
+func (h *TimingHistogram) Sum() time.Duration {
+	return time.Duration(atomic.LoadUint64(&h.sum))
+}
+
+// Buckets returns the counts of the histogram. The last bucket also holds
+// every duration longer than its UpperBound
+func (h *TimingHistogram) Buckets() []TimingBucket {
+	buckets := make([]TimingBucket, timingHistogramBuckets)
+	for i := range buckets {
+		buckets[i] = TimingBucket{
+			UpperBound: time.Duration(64) << uint(i),
+			Count:      atomic.LoadUint64(&h.buckets[i]),
+		}
+	}
+	return buckets
+}
+
+// packetTimer observes the time from mark until observe, e.g. from a packet
+// being read until the next one is
+type packetTimer struct {
+	histogram *TimingHistogram
+	marked    int64 // nanoseconds since timingEpoch, 0 before the first mark
+}
+
+func (t *packetTimer) observe() {
+	if marked := atomic.LoadInt64(&t.marked); marked != 0 {
+		t.histogram.Observe(time.Since(timingEpoch) - time.Duration(marked))
+	}
+}
+
+func (t *packetTimer) mark() {
+	atomic.StoreInt64(&t.marked, int64(time.Since(timingEpoch)))
+}
+
+// timedConn times the layer reading packets from a net.Conn
+type timedConn struct {
+	net.Conn
+	timer packetTimer
+}
+
+// timeReceive times the reads from conn in the histogram of timings, conn is
+// returned as is when timings is nil
+func timeReceive(timings *ReceiveTimings, conn net.Conn, histogram func(*ReceiveTimings) *TimingHistogram) net.Conn {
+	if timings == nil {
+		return conn
+	}
+	return &timedConn{Conn: conn, timer: packetTimer{histogram: histogram(timings)}}
+}
+
+func (c *timedConn) Read(b []byte) (int, error) {
+	c.timer.observe()
+	n, err := c.Conn.Read(b)
+	c.timer.mark()
+	return n, err
+}
+
+// bindTimedRemoteStream binds reader to the interceptors and times them from
+// reader returning a packet until the interceptors return it
+func bindTimedRemoteStream(chain interceptor.Interceptor, info *interceptor.StreamInfo, reader interceptor.RTPReader, histogram *TimingHistogram) interceptor.RTPReader {
+	timer := &packetTimer{histogram: histogram}
+	bound := chain.BindRemoteStream(info, interceptor.RTPReaderFunc(func(b []byte) (int, error) {
+		n, err := reader.Read(b)
+		timer.mark()
+		return n, err
+	}))
+
+	return interceptor.RTPReaderFunc(func(b []byte) (int, error) {
+		n, err := bound.Read(b)
+		if err == nil {
+			timer.observe()
+		}
+		return n, err
+	})
+}
@@ -0,0 +1,113 @@
+// +build !js
+
+package webrtc
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/pion/transport/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimingHistogram(t *testing.T) {
+	h := TimingHistogram{}
+	h.Observe(0)
+	h.Observe(64 * time.Nanosecond)
+	h.Observe(65 * time.Nanosecond)
+	h.Observe(time.Millisecond)
+	h.Observe(time.Hour)
+
+	assert.Equal(t, uint64(5), h.Count())
+	assert.Equal(t, 129*time.Nanosecond+time.Millisecond+time.Hour, h.Sum())
+
+	buckets := h.Buckets()
+	assert.Equal(t, timingHistogramBuckets, len(buckets))
+	assert.Equal(t, TimingBucket{UpperBound: 64 * time.Nanosecond, Count: 2}, buckets[0])
+	assert.Equal(t, TimingBucket{UpperBound: 128 * time.Nanosecond, Count: 1}, buckets[1])
+	assert.Equal(t, TimingBucket{UpperBound: 64 * time.Nanosecond << 14, Count: 1}, buckets[14])
+	assert.Equal(t, uint64(1), buckets[timingHistogramBuckets-1].Count)
+}
+
+func TestReceiveTimings(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	m := MediaEngine{}
+	m.RegisterDefaultCodecs()
+
+	timings := &ReceiveTimings{}
+	s := SettingEngine{}
+	s.SetReceiveTimings(timings)
+
+	pcOffer, err := NewAPI(WithMediaEngine(m)).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	pcAnswer, err := NewAPI(WithMediaEngine(m), WithSettingEngine(s)).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	track, err := pcOffer.NewTrack(DefaultPayloadTypeVP8, rand.Uint32(), "video", "pion")
+	assert.NoError(t, err)
+
+	_, err = pcOffer.AddTrack(track)
+	assert.NoError(t, err)
+
+	dc, err := pcOffer.CreateDataChannel(expectedLabel, nil)
+	assert.NoError(t, err)
+	dc.OnOpen(func() {
+		assert.NoError(t, dc.SendText("timed"))
+	})
+
+	messageReceived := make(chan struct{})
+	pcAnswer.OnDataChannel(func(d *DataChannel) {
+		if d.Label() != expectedLabel {
+			return
+		}
+		d.OnMessage(func(DataChannelMessage) {
+			close(messageReceived)
+		})
+	})
+
+	packetsReceived := make(chan struct{})
+	pcAnswer.OnTrack(func(remoteTrack *Track, receiver *RTPReceiver) {
+		// A packet is only timed once the next one is read
+		for i := 0; i < 3; i++ {
+			if _, routineErr := remoteTrack.ReadRTP(); routineErr != nil {
+				return
+			}
+		}
+		close(packetsReceived)
+	})
+
+	assert.NoError(t, signalPair(pcOffer, pcAnswer))
+
+	func() {
+		for sequenceNumber := uint16(0); ; sequenceNumber++ {
+			select {
+			case <-time.After(20 * time.Millisecond):
+				assert.NoError(t, track.WriteRTP(&rtp.Packet{
+					Header:  rtp.Header{Version: 2, SSRC: track.SSRC(), SequenceNumber: sequenceNumber},
+					Payload: []byte{0x00},
+				}))
+			case <-packetsReceived:
+				return
+			}
+		}
+	}()
+	<-messageReceived
+
+	for name, h := range map[string]*TimingHistogram{
+		"Demux":        &timings.Demux,
+		"DTLS":         &timings.DTLS,
+		"SCTP":         &timings.SCTP,
+		"SRTP":         &timings.SRTP,
+		"Interceptors": &timings.Interceptors,
+	} {
+		assert.NotZero(t, h.Count(), name)
+	}
+
+	assert.NoError(t, pcOffer.Close())
+	assert.NoError(t, pcAnswer.Close())
+}
@@ -0,0 +1,134 @@
+// +build !js
+
+package webrtc
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+)
+
+// receptionStats keeps the statistics of a single incoming SSRC that are
+// reported back to the sender in reception reports, RFC 3550 Appendix A
+type receptionStats struct {
+	mu sync.Mutex
+
+	started         bool
+	baseSequence    uint16
+	maxSequence     uint16
+	cycles          uint32
+	packetsReceived uint32
+
+	// Values at the time of the previous report, to compute the fraction lost
+	expectedPrior uint32
+	receivedPrior uint32
+
+	// Interarrival jitter in RTP timestamp units. Arrivals are measured from
+	// the first one, so they don't overflow once scaled to the clock rate
+	haveTransit bool
+	transitBase time.Time
+	lastTransit uint32
+	jitter      float64
+
+	// Middle 32 bits of the NTP timestamp of the last Sender Report, and when it arrived
+	lastSenderReport     uint32
+	lastSenderReportTime time.Time
+}
+
+// update accounts for a packet that arrived at arrival. clockRate is the one
+// of the codec the packet belongs to, jitter isn't computed while it is unknown
+func (s *receptionStats) update(header *rtp.Header, clockRate uint32, arrival time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.packetsReceived++
+	if !s.started {
+		s.started = true
+		s.baseSequence = header.SequenceNumber
+		s.maxSequence = header.SequenceNumber
+	} else if delta := header.SequenceNumber - s.maxSequence; delta != 0 && delta < 1<<15 {
+		// In order, with a permissible gap. Anything else is a duplicate or reordered
+		if header.SequenceNumber < s.maxSequence {
+			s.cycles += 1 << 16
+		}
+		s.maxSequence = header.SequenceNumber
+	}
+
+	if clockRate == 0 {
+		return
+	}
+
+	// RFC 3550 Appendix A.8, the transit times wrap like the RTP timestamps
+	if !s.haveTransit {
+		s.transitBase = arrival
+	}
+	arrivalRTP := uint32(int64(arrival.Sub(s.transitBase).Seconds() * float64(clockRate)))
+	transit := arrivalRTP - header.Timestamp
+	if s.haveTransit {
+		d := int64(int32(transit - s.lastTransit))
+		if d < 0 {
+			d = -d
+		}
+		s.jitter += (float64(d) - s.jitter) / 16
+	}
+	s.haveTransit = true
+	s.lastTransit = transit
+}
+
+// updateSenderReport remembers the last Sender Report of the SSRC so the
+// sender can compute the round trip time from the reception reports
+func (s *receptionStats) updateSenderReport(sr *rtcp.SenderReport, arrival time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastSenderReport = uint32(sr.NTPTime >> 16)
+	s.lastSenderReportTime = arrival
+}
+
+// receptionReport builds a report about ssrc, it returns false if no packet has been received yet
+func (s *receptionStats) receptionReport(ssrc uint32, now time.Time) (rtcp.ReceptionReport, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.started {
+		return rtcp.ReceptionReport{}, false
+	}
+
+	// RFC 3550 Appendix A.3
+	extendedMax := s.cycles | uint32(s.maxSequence)
+	expected := extendedMax - uint32(s.baseSequence) + 1
+	lost := int64(expected) - int64(s.packetsReceived)
+	switch {
+	case lost > 0x7fffff:
+		lost = 0x7fffff
+	case lost < -0x800000:
+		lost = -0x800000
+	}
+
+	expectedInterval := expected - s.expectedPrior
+	receivedInterval := s.packetsReceived - s.receivedPrior
+	s.expectedPrior = expected
+	s.receivedPrior = s.packetsReceived
+
+	var fractionLost uint8
+	if lostInterval := int64(expectedInterval) - int64(receivedInterval); expectedInterval != 0 && lostInterval > 0 {
+		fractionLost = uint8((lostInterval << 8) / int64(expectedInterval))
+	}
+
+	report := rtcp.ReceptionReport{
+		SSRC:               ssrc,
+		FractionLost:       fractionLost,
+		TotalLost:          uint32(lost) & 0xffffff,
+		LastSequenceNumber: extendedMax,
+		Jitter:             uint32(s.jitter),
+		LastSenderReport:   s.lastSenderReport,
+	}
+
+	// The delay since the last Sender Report is in units of 1/65536 seconds
+	if !s.lastSenderReportTime.IsZero() {
+		report.Delay = uint32(now.Sub(s.lastSenderReportTime).Seconds() * 65536)
+	}
+	return report, true
+}
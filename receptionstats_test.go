@@ -0,0 +1,79 @@
+// +build !js
+
+package webrtc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReceptionStats(t *testing.T) {
+	t.Run("Nothing received", func(t *testing.T) {
+		s := &receptionStats{}
+		_, ok := s.receptionReport(1234, time.Now())
+		assert.False(t, ok)
+	})
+
+	t.Run("Loss and wraparound", func(t *testing.T) {
+		s := &receptionStats{}
+		now := time.Now()
+		for _, sequenceNumber := range []uint16{65530, 65531, 65533, 65532, 65535, 0, 1, 3} {
+			s.update(&rtp.Header{SequenceNumber: sequenceNumber}, 0, now)
+		}
+
+		report, ok := s.receptionReport(1234, now)
+		assert.True(t, ok)
+		assert.Equal(t, uint32(1234), report.SSRC)
+		assert.Equal(t, uint32(1<<16|3), report.LastSequenceNumber)
+		assert.Equal(t, uint32(2), report.TotalLost)
+		assert.Equal(t, uint8(2*256/10), report.FractionLost)
+
+		// The fraction lost only covers the packets since the previous report
+		s.update(&rtp.Header{SequenceNumber: 4}, 0, now)
+		report, _ = s.receptionReport(1234, now)
+		assert.Equal(t, uint32(2), report.TotalLost)
+		assert.Equal(t, uint8(0), report.FractionLost)
+	})
+
+	t.Run("Jitter", func(t *testing.T) {
+		s := &receptionStats{}
+		now := time.Now()
+
+		// 20ms of audio at 48kHz in every packet, each arriving 10ms late
+		for i := 0; i < 2; i++ {
+			s.update(&rtp.Header{SequenceNumber: uint16(i), Timestamp: uint32(i * 960)}, 48000, now.Add(time.Duration(i)*30*time.Millisecond))
+		}
+
+		report, _ := s.receptionReport(1234, now)
+		assert.Equal(t, uint32(480/16), report.Jitter)
+	})
+
+	t.Run("Jitter with wrapping timestamps", func(t *testing.T) {
+		s := &receptionStats{}
+		now := time.Now()
+
+		// A steady stream of video over days, the RTP timestamps wrap every 13 hours
+		for i := 0; i < 100; i++ {
+			timestamp := uint32(uint64(i) * 3600 * 90000)
+			s.update(&rtp.Header{SequenceNumber: uint16(i), Timestamp: timestamp}, 90000, now.Add(time.Duration(i)*time.Hour))
+		}
+
+		report, _ := s.receptionReport(1234, now)
+		assert.Equal(t, uint32(0), report.Jitter)
+	})
+
+	t.Run("Last Sender Report", func(t *testing.T) {
+		s := &receptionStats{}
+		now := time.Now()
+		s.update(&rtp.Header{}, 0, now)
+		s.updateSenderReport(&rtcp.SenderReport{NTPTime: 0x1122334455667788}, now)
+
+		report, _ := s.receptionReport(1234, now.Add(time.Second))
+		assert.Equal(t, uint32(0x33445566), report.LastSenderReport)
+		assert.Equal(t, uint32(65536), report.Delay)
+	})
+}
@@ -0,0 +1,55 @@
+// +build !js
+
+package webrtc
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// resourceCounters count the goroutines of routineGroups and the ICE agents,
+// which own the sockets, that are alive in the process. Tests compare them
+// before and after closing PeerConnections to assert that nothing leaked.
+var resourceCounters struct {
+	routines  int64
+	iceAgents int64
+}
+
+// routineGroup runs the goroutines of an object so that closing it can wait
+// for them to return. The goroutines of event handlers must not be run by a
+// routineGroup, as a handler closing the object would wait for itself.
+type routineGroup struct {
+	mu     sync.Mutex
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// Go runs f in a new goroutine, f is not run once the group is closed
+func (g *routineGroup) Go(f func()) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.closed {
+		return
+	}
+
+	g.wg.Add(1)
+	atomic.AddInt64(&resourceCounters.routines, 1)
+	go func() {
+		defer func() {
+			atomic.AddInt64(&resourceCounters.routines, -1)
+			g.wg.Done()
+		}()
+		f()
+	}()
+}
+
+// Close prevents new goroutines from being run and waits for the running
+// ones to return, they must have been told to stop beforehand
+func (g *routineGroup) Close() {
+	g.mu.Lock()
+	g.closed = true
+	g.mu.Unlock()
+
+	g.wg.Wait()
+}
@@ -0,0 +1,11 @@
+// +build !js
+
+package webrtc
+
+// RTPCodecParameters is a codec both sides agreed on, with the payload type
+// its packets are sent with
+// https://w3c.github.io/webrtc-pc/#dom-rtcrtpcodecparameters
+type RTPCodecParameters struct {
+	RTPCodecCapability
+	PayloadType uint8 `json:"payloadType"`
+}
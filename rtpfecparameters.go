@@ -0,0 +1,8 @@
+package webrtc
+
+// RTPFecParameters describes the forward error correction (FEC) stream of an
+// encoding, the SSRC is zero if FlexFEC hasn't been negotiated
+// http://draft.ortc.org/#dom-rtcrtpfecparameters
+type RTPFecParameters struct {
+	SSRC uint32 `json:"ssrc"`
+}
@@ -0,0 +1,11 @@
+// +build !js
+
+package webrtc
+
+// RTPParameters is a list of negotiated codecs and header extensions.
+// This is a subset of the RFC, the RTCP parameters are not provided
+// https://w3c.github.io/webrtc-pc/#dictionary-rtcrtpparameters-members
+type RTPParameters struct {
+	HeaderExtensions []RTPHeaderExtensionParameter `json:"headerExtensions"`
+	Codecs           []RTPCodecParameters          `json:"codecs"`
+}
@@ -4,24 +4,55 @@ package webrtc
 
 import (
 	"fmt"
+	"io"
 	"sync"
+	"time"
 
 	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
 	"github.com/pion/srtp"
+	"github.com/pion/webrtc/v2/internal/util"
+	"github.com/pion/webrtc/v2/pkg/interceptor"
 )
 
+// trackStreams maintains a mapping of RTP/RTCP streams to a specific track
+// a RTPReceiver may contain multiple streams if we are dealing with Simulcast
+type trackStreams struct {
+	track *Track
+
+	// The encoding as announced by the remote
+	parameters RTPCodingParameters
+
+	rtpReadStream  *srtp.ReadStreamSRTP
+	rtcpReadStream *srtp.ReadStreamSRTCP
+
+	// The FlexFEC stream protecting the RTP stream, nil if there is none
+	fecReadStream *srtp.ReadStreamSRTP
+
+	// The streams as bound to the interceptors of the transport
+	streamInfo *interceptor.StreamInfo
+	rtpReader  interceptor.RTPReader
+	rtcpReader interceptor.RTCPReader
+
+	jitterBuffer *jitterBuffer
+}
+
 // RTPReceiver allows an application to inspect the receipt of a Track
 type RTPReceiver struct {
 	kind      RTPCodecType
 	transport *DTLSTransport
 
-	track *Track
+	tracks           []trackStreams
+	headerExtensions []RTPHeaderExtensionParameter
+	codecs           []RTPCodecParameters
 
 	closed, received chan interface{}
 	mu               sync.RWMutex
 
-	rtpReadStream  *srtp.ReadStreamSRTP
-	rtcpReadStream *srtp.ReadStreamSRTCP
+	// routines runs the goroutines reading the FlexFEC streams
+	routines routineGroup
+
+	statsID string
 
 	// A reference to the associated api object
 	api *API
@@ -30,7 +61,7 @@ type RTPReceiver struct {
 // NewRTPReceiver constructs a new RTPReceiver
 func (api *API) NewRTPReceiver(kind RTPCodecType, transport *DTLSTransport) (*RTPReceiver, error) {
 	if transport == nil {
-		return nil, fmt.Errorf("DTLSTransport must not be nil")
+		return nil, ErrNilDTLSTransport
 	}
 
 	return &RTPReceiver{
@@ -39,6 +70,7 @@ func (api *API) NewRTPReceiver(kind RTPCodecType, transport *DTLSTransport) (*RT
 		api:       api,
 		closed:    make(chan interface{}),
 		received:  make(chan interface{}),
+		statsID:   fmt.Sprintf("RTPReceiver-%d", time.Now().UnixNano()),
 	}, nil
 }
 
@@ -50,11 +82,69 @@ func (r *RTPReceiver) Transport() *DTLSTransport {
 	return r.transport
 }
 
-// Track returns the RTCRtpTransceiver track
+// GetParameters describes the current configuration for the reception of
+// media. The IDs of the negotiated header extensions are used with
+// rtp.Header.GetExtension on incoming packets
+func (r *RTPReceiver) GetParameters() RTPParameters {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return RTPParameters{
+		HeaderExtensions: append([]RTPHeaderExtensionParameter{}, r.headerExtensions...),
+		Codecs:           append([]RTPCodecParameters{}, r.codecs...),
+	}
+}
+
+// GetEncodings describes the encodings received by this RTPReceiver, one per
+// Simulcast layer. The payload type is known once the first packet was read
+func (r *RTPReceiver) GetEncodings() []RTPDecodingParameters {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	encodings := make([]RTPDecodingParameters, 0, len(r.tracks))
+	for i := range r.tracks {
+		encoding := RTPDecodingParameters{RTPCodingParameters: r.tracks[i].parameters}
+		encoding.PayloadType = r.tracks[i].track.PayloadType()
+		encodings = append(encodings, encoding)
+	}
+	return encodings
+}
+
+func (r *RTPReceiver) setHeaderExtensions(headerExtensions []RTPHeaderExtensionParameter) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.headerExtensions = headerExtensions
+}
+
+func (r *RTPReceiver) setCodecs(codecs []RTPCodecParameters) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.codecs = codecs
+}
+
+// Track returns the RTCRtpTransceiver track. If the remote is sending
+// Simulcast this is the first layer that was received
 func (r *RTPReceiver) Track() *Track {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
-	return r.track
+
+	if len(r.tracks) == 0 {
+		return nil
+	}
+	return r.tracks[0].track
+}
+
+// Tracks returns the RTCRtpTransceiver tracks. A RTPReceiver only has more
+// than one Track when the remote is sending Simulcast, there is one Track per
+// layer and each can be read independently
+func (r *RTPReceiver) Tracks() []*Track {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var tracks []*Track
+	for i := range r.tracks {
+		tracks = append(tracks, r.tracks[i].track)
+	}
+	return tracks
 }
 
 // Receive initialize the track and starts all the transports
@@ -63,53 +153,161 @@ func (r *RTPReceiver) Receive(parameters RTPReceiveParameters) error {
 	defer r.mu.Unlock()
 	select {
 	case <-r.received:
-		return fmt.Errorf("Receive has already been called")
+		return ErrRTPReceiverReceiveAlreadyCalled
 	default:
 	}
 	defer close(r.received)
 
-	r.track = &Track{
-		kind:     r.kind,
-		ssrc:     parameters.Encodings.SSRC,
-		receiver: r,
+	_, err := r.addTrack(parameters.Encodings.RTPCodingParameters, nil)
+	return err
+}
+
+// receiveForRid starts receiving the Simulcast layer identified by rid. The
+// first layer also completes Receive for this RTPReceiver. The packets that
+// were read from the SSRC to learn its rid are read from the Track first
+func (r *RTPReceiver) receiveForRid(rid string, ssrc uint32, probed [][]byte) (*Track, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	select {
+	case <-r.closed:
+		return nil, ErrRTPReceiverStopped
+	case <-r.received:
+	default:
+		defer close(r.received)
+	}
+
+	for i := range r.tracks {
+		if r.tracks[i].track.RID() == rid {
+			return nil, fmt.Errorf("%w %s", ErrRTPReceiverRIDExists, rid)
+		}
+	}
+
+	return r.addTrack(RTPCodingParameters{RID: rid, SSRC: ssrc}, probed)
+}
+
+// addTrack opens the streams for a single SSRC and binds them to the interceptors,
+// the packets already read from the SSRC are replayed before the stream.
+// Caller must hold r.mu
+func (r *RTPReceiver) addTrack(parameters RTPCodingParameters, probed [][]byte) (*Track, error) {
+	t := trackStreams{
+		parameters: parameters,
+		track: &Track{
+			kind:     r.kind,
+			ssrc:     parameters.SSRC,
+			rid:      parameters.RID,
+			receiver: r,
+		},
+	}
+	if depth := r.api.settingEngine.receive.JitterBufferDepth; depth != 0 {
+		t.jitterBuffer = newJitterBuffer(depth, r.api.settingEngine.getJitterBufferMaxDelay())
 	}
 
 	srtpSession, err := r.transport.getSRTPSession()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	r.rtpReadStream, err = srtpSession.OpenReadStream(parameters.Encodings.SSRC)
+	r.transport.arrivals.watch(parameters.SSRC)
+	t.rtpReadStream, err = srtpSession.OpenReadStream(parameters.SSRC)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	srtcpSession, err := r.transport.getSRTCPSession()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	r.rtcpReadStream, err = srtcpSession.OpenReadStream(parameters.Encodings.SSRC)
+	t.rtcpReadStream, err = srtcpSession.OpenReadStream(parameters.SSRC)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	var rtpReader interceptor.RTPReader = t.rtpReadStream
+	if len(probed) != 0 {
+		rtpReader = &replayReader{RTPReader: rtpReader, packets: probed}
+	}
+	if parameters.FEC.SSRC != 0 {
+		fecReadStream, err := srtpSession.OpenReadStream(parameters.FEC.SSRC)
+		if err != nil {
+			return nil, err
+		}
+		t.fecReadStream = fecReadStream
+
+		recoverer := newFECRecoverer(parameters.SSRC, t.rtpReadStream)
+		r.routines.Go(func() { recoverer.readFEC(fecReadStream, r.api.settingEngine.getReceiveMTU()) })
+		rtpReader = recoverer
+	}
+
+	t.streamInfo = remoteStreamInfo(parameters.SSRC, r.codecs, r.headerExtensions)
+	if timings := r.api.settingEngine.instrumentation.ReceiveTimings; timings != nil {
+		t.rtpReader = bindTimedRemoteStream(r.transport.interceptor, t.streamInfo, rtpReader, &timings.Interceptors)
+	} else {
+		t.rtpReader = r.transport.interceptor.BindRemoteStream(t.streamInfo, rtpReader)
+	}
+	t.rtcpReader = r.transport.interceptor.BindRTCPReader(t.rtcpReadStream)
+
+	// The packets are read as they arrive, so the ones waiting behind a gap
+	// are given up on after the max delay even if no more arrive
+	if jitterBuffer := t.jitterBuffer; jitterBuffer != nil {
+		rtpReader, mtu := t.rtpReader, r.api.settingEngine.getReceiveMTU()
+		arrival := func(header *rtp.Header) time.Time {
+			return r.transport.arrivals.get(header.SSRC, header.SequenceNumber)
+		}
+		r.routines.Go(func() { jitterBuffer.readFrom(rtpReader, mtu, arrival, r.closed) })
 	}
 
-	return nil
+	r.tracks = append(r.tracks, t)
+	return t.track, nil
 }
 
-// Read reads incoming RTCP for this RTPReceiver
+// Read reads incoming RTCP for this RTPReceiver. The Sender Reports of the remote
+// are used to compute the round trip time in Receiver Reports, so this only happens if RTCP is read.
+// If the remote is sending Simulcast this reads the RTCP of the first layer, see ReadSimulcast
 func (r *RTPReceiver) Read(b []byte) (n int, err error) {
 	select {
 	case <-r.received:
-		return r.rtcpReadStream.Read(b)
+		r.mu.RLock()
+		if len(r.tracks) == 0 {
+			r.mu.RUnlock()
+			return 0, ErrRTPReceiverNoStreams
+		}
+		rtcpReader := r.tracks[0].rtcpReader
+		r.mu.RUnlock()
+
+		return rtcpReader.Read(b)
 	case <-r.closed:
-		return 0, fmt.Errorf("RtpReceiver has been stopped")
+		return 0, ErrRTPReceiverStopped
+	}
+}
+
+// ReadSimulcast reads incoming RTCP for the Simulcast layer identified by rid
+func (r *RTPReceiver) ReadSimulcast(b []byte, rid string) (n int, err error) {
+	select {
+	case <-r.received:
+		var rtcpReader interceptor.RTCPReader
+		r.mu.RLock()
+		for i := range r.tracks {
+			if r.tracks[i].track.RID() == rid {
+				rtcpReader = r.tracks[i].rtcpReader
+				break
+			}
+		}
+		r.mu.RUnlock()
+
+		if rtcpReader == nil {
+			return 0, fmt.Errorf("%w for rid %s", ErrRTPReceiverStreamNotFound, rid)
+		}
+		return rtcpReader.Read(b)
+	case <-r.closed:
+		return 0, ErrRTPReceiverStopped
 	}
 }
 
 // ReadRTCP is a convenience method that wraps Read and unmarshals for you
 func (r *RTPReceiver) ReadRTCP() ([]rtcp.Packet, error) {
-	b := make([]byte, receiveMTU)
+	b := make([]byte, r.api.settingEngine.getReceiveMTU())
 	i, err := r.Read(b)
 	if err != nil {
 		return nil, err
@@ -118,6 +316,46 @@ func (r *RTPReceiver) ReadRTCP() ([]rtcp.Packet, error) {
 	return rtcp.Unmarshal(b[:i])
 }
 
+// ReadSimulcastRTCP is a convenience method that wraps ReadSimulcast and unmarshals for you
+func (r *RTPReceiver) ReadSimulcastRTCP(rid string) ([]rtcp.Packet, error) {
+	b := make([]byte, r.api.settingEngine.getReceiveMTU())
+	i, err := r.ReadSimulcast(b, rid)
+	if err != nil {
+		return nil, err
+	}
+
+	return rtcp.Unmarshal(b[:i])
+}
+
+// collectStats reports an InboundRTPStreamStats per SSRC received, the packets
+// are counted as they arrive whether they are read or not
+func (r *RTPReceiver) collectStats(collector *statsReportCollector) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for i := range r.tracks {
+		track := r.tracks[i].track
+		packets, bytes, last := r.transport.arrivals.received(track.SSRC())
+		stats := InboundRTPStreamStats{
+			Timestamp:       statsTimestampNow(),
+			Type:            StatsTypeInboundRTP,
+			ID:              fmt.Sprintf("%s-%d", r.statsID, track.SSRC()),
+			SSRC:            track.SSRC(),
+			Kind:            track.Kind().String(),
+			TrackID:         track.ID(),
+			ReceiverID:      r.statsID,
+			PacketsReceived: uint32(packets),
+			BytesReceived:   bytes,
+		}
+		if !last.IsZero() {
+			stats.LastPacketReceivedTimestamp = statsTimestampFrom(last)
+		}
+
+		collector.Collecting()
+		collector.Collect(stats.ID, stats)
+	}
+}
+
 func (r *RTPReceiver) haveReceived() bool {
 	select {
 	case <-r.received:
@@ -129,6 +367,9 @@ func (r *RTPReceiver) haveReceived() bool {
 
 // Stop irreversibly stops the RTPReceiver
 func (r *RTPReceiver) Stop() error {
+	// Deferred first, so the goroutines are waited for once the lock is released
+	defer r.routines.Close()
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
@@ -138,27 +379,82 @@ func (r *RTPReceiver) Stop() error {
 	default:
 	}
 
+	// Every stream is closed even if closing another fails, the routines
+	// reading the FEC streams are waited for
+	var closeErrs []error
 	select {
 	case <-r.received:
-		if r.rtcpReadStream != nil {
-			if err := r.rtcpReadStream.Close(); err != nil {
-				return err
+		for i := range r.tracks {
+			r.transport.interceptor.UnbindRemoteStream(r.tracks[i].streamInfo)
+			r.transport.arrivals.forget(r.tracks[i].track.ssrc)
+			if r.tracks[i].rtcpReadStream != nil {
+				if err := r.tracks[i].rtcpReadStream.Close(); err != nil {
+					closeErrs = append(closeErrs, err)
+				}
 			}
-		}
-		if r.rtpReadStream != nil {
-			if err := r.rtpReadStream.Close(); err != nil {
-				return err
+			if r.tracks[i].rtpReadStream != nil {
+				if err := r.tracks[i].rtpReadStream.Close(); err != nil {
+					closeErrs = append(closeErrs, err)
+				}
+			}
+			if r.tracks[i].fecReadStream != nil {
+				if err := r.tracks[i].fecReadStream.Close(); err != nil {
+					closeErrs = append(closeErrs, err)
+				}
 			}
 		}
 	default:
 	}
 
 	close(r.closed)
-	return nil
+	return util.FlattenErrs(closeErrs)
 }
 
 // readRTP should only be called by a track, this only exists so we can keep state in one place
-func (r *RTPReceiver) readRTP(b []byte) (n int, err error) {
+func (r *RTPReceiver) readRTP(b []byte, reader *Track) (n int, err error) {
 	<-r.received
-	return r.rtpReadStream.Read(b)
+
+	r.mu.RLock()
+	var t *trackStreams
+	for i := range r.tracks {
+		if r.tracks[i].track == reader {
+			t = &r.tracks[i]
+			break
+		}
+	}
+	r.mu.RUnlock()
+
+	if t == nil {
+		return 0, fmt.Errorf("%w with SSRC(%d)", ErrRTPReceiverStreamNotFound, reader.SSRC())
+	}
+
+	if t.jitterBuffer == nil {
+		return t.rtpReader.Read(b)
+	}
+	return t.jitterBuffer.read(b)
+}
+
+// replayReader returns packets that were already read from its RTPReader
+// before reading it again. A packet is kept if b is too small for it
+type replayReader struct {
+	interceptor.RTPReader
+
+	mu      sync.Mutex
+	packets [][]byte
+}
+
+func (r *replayReader) Read(b []byte) (int, error) {
+	r.mu.Lock()
+	if len(r.packets) == 0 {
+		r.mu.Unlock()
+		return r.RTPReader.Read(b)
+	}
+	defer r.mu.Unlock()
+
+	if len(r.packets[0]) > len(b) {
+		return 0, io.ErrShortBuffer
+	}
+	n := copy(b, r.packets[0])
+	r.packets = r.packets[1:]
+	return n, nil
 }
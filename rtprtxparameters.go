@@ -0,0 +1,8 @@
+package webrtc
+
+// RTPRtxParameters describes the retransmission (RTX) stream of an encoding,
+// the SSRC is zero if RTX hasn't been negotiated
+// http://draft.ortc.org/#dom-rtcrtprtxparameters
+type RTPRtxParameters struct {
+	SSRC uint32 `json:"ssrc"`
+}
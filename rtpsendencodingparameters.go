@@ -0,0 +1,11 @@
+package webrtc
+
+// RTPSendEncodingParameters describes an encoding as it is sent by a RTPSender.
+// This is a subset of the RFC, maxBitrate is not supported
+// https://w3c.github.io/webrtc-pc/#dom-rtcrtpencodingparameters
+type RTPSendEncodingParameters struct {
+	RTPEncodingParameters
+
+	// Active is true while the encoding is being sent
+	Active bool `json:"active"`
+}
@@ -4,11 +4,15 @@ package webrtc
 
 import (
 	"fmt"
+	mathRand "math/rand"
 	"sync"
+	"time"
 
 	"github.com/pion/rtcp"
 	"github.com/pion/rtp"
 	"github.com/pion/srtp"
+	"github.com/pion/webrtc/v2/internal/util"
+	"github.com/pion/webrtc/v2/pkg/interceptor"
 )
 
 // RTPSender allows an application to control how a given Track is encoded and transmitted to a remote peer
@@ -16,6 +20,11 @@ type RTPSender struct {
 	track          *Track
 	rtcpReadStream *srtp.ReadStreamSRTCP
 
+	// The stream as bound to the interceptors of the transport
+	streamInfo *interceptor.StreamInfo
+	rtpWriter  interceptor.RTPWriter
+	rtcpReader interceptor.RTCPReader
+
 	transport *DTLSTransport
 
 	// TODO(sgotti) remove this when in future we'll avoid replacing
@@ -29,20 +38,59 @@ type RTPSender struct {
 	mu                     sync.RWMutex
 	sendCalled, stopCalled chan interface{}
 	payloadType            *uint8 // Senders should have a codec parameter dictionary at some point
+	ssrc                   uint32
+	headerExtensions       []RTPHeaderExtensionParameter
+	codecs                 []RTPCodecParameters
+
+	// routines runs the goroutine sending Sender Reports
+	routines routineGroup
+
+	// Retransmissions are sent on rtxSSRC when RTX has been negotiated,
+	// rtxPayloadTypes maps the payload type of the media to the one of RTX
+	rtxSSRC         uint32
+	rtxPayloadTypes map[uint8]uint8
+
+	// FEC packets protecting video are sent on fecSSRC when FlexFEC has been
+	// negotiated, fecPayloadType is zero otherwise
+	fecSSRC        uint32
+	fecPayloadType uint8
+
+	// Negotiated telephone-event codec, the clock rate is zero if there is none
+	dtmfPayloadType uint8
+	dtmfClockRate   uint32
+	dtmf            dtmfState
+
+	statsID string
+	stats   struct {
+		sync.Mutex
+		packetCount      uint32
+		octetCount       uint32
+		lastRTPTimestamp uint32
+		lastRTPTime      time.Time
+
+		// Taken from the last reception report about this sender
+		remoteReportTime   time.Time
+		remoteFractionLost uint8
+		remotePacketsLost  uint32
+		remoteJitter       uint32
+		remoteRoundTrip    time.Duration
+
+		nackCount uint32
+	}
 }
 
 // NewRTPSender constructs a new RTPSender
 func (api *API) NewRTPSender(track *Track, transport *DTLSTransport) (*RTPSender, error) {
 	if track == nil {
-		return nil, fmt.Errorf("Track must not be nil")
+		return nil, ErrNilTrack
 	} else if transport == nil {
-		return nil, fmt.Errorf("DTLSTransport must not be nil")
+		return nil, ErrNilDTLSTransport
 	}
 
 	track.mu.Lock()
 	defer track.mu.Unlock()
 	if track.receiver != nil {
-		return nil, fmt.Errorf("RTPSender can not be constructed with remote track")
+		return nil, ErrRTPSenderWithRemoteTrack
 	}
 	track.totalSenderCount++
 
@@ -52,6 +100,9 @@ func (api *API) NewRTPSender(track *Track, transport *DTLSTransport) (*RTPSender
 		api:        api,
 		sendCalled: make(chan interface{}),
 		stopCalled: make(chan interface{}),
+		rtxSSRC:    mathRand.Uint32(),
+		fecSSRC:    mathRand.Uint32(),
+		statsID:    fmt.Sprintf("RTPSender-%d", time.Now().UnixNano()),
 	}, nil
 }
 
@@ -82,13 +133,139 @@ func (r *RTPSender) Track() *Track {
 	return r.track
 }
 
+// GetParameters describes the current configuration for the encoding and
+// transmission of media on the sender's track. The IDs of the negotiated
+// header extensions are used with rtp.Header.SetExtension on outgoing packets
+func (r *RTPSender) GetParameters() RTPParameters {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return RTPParameters{
+		HeaderExtensions: append([]RTPHeaderExtensionParameter{}, r.headerExtensions...),
+		Codecs:           append([]RTPCodecParameters{}, r.codecs...),
+	}
+}
+
+// GetEncodings describes the encodings sent for the sender's track. Simulcast
+// isn't sent, so there is a single encoding and its rid is empty
+func (r *RTPSender) GetEncodings() []RTPSendEncodingParameters {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	encoding := RTPSendEncodingParameters{
+		Active: r.hasSent() && !r.hasStopped(),
+	}
+	if r.track != nil {
+		encoding.SSRC = r.track.SSRC()
+	}
+	if r.hasSent() {
+		encoding.SSRC = r.ssrc
+	}
+	if r.payloadType != nil {
+		encoding.PayloadType = *r.payloadType
+	}
+	if len(r.rtxPayloadTypes) != 0 {
+		encoding.RTX.SSRC = r.rtxSSRC
+	}
+	if r.fecPayloadType != 0 {
+		encoding.FEC.SSRC = r.fecSSRC
+	}
+	return []RTPSendEncodingParameters{encoding}
+}
+
+func (r *RTPSender) setHeaderExtensions(headerExtensions []RTPHeaderExtensionParameter) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.headerExtensions = headerExtensions
+}
+
+func (r *RTPSender) setCodecs(codecs []RTPCodecParameters) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.codecs = codecs
+}
+
+func (r *RTPSender) setRTXPayloadTypes(rtxPayloadTypes map[uint8]uint8) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rtxPayloadTypes = rtxPayloadTypes
+}
+
+func (r *RTPSender) setFECPayloadType(fecPayloadType uint8) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fecPayloadType = fecPayloadType
+}
+
+func (r *RTPSender) setDTMFPayloadType(payloadType uint8, clockRate uint32, negotiated bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !negotiated {
+		payloadType, clockRate = 0, 0
+	}
+	r.dtmfPayloadType, r.dtmfClockRate = payloadType, clockRate
+}
+
 // Send Attempts to set the parameters controlling the sending of media.
 func (r *RTPSender) Send(parameters RTPSendParameters) error {
+	if err := r.startSending(parameters); err != nil {
+		return err
+	}
+
+	if interval := r.api.settingEngine.rtcp.SenderReportInterval; interval != 0 {
+		r.routines.Go(func() { r.sendSenderReports(interval) })
+	}
+
+	r.flushSendQueue()
+	return nil
+}
+
+// flushSendQueue sends the packets written to the Track before any sender
+// started, then makes the RTPSender active. The packets written meanwhile are
+// queued behind them, so they are not overtaken. The Track isn't locked while
+// sending, SendRTP takes r.mu which Stop takes before the Track lock. A queued
+// packet that can't be sent is dropped like one written once the queue is full
+func (r *RTPSender) flushSendQueue() {
+	for {
+		r.track.mu.Lock()
+		select {
+		case <-r.stopCalled:
+			r.track.mu.Unlock()
+			return
+		default:
+		}
+
+		// Only the first sender to start gets the queue
+		if r.track.flushingSender != nil && r.track.flushingSender != r {
+			r.track.activeSenders = append(r.track.activeSenders, r)
+			r.track.mu.Unlock()
+			return
+		}
+
+		sendQueue := r.track.sendQueue
+		r.track.sendQueue = nil
+		if len(sendQueue) == 0 {
+			r.track.flushingSender = nil
+			r.track.activeSenders = append(r.track.activeSenders, r)
+			r.track.mu.Unlock()
+			return
+		}
+		r.track.flushingSender = r
+		r.track.mu.Unlock()
+
+		for _, p := range sendQueue {
+			_, _ = r.SendRTP(&p.Header, p.Payload)
+		}
+	}
+}
+
+// startSending opens the streams of the RTPSender and binds them to the interceptors,
+// SendRTP can be called once it returns
+func (r *RTPSender) startSending(parameters RTPSendParameters) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
 	if r.hasSent() {
-		return fmt.Errorf("Send has already been called")
+		return ErrRTPSenderSendAlreadyCalled
 	}
 
 	srtcpSession, err := r.transport.getSRTCPSession()
@@ -100,17 +277,65 @@ func (r *RTPSender) Send(parameters RTPSendParameters) error {
 	if err != nil {
 		return err
 	}
+	r.ssrc = parameters.Encodings.SSRC
 
-	r.track.mu.Lock()
-	r.track.activeSenders = append(r.track.activeSenders, r)
-	r.track.mu.Unlock()
+	r.streamInfo = r.newStreamInfo()
+	r.rtpWriter = r.transport.interceptor.BindLocalStream(r.streamInfo, interceptor.RTPWriterFunc(r.writeRTP))
+	r.rtcpReader = r.transport.interceptor.BindRTCPReader(r.rtcpReadStream)
 
 	close(r.sendCalled)
 	return nil
 }
 
+// newStreamInfo describes the stream with the negotiated payload types.
+// Caller must hold r.mu
+func (r *RTPSender) newStreamInfo() *interceptor.StreamInfo {
+	// The payload type is looked up again by SendRTP if it can't be found yet
+	payloadType, _ := r.resolvePayloadType()
+	info := localStreamInfo(r.ssrc, payloadType, r.track.Codec(), r.headerExtensions)
+	if rtxPayloadType, ok := r.rtxPayloadTypes[payloadType]; ok {
+		info.RTXSSRC = r.rtxSSRC
+		info.RTXPayloadType = rtxPayloadType
+	}
+	if r.fecPayloadType != 0 && r.track.Kind() == RTPCodecTypeVideo {
+		info.FECSSRC = r.fecSSRC
+		info.FECPayloadType = r.fecPayloadType
+	}
+	return info
+}
+
+// rebindLocalStream binds the stream to the interceptors again if a
+// renegotiation changed the payload types it was bound with
+func (r *RTPSender) rebindLocalStream() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	select {
+	case <-r.stopCalled:
+		return
+	default:
+	}
+	if !r.hasSent() {
+		return
+	}
+
+	info := r.newStreamInfo()
+	if info.PayloadType == r.streamInfo.PayloadType &&
+		info.RTXPayloadType == r.streamInfo.RTXPayloadType &&
+		info.FECPayloadType == r.streamInfo.FECPayloadType {
+		return
+	}
+
+	r.transport.interceptor.UnbindLocalStream(r.streamInfo)
+	r.streamInfo = info
+	r.rtpWriter = r.transport.interceptor.BindLocalStream(r.streamInfo, interceptor.RTPWriterFunc(r.writeRTP))
+}
+
 // Stop irreversibly stops the RTPSender
 func (r *RTPSender) Stop() error {
+	// Deferred first, so the goroutines are waited for once the locks are released
+	defer r.routines.Close()
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
@@ -131,28 +356,43 @@ func (r *RTPSender) Stop() error {
 		}
 	}
 	r.track.activeSenders = filtered
+	if r.track.flushingSender == r {
+		r.track.flushingSender = nil
+		r.track.totalSenderCount--
+		// The senders that started meanwhile have been sent the newer packets
+		if len(r.track.activeSenders) != 0 {
+			r.track.sendQueue = nil
+		}
+	}
 	close(r.stopCalled)
 
 	if r.hasSent() {
+		r.transport.interceptor.UnbindLocalStream(r.streamInfo)
 		return r.rtcpReadStream.Close()
 	}
 
 	return nil
 }
 
-// Read reads incoming RTCP for this RTPReceiver
+// Read reads incoming RTCP for this RTPReceiver. Reception reports about this
+// sender are used to populate its remote-inbound-rtp stats, and the packets
+// requested by Generic NACKs are retransmitted, so this only happens if RTCP is read
 func (r *RTPSender) Read(b []byte) (n int, err error) {
 	select {
 	case <-r.sendCalled:
-		return r.rtcpReadStream.Read(b)
+		n, err = r.rtcpReader.Read(b)
+		if err == nil {
+			r.handleRTCP(b[:n], time.Now())
+		}
+		return n, err
 	case <-r.stopCalled:
-		return 0, fmt.Errorf("RTPSender has been stopped")
+		return 0, ErrRTPSenderStopped
 	}
 }
 
 // ReadRTCP is a convenience method that wraps Read and unmarshals for you
 func (r *RTPSender) ReadRTCP() ([]rtcp.Packet, error) {
-	b := make([]byte, receiveMTU)
+	b := make([]byte, r.api.settingEngine.getReceiveMTU())
 	i, err := r.Read(b)
 	if err != nil {
 		return nil, err
@@ -170,46 +410,254 @@ func (r *RTPSender) ReadRTCP() ([]rtcp.Packet, error) {
 func (r *RTPSender) SendRTP(header *rtp.Header, payload []byte) (int, error) {
 	select {
 	case <-r.stopCalled:
-		return 0, fmt.Errorf("RTPSender has been stopped")
+		return 0, ErrRTPSenderStopped
 	case <-r.sendCalled:
-		srtpSession, err := r.transport.getSRTPSession()
+		// The writer is replaced when the stream is bound again
+		r.mu.Lock()
+		payloadType, err := r.resolvePayloadType()
+		rtpWriter := r.rtpWriter
+		r.mu.Unlock()
 		if err != nil {
 			return 0, err
 		}
+		// Overwrite the payload type in the RTP header.
+		header.PayloadType = payloadType
+
+		n, err := rtpWriter.Write(header, payload)
+		if err == nil {
+			r.stats.Lock()
+			r.stats.packetCount++
+			r.stats.octetCount += uint32(len(payload))
+			r.stats.lastRTPTimestamp = header.Timestamp
+			r.stats.lastRTPTime = time.Now()
+			r.stats.Unlock()
+		}
+		return n, err
+	}
+}
 
-		writeStream, err := srtpSession.OpenWriteStream()
-		if err != nil {
-			return 0, err
+// resolvePayloadType returns the payload type of the codec of the Track. It is the one the
+// remote used for the codec if it has been negotiated. Caller must hold r.mu
+func (r *RTPSender) resolvePayloadType() (uint8, error) {
+	// Hopefully this next part is temporary and will be removed when senders obtain payload
+	// types from their session instead of the track.
+	// Obtain payload type for this sender. Currently taken from the sender's MediaEngine
+	// to match the track's codec, which could have a different payload type.
+	// (But tracks should not have codecs - this should be set here by the
+	// peer connection or transceiver...)
+	if r.payloadType == nil {
+		// this setup should only happen on the first call to sendRTP
+		codecs := r.api.mediaEngine.GetCodecsByName(r.track.codec.Name)
+		if len(codecs) == 0 {
+			return 0, fmt.Errorf("%w: no %s codecs in media engine", ErrCodecNotFound, r.track.codec.Name)
+		}
+		for _, c := range codecs {
+			if sameCodec(c, r.track.codec) {
+				r.payloadType = &c.PayloadType
+				break
+			}
 		}
-		// Hopefully this next part is temporary and will be removed when senders obtain payload
-		// types from their session instead of the track.
-		// Obtain payload type for this sender. Currently taken from the sender's MediaEngine
-		// to match the track's codec, which could have a different payload type.
-		// (But tracks should not have codecs - this should be set here by the
-		// peer connection or transceiver...)
 		if r.payloadType == nil {
-			// this setup should only happen on the first call to sendRTP
-			codecs := r.api.mediaEngine.GetCodecsByName(r.track.codec.Name)
-			if len(codecs) == 0 {
-				return 0, fmt.Errorf("no %s codecs in media engine", r.track.codec.Name)
+			return 0, fmt.Errorf("%w: could not match %s codec from track to media engine", ErrCodecNotFound, r.track.codec.Name)
+		}
+	}
+	return *r.payloadType, nil
+}
+
+func (r *RTPSender) setPayloadType(payloadType uint8) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.payloadType = &payloadType
+}
+
+// writeRTP is the end of the interceptor chain of the RTPSender
+func (r *RTPSender) writeRTP(header *rtp.Header, payload []byte) (int, error) {
+	srtpSession, err := r.transport.getSRTPSession()
+	if err != nil {
+		return 0, err
+	}
+
+	writeStream, err := srtpSession.OpenWriteStream()
+	if err != nil {
+		return 0, err
+	}
+
+	return writeStream.WriteRTP(header, payload)
+}
+
+// SenderReport builds a RTCP Sender Report describing what has been sent so far.
+// The RTP timestamp is extrapolated from the last packet written, using the
+// clock rate of the Track, so that it corresponds to the NTP timestamp of now.
+func (r *RTPSender) SenderReport(now time.Time) *rtcp.SenderReport {
+	r.mu.RLock()
+	ssrc := r.ssrc
+	clockRate := r.track.Codec().ClockRate
+	r.mu.RUnlock()
+
+	r.stats.Lock()
+	defer r.stats.Unlock()
+
+	return &rtcp.SenderReport{
+		SSRC:        ssrc,
+		NTPTime:     util.NTPTime(now),
+		RTPTime:     r.rtpTimestamp(now, clockRate),
+		PacketCount: r.stats.packetCount,
+		OctetCount:  r.stats.octetCount,
+	}
+}
+
+// rtpTimestamp extrapolates the RTP timestamp of the media at now from the last
+// packet that was sent. Caller must hold r.stats
+func (r *RTPSender) rtpTimestamp(now time.Time, clockRate uint32) uint32 {
+	rtpTime := r.stats.lastRTPTimestamp
+	if !r.stats.lastRTPTime.IsZero() {
+		rtpTime += uint32(now.Sub(r.stats.lastRTPTime).Seconds() * float64(clockRate))
+	}
+	return rtpTime
+}
+
+// sendSenderReports periodically sends a Sender Report until the RTPSender is stopped.
+// Reports are only sent once media has been written. The CNAME matches the one
+// announced in the SDP.
+func (r *RTPSender) sendSenderReports(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.stopCalled:
+			return
+		case now := <-ticker.C:
+			r.stats.Lock()
+			hasSent := r.stats.packetCount != 0
+			r.stats.Unlock()
+			if !hasSent {
+				continue
+			}
+
+			// RTCP packets must be sent as compound packets that carry a CNAME
+			sr := r.SenderReport(now)
+			sdes := &rtcp.SourceDescription{Chunks: []rtcp.SourceDescriptionChunk{{
+				Source: sr.SSRC,
+				Items:  []rtcp.SourceDescriptionItem{{Type: rtcp.SDESCNAME, Text: r.track.Label()}},
+			}}}
+			if _, err := r.transport.rtcpWriter.Write([]rtcp.Packet{sr, sdes}); err != nil {
+				return
 			}
-			for _, c := range codecs {
-				if sameCodec(c, r.track.codec) {
-					r.payloadType = &c.PayloadType
-					break
-				}
+		}
+	}
+}
+
+// handleRTCP updates the remote-inbound-rtp stats from the reception reports
+// contained in Receiver and Sender Reports, and counts Generic NACKs. They are
+// answered by the interceptor chain
+func (r *RTPSender) handleRTCP(raw []byte, now time.Time) {
+	pkts, err := rtcp.Unmarshal(raw)
+	if err != nil {
+		return
+	}
+
+	r.mu.RLock()
+	ssrc := r.ssrc
+	r.mu.RUnlock()
+
+	for _, pkt := range pkts {
+		var reports []rtcp.ReceptionReport
+		switch p := pkt.(type) {
+		case *rtcp.ReceiverReport:
+			reports = p.Reports
+		case *rtcp.SenderReport:
+			reports = p.Reports
+		case *rtcp.TransportLayerNack:
+			if p.MediaSSRC == ssrc {
+				r.stats.Lock()
+				r.stats.nackCount++
+				r.stats.Unlock()
 			}
-			if r.payloadType == nil {
-				return 0, fmt.Errorf("could not match %s codec from track to media engine", r.track.codec.Name)
+		}
+
+		for _, report := range reports {
+			if report.SSRC == ssrc {
+				r.handleReceptionReport(report, now)
 			}
 		}
-		// Overwrite the payload type in the RTP header.
-		if r.payloadType != nil {
-			header.PayloadType = *r.payloadType
+	}
+}
+
+func (r *RTPSender) handleReceptionReport(report rtcp.ReceptionReport, now time.Time) {
+	r.stats.Lock()
+	defer r.stats.Unlock()
+
+	r.stats.remoteReportTime = now
+	r.stats.remoteFractionLost = report.FractionLost
+	r.stats.remotePacketsLost = report.TotalLost
+	r.stats.remoteJitter = report.Jitter
+
+	// RFC 3550 Section 6.4.1, LSR and DLSR are in units of 1/65536 seconds
+	if report.LastSenderReport != 0 {
+		arrival := uint32(util.NTPTime(now) >> 16)
+		if elapsed := arrival - report.LastSenderReport; elapsed >= report.Delay {
+			r.stats.remoteRoundTrip = time.Duration(float64(elapsed-report.Delay) / 65536 * float64(time.Second))
+		}
+	}
+}
+
+func (r *RTPSender) getStatsID() string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.statsID
+}
+
+func (r *RTPSender) collectStats(collector *statsReportCollector) {
+	collector.Collecting()
+
+	r.mu.RLock()
+	kind := r.track.Kind()
+	clockRate := r.track.Codec().ClockRate
+	stats := OutboundRTPStreamStats{
+		Timestamp: statsTimestampNow(),
+		Type:      StatsTypeOutboundRTP,
+		ID:        r.statsID,
+		SSRC:      r.ssrc,
+		Kind:      kind.String(),
+		TrackID:   r.track.ID(),
+		SenderID:  r.statsID,
+	}
+	r.mu.RUnlock()
+
+	r.stats.Lock()
+	stats.PacketsSent = r.stats.packetCount
+	stats.NACKCount = r.stats.nackCount
+	stats.BytesSent = uint64(r.stats.octetCount)
+	if !r.stats.lastRTPTime.IsZero() {
+		stats.LastPacketSentTimestamp = statsTimestampFrom(r.stats.lastRTPTime)
+	}
+
+	var remoteStats *RemoteInboundRTPStreamStats
+	if !r.stats.remoteReportTime.IsZero() {
+		stats.RemoteID = r.statsID + "-remote"
+		remoteStats = &RemoteInboundRTPStreamStats{
+			Timestamp:     statsTimestampFrom(r.stats.remoteReportTime),
+			Type:          StatsTypeRemoteInboundRTP,
+			ID:            stats.RemoteID,
+			SSRC:          stats.SSRC,
+			Kind:          stats.Kind,
+			PacketsLost:   int32(r.stats.remotePacketsLost<<8) >> 8, // 24-bit signed
+			RoundTripTime: r.stats.remoteRoundTrip.Seconds(),
+			FractionLost:  float64(r.stats.remoteFractionLost) / 256,
+			LocalID:       stats.ID,
+		}
+		if clockRate != 0 {
+			remoteStats.Jitter = float64(r.stats.remoteJitter) / float64(clockRate)
 		}
+	}
+	r.stats.Unlock()
 
-		return writeStream.WriteRTP(header, payload)
+	if remoteStats != nil {
+		collector.Collecting()
+		collector.Collect(remoteStats.ID, *remoteStats)
 	}
+	collector.Collect(stats.ID, stats)
 }
 
 // hasSent tells if data has been ever sent for this instance
@@ -246,3 +694,12 @@ func sameCodec(codecA, codecB *RTPCodec) bool {
 	}
 	return true
 }
+
+func (r *RTPSender) hasStopped() bool {
+	select {
+	case <-r.stopCalled:
+		return true
+	default:
+		return false
+	}
+}
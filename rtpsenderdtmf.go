@@ -0,0 +1,244 @@
+// +build !js
+
+package webrtc
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v2/pkg/rtcerr"
+)
+
+const (
+	dtmfMinDuration     = 40 * time.Millisecond
+	dtmfMaxDuration     = 6000 * time.Millisecond
+	dtmfDefaultDuration = 100 * time.Millisecond
+	dtmfMinInterToneGap = 30 * time.Millisecond
+	dtmfDefaultGap      = 70 * time.Millisecond
+	dtmfCommaPause      = 2 * time.Second
+
+	// Interval between the packets of an event, and how many times its end is sent
+	dtmfPacketInterval = 50 * time.Millisecond
+	dtmfEndPackets     = 3
+
+	// -10 dBm0, the volume used by most implementations
+	dtmfVolume = 10
+
+	dtmfEndBit         = 0x80
+	dtmfMaxEventLength = 0xffff
+)
+
+// dtmfEvents maps the DTMF tones to their telephone-event codes, RFC 4733 Section 3.2
+var dtmfEvents = map[rune]uint8{
+	'0': 0, '1': 1, '2': 2, '3': 3, '4': 4, '5': 5, '6': 6, '7': 7, '8': 8, '9': 9,
+	'*': 10, '#': 11, 'A': 12, 'B': 13, 'C': 14, 'D': 15,
+}
+
+// dtmfState holds the tones of a RTPSender that remain to be played
+type dtmfState struct {
+	sync.Mutex
+	tones        string
+	duration     time.Duration
+	interToneGap time.Duration
+	playing      bool
+}
+
+// InsertDTMF sends tones as RFC 4733 telephone-events on the SSRC of the audio Track, for
+// interop with SIP gateways. It returns once the tones are queued and plays them in the
+// background, calling it again replaces the tones that have not been played yet, and an
+// empty string cancels them.
+//
+// tones may contain 0-9, A-D, * and #, and a comma for a two second pause. Each tone lasts
+// duration, between 40ms and 6s, and is followed by interToneGap, at least 30ms. Zero values
+// select 100ms and 70ms. The telephone-event codec must have been negotiated, see
+// NewRTPTelephoneEventCodec. Events take their sequence numbers from the Track, so they
+// must not be mixed with packets written with WriteRTP.
+func (r *RTPSender) InsertDTMF(tones string, duration, interToneGap time.Duration) error {
+	select {
+	case <-r.stopCalled:
+		return &rtcerr.InvalidStateError{Err: ErrRTPSenderStopped}
+	case <-r.sendCalled:
+	default:
+		return &rtcerr.InvalidStateError{Err: ErrRTPSenderNotSending}
+	}
+
+	r.mu.RLock()
+	negotiated := r.dtmfClockRate != 0
+	r.mu.RUnlock()
+	if !negotiated {
+		return &rtcerr.InvalidStateError{Err: ErrDTMFNotNegotiated}
+	}
+
+	tones = strings.ToUpper(tones)
+	for _, tone := range tones {
+		if _, ok := dtmfEvents[tone]; !ok && tone != ',' {
+			return &rtcerr.SyntaxError{Err: ErrDTMFInvalidTone}
+		}
+	}
+
+	switch {
+	case duration == 0:
+		duration = dtmfDefaultDuration
+	case duration < dtmfMinDuration:
+		duration = dtmfMinDuration
+	case duration > dtmfMaxDuration:
+		duration = dtmfMaxDuration
+	}
+	switch {
+	case interToneGap == 0:
+		interToneGap = dtmfDefaultGap
+	case interToneGap < dtmfMinInterToneGap:
+		interToneGap = dtmfMinInterToneGap
+	}
+
+	r.dtmf.Lock()
+	r.dtmf.tones = tones
+	r.dtmf.duration = duration
+	r.dtmf.interToneGap = interToneGap
+	start := !r.dtmf.playing && tones != ""
+	r.dtmf.playing = r.dtmf.playing || start
+	r.dtmf.Unlock()
+
+	if start {
+		go r.playDTMF()
+	}
+	return nil
+}
+
+// ToneBuffer returns the DTMF tones that remain to be played
+func (r *RTPSender) ToneBuffer() string {
+	r.dtmf.Lock()
+	defer r.dtmf.Unlock()
+	return r.dtmf.tones
+}
+
+// playDTMF plays the queued tones one at a time until there are none left
+func (r *RTPSender) playDTMF() {
+	stop := func() {
+		r.dtmf.Lock()
+		r.dtmf.tones = ""
+		r.dtmf.playing = false
+		r.dtmf.Unlock()
+	}
+
+	for {
+		r.dtmf.Lock()
+		if r.dtmf.tones == "" {
+			r.dtmf.playing = false
+			r.dtmf.Unlock()
+			return
+		}
+		tone := rune(r.dtmf.tones[0])
+		r.dtmf.tones = r.dtmf.tones[1:]
+		duration, interToneGap := r.dtmf.duration, r.dtmf.interToneGap
+		r.dtmf.Unlock()
+
+		pause := dtmfCommaPause
+		if tone != ',' {
+			if err := r.sendDTMFEvent(dtmfEvents[tone], duration); err != nil {
+				stop()
+				return
+			}
+			pause = interToneGap
+		}
+
+		select {
+		case <-time.After(pause):
+		case <-r.stopCalled:
+			stop()
+			return
+		}
+	}
+}
+
+// sendDTMFEvent sends a single event lasting duration. A packet reporting the duration
+// so far is sent every dtmfPacketInterval, and the final one is repeated for reliability.
+// Events longer than a 16 bit duration are split into segments, RFC 4733 Section 2.5.1.3.
+// The durations are in units of the telephone-event clock rate, while the timestamps
+// continue the ones of the audio, in units of its clock rate
+func (r *RTPSender) sendDTMFEvent(event uint8, duration time.Duration) error {
+	r.mu.RLock()
+	payloadType, clockRate, ssrc, track := r.dtmfPayloadType, r.dtmfClockRate, r.ssrc, r.track
+	r.mu.RUnlock()
+	audioClockRate := track.Codec().ClockRate
+
+	r.stats.Lock()
+	timestamp := r.rtpTimestamp(time.Now(), audioClockRate)
+	r.stats.Unlock()
+
+	total := uint32(duration.Seconds() * float64(clockRate))
+	interval := uint32(dtmfPacketInterval.Seconds() * float64(clockRate))
+
+	ticker := time.NewTicker(dtmfPacketInterval)
+	defer ticker.Stop()
+
+	marker := true
+	send := func(segmentStart, length uint32, end bool) error {
+		if length > dtmfMaxEventLength {
+			length = dtmfMaxEventLength
+		}
+
+		payload := []byte{event, dtmfVolume, byte(length >> 8), byte(length)}
+		if end {
+			payload[1] |= dtmfEndBit
+		}
+
+		header := &rtp.Header{
+			Version:        2,
+			Marker:         marker,
+			PayloadType:    payloadType,
+			SequenceNumber: track.sequencer.NextSequenceNumber(),
+			Timestamp:      timestamp + uint32(uint64(segmentStart)*uint64(audioClockRate)/uint64(clockRate)),
+			SSRC:           ssrc,
+		}
+		marker = false
+		return r.sendTelephoneEvent(header, payload)
+	}
+
+	var segmentStart uint32
+	for played := interval; ; played += interval {
+		select {
+		case <-ticker.C:
+		case <-r.stopCalled:
+			return ErrRTPSenderStopped
+		}
+
+		if played >= total {
+			break
+		}
+
+		if played-segmentStart > dtmfMaxEventLength {
+			segmentStart = played - interval
+		}
+		if err := send(segmentStart, played-segmentStart, false); err != nil {
+			return err
+		}
+	}
+
+	for i := 0; i < dtmfEndPackets; i++ {
+		if err := send(segmentStart, total-segmentStart, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sendTelephoneEvent writes a telephone-event packet, unlike SendRTP it keeps the payload
+// type and doesn't move the RTP timestamp used for Sender Reports
+func (r *RTPSender) sendTelephoneEvent(header *rtp.Header, payload []byte) error {
+	r.mu.RLock()
+	rtpWriter := r.rtpWriter
+	r.mu.RUnlock()
+
+	if _, err := rtpWriter.Write(header, payload); err != nil {
+		return err
+	}
+
+	r.stats.Lock()
+	r.stats.packetCount++
+	r.stats.octetCount += uint32(len(payload))
+	r.stats.Unlock()
+	return nil
+}
@@ -0,0 +1,48 @@
+// +build !js
+
+package webrtc
+
+import (
+	"sync"
+
+	"github.com/pion/rtp"
+)
+
+// rtpSendHistory keeps the most recently sent RTP packets of a RTPSender so
+// they can be retransmitted when the remote reports them lost
+type rtpSendHistory struct {
+	mu      sync.Mutex
+	packets [][]byte
+}
+
+func newRTPSendHistory(size uint16) *rtpSendHistory {
+	return &rtpSendHistory{packets: make([][]byte, size)}
+}
+
+// add stores a copy of the packet, replacing the oldest one
+func (h *rtpSendHistory) add(header *rtp.Header, payload []byte) {
+	raw, err := (&rtp.Packet{Header: *header, Payload: payload}).Marshal()
+	if err != nil {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.packets[int(header.SequenceNumber)%len(h.packets)] = raw
+}
+
+// get returns the packet with sequenceNumber, or nil if it is no longer kept
+func (h *rtpSendHistory) get(sequenceNumber uint16) *rtp.Packet {
+	h.mu.Lock()
+	raw := h.packets[int(sequenceNumber)%len(h.packets)]
+	h.mu.Unlock()
+	if raw == nil {
+		return nil
+	}
+
+	p := &rtp.Packet{}
+	if err := p.Unmarshal(raw); err != nil || p.SequenceNumber != sequenceNumber {
+		return nil
+	}
+	return p
+}
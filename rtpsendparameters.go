@@ -1,6 +1,6 @@
 package webrtc
 
-// RTPSendParameters contains the RTP stack settings used by receivers
+// RTPSendParameters contains the RTP stack settings used by senders
 type RTPSendParameters struct {
 	Encodings RTPEncodingParameters
 }
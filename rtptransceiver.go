@@ -14,7 +14,7 @@ type RTPTransceiver struct {
 	receiver  atomic.Value // *RTPReceiver
 	direction atomic.Value // RTPTransceiverDirection
 
-	stopped bool
+	stopped atomicBool
 	kind    RTPCodecType
 }
 
@@ -67,8 +67,10 @@ func (t *RTPTransceiver) Direction() RTPTransceiverDirection {
 	return t.direction.Load().(RTPTransceiverDirection)
 }
 
-// Stop irreversibly stops the RTPTransceiver
+// Stop irreversibly stops the RTPTransceiver. Its m= section is rejected in
+// the next negotiation and can then be recycled by a new RTPTransceiver
 func (t *RTPTransceiver) Stop() error {
+	t.stopped.set(true)
 	if t.Sender() != nil {
 		if err := t.Sender().Stop(); err != nil {
 			return err
@@ -84,6 +86,11 @@ func (t *RTPTransceiver) Stop() error {
 	return nil
 }
 
+// Stopped reports if Stop has been called on the RTPTransceiver
+func (t *RTPTransceiver) Stopped() bool {
+	return t.stopped.get()
+}
+
 func (t *RTPTransceiver) setReceiver(r *RTPReceiver) {
 	t.receiver.Store(r)
 }
@@ -60,3 +60,7 @@ func (t RTPTransceiverDirection) String() string {
 		return ErrUnknownType.Error()
 	}
 }
+
+func hasSendDirection(d RTPTransceiverDirection) bool {
+	return d == RTPTransceiverDirectionSendrecv || d == RTPTransceiverDirectionSendonly
+}
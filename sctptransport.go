@@ -42,6 +42,11 @@ type SCTPTransport struct {
 	onDataChannelHandler       func(*DataChannel)
 	onDataChannelOpenedHandler func(*DataChannel)
 
+	// Deliver the events to the handlers above in order, a queue per kind.
+	// The handler of OnDataChannel isn't ordered, it may keep running.
+	errorEvents             eventDispatcher
+	dataChannelOpenedEvents eventDispatcher
+
 	// DataChannels
 	dataChannels          []*DataChannel
 	dataChannelsOpened    uint32
@@ -61,6 +66,9 @@ func (api *API) NewSCTPTransport(dtls *DTLSTransport) *SCTPTransport {
 		state:         SCTPTransportStateConnecting,
 		api:           api,
 		log:           api.settingEngine.LoggerFactory.NewLogger("ortc"),
+
+		errorEvents:             eventDispatcher{executor: api.settingEngine.events.Executor},
+		dataChannelOpenedEvents: eventDispatcher{executor: api.settingEngine.events.Executor},
 	}
 
 	res.updateMessageSize()
@@ -93,10 +101,14 @@ func (r *SCTPTransport) Start(remoteCaps SCTPCapabilities) error {
 	}
 
 	sctpAssociation, err := sctp.Client(sctp.Config{
-		NetConn:       r.Transport().conn,
-		LoggerFactory: r.api.settingEngine.LoggerFactory,
+		NetConn: timeReceive(r.api.settingEngine.instrumentation.ReceiveTimings, tapPackets(r.api.settingEngine.instrumentation.PacketTap, r.Transport().conn, packetTapSCTP), func(t *ReceiveTimings) *TimingHistogram {
+			return &t.SCTP
+		}),
+		MaxReceiveBufferSize: r.api.settingEngine.sctp.MaxReceiveBufferSize,
+		LoggerFactory:        r.api.settingEngine.LoggerFactory,
 	})
 	if err != nil {
+		r.log.Warnf("Failed to establish SCTP association: %v", err)
 		return err
 	}
 
@@ -202,7 +214,7 @@ func (r *SCTPTransport) acceptDataChannels(a *sctp.Association) {
 		r.lock.Unlock()
 
 		if dcOpenedHdlr != nil {
-			dcOpenedHdlr(rtcDC)
+			r.dataChannelOpenedEvents.dispatch(func() { dcOpenedHdlr(rtcDC) })
 		}
 	}
 }
@@ -221,7 +233,7 @@ func (r *SCTPTransport) onError(err error) {
 	r.lock.RUnlock()
 
 	if hdlr != nil {
-		go hdlr(err)
+		r.errorEvents.dispatch(func() { hdlr(err) })
 	}
 }
 
@@ -256,10 +268,10 @@ func (r *SCTPTransport) onDataChannel(dc *DataChannel) (done chan struct{}) {
 
 	// Run this synchronously to allow setup done in onDataChannelFn()
 	// to complete before datachannel event handlers might be called.
-	go func() {
+	runEvent(r.api.settingEngine.events.Executor, func() {
 		hdlr(dc)
 		close(done)
-	}()
+	})
 
 	return
 }
@@ -5,6 +5,7 @@ package webrtc
 import (
 	"fmt"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -18,12 +19,19 @@ type trackDetails struct {
 	label string
 	id    string
 	ssrc  uint32
+
+	// The SSRCs of the RTX stream repairing this one and of the FlexFEC stream
+	// protecting it, zero if there is none
+	rtxSSRC uint32
+	fecSSRC uint32
 }
 
 // extract all trackDetails from an SDP.
 func trackDetailsFromSDP(log logging.LeveledLogger, s *sdp.SessionDescription) map[uint32]trackDetails {
 	incomingTracks := map[uint32]trackDetails{}
-	rtxRepairFlows := map[uint32]bool{}
+	repairFlows := map[uint32]bool{}
+	rtxFlows := map[uint32]uint32{}
+	fecFlows := map[uint32]uint32{}
 
 	for _, media := range s.MediaDescriptions {
 		// Plan B can have multiple tracks in a signle media section
@@ -51,24 +59,30 @@ func trackDetailsFromSDP(log logging.LeveledLogger, s *sdp.SessionDescription) m
 			switch attr.Key {
 			case sdp.AttrKeySSRCGroup:
 				split := strings.Split(attr.Value, " ")
-				if split[0] == sdp.SemanticTokenFlowIdentification {
-					// Add rtx ssrcs to blacklist, to avoid adding them as tracks
+				if split[0] == sdp.SemanticTokenFlowIdentification || split[0] == sdpSemanticTokenFECFramework {
+					// Add rtx and fec ssrcs to blacklist, to avoid adding them as tracks
 					// Essentially lines like `a=ssrc-group:FID 2231627014 632943048` are processed by this section
 					// as this declares that the second SSRC (632943048) is a rtx repair flow (RFC4588) for the first
-					// (2231627014) as specified in RFC5576
+					// (2231627014) as specified in RFC5576. Lines like `a=ssrc-group:FEC-FR 2231627014 1897376311`
+					// declare that the second SSRC is a FlexFEC stream protecting the first (RFC5956)
 					if len(split) == 3 {
-						_, err := strconv.ParseUint(split[1], 10, 32)
+						mediaSSRC, err := strconv.ParseUint(split[1], 10, 32)
 						if err != nil {
 							log.Warnf("Failed to parse SSRC: %v", err)
 							continue
 						}
-						rtxRepairFlow, err := strconv.ParseUint(split[2], 10, 32)
+						repairFlow, err := strconv.ParseUint(split[2], 10, 32)
 						if err != nil {
 							log.Warnf("Failed to parse SSRC: %v", err)
 							continue
 						}
-						rtxRepairFlows[uint32(rtxRepairFlow)] = true
-						delete(incomingTracks, uint32(rtxRepairFlow)) // Remove if rtx was added as track before
+						repairFlows[uint32(repairFlow)] = true
+						delete(incomingTracks, uint32(repairFlow)) // Remove if rtx or fec was added as track before
+						if split[0] == sdpSemanticTokenFECFramework {
+							fecFlows[uint32(mediaSSRC)] = uint32(repairFlow)
+						} else {
+							rtxFlows[uint32(mediaSSRC)] = uint32(repairFlow)
+						}
 					}
 				}
 
@@ -89,8 +103,8 @@ func trackDetailsFromSDP(log logging.LeveledLogger, s *sdp.SessionDescription) m
 					log.Warnf("Failed to parse SSRC: %v", err)
 					continue
 				}
-				if rtxRepairFlow := rtxRepairFlows[uint32(ssrc)]; rtxRepairFlow {
-					continue // This ssrc is a RTX repair flow, ignore
+				if repairFlow := repairFlows[uint32(ssrc)]; repairFlow {
+					continue // This ssrc is a RTX or FEC repair flow, ignore
 				}
 				if existingValues, ok := incomingTracks[uint32(ssrc)]; ok && existingValues.label != "" && existingValues.id != "" {
 					continue // This ssrc is already fully defined
@@ -103,11 +117,24 @@ func trackDetailsFromSDP(log logging.LeveledLogger, s *sdp.SessionDescription) m
 
 				// Plan B might send multiple a=ssrc lines under a single m= section. This is also why a single trackDetails{}
 				// is not defined at the top of the loop over s.MediaDescriptions.
-				incomingTracks[uint32(ssrc)] = trackDetails{midValue, codecType, trackLabel, trackID, uint32(ssrc)}
+				incomingTracks[uint32(ssrc)] = trackDetails{midValue, codecType, trackLabel, trackID, uint32(ssrc), 0, 0}
 			}
 		}
 	}
 
+	for ssrc, rtxSSRC := range rtxFlows {
+		if incoming, ok := incomingTracks[ssrc]; ok {
+			incoming.rtxSSRC = rtxSSRC
+			incomingTracks[ssrc] = incoming
+		}
+	}
+	for ssrc, fecSSRC := range fecFlows {
+		if incoming, ok := incomingTracks[ssrc]; ok {
+			incoming.fecSSRC = fecSSRC
+			incomingTracks[ssrc] = incoming
+		}
+	}
+
 	return incomingTracks
 }
 
@@ -127,9 +154,11 @@ func addCandidatesToMediaDescriptions(candidates []ICECandidate, m *sdp.MediaDes
 		sdpCandidate := iceCandidateToSDP(c)
 		sdpCandidate.ExtensionAttributes = append(sdpCandidate.ExtensionAttributes, sdp.ICECandidateAttribute{Key: "generation", Value: "0"})
 		sdpCandidate.Component = 1
+		sdpCandidate.Priority = priorityForComponent(c.Priority, c.Component, 1)
 		appendCandidateIfNew(sdpCandidate, m.Attributes)
 
 		sdpCandidate.Component = 2
+		sdpCandidate.Priority = priorityForComponent(c.Priority, c.Component, 2)
 		appendCandidateIfNew(sdpCandidate, m.Attributes)
 	}
 
@@ -150,7 +179,7 @@ func addDataMediaSection(d *sdp.SessionDescription, midValue string, iceParams I
 		MediaName: sdp.MediaName{
 			Media:   mediaSectionApplication,
 			Port:    sdp.RangedPort{Value: 9},
-			Protos:  []string{"DTLS", "SCTP"},
+			Protos:  sdpProtos(SDPProtoDTLSSCTP),
 			Formats: []string{"5000"},
 		},
 		ConnectionInformation: &sdp.ConnectionInformation{
@@ -164,7 +193,7 @@ func addDataMediaSection(d *sdp.SessionDescription, midValue string, iceParams I
 		WithValueAttribute(sdp.AttrKeyConnectionSetup, dtlsRole.String()).
 		WithValueAttribute(sdp.AttrKeyMID, midValue).
 		WithPropertyAttribute(RTPTransceiverDirectionSendrecv.String()).
-		WithPropertyAttribute("sctpmap:5000 webrtc-datachannel 1024").
+		WithPropertyAttribute("sctpmap:5000 "+SDPSCTPProtocolWebRTCDataChannel+" 1024").
 		WithICECredentials(iceParams.UsernameFragment, iceParams.Password)
 
 	addCandidatesToMediaDescriptions(candidates, media, iceGatheringState)
@@ -207,12 +236,19 @@ func populateLocalCandidates(sessionDescription *SessionDescription, i *ICEGathe
 	}
 }
 
-func addTransceiverSDP(d *sdp.SessionDescription, isPlanB bool, mediaEngine *MediaEngine, midValue string, iceParams ICEParameters, candidates []ICECandidate, dtlsRole sdp.ConnectionRole, iceGatheringState ICEGatheringState, transceivers ...*RTPTransceiver) (bool, error) {
+func addTransceiverSDP(d *sdp.SessionDescription, isPlanB bool, mediaEngine *MediaEngine, iceParams ICEParameters, candidates []ICECandidate, dtlsRole sdp.ConnectionRole, iceGatheringState ICEGatheringState, mediaSection mediaSection) (bool, error) {
+	midValue := mediaSection.id
+	transceivers := mediaSection.transceivers
 	if len(transceivers) < 1 {
 		return false, fmt.Errorf("addTransceiverSDP() called with 0 transceivers")
 	}
 	// Use the first transceiver to generate the section attributes
 	t := transceivers[0]
+	if !isPlanB && t.Stopped() {
+		addRejectedMediaSDP(d, t.kind, midValue)
+		return false, nil
+	}
+
 	media := sdp.NewJSEPMediaDescription(t.kind.String(), []string{}).
 		WithValueAttribute(sdp.AttrKeyConnectionSetup, dtlsRole.String()).
 		WithValueAttribute(sdp.AttrKeyMID, midValue).
@@ -220,34 +256,63 @@ func addTransceiverSDP(d *sdp.SessionDescription, isPlanB bool, mediaEngine *Med
 		WithPropertyAttribute(sdp.AttrKeyRTCPMux).
 		WithPropertyAttribute(sdp.AttrKeyRTCPRsize)
 
-	codecs := mediaEngine.GetCodecsByKind(t.kind)
+	headerExtensions := headerExtensionsForMediaSection(mediaEngine, t.kind, mediaSection)
+	haveTransportCCExtMap := false
+	for _, e := range headerExtensions {
+		media.WithValueAttribute("extmap", fmt.Sprintf("%d %s", e.ID, e.URI))
+		if e.URI == TransportCCURI {
+			haveTransportCCExtMap = true
+		}
+	}
+
+	codecs := mediaSection.codecs
+	if codecs == nil {
+		codecs = mediaEngine.GetCodecsByKind(t.kind)
+	}
+	haveRTX, haveFlexFEC := false, false
 	for _, codec := range codecs {
+		switch codec.Name {
+		case RTX:
+			haveRTX = true
+		case FlexFEC:
+			haveFlexFEC = true
+		}
 		media.WithCodec(codec.PayloadType, codec.Name, codec.ClockRate, codec.Channels, codec.SDPFmtpLine)
 
 		for _, feedback := range codec.RTPCodecCapability.RTCPFeedback {
 			media.WithValueAttribute("rtcp-fb", fmt.Sprintf("%d %s %s", codec.PayloadType, feedback.Type, feedback.Parameter))
-			if feedback.Type == TypeRTCPFBTransportCC {
+			if feedback.Type == TypeRTCPFBTransportCC && !haveTransportCCExtMap {
 				media.WithTransportCCExtMap()
+				haveTransportCCExtMap = true
 			}
 		}
 	}
 	if len(codecs) == 0 {
 		// Explicitly reject track if we don't have the codec
-		d.WithMedia(&sdp.MediaDescription{
-			MediaName: sdp.MediaName{
-				Media:   t.kind.String(),
-				Port:    sdp.RangedPort{Value: 0},
-				Protos:  []string{"UDP", "TLS", "RTP", "SAVPF"},
-				Formats: []string{"0"},
-			},
-		})
+		addRejectedMediaSDP(d, t.kind, midValue)
 		return false, nil
 	}
 
 	for _, mt := range transceivers {
 		if mt.Sender() != nil && mt.Sender().track != nil {
 			track := mt.Sender().track
+			// Announce the SSRC retransmissions are sent on, RFC 4588 Section 8.1
+			rtxSSRC := mt.Sender().rtxSSRC
+			if haveRTX {
+				media = media.WithValueAttribute(sdp.AttrKeySSRCGroup, fmt.Sprintf("%s %d %d", sdp.SemanticTokenFlowIdentification, track.SSRC(), rtxSSRC))
+			}
+			// Announce the SSRC FEC packets are sent on, RFC 5956 Section 4.3
+			fecSSRC := mt.Sender().fecSSRC
+			if haveFlexFEC {
+				media = media.WithValueAttribute(sdp.AttrKeySSRCGroup, fmt.Sprintf("%s %d %d", sdpSemanticTokenFECFramework, track.SSRC(), fecSSRC))
+			}
 			media = media.WithMediaSource(track.SSRC(), track.Label() /* cname */, track.Label() /* streamLabel */, track.ID())
+			if haveRTX {
+				media = media.WithMediaSource(rtxSSRC, track.Label() /* cname */, track.Label() /* streamLabel */, track.ID())
+			}
+			if haveFlexFEC {
+				media = media.WithMediaSource(fecSSRC, track.Label() /* cname */, track.Label() /* streamLabel */, track.ID())
+			}
 			if !isPlanB {
 				media = media.WithPropertyAttribute("msid:" + track.Label() + " " + track.ID())
 				break
@@ -255,6 +320,14 @@ func addTransceiverSDP(d *sdp.SessionDescription, isPlanB bool, mediaEngine *Med
 		}
 	}
 
+	// Accept the Simulcast layers the remote offered
+	if acceptSimulcast(t, mediaSection) {
+		for _, rid := range mediaSection.rids {
+			media.WithValueAttribute(sdpAttributeRid, rid+" recv")
+		}
+		media.WithValueAttribute(sdpAttributeSimulcast, "recv "+strings.Join(mediaSection.rids, ";"))
+	}
+
 	media = media.WithPropertyAttribute(t.Direction().String())
 
 	addCandidatesToMediaDescriptions(candidates, media, iceGatheringState)
@@ -267,6 +340,119 @@ type mediaSection struct {
 	id           string
 	transceivers []*RTPTransceiver
 	data         bool
+
+	// Simulcast layers sent by the remote, and the header extensions it offered.
+	// extMaps is nil when the media section is not matched to a remote one
+	rids    []string
+	extMaps map[string]int
+
+	// IDs the session already maps header extensions to, unmatched media
+	// sections keep them as the remote rejects IDs that are reassigned
+	sessionExtMaps map[string]int
+
+	// Codecs matched to the ones offered by the remote when answering, they are
+	// used instead of all the codecs of the MediaEngine when not nil
+	codecs []*RTPCodec
+}
+
+// acceptSimulcast reports if the Simulcast layers offered by the remote are received
+func acceptSimulcast(t *RTPTransceiver, m mediaSection) bool {
+	return len(m.rids) > 0 && (t.Direction() == RTPTransceiverDirectionRecvonly || t.Direction() == RTPTransceiverDirectionSendrecv)
+}
+
+// headerExtensionsForMediaSection returns the RTP header extensions to announce in a media section.
+// Unmatched media sections offer all extensions registered in the MediaEngine, with the IDs
+// already used in the session if any. Matched ones only accept what the remote offered using its
+// IDs, the MID and RID extensions are also accepted when receiving Simulcast as the layers can't
+// be told apart otherwise
+func headerExtensionsForMediaSection(mediaEngine *MediaEngine, kind RTPCodecType, m mediaSection) []RTPHeaderExtensionParameter {
+	registered := mediaEngine.getHeaderExtensions(kind)
+	if m.extMaps == nil {
+		return keepSessionExtMaps(registered, m.sessionExtMaps)
+	}
+
+	accepted := map[string]bool{}
+	for _, e := range registered {
+		accepted[e.URI] = true
+	}
+	if len(m.transceivers) > 0 && acceptSimulcast(m.transceivers[0], m) {
+		accepted[SDESMidURI] = true
+		accepted[SDESRTPStreamIDURI] = true
+	}
+
+	headerExtensions := []RTPHeaderExtensionParameter{}
+	for uri, id := range m.extMaps {
+		if accepted[uri] {
+			headerExtensions = append(headerExtensions, RTPHeaderExtensionParameter{URI: uri, ID: id})
+		}
+	}
+	sort.Slice(headerExtensions, func(i, j int) bool {
+		return headerExtensions[i].ID < headerExtensions[j].ID
+	})
+	return headerExtensions
+}
+
+// keepSessionExtMaps returns the extensions with the IDs the session already maps them to. The
+// others keep their ID unless the session uses it already, then they get the lowest free one or
+// are left out if there is none
+func keepSessionExtMaps(headerExtensions []RTPHeaderExtensionParameter, sessionExtMaps map[string]int) []RTPHeaderExtensionParameter {
+	if len(sessionExtMaps) == 0 {
+		return headerExtensions
+	}
+
+	inUse := map[int]bool{}
+	for _, id := range sessionExtMaps {
+		inUse[id] = true
+	}
+
+	kept := []RTPHeaderExtensionParameter{}
+	var unmapped []RTPHeaderExtensionParameter
+	for _, e := range headerExtensions {
+		if id, ok := sessionExtMaps[e.URI]; ok {
+			kept = append(kept, RTPHeaderExtensionParameter{URI: e.URI, ID: id})
+		} else {
+			unmapped = append(unmapped, e)
+		}
+	}
+
+	for _, e := range unmapped {
+		if inUse[e.ID] {
+			e.ID = 0
+			for id := 1; id <= maxHeaderExtensionID; id++ {
+				// Like in RegisterHeaderExtension, the transport-cc ID stays reserved
+				if !inUse[id] && (id != sdp.ExtMapValueTransportCC || e.URI == TransportCCURI) {
+					e.ID = id
+					break
+				}
+			}
+			if e.ID == 0 {
+				continue
+			}
+		}
+		inUse[e.ID] = true
+		kept = append(kept, e)
+	}
+
+	sort.Slice(kept, func(i, j int) bool {
+		return kept[i].ID < kept[j].ID
+	})
+	return kept
+}
+
+// negotiatedHeaderExtensions returns the RTP header extensions announced with the same ID in both media sections
+func negotiatedHeaderExtensions(local, remote *sdp.MediaDescription) []RTPHeaderExtensionParameter {
+	remoteExtMaps := getExtMaps(remote)
+
+	headerExtensions := []RTPHeaderExtensionParameter{}
+	for uri, id := range getExtMaps(local) {
+		if remoteID, ok := remoteExtMaps[uri]; ok && remoteID == id {
+			headerExtensions = append(headerExtensions, RTPHeaderExtensionParameter{URI: uri, ID: id})
+		}
+	}
+	sort.Slice(headerExtensions, func(i, j int) bool {
+		return headerExtensions[i].ID < headerExtensions[j].ID
+	})
+	return headerExtensions
 }
 
 // populateSDP serializes a PeerConnections state into an SDP
@@ -290,7 +476,7 @@ func populateSDP(d *sdp.SessionDescription, isPlanB bool, isICELite bool, mediaE
 		shouldAddID := true
 		if m.data {
 			addDataMediaSection(d, m.id, iceParams, candidates, connectionRole, iceGatheringState)
-		} else if shouldAddID, err = addTransceiverSDP(d, isPlanB, mediaEngine, m.id, iceParams, candidates, connectionRole, iceGatheringState, m.transceivers...); err != nil {
+		} else if shouldAddID, err = addTransceiverSDP(d, isPlanB, mediaEngine, iceParams, candidates, connectionRole, iceGatheringState, m); err != nil {
 			return nil, err
 		}
 
@@ -315,6 +501,34 @@ func getMidValue(media *sdp.MediaDescription) string {
 	return ""
 }
 
+func findMediaByMid(desc *sdp.SessionDescription, mid string) *sdp.MediaDescription {
+	for _, m := range desc.MediaDescriptions {
+		if getMidValue(m) == mid {
+			return m
+		}
+	}
+	return nil
+}
+
+// isMediaSectionRejected reports if the m= section was rejected, JSEP 5.2.2
+func isMediaSectionRejected(media *sdp.MediaDescription) bool {
+	return media.MediaName.Port.Value == 0
+}
+
+// addRejectedMediaSDP adds a m= section that rejects the media identified by
+// midValue, the mid stays so the section can be recycled later
+func addRejectedMediaSDP(d *sdp.SessionDescription, kind RTPCodecType, midValue string) {
+	d.WithMedia(&sdp.MediaDescription{
+		MediaName: sdp.MediaName{
+			Media:   kind.String(),
+			Port:    sdp.RangedPort{Value: 0},
+			Protos:  sdpProtos(SDPProtoUDPTLSRTPSAVPF),
+			Formats: []string{"0"},
+		},
+		Attributes: []sdp.Attribute{{Key: sdp.AttrKeyMID, Value: midValue}},
+	})
+}
+
 func descriptionIsPlanB(desc *SessionDescription) bool {
 	if desc == nil || desc.parsed == nil {
 		return false
@@ -329,6 +543,194 @@ func descriptionIsPlanB(desc *SessionDescription) bool {
 	return false
 }
 
+// getRids returns the RTP Stream IDs the remote sends in this media section
+// https://tools.ietf.org/html/draft-ietf-mmusic-rid-15#section-10
+func getRids(media *sdp.MediaDescription) []string {
+	rids := []string{}
+	for _, attr := range media.Attributes {
+		if attr.Key != sdpAttributeRid {
+			continue
+		}
+
+		split := strings.Fields(attr.Value)
+		if len(split) >= 2 && split[1] == "send" {
+			rids = append(rids, split[0])
+		}
+	}
+	return rids
+}
+
+// getExtMaps returns the IDs of the RTP header extensions negotiated in this media section keyed by URI
+func getExtMaps(media *sdp.MediaDescription) map[string]int {
+	extMaps := map[string]int{}
+	for _, attr := range media.Attributes {
+		if attr.Key != "extmap" {
+			continue
+		}
+
+		e := sdp.ExtMap{}
+		if err := e.Unmarshal(attr.Key + ":" + attr.Value); err != nil || e.URI == nil {
+			continue
+		}
+		extMaps[e.URI.String()] = e.Value
+	}
+	return extMaps
+}
+
+// getRTXPayloadTypes returns the payload types of the RTX codecs in this media section
+// keyed by the payload type they carry retransmissions for
+func getRTXPayloadTypes(media *sdp.MediaDescription) map[uint8]uint8 {
+	rtxPayloadTypes := map[string]bool{}
+	for _, attr := range media.Attributes {
+		if attr.Key != "rtpmap" {
+			continue
+		}
+
+		split := strings.Fields(attr.Value)
+		if len(split) == 2 && strings.HasPrefix(strings.ToLower(split[1]), RTX+"/") {
+			rtxPayloadTypes[split[0]] = true
+		}
+	}
+
+	associated := map[uint8]uint8{}
+	for _, attr := range media.Attributes {
+		if attr.Key != "fmtp" {
+			continue
+		}
+
+		split := strings.Fields(attr.Value)
+		if len(split) != 2 || !rtxPayloadTypes[split[0]] {
+			continue
+		}
+
+		payloadType, err := strconv.ParseUint(split[0], 10, 8)
+		if err != nil {
+			continue
+		}
+		for _, param := range strings.Split(split[1], ";") {
+			if !strings.HasPrefix(param, "apt=") {
+				continue
+			}
+			if apt, err := strconv.ParseUint(strings.TrimPrefix(param, "apt="), 10, 8); err == nil {
+				associated[uint8(apt)] = uint8(payloadType)
+			}
+		}
+	}
+	return associated
+}
+
+// negotiatedRTXPayloadTypes returns the RTX payload types of the remote for the
+// payload types that both sides offered RTX for
+func negotiatedRTXPayloadTypes(local, remote *sdp.MediaDescription) map[uint8]uint8 {
+	localRTX := getRTXPayloadTypes(local)
+
+	negotiated := map[uint8]uint8{}
+	for apt, payloadType := range getRTXPayloadTypes(remote) {
+		if _, ok := localRTX[apt]; ok {
+			negotiated[apt] = payloadType
+		}
+	}
+	return negotiated
+}
+
+// haveRTXSSRC reports if this media section announces ssrc as the RTX repair flow of another SSRC
+func haveRTXSSRC(media *sdp.MediaDescription, ssrc uint32) bool {
+	return haveRepairFlow(media, sdp.SemanticTokenFlowIdentification, ssrc)
+}
+
+// haveFECSSRC reports if this media section announces ssrc as the FlexFEC stream protecting another SSRC
+func haveFECSSRC(media *sdp.MediaDescription, ssrc uint32) bool {
+	return haveRepairFlow(media, sdpSemanticTokenFECFramework, ssrc)
+}
+
+// haveRepairFlow reports if this media section groups ssrc after another SSRC with semantics
+func haveRepairFlow(media *sdp.MediaDescription, semantics string, ssrc uint32) bool {
+	for _, attr := range media.Attributes {
+		if attr.Key != sdp.AttrKeySSRCGroup {
+			continue
+		}
+
+		split := strings.Fields(attr.Value)
+		if len(split) == 3 && split[0] == semantics && split[2] == strconv.FormatUint(uint64(ssrc), 10) {
+			return true
+		}
+	}
+	return false
+}
+
+// getFlexFECPayloadType returns the payload type of the FlexFEC codec in this media section
+func getFlexFECPayloadType(media *sdp.MediaDescription) (uint8, bool) {
+	for _, attr := range media.Attributes {
+		if attr.Key != "rtpmap" {
+			continue
+		}
+
+		split := strings.Fields(attr.Value)
+		if len(split) != 2 || !strings.HasPrefix(strings.ToLower(split[1]), FlexFEC+"/") {
+			continue
+		}
+		if payloadType, err := strconv.ParseUint(split[0], 10, 8); err == nil {
+			return uint8(payloadType), true
+		}
+	}
+	return 0, false
+}
+
+// negotiatedFlexFECPayloadType returns the payload type of the FlexFEC codec of the remote if
+// both sides offered it, zero otherwise
+func negotiatedFlexFECPayloadType(local, remote *sdp.MediaDescription) uint8 {
+	if _, ok := getFlexFECPayloadType(local); !ok {
+		return 0
+	}
+	payloadType, _ := getFlexFECPayloadType(remote)
+	return payloadType
+}
+
+// getTelephoneEventPayloadTypes returns the payload types of the telephone-event codecs in this
+// media section, by clock rate
+func getTelephoneEventPayloadTypes(media *sdp.MediaDescription) map[uint32]uint8 {
+	payloadTypes := map[uint32]uint8{}
+	for _, attr := range media.Attributes {
+		if attr.Key != "rtpmap" {
+			continue
+		}
+
+		split := strings.Fields(attr.Value)
+		if len(split) != 2 || !strings.HasPrefix(strings.ToLower(split[1]), TelephoneEvent+"/") {
+			continue
+		}
+
+		payloadType, err := strconv.ParseUint(split[0], 10, 8)
+		if err != nil {
+			continue
+		}
+		clockRate, err := strconv.ParseUint(strings.Split(split[1], "/")[1], 10, 32)
+		if err != nil {
+			continue
+		}
+		payloadTypes[uint32(clockRate)] = uint8(payloadType)
+	}
+	return payloadTypes
+}
+
+// negotiatedTelephoneEvent returns the payload type and clock rate of the remote telephone-event
+// codec that both sides offered. The one with clockRate, the rate of the audio codec, is preferred
+func negotiatedTelephoneEvent(local, remote *sdp.MediaDescription, clockRate uint32) (uint8, uint32, bool) {
+	localPayloadTypes := getTelephoneEventPayloadTypes(local)
+
+	var payloadType uint8
+	var negotiatedClockRate uint32
+	for rate, remotePayloadType := range getTelephoneEventPayloadTypes(remote) {
+		if _, ok := localPayloadTypes[rate]; !ok {
+			continue
+		}
+		if negotiatedClockRate == 0 || rate == clockRate || (negotiatedClockRate != clockRate && rate < negotiatedClockRate) {
+			payloadType, negotiatedClockRate = remotePayloadType, rate
+		}
+	}
+	return payloadType, negotiatedClockRate, negotiatedClockRate != 0
+}
+
 func getPeerDirection(media *sdp.MediaDescription) RTPTransceiverDirection {
 	for _, a := range media.Attributes {
 		if direction := NewRTPTransceiverDirection(a.Key); direction != RTPTransceiverDirection(Unknown) {
@@ -388,10 +790,10 @@ func extractICEDetails(desc *sdp.SessionDescription) (string, string, []ICECandi
 				}
 
 				candidates = append(candidates, candidate)
-			case strings.HasPrefix(*a.String(), "ice-ufrag"):
-				remoteUfrag = (*a.String())[len("ice-ufrag:"):]
-			case strings.HasPrefix(*a.String(), "ice-pwd"):
-				remotePwd = (*a.String())[len("ice-pwd:"):]
+			case a.Key == "ice-ufrag":
+				remoteUfrag = a.Value
+			case a.Key == "ice-pwd":
+				remotePwd = a.Value
 			}
 		}
 	}
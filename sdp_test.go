@@ -85,6 +85,17 @@ func TestExtractICEDetails(t *testing.T) {
 		_, _, _, err := extractICEDetails(s)
 		assert.Equal(t, err, ErrSessionDescriptionMissingIceUfrag)
 	})
+
+	t.Run("Empty ice-ufrag", func(t *testing.T) {
+		s := &sdp.SessionDescription{
+			MediaDescriptions: []*sdp.MediaDescription{
+				{Attributes: []sdp.Attribute{{Key: "ice-ufrag"}, {Key: "ice-pwd", Value: "foobar"}}},
+			},
+		}
+
+		_, _, _, err := extractICEDetails(s)
+		assert.Equal(t, err, ErrSessionDescriptionMissingIceUfrag)
+	})
 }
 
 func TestTrackDetailsFromSDP(t *testing.T) {
@@ -195,6 +206,33 @@ func TestTrackDetailsFromSDP(t *testing.T) {
 
 		assert.Equal(t, 0, len(trackDetailsFromSDP(nil, s)))
 	})
+
+	t.Run("video with RTX and FlexFEC", func(t *testing.T) {
+		s := &sdp.SessionDescription{
+			MediaDescriptions: []*sdp.MediaDescription{
+				{
+					MediaName: sdp.MediaName{
+						Media: "video",
+					},
+					Attributes: []sdp.Attribute{
+						{Key: "mid", Value: "0"},
+						{Key: "sendrecv"},
+						{Key: "ssrc-group", Value: "FID 3000 4000"},
+						{Key: "ssrc-group", Value: "FEC-FR 3000 5000"},
+						{Key: "ssrc", Value: "3000 msid:video_trk_label video_trk_guid"},
+						{Key: "ssrc", Value: "4000 msid:video_trk_label video_trk_guid"},
+						{Key: "ssrc", Value: "5000 msid:video_trk_label video_trk_guid"},
+					},
+				},
+			},
+		}
+
+		tracks := trackDetailsFromSDP(nil, s)
+		assert.Equal(t, 1, len(tracks))
+		assert.Equal(t, uint32(3000), tracks[3000].ssrc)
+		assert.Equal(t, uint32(4000), tracks[3000].rtxSSRC)
+		assert.Equal(t, uint32(5000), tracks[3000].fecSSRC)
+	})
 }
 
 func TestHaveApplicationMediaSection(t *testing.T) {
@@ -230,3 +268,142 @@ func TestHaveApplicationMediaSection(t *testing.T) {
 		assert.True(t, haveApplicationMediaSection(s))
 	})
 }
+
+func TestGetRidsAndExtMaps(t *testing.T) {
+	m := &sdp.MediaDescription{
+		Attributes: []sdp.Attribute{
+			{Key: "extmap", Value: "3 " + SDESMidURI},
+			{Key: "extmap", Value: "4/sendonly " + SDESRTPStreamIDURI},
+			{Key: "extmap", Value: "invalid"},
+			{Key: "rid", Value: "f send pt=96;max-width=1280"},
+			{Key: "rid", Value: "h send"},
+			{Key: "rid", Value: "r recv"},
+			{Key: "simulcast", Value: "send f;h"},
+		},
+	}
+
+	assert.Equal(t, []string{"f", "h"}, getRids(m))
+	assert.Equal(t, map[string]int{SDESMidURI: 3, SDESRTPStreamIDURI: 4}, getExtMaps(m))
+}
+
+func TestGetRTXPayloadTypes(t *testing.T) {
+	local := &sdp.MediaDescription{
+		Attributes: []sdp.Attribute{
+			{Key: "rtpmap", Value: "96 VP8/90000"},
+			{Key: "rtpmap", Value: "97 rtx/90000"},
+			{Key: "fmtp", Value: "97 apt=96"},
+			{Key: "ssrc-group", Value: "FID 1000 2000"},
+		},
+	}
+	remote := &sdp.MediaDescription{
+		Attributes: []sdp.Attribute{
+			{Key: "rtpmap", Value: "96 VP8/90000"},
+			{Key: "rtpmap", Value: "102 H264/90000"},
+			{Key: "fmtp", Value: "102 level-asymmetry-allowed=1;packetization-mode=1"},
+			{Key: "rtpmap", Value: "99 RTX/90000"},
+			{Key: "fmtp", Value: "99 apt=96"},
+			{Key: "rtpmap", Value: "103 rtx/90000"},
+			{Key: "fmtp", Value: "103 apt=102"},
+		},
+	}
+
+	assert.Equal(t, map[uint8]uint8{96: 97}, getRTXPayloadTypes(local))
+	assert.Equal(t, map[uint8]uint8{96: 99, 102: 103}, getRTXPayloadTypes(remote))
+	assert.Equal(t, map[uint8]uint8{96: 99}, negotiatedRTXPayloadTypes(local, remote))
+
+	assert.True(t, haveRTXSSRC(local, 2000))
+	assert.False(t, haveRTXSSRC(local, 1000))
+	assert.False(t, haveRTXSSRC(remote, 2000))
+}
+
+func TestGetFlexFECPayloadType(t *testing.T) {
+	local := &sdp.MediaDescription{
+		Attributes: []sdp.Attribute{
+			{Key: "rtpmap", Value: "96 VP8/90000"},
+			{Key: "rtpmap", Value: "118 flexfec-03/90000"},
+			{Key: "fmtp", Value: "118 repair-window=10000000"},
+			{Key: "ssrc-group", Value: "FEC-FR 1000 2000"},
+		},
+	}
+	remote := &sdp.MediaDescription{
+		Attributes: []sdp.Attribute{
+			{Key: "rtpmap", Value: "96 VP8/90000"},
+			{Key: "rtpmap", Value: "49 FLEXFEC-03/90000"},
+		},
+	}
+	withoutFEC := &sdp.MediaDescription{
+		Attributes: []sdp.Attribute{
+			{Key: "rtpmap", Value: "96 VP8/90000"},
+		},
+	}
+
+	payloadType, ok := getFlexFECPayloadType(local)
+	assert.True(t, ok)
+	assert.Equal(t, uint8(118), payloadType)
+	_, ok = getFlexFECPayloadType(withoutFEC)
+	assert.False(t, ok)
+
+	assert.Equal(t, uint8(49), negotiatedFlexFECPayloadType(local, remote))
+	assert.Equal(t, uint8(0), negotiatedFlexFECPayloadType(withoutFEC, remote))
+	assert.Equal(t, uint8(0), negotiatedFlexFECPayloadType(local, withoutFEC))
+
+	assert.True(t, haveFECSSRC(local, 2000))
+	assert.False(t, haveFECSSRC(local, 1000))
+	assert.False(t, haveRTXSSRC(local, 2000))
+}
+
+func TestNegotiatedTelephoneEvent(t *testing.T) {
+	local := &sdp.MediaDescription{
+		Attributes: []sdp.Attribute{
+			{Key: "rtpmap", Value: "111 opus/48000/2"},
+			{Key: "rtpmap", Value: "101 telephone-event/48000"},
+			{Key: "rtpmap", Value: "126 telephone-event/8000"},
+		},
+	}
+	remote := &sdp.MediaDescription{
+		Attributes: []sdp.Attribute{
+			{Key: "rtpmap", Value: "111 opus/48000/2"},
+			{Key: "rtpmap", Value: "110 telephone-event/48000"},
+			{Key: "rtpmap", Value: "126 telephone-event/8000"},
+		},
+	}
+
+	assert.Equal(t, map[uint32]uint8{48000: 110, 8000: 126}, getTelephoneEventPayloadTypes(remote))
+
+	payloadType, clockRate, ok := negotiatedTelephoneEvent(local, remote, 48000)
+	assert.True(t, ok)
+	assert.Equal(t, uint8(110), payloadType)
+	assert.Equal(t, uint32(48000), clockRate)
+
+	payloadType, clockRate, ok = negotiatedTelephoneEvent(local, remote, 16000)
+	assert.True(t, ok)
+	assert.Equal(t, uint8(126), payloadType)
+	assert.Equal(t, uint32(8000), clockRate)
+
+	_, _, ok = negotiatedTelephoneEvent(&sdp.MediaDescription{}, remote, 48000)
+	assert.False(t, ok)
+}
+
+func TestAddCandidatesToMediaDescriptions(t *testing.T) {
+	m := &sdp.MediaDescription{}
+	addCandidatesToMediaDescriptions([]ICECandidate{{
+		Foundation: "foundation",
+		Priority:   2122260223,
+		Address:    "192.168.1.2",
+		Protocol:   ICEProtocolUDP,
+		Port:       1234,
+		Typ:        ICECandidateTypeHost,
+		Component:  1,
+	}}, m, ICEGatheringStateGathering)
+
+	candidates := []string{}
+	for _, a := range m.Attributes {
+		if a.IsICECandidate() {
+			candidates = append(candidates, a.Value)
+		}
+	}
+	assert.Equal(t, []string{
+		"foundation 1 udp 2122260223 192.168.1.2 1234 typ host generation 0",
+		"foundation 2 udp 2122260222 192.168.1.2 1234 typ host generation 0",
+	}, candidates)
+}
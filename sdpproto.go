@@ -0,0 +1,34 @@
+package webrtc
+
+import (
+	"strings"
+)
+
+// Transport protocols of the m= lines of a SessionDescription
+const (
+	// SDPProtoUDPTLSRTPSAVPF is the protocol of audio and video media
+	// sections, SRTP keyed with DTLS, RFC 5764 Section 8
+	SDPProtoUDPTLSRTPSAVPF = "UDP/TLS/RTP/SAVPF"
+
+	// SDPProtoUDPDTLSSCTP is the protocol of DataChannel media sections
+	// over UDP, RFC 8841 Section 4.1
+	SDPProtoUDPDTLSSCTP = "UDP/DTLS/SCTP"
+
+	// SDPProtoTCPDTLSSCTP is the protocol of DataChannel media sections
+	// over TCP, RFC 8841 Section 4.1
+	SDPProtoTCPDTLSSCTP = "TCP/DTLS/SCTP"
+
+	// SDPProtoDTLSSCTP is the protocol of DataChannel media sections of
+	// draft-ietf-mmusic-sctp-sdp-05, used together with a=sctpmap. It is the
+	// one offered, as it is understood by every browser
+	SDPProtoDTLSSCTP = "DTLS/SCTP"
+)
+
+// SDPSCTPProtocolWebRTCDataChannel is the association usage of the
+// a=sctpmap and a=sctp-port attributes of DataChannel media sections
+const SDPSCTPProtocolWebRTCDataChannel = "webrtc-datachannel"
+
+// sdpProtos splits a transport protocol into the Protos of a sdp.MediaName
+func sdpProtos(proto string) []string {
+	return strings.Split(proto, "/")
+}
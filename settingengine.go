@@ -4,6 +4,7 @@ package webrtc
 
 import (
 	"errors"
+	"net"
 	"time"
 
 	"github.com/pion/ice"
@@ -37,17 +38,52 @@ type SettingEngine struct {
 		ICENetworkTypes                []NetworkType
 		InterfaceFilter                func(string) bool
 		NAT1To1IPs                     []string
+		NAT1To1IPMapper                func(net.IP) net.IP
 		NAT1To1IPCandidateType         ICECandidateType
 		GenerateMulticastDNSCandidates bool
+		MulticastDNSMode               ICEMulticastDNSMode
 		MulticastDNSHostName           string
 		UsernameFragment               string
 		Password                       string
+		InsecureSkipVerify             bool
+	}
+	rtcp struct {
+		SenderReportInterval   time.Duration
+		ReceiverReportInterval time.Duration
+		NACKHistorySize        uint16
+	}
+	receive struct {
+		JitterBufferDepth    uint16
+		JitterBufferMaxDelay time.Duration
+	}
+	video struct {
+		PlayoutDelay      *PlayoutDelayExtension
+		FECProtectionRate float64
+	}
+	sctp struct {
+		MaxReceiveBufferSize uint32
+	}
+	sendQueue struct {
+		DataChannelBytes uint64
+		TrackPackets     int
+	}
+	instrumentation struct {
+		ReceiveTimings *ReceiveTimings
+		PacketTap      *PacketTap
+	}
+	sendBuffer struct {
+		DataChannelMaxBytes uint64
+		DataChannelBlock    bool
+	}
+	events struct {
+		Executor func(func())
 	}
 	replayProtection struct {
 		DTLS  *uint
 		SRTP  *uint
 		SRTCP *uint
 	}
+	mtu                                       uint
 	answeringDTLSRole                         DTLSRole
 	disableCertificateFingerprintVerification bool
 	disableSRTPReplayProtection               bool
@@ -97,7 +133,8 @@ func (e *SettingEngine) SetRelayAcceptanceMinWait(t time.Duration) {
 
 // SetEphemeralUDPPortRange limits the pool of ephemeral ports that
 // ICE UDP connections can allocate from. This affects both host candidates,
-// and the local address of server reflexive candidates.
+// and the local address of server reflexive candidates. Every PeerConnection
+// allocates ports of its own, a single port can't be shared between them yet.
 func (e *SettingEngine) SetEphemeralUDPPortRange(portMin, portMax uint16) error {
 	if portMax < portMin {
 		return ice.ErrPort
@@ -121,6 +158,11 @@ func (e *SettingEngine) SetTrickle(trickle bool) {
 
 // SetNetworkTypes configures what types of candidate networks are supported
 // during local and server reflexive gathering.
+//
+// This is also how the IP family is chosen: pass NetworkTypeUDP4 to only use IPv4,
+// NetworkTypeUDP6 to only use IPv6, or both for dual-stack which is the default.
+// Link-local and site-local IPv6 addresses are never gathered, and candidate
+// priorities follow the RFC 8445 type and local preferences for both families.
 func (e *SettingEngine) SetNetworkTypes(candidateTypes []NetworkType) {
 	e.candidates.ICENetworkTypes = candidateTypes
 }
@@ -161,6 +203,16 @@ func (e *SettingEngine) SetNAT1To1IPs(ips []string, candidateType ICECandidateTy
 	e.candidates.NAT1To1IPCandidateType = candidateType
 }
 
+// SetNAT1To1IPMapper is like SetNAT1To1IPs for hosts with several interfaces,
+// each behind a 1:1 NAT of its own. When gathering, mapper is called with the
+// IP address of every interface allowed by the interface filter, and returns
+// the external IP address to advertise for it, or nil if it isn't mapped.
+// The mapping replaces the IP addresses set by SetNAT1To1IPs.
+func (e *SettingEngine) SetNAT1To1IPMapper(mapper func(localIP net.IP) net.IP, candidateType ICECandidateType) {
+	e.candidates.NAT1To1IPMapper = mapper
+	e.candidates.NAT1To1IPCandidateType = candidateType
+}
+
 // SetAnsweringDTLSRole sets the DTLS role that is selected when offering
 // The DTLS role controls if the WebRTC Client as a client or server. This
 // may be useful when interacting with non-compliant clients or debugging issues.
@@ -192,6 +244,16 @@ func (e *SettingEngine) GenerateMulticastDNSCandidates(generateMulticastDNSCandi
 	e.candidates.GenerateMulticastDNSCandidates = generateMulticastDNSCandidates
 }
 
+// SetICEMulticastDNSMode controls if pion/ice resolves remote mDNS (.local) candidates,
+// and if it publishes its own host candidates with mDNS hostnames. When not set remote
+// mDNS candidates are resolved, and GenerateMulticastDNSCandidates decides about local ones.
+//
+// If multicast isn't available on the host, pion/ice logs an error and continues
+// with mDNS disabled instead of failing.
+func (e *SettingEngine) SetICEMulticastDNSMode(multicastDNSMode ICEMulticastDNSMode) {
+	e.candidates.MulticastDNSMode = multicastDNSMode
+}
+
 // SetMulticastDNSHostName sets a static HostName to be used by pion/ice instead of generating one on startup
 //
 // This should only be used for a single PeerConnection. Having multiple PeerConnections with the same HostName will cause
@@ -208,11 +270,195 @@ func (e *SettingEngine) SetICECredentials(usernameFragment, password string) {
 	e.candidates.Password = password
 }
 
+// SetICEInsecureSkipVerify controls if self-signed certificates are accepted when
+// connecting to TURN servers over TLS or DTLS (turns: URLs). This should only be
+// used for testing.
+func (e *SettingEngine) SetICEInsecureSkipVerify(insecureSkipVerify bool) {
+	e.candidates.InsecureSkipVerify = insecureSkipVerify
+}
+
 // DisableCertificateFingerprintVerification disables fingerprint verification after DTLS Handshake has finished
 func (e *SettingEngine) DisableCertificateFingerprintVerification(isDisabled bool) {
 	e.disableCertificateFingerprintVerification = isDisabled
 }
 
+// SetMTU sets the maximum size of the UDP datagrams sent by this API, the
+// default is 1200 bytes. DTLS fragments its handshake messages and Tracks
+// created by a PeerConnection packetize their samples to fit into it, and
+// inbound buffers are grown to hold datagrams of this size. Use a smaller
+// value on networks with a reduced path MTU (e.g. VPNs), or a larger one
+// on LANs with jumbo frames.
+//
+// SCTP and TURN framing sizes are fixed by pion/sctp and pion/ice and not
+// affected by this setting.
+func (e *SettingEngine) SetMTU(mtu uint) {
+	e.mtu = mtu
+}
+
+func (e *SettingEngine) getMTU() int {
+	if e.mtu == 0 {
+		return defaultMTU
+	}
+	return int(e.mtu)
+}
+
+// getReceiveMTU returns the size of the buffers inbound datagrams are read into
+func (e *SettingEngine) getReceiveMTU() int {
+	if mtu := e.getMTU(); mtu > receiveMTU {
+		return mtu
+	}
+	return receiveMTU
+}
+
+// SetSenderReportInterval enables periodic RTCP Sender Reports for every RTPSender.
+// Reports carry the packet and octet counts, and a NTP timestamp matched to the RTP
+// timestamp of the media written to the Track, which receivers need for lip sync and
+// bitrate measurement. A zero interval disables Sender Reports, which is the default.
+func (e *SettingEngine) SetSenderReportInterval(interval time.Duration) {
+	e.rtcp.SenderReportInterval = interval
+}
+
+// SetReceiverReportInterval enables periodic RTCP Receiver Reports for every
+// RTPReceiver. Reports carry the fraction of packets lost, the cumulative number
+// lost and the interarrival jitter of every incoming SSRC, which remote senders
+// use to adapt their bitrate. A zero interval disables Receiver Reports, which is the default.
+func (e *SettingEngine) SetReceiverReportInterval(interval time.Duration) {
+	e.rtcp.ReceiverReportInterval = interval
+}
+
+// SetJitterBufferDepth makes every RTPReceiver reorder incoming RTP packets by
+// sequence number before they are read from the Track. Up to packets packets
+// are held while waiting for a missing one, after that it is considered lost.
+// The default of zero disables reordering, packets are read in arrival order.
+func (e *SettingEngine) SetJitterBufferDepth(packets uint16) {
+	e.receive.JitterBufferDepth = packets
+}
+
+// SetJitterBufferMaxDelay sets how long the jitter buffer enabled with
+// SetJitterBufferDepth holds packets while waiting for a missing one, so that a
+// low rate stream doesn't wait for many packets to give up on it. The delay is
+// checked when a packet is read from the Track, it defaults to 100ms.
+func (e *SettingEngine) SetJitterBufferMaxDelay(delay time.Duration) {
+	e.receive.JitterBufferMaxDelay = delay
+}
+
+func (e *SettingEngine) getJitterBufferMaxDelay() time.Duration {
+	if e.receive.JitterBufferMaxDelay != 0 {
+		return e.receive.JitterBufferMaxDelay
+	}
+	return defaultJitterBufferMaxDelay
+}
+
+// SetPlayoutDelay makes every RTPSender of video ask the receiver to keep its
+// playout delay between min and max, browsers buffer less than they would
+// otherwise when max is small. A zero max asks for frames to be rendered as soon
+// as they are decoded, which suits latency sensitive applications like cloud
+// gaming. The delay is sent with the playout-delay header extension, which has
+// to be registered with MediaEngine.RegisterHeaderExtension and PlayoutDelayURI
+// to be negotiated. The delays have a granularity of 10ms and can't exceed 40.95s
+func (e *SettingEngine) SetPlayoutDelay(min, max time.Duration) error {
+	playoutDelay := PlayoutDelayExtension{Min: min, Max: max}
+	if _, err := playoutDelay.Marshal(); err != nil {
+		return err
+	}
+
+	e.video.PlayoutDelay = &playoutDelay
+	return nil
+}
+
+// SetFECProtectionRate sets the number of FlexFEC packets every RTPSender of
+// video sends per media packet, between 0 and 1. A FEC packet protects
+// round(1/rate) consecutive media packets, any one of them that is lost can be
+// recovered by the receiver. FEC packets are only sent when the codec created by
+// NewRTPFlexFECCodec is registered and negotiated. The default is 0.1, a FEC
+// packet every 10 media packets.
+func (e *SettingEngine) SetFECProtectionRate(rate float64) error {
+	if rate <= 0 || rate > 1 {
+		return ErrFECProtectionRateInvalid
+	}
+
+	e.video.FECProtectionRate = rate
+	return nil
+}
+
+// SetSCTPMaxReceiveBufferSize sets how many bytes of received DataChannel
+// messages the SCTP association buffers before they are read. The receiver
+// window advertised to the remote shrinks as the buffer fills and grows again
+// as messages are read, so a slow reader makes the remote sender throttle instead
+// of the buffer growing. Messages are only left unread by detached DataChannels,
+// see DetachDataChannels. The default of zero uses the 1MB of pion/sctp.
+func (e *SettingEngine) SetSCTPMaxReceiveBufferSize(bytes uint32) {
+	e.sctp.MaxReceiveBufferSize = bytes
+}
+
+// SetNACKHistorySize sets how many of the most recently sent RTP packets every
+// RTPSender keeps to answer Generic NACKs. Packets are only kept when the codec
+// of the Track announces nack feedback. The default is 512 packets.
+func (e *SettingEngine) SetNACKHistorySize(packets uint16) {
+	e.rtcp.NACKHistorySize = packets
+}
+
+func (e *SettingEngine) getNACKHistorySize() uint16 {
+	if e.rtcp.NACKHistorySize != 0 {
+		return e.rtcp.NACKHistorySize
+	}
+	return defaultNACKHistorySize
+}
+
+// SetDataChannelSendQueueSize allows DataChannel.Send and SendText to be called
+// before the DataChannel is open. Up to bytes of messages are queued per
+// DataChannel and sent in order once it opens, sends that would exceed the
+// limit fail as they do without a queue. The default of zero disables queueing.
+func (e *SettingEngine) SetDataChannelSendQueueSize(bytes uint64) {
+	e.sendQueue.DataChannelBytes = bytes
+}
+
+// SetDataChannelMaxBufferedAmount limits the bytes an open DataChannel buffers
+// before they are sent. A send that would exceed bytes returns
+// ErrDataChannelBufferFull, or if block is true waits until the buffered amount
+// falls to the BufferedAmountLowThreshold. A message is always sent when the
+// buffered amount is at or below the threshold, so larger messages still go
+// through. The default of zero doesn't limit the buffered amount.
+func (e *SettingEngine) SetDataChannelMaxBufferedAmount(bytes uint64, block bool) {
+	e.sendBuffer.DataChannelMaxBytes = bytes
+	e.sendBuffer.DataChannelBlock = block
+}
+
+// SetTrackSendQueueSize allows Tracks created by a PeerConnection to be written
+// before any of their RTPSenders has started, e.g. while the connection is
+// still being established. Up to packets RTP packets are queued per Track and
+// sent by the first RTPSender that starts, older packets are dropped once the
+// queue is full. The default of zero drops packets written before then.
+func (e *SettingEngine) SetTrackSendQueueSize(packets int) {
+	e.sendQueue.TrackPackets = packets
+}
+
+// SetReceiveTimings enables measuring how long every layer of the receive
+// pipeline spends on a packet, the durations are added to timings. Timing
+// every packet has a cost, the default of nil disables it.
+func (e *SettingEngine) SetReceiveTimings(timings *ReceiveTimings) {
+	e.instrumentation.ReceiveTimings = timings
+}
+
+// SetPacketTap enables capturing the packets of the transports to tap, to
+// debug them in Wireshark. The default of nil disables it.
+func (e *SettingEngine) SetPacketTap(tap *PacketTap) {
+	e.instrumentation.PacketTap = tap
+}
+
+// SetEventExecutor sets how the event handlers of PeerConnections, DataChannels,
+// ICEGatherers and SCTPTransports are run. The handlers of each kind of event
+// of an object are passed to executor one at a time, in the order the events
+// were fired, the next once the previous has returned. The handlers of
+// OnTrack, OnDataChannel and DataChannel.OnOpen, which may keep running to
+// read or send, are passed as soon as their event is fired. executor must run
+// every handler it is given, on any goroutine. It is called from the
+// goroutines reading the network, so it must not block nor run the handler
+// itself. The default of nil runs the handlers on goroutines of their own.
+func (e *SettingEngine) SetEventExecutor(executor func(handler func())) {
+	e.events.Executor = executor
+}
+
 // SetDTLSReplayProtectionWindow sets a replay attack protection window size of DTLS connection.
 func (e *SettingEngine) SetDTLSReplayProtectionWindow(n uint) {
 	e.replayProtection.DTLS = &n
@@ -3,9 +3,13 @@
 package webrtc
 
 import (
+	"bytes"
+	"net"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/pion/logging"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -84,6 +88,15 @@ func TestSetNAT1To1IPs(t *testing.T) {
 	}
 }
 
+func TestSetNAT1To1IPMapper(t *testing.T) {
+	s := SettingEngine{}
+	assert.Nil(t, s.candidates.NAT1To1IPMapper)
+
+	s.SetNAT1To1IPMapper(func(net.IP) net.IP { return net.ParseIP("1.2.3.4") }, ICECandidateTypeSrflx)
+	assert.Equal(t, "1.2.3.4", s.candidates.NAT1To1IPMapper(net.ParseIP("10.0.0.2")).String())
+	assert.Equal(t, ICECandidateTypeSrflx, s.candidates.NAT1To1IPCandidateType)
+}
+
 func TestSetAnsweringDTLSRole(t *testing.T) {
 	s := SettingEngine{}
 	assert.Error(t, s.SetAnsweringDTLSRole(DTLSRoleAuto), "SetAnsweringDTLSRole can only be called with DTLSRoleClient or DTLSRoleServer")
@@ -116,3 +129,140 @@ func TestSetReplayProtection(t *testing.T) {
 		t.Errorf("Failed to set SRTCP replay protection window")
 	}
 }
+
+type scopeRecordingLoggerFactory struct {
+	mu     sync.Mutex
+	scopes map[string]bool
+}
+
+func (f *scopeRecordingLoggerFactory) NewLogger(scope string) logging.LeveledLogger {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.scopes[scope] = true
+	return logging.NewDefaultLoggerFactory().NewLogger(scope)
+}
+
+func TestSettingEngine_LoggerFactory(t *testing.T) {
+	loggerFactory := &scopeRecordingLoggerFactory{scopes: map[string]bool{}}
+
+	s := SettingEngine{LoggerFactory: loggerFactory}
+	api := NewAPI(WithSettingEngine(s))
+
+	gatherer, err := api.NewICEGatherer(ICEGatherOptions{})
+	assert.NoError(t, err)
+
+	ice := api.NewICETransport(gatherer)
+	dtls, err := api.NewDTLSTransport(ice, nil)
+	assert.NoError(t, err)
+	api.NewSCTPTransport(dtls)
+
+	for _, scope := range []string{"ice", "ortc", "dtls"} {
+		assert.True(t, loggerFactory.scopes[scope], "expected a logger for scope %s", scope)
+	}
+
+	assert.NoError(t, gatherer.Close())
+}
+
+func TestSetICEMulticastDNSMode(t *testing.T) {
+	s := SettingEngine{}
+
+	if s.candidates.MulticastDNSMode != 0 {
+		t.Fatalf("SettingEngine defaults aren't as expected.")
+	}
+
+	s.SetICEMulticastDNSMode(ICEMulticastDNSModeDisabled)
+	assert.Equal(t, ICEMulticastDNSModeDisabled, s.candidates.MulticastDNSMode)
+}
+
+func TestSetICEInsecureSkipVerify(t *testing.T) {
+	s := SettingEngine{}
+
+	if s.candidates.InsecureSkipVerify {
+		t.Fatalf("SettingEngine defaults aren't as expected.")
+	}
+
+	s.SetICEInsecureSkipVerify(true)
+	assert.True(t, s.candidates.InsecureSkipVerify)
+}
+
+func TestSetMTU(t *testing.T) {
+	s := SettingEngine{}
+
+	assert.Equal(t, defaultMTU, s.getMTU())
+	assert.Equal(t, receiveMTU, s.getReceiveMTU())
+
+	s.SetMTU(1000)
+	assert.Equal(t, 1000, s.getMTU())
+	assert.Equal(t, receiveMTU, s.getReceiveMTU())
+
+	s.SetMTU(9000)
+	assert.Equal(t, 9000, s.getMTU())
+	assert.Equal(t, 9000, s.getReceiveMTU())
+}
+
+func TestSetSendQueueSize(t *testing.T) {
+	s := SettingEngine{}
+	s.SetDataChannelSendQueueSize(1024)
+	s.SetTrackSendQueueSize(64)
+
+	assert.Equal(t, uint64(1024), s.sendQueue.DataChannelBytes)
+	assert.Equal(t, 64, s.sendQueue.TrackPackets)
+}
+
+func TestSetDataChannelMaxBufferedAmount(t *testing.T) {
+	s := SettingEngine{}
+	s.SetDataChannelMaxBufferedAmount(1024, true)
+
+	assert.Equal(t, uint64(1024), s.sendBuffer.DataChannelMaxBytes)
+	assert.True(t, s.sendBuffer.DataChannelBlock)
+}
+
+func TestSetReceiveTimings(t *testing.T) {
+	s := SettingEngine{}
+	assert.Nil(t, s.instrumentation.ReceiveTimings)
+
+	timings := &ReceiveTimings{}
+	s.SetReceiveTimings(timings)
+	assert.Equal(t, timings, s.instrumentation.ReceiveTimings)
+}
+
+func TestSetPacketTap(t *testing.T) {
+	s := SettingEngine{}
+	assert.Nil(t, s.instrumentation.PacketTap)
+
+	tap := NewPacketTap(&bytes.Buffer{})
+	s.SetPacketTap(tap)
+	assert.Equal(t, tap, s.instrumentation.PacketTap)
+}
+
+func TestSetPlayoutDelay(t *testing.T) {
+	s := SettingEngine{}
+	assert.Nil(t, s.video.PlayoutDelay)
+
+	assert.Equal(t, ErrPlayoutDelayInvalid, s.SetPlayoutDelay(time.Second, 0))
+	assert.Nil(t, s.video.PlayoutDelay)
+
+	assert.NoError(t, s.SetPlayoutDelay(0, 100*time.Millisecond))
+	assert.Equal(t, &PlayoutDelayExtension{Max: 100 * time.Millisecond}, s.video.PlayoutDelay)
+}
+
+func TestSetFECProtectionRate(t *testing.T) {
+	s := SettingEngine{}
+	assert.Equal(t, float64(0), s.video.FECProtectionRate)
+
+	assert.Equal(t, ErrFECProtectionRateInvalid, s.SetFECProtectionRate(0))
+	assert.Equal(t, ErrFECProtectionRateInvalid, s.SetFECProtectionRate(1.5))
+	assert.Equal(t, float64(0), s.video.FECProtectionRate)
+
+	assert.NoError(t, s.SetFECProtectionRate(0.2))
+	assert.Equal(t, 0.2, s.video.FECProtectionRate)
+}
+
+func TestSetSCTPMaxReceiveBufferSize(t *testing.T) {
+	s := SettingEngine{}
+	assert.Equal(t, uint32(0), s.sctp.MaxReceiveBufferSize)
+
+	s.SetSCTPMaxReceiveBufferSize(64 * 1024)
+	assert.Equal(t, uint32(64*1024), s.sctp.MaxReceiveBufferSize)
+}
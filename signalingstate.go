@@ -1,8 +1,6 @@
 package webrtc
 
 import (
-	"fmt"
-
 	"github.com/pion/webrtc/v2/pkg/rtcerr"
 )
 
@@ -104,11 +102,14 @@ func (t SignalingState) String() string {
 }
 
 func checkNextSignalingState(cur, next SignalingState, op stateChangeOp, sdpType SDPType) (SignalingState, error) {
-	// Special case for rollbacks
-	if sdpType == SDPTypeRollback && cur == SignalingStateStable {
-		return cur, &rtcerr.InvalidModificationError{
-			Err: fmt.Errorf("can't rollback from stable state"),
+	// Special case for rollbacks, only an offer of the same side can be rolled back
+	if sdpType == SDPTypeRollback {
+		if next == SignalingStateStable &&
+			((cur == SignalingStateHaveLocalOffer && op == stateChangeOpSetLocal) ||
+				(cur == SignalingStateHaveRemoteOffer && op == stateChangeOpSetRemote)) {
+			return next, nil
 		}
+		return cur, &rtcerr.InvalidStateError{Err: ErrSignalingStateCannotRollback}
 	}
 
 	// 4.3.1 valid state transitions
@@ -172,7 +173,5 @@ func checkNextSignalingState(cur, next SignalingState, op stateChangeOp, sdpType
 		}
 	}
 
-	return cur, &rtcerr.InvalidModificationError{
-		Err: fmt.Errorf("invalid proposed signaling state transition %s->%s(%s)->%s", cur, op, sdpType, next),
-	}
+	return cur, &rtcerr.InvalidStateError{Err: ErrSignalingStateProposedTransitionInvalid}
 }
@@ -133,7 +133,7 @@ func TestSignalingState_Transitions(t *testing.T) {
 			SignalingStateHaveRemotePranswer,
 			stateChangeOpSetRemote,
 			SDPTypePranswer,
-			&rtcerr.InvalidModificationError{},
+			&rtcerr.InvalidStateError{Err: ErrSignalingStateProposedTransitionInvalid},
 		},
 		{
 			"(invalid) stable->SetRemote(rollback)->have-local-offer",
@@ -141,14 +141,55 @@ func TestSignalingState_Transitions(t *testing.T) {
 			SignalingStateHaveLocalOffer,
 			stateChangeOpSetRemote,
 			SDPTypeRollback,
-			&rtcerr.InvalidModificationError{},
+			&rtcerr.InvalidStateError{Err: ErrSignalingStateCannotRollback},
+		},
+		{
+			"have-local-offer->SetLocal(rollback)->stable",
+			SignalingStateHaveLocalOffer,
+			SignalingStateStable,
+			stateChangeOpSetLocal,
+			SDPTypeRollback,
+			nil,
+		},
+		{
+			"have-remote-offer->SetRemote(rollback)->stable",
+			SignalingStateHaveRemoteOffer,
+			SignalingStateStable,
+			stateChangeOpSetRemote,
+			SDPTypeRollback,
+			nil,
+		},
+		{
+			"(invalid) have-local-offer->SetRemote(rollback)->stable",
+			SignalingStateHaveLocalOffer,
+			SignalingStateStable,
+			stateChangeOpSetRemote,
+			SDPTypeRollback,
+			&rtcerr.InvalidStateError{Err: ErrSignalingStateCannotRollback},
+		},
+		{
+			"(invalid) have-local-pranswer->SetLocal(rollback)->stable",
+			SignalingStateHaveLocalPranswer,
+			SignalingStateStable,
+			stateChangeOpSetLocal,
+			SDPTypeRollback,
+			&rtcerr.InvalidStateError{Err: ErrSignalingStateCannotRollback},
+		},
+		{
+			"(invalid) stable->SetLocal(answer)->stable",
+			SignalingStateStable,
+			SignalingStateStable,
+			stateChangeOpSetLocal,
+			SDPTypeAnswer,
+			&rtcerr.InvalidStateError{Err: ErrSignalingStateProposedTransitionInvalid},
 		},
 	}
 
 	for i, tc := range testCases {
 		next, err := checkNextSignalingState(tc.current, tc.next, tc.op, tc.sdpType)
 		if tc.expectedErr != nil {
-			assert.Error(t, err, "testCase: %d %s", i, tc.desc)
+			assert.Equal(t, tc.expectedErr, err, "testCase: %d %s", i, tc.desc)
+			assert.Equal(t, tc.current, next, "testCase: %d %s", i, tc.desc)
 		} else {
 			assert.NoError(t, err, "testCase: %d %s", i, tc.desc)
 			assert.Equal(t,
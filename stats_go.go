@@ -61,3 +61,37 @@ func (r StatsReport) GetICECandidatePairStats(c *ICECandidatePair) (ICECandidate
 	}
 	return candidateStats, true
 }
+
+// GetOutboundRTPStreamStats is a helper method to return the associated stats for a given RTPSender
+func (r StatsReport) GetOutboundRTPStreamStats(s *RTPSender) (OutboundRTPStreamStats, bool) {
+	statsID := s.getStatsID()
+	stats, ok := r[statsID]
+	if !ok {
+		return OutboundRTPStreamStats{}, false
+	}
+
+	senderStats, ok := stats.(OutboundRTPStreamStats)
+	if !ok {
+		return OutboundRTPStreamStats{}, false
+	}
+	return senderStats, true
+}
+
+// GetRemoteInboundRTPStreamStats is a helper method to return the stats the remote reported about a given RTPSender
+func (r StatsReport) GetRemoteInboundRTPStreamStats(s *RTPSender) (RemoteInboundRTPStreamStats, bool) {
+	senderStats, ok := r.GetOutboundRTPStreamStats(s)
+	if !ok || senderStats.RemoteID == "" {
+		return RemoteInboundRTPStreamStats{}, false
+	}
+
+	stats, ok := r[senderStats.RemoteID]
+	if !ok {
+		return RemoteInboundRTPStreamStats{}, false
+	}
+
+	remoteStats, ok := stats.(RemoteInboundRTPStreamStats)
+	if !ok {
+		return RemoteInboundRTPStreamStats{}, false
+	}
+	return remoteStats, true
+}
@@ -0,0 +1,186 @@
+// +build !js
+
+package webrtc
+
+import (
+	"hash/fnv"
+	"runtime"
+	"sync"
+)
+
+// StatsCollector gathers the stats of many PeerConnections at once and sums
+// them up in an AggregateStats. The PeerConnections are spread over shards that
+// are collected concurrently, each with its own lock, so adding and removing
+// PeerConnections doesn't contend with a collection of the other shards.
+// PeerConnections that have been closed are removed when collecting
+type StatsCollector struct {
+	shards []statsCollectorShard
+}
+
+type statsCollectorShard struct {
+	mu              sync.Mutex
+	peerConnections map[*PeerConnection]struct{}
+}
+
+// AggregateStats is the sum of the stats of the PeerConnections of a StatsCollector
+type AggregateStats struct {
+	// Timestamp is the time the collection completed
+	Timestamp StatsTimestamp `json:"timestamp"`
+
+	// PeerConnections is the number of PeerConnections that were collected
+	PeerConnections uint32 `json:"peerConnections"`
+
+	// BytesSent and BytesReceived are the bytes sent and received on the ICE
+	// transports, this includes the bytes of every protocol
+	BytesSent     uint64 `json:"bytesSent"`
+	BytesReceived uint64 `json:"bytesReceived"`
+
+	// OutboundRTPStreams is the number of RTPSenders that have started sending.
+	// The counters are summed as uint64, the ones of a single stream are
+	// uint32 and would overflow across many PeerConnections
+	OutboundRTPStreams uint32 `json:"outboundRtpStreams"`
+	RTPPacketsSent     uint64 `json:"rtpPacketsSent"`
+	RTPBytesSent       uint64 `json:"rtpBytesSent"`
+	NACKCount          uint64 `json:"nackCount"`
+	PLICount           uint64 `json:"pliCount"`
+	FIRCount           uint64 `json:"firCount"`
+
+	// InboundRTPStreams is the number of SSRCs the RTPReceivers have started receiving
+	InboundRTPStreams  uint32 `json:"inboundRtpStreams"`
+	RTPPacketsReceived uint64 `json:"rtpPacketsReceived"`
+	RTPBytesReceived   uint64 `json:"rtpBytesReceived"`
+
+	// DataChannelsOpen is the number of DataChannels in the open state
+	DataChannelsOpen            uint32 `json:"dataChannelsOpen"`
+	DataChannelMessagesSent     uint64 `json:"dataChannelMessagesSent"`
+	DataChannelMessagesReceived uint64 `json:"dataChannelMessagesReceived"`
+	DataChannelBytesSent        uint64 `json:"dataChannelBytesSent"`
+	DataChannelBytesReceived    uint64 `json:"dataChannelBytesReceived"`
+}
+
+// NewStatsCollector creates a StatsCollector with a shard for every CPU
+func NewStatsCollector() *StatsCollector {
+	c := &StatsCollector{shards: make([]statsCollectorShard, runtime.GOMAXPROCS(0))}
+	for i := range c.shards {
+		c.shards[i].peerConnections = map[*PeerConnection]struct{}{}
+	}
+	return c
+}
+
+func (c *StatsCollector) shard(pc *PeerConnection) *statsCollectorShard {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(pc.getStatsID()))
+	return &c.shards[h.Sum32()%uint32(len(c.shards))]
+}
+
+// Add adds a PeerConnection to the ones that are collected
+func (c *StatsCollector) Add(pc *PeerConnection) {
+	s := c.shard(pc)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.peerConnections[pc] = struct{}{}
+}
+
+// Remove removes a PeerConnection from the ones that are collected
+func (c *StatsCollector) Remove(pc *PeerConnection) {
+	s := c.shard(pc)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.peerConnections, pc)
+}
+
+// Collect gets the stats of every PeerConnection and returns their sum
+func (c *StatsCollector) Collect() AggregateStats {
+	aggregates := make([]AggregateStats, len(c.shards))
+
+	var wg sync.WaitGroup
+	for i := range c.shards {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			aggregates[i] = c.shards[i].collect()
+		}(i)
+	}
+	wg.Wait()
+
+	total := AggregateStats{}
+	for i := range aggregates {
+		total.add(&aggregates[i])
+	}
+	total.Timestamp = statsTimestampNow()
+	return total
+}
+
+// collect sums up the stats of the PeerConnections of the shard, the lock is
+// only held to copy the PeerConnections so Add and Remove don't wait for GetStats
+func (s *statsCollectorShard) collect() AggregateStats {
+	s.mu.Lock()
+	peerConnections := make([]*PeerConnection, 0, len(s.peerConnections))
+	for pc := range s.peerConnections {
+		if pc.isClosed.get() {
+			delete(s.peerConnections, pc)
+			continue
+		}
+		peerConnections = append(peerConnections, pc)
+	}
+	s.mu.Unlock()
+
+	aggregate := AggregateStats{}
+	for _, pc := range peerConnections {
+		aggregate.PeerConnections++
+		for _, stats := range pc.GetStats() {
+			aggregate.addStats(stats)
+		}
+	}
+	return aggregate
+}
+
+func (a *AggregateStats) addStats(stats Stats) {
+	switch s := stats.(type) {
+	case TransportStats:
+		// The SCTP transport runs on top of the ICE transport, its bytes are already counted
+		if s.ID == "iceTransport" {
+			a.BytesSent += s.BytesSent
+			a.BytesReceived += s.BytesReceived
+		}
+	case OutboundRTPStreamStats:
+		a.OutboundRTPStreams++
+		a.RTPPacketsSent += uint64(s.PacketsSent)
+		a.RTPBytesSent += s.BytesSent
+		a.NACKCount += uint64(s.NACKCount)
+		a.PLICount += uint64(s.PLICount)
+		a.FIRCount += uint64(s.FIRCount)
+	case InboundRTPStreamStats:
+		a.InboundRTPStreams++
+		a.RTPPacketsReceived += uint64(s.PacketsReceived)
+		a.RTPBytesReceived += s.BytesReceived
+	case DataChannelStats:
+		if s.State == DataChannelStateOpen {
+			a.DataChannelsOpen++
+		}
+		a.DataChannelMessagesSent += uint64(s.MessagesSent)
+		a.DataChannelMessagesReceived += uint64(s.MessagesReceived)
+		a.DataChannelBytesSent += s.BytesSent
+		a.DataChannelBytesReceived += s.BytesReceived
+	}
+}
+
+func (a *AggregateStats) add(b *AggregateStats) {
+	a.PeerConnections += b.PeerConnections
+	a.BytesSent += b.BytesSent
+	a.BytesReceived += b.BytesReceived
+	a.OutboundRTPStreams += b.OutboundRTPStreams
+	a.RTPPacketsSent += b.RTPPacketsSent
+	a.RTPBytesSent += b.RTPBytesSent
+	a.NACKCount += b.NACKCount
+	a.PLICount += b.PLICount
+	a.FIRCount += b.FIRCount
+	a.InboundRTPStreams += b.InboundRTPStreams
+	a.RTPPacketsReceived += b.RTPPacketsReceived
+	a.RTPBytesReceived += b.RTPBytesReceived
+	a.DataChannelsOpen += b.DataChannelsOpen
+	a.DataChannelMessagesSent += b.DataChannelMessagesSent
+	a.DataChannelMessagesReceived += b.DataChannelMessagesReceived
+	a.DataChannelBytesSent += b.DataChannelBytesSent
+	a.DataChannelBytesReceived += b.DataChannelBytesReceived
+}
@@ -0,0 +1,100 @@
+// +build !js
+
+package webrtc
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/pion/transport/test"
+	"github.com/pion/webrtc/v2/pkg/media"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatsCollector(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	collector := NewStatsCollector()
+	assert.Equal(t, AggregateStats{}, func() AggregateStats {
+		s := collector.Collect()
+		s.Timestamp = 0
+		return s
+	}())
+
+	newConnectedPair := func() (*PeerConnection, *PeerConnection) {
+		pcOffer, pcAnswer, err := newPair()
+		assert.NoError(t, err)
+
+		messageReceived := make(chan struct{})
+		pcAnswer.OnDataChannel(func(d *DataChannel) {
+			d.OnMessage(func(DataChannelMessage) {
+				close(messageReceived)
+			})
+		})
+
+		dc, err := pcOffer.CreateDataChannel("data", nil)
+		assert.NoError(t, err)
+		dc.OnOpen(func() {
+			assert.NoError(t, dc.SendText("ping"))
+		})
+
+		track, err := pcOffer.NewTrack(DefaultPayloadTypeOpus, rand.Uint32(), "audio", "pion")
+		assert.NoError(t, err)
+		_, err = pcOffer.AddTrack(track)
+		assert.NoError(t, err)
+
+		trackReceived := make(chan struct{})
+		pcAnswer.OnTrack(func(track *Track, receiver *RTPReceiver) {
+			close(trackReceived)
+		})
+
+		assert.NoError(t, signalPair(pcOffer, pcAnswer))
+		<-messageReceived
+
+		func() {
+			for {
+				select {
+				case <-trackReceived:
+					return
+				case <-time.After(20 * time.Millisecond):
+					assert.NoError(t, track.WriteSample(media.Sample{Data: []byte{0x00}, Samples: 1}))
+				}
+			}
+		}()
+
+		collector.Add(pcOffer)
+		collector.Add(pcAnswer)
+		return pcOffer, pcAnswer
+	}
+
+	pcOffer1, pcAnswer1 := newConnectedPair()
+	pcOffer2, pcAnswer2 := newConnectedPair()
+
+	stats := collector.Collect()
+	assert.Equal(t, uint32(4), stats.PeerConnections)
+	// signalPair opens a DataChannel of its own
+	assert.Equal(t, uint32(8), stats.DataChannelsOpen)
+	assert.Equal(t, uint64(2), stats.DataChannelMessagesSent)
+	assert.Equal(t, uint64(2), stats.DataChannelMessagesReceived)
+	assert.Equal(t, uint64(8), stats.DataChannelBytesSent)
+	assert.Equal(t, uint32(2), stats.OutboundRTPStreams)
+	assert.Equal(t, uint32(2), stats.InboundRTPStreams)
+	assert.NotZero(t, stats.RTPPacketsSent)
+	assert.NotZero(t, stats.RTPPacketsReceived)
+	assert.NotZero(t, stats.RTPBytesReceived)
+	assert.NotZero(t, stats.BytesSent)
+	assert.NotZero(t, stats.BytesReceived)
+	assert.NotZero(t, stats.Timestamp)
+
+	// Closed PeerConnections are dropped, the removed ones aren't collected
+	closePairNow(t, pcOffer1, pcAnswer1)
+	collector.Remove(pcAnswer2)
+	stats = collector.Collect()
+	assert.Equal(t, uint32(1), stats.PeerConnections)
+	assert.Equal(t, uint64(1), stats.DataChannelMessagesSent)
+	assert.Equal(t, uint32(0), stats.InboundRTPStreams)
+
+	closePairNow(t, pcOffer2, pcAnswer2)
+}
@@ -12,7 +12,6 @@ import (
 )
 
 const (
-	rtpOutboundMTU          = 1200
 	trackDefaultIDLength    = 16
 	trackDefaultLabelLength = 16
 )
@@ -22,6 +21,7 @@ type Track struct {
 	mu sync.RWMutex
 
 	id          string
+	rid         string
 	payloadType uint8
 	kind        RTPCodecType
 	label       string
@@ -29,10 +29,18 @@ type Track struct {
 	codec       *RTPCodec
 
 	packetizer rtp.Packetizer
+	sequencer  rtp.Sequencer
 
 	receiver         *RTPReceiver
 	activeSenders    []*RTPSender
 	totalSenderCount int // count of all senders (accounts for senders that have not been started yet)
+
+	// Packets written before any sender has started. They are sent by the
+	// first sender to start, new packets are queued behind them until it
+	// is done and joins the activeSenders
+	sendQueue      []*rtp.Packet
+	sendQueueSize  int
+	flushingSender *RTPSender
 }
 
 // ID gets the ID of the track
@@ -42,6 +50,15 @@ func (t *Track) ID() string {
 	return t.id
 }
 
+// RID gets the RTP Stream ID of this Track. With Simulcast you will have
+// multiple tracks with the same ID, but different RID values. In many cases
+// a Track will not have a RID, so it is important to assert it is non-zero
+func (t *Track) RID() string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.rid
+}
+
 // PayloadType gets the PayloadType of the track
 func (t *Track) PayloadType() uint8 {
 	t.mu.RLock()
@@ -94,12 +111,19 @@ func (t *Track) Read(b []byte) (n int, err error) {
 	r := t.receiver
 	t.mu.RUnlock()
 
-	return r.readRTP(b)
+	return r.readRTP(b, t)
 }
 
 // ReadRTP is a convenience method that wraps Read and unmarshals for you
 func (t *Track) ReadRTP() (*rtp.Packet, error) {
-	b := make([]byte, receiveMTU)
+	mtu := receiveMTU
+	t.mu.RLock()
+	if t.receiver != nil {
+		mtu = t.receiver.api.settingEngine.getReceiveMTU()
+	}
+	t.mu.RUnlock()
+
+	b := make([]byte, mtu)
 	i, err := t.Read(b)
 	if err != nil {
 		return nil, err
@@ -128,9 +152,15 @@ func (t *Track) Write(b []byte) (n int, err error) {
 	return len(b), nil
 }
 
-// WriteSample packetizes and writes to the track
+// WriteSample packetizes and writes to the track. The RTP timestamps advance
+// by s.Samples, or by s.Duration at the clock rate of the codec if it is zero
 func (t *Track) WriteSample(s media.Sample) error {
-	packets := t.packetizer.Packetize(s.Data, s.Samples)
+	samples := s.Samples
+	if samples == 0 {
+		samples = media.NSamples(s.Duration, int(t.Codec().ClockRate))
+	}
+
+	packets := t.packetizer.Packetize(s.Data, samples)
 	for _, p := range packets {
 		err := t.WriteRTP(p)
 		if err != nil {
@@ -149,6 +179,7 @@ func (t *Track) WriteRTP(p *rtp.Packet) error {
 		return fmt.Errorf("this is a remote track and must not be written to")
 	}
 	senders := t.activeSenders
+	queue := len(senders) == 0 || t.flushingSender != nil
 	totalSenderCount := t.totalSenderCount
 	t.mu.RUnlock()
 
@@ -156,6 +187,10 @@ func (t *Track) WriteRTP(p *rtp.Packet) error {
 		return io.ErrClosedPipe
 	}
 
+	if queue {
+		senders = t.queuePacket(p)
+	}
+
 	for _, s := range senders {
 		_, err := s.SendRTP(&p.Header, p.Payload)
 		if err != nil {
@@ -166,18 +201,46 @@ func (t *Track) WriteRTP(p *rtp.Packet) error {
 	return nil
 }
 
+// queuePacket stores a packet until a sender starts, dropping the oldest once the queue is full.
+// It returns the active senders, which the packet must be sent to directly
+func (t *Track) queuePacket(p *rtp.Packet) []*RTPSender {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// A sender may have started, or be done with the queue, since the caller checked
+	if t.sendQueueSize == 0 || (len(t.activeSenders) != 0 && t.flushingSender == nil) {
+		return t.activeSenders
+	}
+
+	if len(t.sendQueue) == t.sendQueueSize {
+		t.sendQueue = t.sendQueue[1:]
+	}
+
+	// The packet is owned by the caller, and packetizers reuse the header
+	queued := &rtp.Packet{Header: p.Header, Payload: append([]byte{}, p.Payload...)}
+	queued.Extensions = append([]rtp.Extension{}, p.Extensions...)
+	queued.CSRC = append([]uint32{}, p.CSRC...)
+	t.sendQueue = append(t.sendQueue, queued)
+	return t.activeSenders
+}
+
 // NewTrack initializes a new *Track
 func NewTrack(payloadType uint8, ssrc uint32, id, label string, codec *RTPCodec) (*Track, error) {
+	return newTrack(payloadType, ssrc, id, label, codec, defaultMTU)
+}
+
+func newTrack(payloadType uint8, ssrc uint32, id, label string, codec *RTPCodec, mtu int) (*Track, error) {
 	if ssrc == 0 {
 		return nil, fmt.Errorf("SSRC supplied to NewTrack() must be non-zero")
 	}
 
+	sequencer := rtp.NewRandomSequencer()
 	packetizer := rtp.NewPacketizer(
-		rtpOutboundMTU,
+		mtu,
 		payloadType,
 		ssrc,
 		codec.Payloader,
-		rtp.NewRandomSequencer(),
+		sequencer,
 		codec.ClockRate,
 	)
 
@@ -189,6 +252,7 @@ func NewTrack(payloadType uint8, ssrc uint32, id, label string, codec *RTPCodec)
 		ssrc:        ssrc,
 		codec:       codec,
 		packetizer:  packetizer,
+		sequencer:   sequencer,
 	}, nil
 }
 
@@ -5,6 +5,12 @@ package webrtc
 import (
 	"math/rand"
 	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v2/pkg/interceptor"
+	"github.com/pion/webrtc/v2/pkg/media"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestNewVideoTrack(t *testing.T) {
@@ -115,3 +121,123 @@ func TestNewTracksWrite(t *testing.T) {
 		t.Error("Failed to write to audio track")
 	}
 }
+
+func TestNewTrack_MTU(t *testing.T) {
+	m := MediaEngine{}
+	m.RegisterCodec(NewRTPVP8Codec(DefaultPayloadTypeVP8, 90000))
+	s := SettingEngine{}
+	s.SetMTU(500)
+	api := NewAPI(WithMediaEngine(m), WithSettingEngine(s))
+
+	peer, err := api.NewPeerConnection(Configuration{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	track, err := peer.NewTrack(DefaultPayloadTypeVP8, rand.Uint32(), "video", "pion")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	packets := track.Packetizer().Packetize(make([]byte, 2000), 1)
+	if len(packets) < 5 {
+		t.Fatalf("Expected a 2000 byte sample to be split into at least 5 packets, got %d", len(packets))
+	}
+	for _, p := range packets {
+		raw, err := p.Marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(raw) > 500 {
+			t.Errorf("Packet of %d bytes exceeds the MTU", len(raw))
+		}
+	}
+
+	if err := peer.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestTrack_WriteSampleDuration(t *testing.T) {
+	m := MediaEngine{}
+	m.RegisterCodec(NewRTPVP8Codec(DefaultPayloadTypeVP8, 90000))
+	s := SettingEngine{}
+	s.SetTrackSendQueueSize(10)
+	api := NewAPI(WithMediaEngine(m), WithSettingEngine(s))
+
+	peer, err := api.NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	track, err := peer.NewTrack(DefaultPayloadTypeVP8, rand.Uint32(), "video", "pion")
+	assert.NoError(t, err)
+
+	_, err = peer.AddTrack(track)
+	assert.NoError(t, err)
+
+	// The packets are queued until the track is sent
+	assert.NoError(t, track.WriteSample(media.Sample{Data: []byte{0x00}, Duration: 20 * time.Millisecond}))
+	assert.NoError(t, track.WriteSample(media.Sample{Data: []byte{0x00}, Samples: 1000}))
+	assert.NoError(t, track.WriteSample(media.Sample{Data: []byte{0x00}}))
+
+	track.mu.RLock()
+	queue := track.sendQueue
+	track.mu.RUnlock()
+
+	assert.Equal(t, 3, len(queue))
+	assert.Equal(t, uint32(1800), queue[1].Timestamp-queue[0].Timestamp)
+	assert.Equal(t, uint32(1000), queue[2].Timestamp-queue[1].Timestamp)
+	assert.NoError(t, peer.Close())
+}
+
+// Assert that the packets written while the first sender sends the queue are
+// queued behind it, and that a sender started meanwhile is sent them directly
+func TestTrack_SendQueueFlush(t *testing.T) {
+	track, err := NewTrack(DefaultPayloadTypeOpus, 1234, "audio", "pion", NewRTPOpusCodec(DefaultPayloadTypeOpus, 48000))
+	assert.NoError(t, err)
+	track.sendQueueSize = 16
+
+	write := func(sequenceNumber uint16) {
+		assert.NoError(t, track.WriteRTP(&rtp.Packet{Header: rtp.Header{SequenceNumber: sequenceNumber}, Payload: []byte{0x00}}))
+	}
+	newSender := func(onWrite func(sequenceNumber uint16)) *RTPSender {
+		payloadType := uint8(DefaultPayloadTypeOpus)
+		sender := &RTPSender{
+			track:       track,
+			payloadType: &payloadType,
+			stopCalled:  make(chan interface{}),
+			sendCalled:  make(chan interface{}),
+			rtpWriter: interceptor.RTPWriterFunc(func(header *rtp.Header, payload []byte) (int, error) {
+				onWrite(header.SequenceNumber)
+				return len(payload), nil
+			}),
+		}
+		close(sender.sendCalled)
+		track.totalSenderCount++
+		return sender
+	}
+
+	var second []uint16
+	secondSender := newSender(func(sequenceNumber uint16) {
+		second = append(second, sequenceNumber)
+	})
+
+	var first []uint16
+	firstSender := newSender(func(sequenceNumber uint16) {
+		first = append(first, sequenceNumber)
+		if sequenceNumber == 0 {
+			write(2)
+			secondSender.flushSendQueue()
+			write(3)
+		}
+	})
+
+	write(0)
+	write(1)
+	firstSender.flushSendQueue()
+	write(4)
+
+	assert.Equal(t, []uint16{0, 1, 2, 3, 4}, first)
+	assert.Equal(t, []uint16{3, 4}, second)
+	assert.Empty(t, track.sendQueue)
+	assert.Nil(t, track.flushingSender)
+}
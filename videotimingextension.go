@@ -0,0 +1,87 @@
+package webrtc
+
+import (
+	"encoding/binary"
+	"time"
+)
+
+const (
+	videoTimingExtensionSize = 13
+
+	// The first version of the extension has no flags
+	videoTimingExtensionSizeNoFlags = 12
+)
+
+// Flags of the VideoTimingExtension telling why timing was recorded for the frame
+const (
+	VideoTimingFlagTriggeredByTimer uint8 = 1 << 0
+	VideoTimingFlagTriggeredBySize  uint8 = 1 << 1
+)
+
+// VideoTimingExtension is the payload of the video-timing RTP header extension,
+// identified by VideoTimingURI. Browsers send it on the last packet of some
+// frames, every delta is the time elapsed since the capture of the frame
+//
+// https://webrtc.googlesource.com/src/+/refs/heads/master/docs/native-code/rtp-hdrext/video-timing
+//
+// 0                   1                   2                   3
+// 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+// |     flags     |    encode start ms delta      | encode finish |
+// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+// |   ms delta    |  packetizer finish ms delta   |  pacer exit   |
+// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+// |   ms delta    |  network timestamp ms delta   |  network2 ts  |
+// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+// |   ms delta    |
+// +-+-+-+-+-+-+-+-+
+type VideoTimingExtension struct {
+	Flags uint8
+
+	EncodeStartDelta         time.Duration
+	EncodeFinishDelta        time.Duration
+	PacketizationFinishDelta time.Duration
+	PacerExitDelta           time.Duration
+	NetworkTimestampDelta    time.Duration
+	Network2TimestampDelta   time.Duration
+}
+
+func (v *VideoTimingExtension) deltas() []*time.Duration {
+	return []*time.Duration{
+		&v.EncodeStartDelta,
+		&v.EncodeFinishDelta,
+		&v.PacketizationFinishDelta,
+		&v.PacerExitDelta,
+		&v.NetworkTimestampDelta,
+		&v.Network2TimestampDelta,
+	}
+}
+
+// Marshal serializes the members to buffer, the deltas are truncated to milliseconds
+func (v VideoTimingExtension) Marshal() ([]byte, error) {
+	buf := make([]byte, videoTimingExtensionSize)
+	buf[0] = v.Flags
+	for i, d := range v.deltas() {
+		binary.BigEndian.PutUint16(buf[1+2*i:], uint16(*d/time.Millisecond))
+	}
+	return buf, nil
+}
+
+// Unmarshal parses the passed byte slice and stores the result in the members.
+// The first version of the extension, without flags, is accepted too
+func (v *VideoTimingExtension) Unmarshal(rawData []byte) error {
+	switch {
+	case len(rawData) >= videoTimingExtensionSize:
+		v.Flags = rawData[0]
+		rawData = rawData[1:]
+	case len(rawData) == videoTimingExtensionSizeNoFlags:
+		v.Flags = 0
+	default:
+		return ErrHeaderExtensionTooSmall
+	}
+
+	for i, d := range v.deltas() {
+		*d = time.Duration(binary.BigEndian.Uint16(rawData[2*i:])) * time.Millisecond
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package webrtc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVideoTimingExtension(t *testing.T) {
+	v := VideoTimingExtension{
+		Flags:                    VideoTimingFlagTriggeredByTimer,
+		EncodeStartDelta:         1 * time.Millisecond,
+		EncodeFinishDelta:        2 * time.Millisecond,
+		PacketizationFinishDelta: 3 * time.Millisecond,
+		PacerExitDelta:           4 * time.Millisecond,
+		NetworkTimestampDelta:    5 * time.Millisecond,
+		Network2TimestampDelta:   258 * time.Millisecond,
+	}
+	raw, err := v.Marshal()
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x01, 0x00, 0x01, 0x00, 0x02, 0x00, 0x03, 0x00, 0x04, 0x00, 0x05, 0x01, 0x02}, raw)
+
+	parsed := VideoTimingExtension{}
+	assert.NoError(t, parsed.Unmarshal(raw))
+	assert.Equal(t, v, parsed)
+
+	// The first version of the extension has no flags
+	v.Flags = 0
+	assert.NoError(t, parsed.Unmarshal(raw[1:]))
+	assert.Equal(t, v, parsed)
+
+	assert.Equal(t, ErrHeaderExtensionTooSmall, parsed.Unmarshal(raw[:11]))
+}